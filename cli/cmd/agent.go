@@ -4,14 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"sync"
 	taskApi "task/controller/api/v1"
+	"task/pkg/cloudclient"
 	v1 "task/pkg/gen/cloud/v1"
 	"task/pkg/gen/cloud/v1/cloudv1connect"
 	k8s "task/pkg/k8s"
-	"task/pkg/plugins"
-	"task/pkg/x"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,8 +32,24 @@ var serveCmd = &cobra.Command{
 // Number of worker goroutines
 var numWorkers = 1000
 
+// taskTTL is the number of seconds after which the Task resources of finished tasks are deleted,
+// or a negative number to keep them
+var taskTTL int32
+
+// cloudConfigFile is the config file of the connection to the task service and the cluster,
+// whose settings are overridden by cloudFlags
+var (
+	cloudConfigFile string
+	cloudFlags      cloudclient.Config
+)
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().Int32Var(&taskTTL, "task-ttl-seconds", 3600, "Seconds after which the Task resources of finished tasks are deleted (negative keeps them)")
+	serveCmd.Flags().StringVar(&cloudConfigFile, "cloud-config", "", "YAML file configuring the connection to the task service and the cluster (default $"+cloudclient.ConfigFileEnv+")")
+	cloudFlags.BindFlags(serveCmd.Flags())
+	serveCmd.Flags().StringVar(&cloudFlags.Namespace, "namespace", "", "Namespace the Task resources are created in (default "+cloudclient.DefaultNamespace+")")
+	serveCmd.Flags().StringVar(&cloudFlags.Kubeconfig, "kubeconfig", "", "Kubeconfig used outside of a cluster (default $KUBECONFIG or ~/.kube/config)")
 }
 
 // runWorkflowOrchestration starts the workflow orchestration server and handles task updates.
@@ -47,6 +61,27 @@ func runWorkflowOrchestration(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	cfg, err := cloudclient.Load(cloudConfigFile, cloudFlags)
+	if err != nil {
+		return err
+	}
+	client, err := cfg.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	k8sClient, err := k8s.NewK8sClient(cfg.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	// A wrong URL, certificate, token or kubeconfig stops the agent rather than being retried forever
+	if err := cloudclient.Check(ctx, client, cfg.ServerURL); err != nil {
+		return err
+	}
+	if err := k8sClient.CheckConnection(); err != nil {
+		return err
+	}
+	logger.Info("Connected to the task service", "serverURL", cfg.ServerURL, "namespace", cfg.Namespace)
+
 	// Create a WaitGroup to wait for all goroutines to finish
 	var wg sync.WaitGroup
 
@@ -58,7 +93,7 @@ func runWorkflowOrchestration(ctx context.Context) error {
 			logger.Info("Workflow orchestration server stopped")
 			return nil
 		default:
-			if err := runStreamConnection(ctx, &wg, logger); err != nil {
+			if err := runStreamConnection(ctx, &wg, logger, client, k8sClient, cfg.Namespace); err != nil {
 				logger.Error("Stream connection error", "error", err)
 				time.Sleep(5 * time.Second) // Wait before retrying
 				continue
@@ -67,15 +102,7 @@ func runWorkflowOrchestration(ctx context.Context) error {
 	}
 }
 
-func runStreamConnection(ctx context.Context, wg *sync.WaitGroup, logger *slog.Logger) error {
-
-	var err error
-
-	client := cloudv1connect.NewTaskManagementServiceClient(http.DefaultClient, "http://localhost:8080")
-	k8sClient, err := k8s.NewK8sClient("/Users/yuvraj/.kube/config")
-	if err != nil {
-		return fmt.Errorf("failed to create k8s client: %w", err)
-	}
+func runStreamConnection(ctx context.Context, wg *sync.WaitGroup, logger *slog.Logger, client cloudv1connect.TaskManagementServiceClient, k8sClient *k8s.K8s, namespace string) error {
 	go sendPeriodicRequests(ctx, logger, client) // Pass stream as a pointer
 
 	stream, err := client.PullEvents(ctx, connect.NewRequest(&v1.PullEventsRequest{}))
@@ -89,7 +116,7 @@ func runStreamConnection(ctx context.Context, wg *sync.WaitGroup, logger *slog.L
 			return fmt.Errorf("failed to receive response: %w", err)
 		}
 
-		go processWork(ctx, stream.Msg(), logger, k8sClient)
+		go processWork(ctx, stream.Msg(), logger, k8sClient, namespace)
 	}
 }
 
@@ -116,21 +143,30 @@ func sendPeriodicRequests(ctx context.Context, logger *slog.Logger, client cloud
 	}
 }
 
-func processWork(ctx context.Context, task *v1.PullEventsResponse, logger *slog.Logger, k8sClient *k8s.K8s) {
+func processWork(ctx context.Context, task *v1.PullEventsResponse, logger *slog.Logger, k8sClient *k8s.K8s, namespace string) {
 
 	_, err := k8sClient.CreateTask(&taskApi.Task{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("task-%d", task.Work.Task.Id),
-			Namespace: "test",
+			Namespace: namespace,
 		},
 		Spec: taskApi.TaskSpec{
-			ID:   task.Work.Task.Id,
-			Type: task.Work.Task.Type,
+			ID:       task.Work.Task.Id,
+			Name:     task.Work.Task.Name,
+			Type:     task.Work.Task.Type,
+			Priority: &task.Work.Task.Priority,
 			Payload: taskApi.Payload{
 				Parameters: task.Work.Task.Payload.Parameters,
 			},
-			Status:      int32(task.Work.Task.Status),
-			Description: task.Work.Task.Description,
+			Status:                  int32(task.Work.Task.Status),
+			Description:             task.Work.Task.Description,
+			BaseImage:               task.Work.Task.BaseImage,
+			Entrypoint:              task.Work.Task.Entrypoint,
+			Args:                    task.Work.Task.Args,
+			Env:                     task.Work.Task.Env,
+			InputArtifacts:          convertArtifactInputs(task.Work.Task.InputArtifacts),
+			OutputArtifacts:         task.Work.Task.OutputArtifacts,
+			TTLSecondsAfterFinished: ttlSecondsAfterFinished(taskTTL),
 		},
 	})
 	if err != nil {
@@ -139,77 +175,22 @@ func processWork(ctx context.Context, task *v1.PullEventsResponse, logger *slog.
 	}
 }
 
-// processWorkflowUpdate handles different types of responses and returns the workflow state.
-func processWorkflowUpdate(ctx context.Context, task *v1.PullEventsResponse, logger *slog.Logger) (v1.TaskStatusEnum, string, error) {
-	response := task.Work
-	logger = logger.With("task_id", response.Task.Id)
-	logger.Info("Received workflow update", "task_type", response.Task.Type)
-
-	startTime := time.Now()
-	defer func() {
-		duration := time.Since(startTime)
-		logger.Info("Task processing completed", "duration", duration)
-	}()
-
-	defer func() {
-		if r := recover(); r != nil {
-			// Return FAILED status in case of panic
-			panic(fmt.Sprintf("Task panicked: %v", r))
-		}
-	}()
-
-	plugin, err := plugins.NewPlugin(response.Task.Type)
-	if err != nil {
-		return v1.TaskStatusEnum_FAILED, fmt.Sprintf("Failed to create plugin: %v", err), err
-	}
-
-	// Add retry logic for running the task
-	runErr := plugin.Run(response.Task.Payload.Parameters)
-
-	if runErr != nil {
-		return v1.TaskStatusEnum_FAILED, fmt.Sprintf("Error running task after %d attempts: %v"), runErr
-	}
-
-	logger.Info("Task completed successfully")
-
-	return v1.TaskStatusEnum_SUCCEEDED, "Task completed successfully", nil
-}
-
-// retry is a helper function to retry operations with exponential backoff
-func retry(ctx context.Context, maxAttempts int, initialBackoff time.Duration, operation func() error) error {
-	var err error
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		err = operation()
-		if err == nil {
-			return nil
-		}
-		if attempt == maxAttempts-1 {
-			break
-		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(initialBackoff * time.Duration(1<<uint(attempt))):
-		}
+// ttlSecondsAfterFinished returns the TTL of the Task resources, which is not set when ttl is negative.
+func ttlSecondsAfterFinished(ttl int32) *int32 {
+	if ttl < 0 {
+		return nil
 	}
-	return fmt.Errorf("operation failed after %d attempts: %w", maxAttempts, err)
+	return &ttl
 }
 
-// updateTaskStatus updates the status of a task using the Task Management Service.
-func updateTaskStatus(ctx context.Context, taskID int64, status v1.TaskStatusEnum, message string) error {
-	client, err := x.CreateClient(address)
-	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+// convertArtifactInputs copies the input artifacts of a task into the Task resource.
+func convertArtifactInputs(inputs []*v1.ArtifactInput) []taskApi.ArtifactInput {
+	if len(inputs) == 0 {
+		return nil
 	}
-
-	_, err = client.UpdateTaskStatus(ctx, connect.NewRequest(&v1.UpdateTaskStatusRequest{
-		Id:      int32(taskID),
-		Status:  status,
-		Message: message,
-	}))
-	if err != nil {
-		return fmt.Errorf("failed to update task %d status: %w", taskID, err)
+	converted := make([]taskApi.ArtifactInput, len(inputs))
+	for i, input := range inputs {
+		converted[i] = taskApi.ArtifactInput{Name: input.Name, TaskID: input.TaskId, Artifact: input.Artifact}
 	}
-
-	return nil
+	return converted
 }
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"task/pkg/artifact"
+	v1 "task/pkg/gen/cloud/v1"
+
+	"connectrpc.com/connect"
+	"github.com/spf13/cobra"
+)
+
+// artifactsTaskCmd represents the task artifacts command
+var artifactsTaskCmd = &cobra.Command{
+	Use:     "artifacts --id <task_id>",
+	Aliases: []string{"a", "art"},
+	Short:   "List or download the artifacts of a specific task",
+	Long: `List the output artifacts a task stored, along with the input and output artifacts
+it declared. Use --get to download an output artifact instead; it is written to a file
+named after the artifact in the current directory, or to --file ("-" writes to stdout).
+You can specify the output format of the list as table (default), json, or yaml.`,
+	Example: `  task artifacts --id 123
+  task artifacts --id 123 --get report.csv
+  task a -i 123 --get model.pt --file /tmp/model.pt
+  task artifacts --id 123 --get report.csv --file -`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetInt64("id")
+		if id <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --id flag is required and must be a positive integer")
+			cmd.Usage()
+			os.Exit(1)
+		}
+		name, _ := cmd.Flags().GetString("get")
+		var err error
+		if name != "" {
+			file, _ := cmd.Flags().GetString("file")
+			err = downloadArtifact(cmd.Context(), id, name, file)
+		} else {
+			outputFormat, _ := cmd.Flags().GetString("output")
+			err = listArtifacts(cmd.Context(), id, outputFormat)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	taskCmd.AddCommand(artifactsTaskCmd)
+	artifactsTaskCmd.Flags().Int64P("id", "i", 0, "ID of the task (required)")
+	artifactsTaskCmd.MarkFlagRequired("id")
+	artifactsTaskCmd.Flags().StringP("get", "g", "", "Download the output artifact with this name")
+	artifactsTaskCmd.Flags().String("file", "", "File the downloaded artifact is written to, defaulting to its name (- for stdout)")
+	artifactsTaskCmd.Flags().StringP("output", "o", "table", "Output format (table, json, yaml)")
+}
+
+// listArtifacts retrieves and prints the artifacts of a task by its ID
+func listArtifacts(ctx context.Context, identifier int64, outputFormat string) error {
+	client, err := createClient(address)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.ListArtifacts(ctx, connect.NewRequest(&v1.ListArtifactsRequest{Id: int32(identifier)}))
+	if err != nil {
+		return fmt.Errorf("failed to list task artifacts: %w", err)
+	}
+	printOutput(resp.Msg, outputFormat)
+	return nil
+}
+
+// downloadArtifact downloads an output artifact of a task to file
+func downloadArtifact(ctx context.Context, identifier int64, name, file string) error {
+	client, err := createClient(address)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.GetArtifactDownloadURL(ctx, connect.NewRequest(&v1.GetArtifactDownloadURLRequest{Id: int32(identifier), Name: name}))
+	if err != nil {
+		return fmt.Errorf("failed to get the artifact URL: %w", err)
+	}
+	body, err := artifact.Open(ctx, resp.Msg)
+	if err != nil {
+		return fmt.Errorf("failed to download artifact: %w", err)
+	}
+	defer body.Close()
+
+	if file == "-" {
+		_, err = io.Copy(os.Stdout, body)
+		return err
+	}
+	if file == "" {
+		file = filepath.Base(name)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", file, err)
+	}
+	n, err := io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	fmt.Fprintf(os.Stderr, "Downloaded %s (%d bytes) to %s\n", name, n, file)
+	return nil
+}
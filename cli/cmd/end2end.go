@@ -118,14 +118,27 @@ func createSingleTask(client cloudv1connect.TaskManagementServiceClient, index i
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	parameters := map[string]string{
+		"test": fmt.Sprintf("test_%d", index+1),
+	}
+	if taskType == "run_query" {
+		// The agents must either configure QUERY_DATASOURCE_END2END or run with QUERY_CHAOS_MODE=true
+		parameters["data_source"] = "end2end"
+		parameters["query"] = "SELECT 1"
+	}
+	if taskType == "send_email" {
+		// The agents must be configured with an SMTP server that accepts mail for example.com
+		parameters["to"] = "end2end@example.com"
+		parameters["subject"] = "End-to-end test {{.test}}"
+		parameters["body"] = "Sent by task-cli end2end for {{.test}}"
+	}
+
 	_, err := client.CreateTask(ctx, connect.NewRequest(&v1.CreateTaskRequest{
 		Name:        fmt.Sprintf("Task %d", index+1),
 		Description: fmt.Sprintf("Description for Task %d", index+1),
 		Type:        taskType,
 		Payload: &v1.Payload{
-			Parameters: map[string]string{
-				"test": fmt.Sprintf("test_%d", index+1),
-			},
+			Parameters: parameters,
 		},
 	}))
 
@@ -4,13 +4,26 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
 
 	v1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
 	"task/pkg/x"
 
 	"connectrpc.com/connect"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// historyPageSize is the number of entries requested per GetTaskHistory call.
+	historyPageSize = 1000
+	// historyFollowInterval is how often --follow polls the server for new entries.
+	historyFollowInterval = 2 * time.Second
 )
 
 // historyCmd represents the history command for tasks
@@ -20,10 +33,16 @@ var historyCmd = &cobra.Command{
 	Short:   "Get history of a specific task",
 	Long: `Retrieve and display the history of a specific task by its ID.
 This command shows all status changes and events related to the task over time.
-You can specify the output format as table (default), json, or yaml.`,
+You can specify the output format as table (default), json, or yaml.
+Use --since to only show entries after a point in time (an RFC 3339 time or a duration
+such as 1h), --limit to only show the most recent entries, --status to filter by
+comma-separated statuses, and --follow to keep printing new entries as they are recorded.`,
 	Example: `  task history --id 123
   task history --id 456 --output json
-  task h -i 789 -o yaml`,
+  task h -i 789 -o yaml
+  task history --id 123 --since 30m --limit 20
+  task history --id 123 --status failed
+  task history --id 123 -f`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		id, err := cmd.Flags().GetInt64("id")
@@ -41,54 +60,198 @@ You can specify the output format as table (default), json, or yaml.`,
 			fmt.Fprintf(os.Stderr, "Error: Failed to get 'output' flag: %v\n", err)
 			os.Exit(1)
 		}
-		if err := getTaskHistory(id, output); err != nil {
+		opts, err := buildHistoryOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			cmd.Usage()
+			os.Exit(1)
+		}
+		if err := getTaskHistory(cmd.Context(), id, output, opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 	},
 }
 
+// historyOptions holds the filters applied by the history command
+type historyOptions struct {
+	since    time.Time
+	limit    int
+	statuses []v1.TaskStatusEnum
+	follow   bool
+}
+
 func init() {
 	rootCmd.AddCommand(historyCmd)
 	historyCmd.Flags().Int64P("id", "i", 0, "ID of the task (required)")
 	historyCmd.MarkFlagRequired("id")
 	historyCmd.Flags().StringP("output", "o", "table", "Output format (table, json, yaml)")
+	historyCmd.Flags().String("since", "", "Only show entries since an RFC 3339 time or a duration ago (e.g. 1h)")
+	historyCmd.Flags().IntP("limit", "n", 0, "Only show the most recent N entries (0 shows all)")
+	historyCmd.Flags().StringP("status", "s", "all", "Filter by comma-separated statuses (queued, running, failed, succeeded, unknown, all)")
+	historyCmd.Flags().BoolP("follow", "f", false, "Keep printing new entries as they are recorded")
 }
 
-// getTaskHistory retrieves and prints the history of a task by its ID
-func getTaskHistory(identifier int64, outputFormat string) error {
-	history, err := fetchTaskHistory(identifier)
+// buildHistoryOptions reads the history command filter flags
+func buildHistoryOptions(cmd *cobra.Command) (historyOptions, error) {
+	var opts historyOptions
+	since, _ := cmd.Flags().GetString("since")
+	opts.limit, _ = cmd.Flags().GetInt("limit")
+	opts.follow, _ = cmd.Flags().GetBool("follow")
+	status, _ := cmd.Flags().GetString("status")
+
+	if opts.limit < 0 {
+		return opts, fmt.Errorf("--limit must not be negative")
+	}
+
+	if since != "" {
+		if d, err := time.ParseDuration(since); err == nil {
+			opts.since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+			opts.since = t
+		} else {
+			return opts, fmt.Errorf("invalid --since %q: expected a duration such as 1h or an RFC 3339 time", since)
+		}
+	}
+
+	statuses, err := parseStatuses(status)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve task history: %w", err)
+		return opts, err
 	}
-	return printTaskHistory(history, outputFormat)
+	opts.statuses = statuses
+	return opts, nil
 }
 
-// fetchTaskHistory retrieves the task history from the server
-func fetchTaskHistory(identifier int64) (*v1.GetTaskHistoryResponse, error) {
+// getTaskHistory retrieves and prints the history of a task by its ID
+func getTaskHistory(ctx context.Context, identifier int64, outputFormat string, opts historyOptions) error {
 	client, err := x.CreateClient(address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create client: %w", err)
+		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	req := connect.NewRequest(&v1.GetTaskHistoryRequest{Id: int32(identifier)})
-	resp, err := client.GetTaskHistory(context.Background(), req)
+	history, err := fetchTaskHistory(ctx, client, identifier, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get task history: %w", err)
+		return fmt.Errorf("failed to retrieve task history: %w", err)
+	}
+	if err := printTaskHistory(history, outputFormat, true); err != nil {
+		return err
+	}
+	if !opts.follow {
+		return nil
+	}
+	return followTaskHistory(ctx, client, identifier, outputFormat, opts, history.History)
+}
+
+// fetchTaskHistory retrieves the task history from the server, following page tokens.
+// With a limit, the newest entries are fetched and returned oldest first.
+func fetchTaskHistory(ctx context.Context, client cloudv1connect.TaskManagementServiceClient, identifier int64, opts historyOptions) (*v1.GetTaskHistoryResponse, error) {
+	req := &v1.GetTaskHistoryRequest{
+		Id:       int32(identifier),
+		Statuses: opts.statuses,
+		PageSize: historyPageSize,
+		// The server returns the newest entries first unless asked otherwise
+		SortOrder: v1.SortOrder_SORT_ORDER_ASC,
+	}
+	if !opts.since.IsZero() {
+		req.Since = timestamppb.New(opts.since)
+	}
+	if opts.limit > 0 {
+		req.SortOrder = v1.SortOrder_SORT_ORDER_DESC
+		if opts.limit < historyPageSize {
+			req.PageSize = int32(opts.limit)
+		}
+	}
+
+	result := &v1.GetTaskHistoryResponse{}
+	for {
+		resp, err := client.GetTaskHistory(ctx, connect.NewRequest(req))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task history: %w", err)
+		}
+		result.History = append(result.History, resp.Msg.History...)
+		if resp.Msg.NextPageToken == "" || (opts.limit > 0 && len(result.History) >= opts.limit) {
+			break
+		}
+		req.PageToken = resp.Msg.NextPageToken
+	}
+
+	if opts.limit > 0 {
+		if len(result.History) > opts.limit {
+			result.History = result.History[:opts.limit]
+		}
+		slices.Reverse(result.History)
+	}
+	return result, nil
+}
+
+// followTaskHistory polls for history entries recorded after the ones already printed until interrupted
+func followTaskHistory(ctx context.Context, client cloudv1connect.TaskManagementServiceClient, identifier int64, outputFormat string, opts historyOptions, seen []*v1.TaskHistory) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// Entry timestamps only have second precision, so resume from the last
+	// timestamp seen and drop the entries that were already printed.
+	var lastID int32
+	since := opts.since
+	for _, entry := range seen {
+		lastID = max(lastID, entry.Id)
+		if createdAt, err := time.Parse(time.RFC3339, entry.CreatedAt); err == nil && createdAt.After(since) {
+			since = createdAt
+		}
+	}
+
+	ticker := time.NewTicker(historyFollowInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		history, err := fetchTaskHistory(ctx, client, identifier, historyOptions{since: since, statuses: opts.statuses})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to retrieve task history: %w", err)
+		}
+
+		fresh := &v1.GetTaskHistoryResponse{}
+		for _, entry := range history.History {
+			if entry.Id <= lastID {
+				continue
+			}
+			fresh.History = append(fresh.History, entry)
+			lastID = entry.Id
+			if createdAt, err := time.Parse(time.RFC3339, entry.CreatedAt); err == nil && createdAt.After(since) {
+				since = createdAt
+			}
+		}
+		if len(fresh.History) == 0 {
+			continue
+		}
+		if err := printTaskHistory(fresh, outputFormat, false); err != nil {
+			return err
+		}
 	}
-	return resp.Msg, nil
 }
 
-// printTaskHistory prints task history in the specified format
-func printTaskHistory(history *v1.GetTaskHistoryResponse, outputFormat string) error {
-	switch outputFormat {
+// printTaskHistory prints task history in the specified format.
+// The table header is only printed when header is set, so followed entries line up with the first batch.
+func printTaskHistory(history *v1.GetTaskHistoryResponse, outputFormat string, header bool) error {
+	switch strings.ToLower(outputFormat) {
 	case "json":
 		return x.PrintJSON(history)
 	case "yaml":
 		return x.PrintYAML(history)
 	case "table":
 		table := tablewriter.NewWriter(os.Stdout)
-		x.PrintTaskHistoryTable(table, history)
+		if header {
+			x.PrintTaskHistoryTable(table, history)
+		} else {
+			x.PrintTaskHistoryRows(table, history)
+		}
 		return nil
 	default:
 		return fmt.Errorf("unsupported output format: %s", outputFormat)
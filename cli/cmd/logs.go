@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "task/pkg/gen/cloud/v1"
+	"task/pkg/x"
+
+	"connectrpc.com/connect"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// logsTaskCmd represents the task logs command
+var logsTaskCmd = &cobra.Command{
+	Use:     "logs --id <task_id>",
+	Aliases: []string{"lg"},
+	Short:   "Get the logs of a specific task",
+	Long: `Retrieve and display the log lines a task logged while it ran, including the output
+of processes it started. Lines are prefixed with their time, level and attempt.
+Use --attempt to only show the lines of one attempt, --since to only show lines after a
+point in time (an RFC 3339 time or a duration such as 1h), and --follow to keep printing
+new lines until the task has finished. While following, the progress the task reports is
+printed to stderr.
+You can specify the output format as text (default) or json, which prints a JSON object per line.`,
+	Example: `  task logs --id 123
+  task logs --id 123 -f
+  task logs --id 123 --attempt 2 --output json
+  task lg -i 123 --since 10m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetInt64("id")
+		if id <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --id flag is required and must be a positive integer")
+			cmd.Usage()
+			os.Exit(1)
+		}
+		req, err := buildStreamTaskLogsRequest(cmd, id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			cmd.Usage()
+			os.Exit(1)
+		}
+		outputFormat, _ := cmd.Flags().GetString("output")
+		if err := streamTaskLogs(cmd.Context(), req, outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	taskCmd.AddCommand(logsTaskCmd)
+	logsTaskCmd.Flags().Int64P("id", "i", 0, "ID of the task (required)")
+	logsTaskCmd.MarkFlagRequired("id")
+	logsTaskCmd.Flags().BoolP("follow", "f", false, "Keep printing new lines until the task has finished")
+	logsTaskCmd.Flags().Int32P("attempt", "a", 0, "Only show the lines of this attempt (0 shows all)")
+	logsTaskCmd.Flags().String("since", "", "Only show lines since an RFC 3339 time or a duration ago (e.g. 1h)")
+	logsTaskCmd.Flags().StringP("output", "o", "text", "Output format (text, json)")
+}
+
+// buildStreamTaskLogsRequest creates a StreamTaskLogsRequest from the logs command flags
+func buildStreamTaskLogsRequest(cmd *cobra.Command, id int64) (*v1.StreamTaskLogsRequest, error) {
+	req := &v1.StreamTaskLogsRequest{Id: int32(id)}
+	req.Follow, _ = cmd.Flags().GetBool("follow")
+	req.Attempt, _ = cmd.Flags().GetInt32("attempt")
+	since, _ := cmd.Flags().GetString("since")
+
+	if req.Attempt < 0 {
+		return nil, fmt.Errorf("--attempt must not be negative")
+	}
+	if since != "" {
+		if d, err := time.ParseDuration(since); err == nil {
+			req.Since = timestamppb.New(time.Now().Add(-d))
+		} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+			req.Since = timestamppb.New(t)
+		} else {
+			return nil, fmt.Errorf("invalid --since %q: expected a duration such as 1h or an RFC 3339 time", since)
+		}
+	}
+	return req, nil
+}
+
+// streamTaskLogs prints the log lines of a task as the server streams them, until the
+// stream ends or the command is interrupted
+func streamTaskLogs(ctx context.Context, req *v1.StreamTaskLogsRequest, outputFormat string) error {
+	outputFormat = strings.ToLower(outputFormat)
+	if outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+
+	client, err := createClient(address)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	stream, err := client.StreamTaskLogs(ctx, connect.NewRequest(req))
+	if err != nil {
+		return fmt.Errorf("failed to stream task logs: %w", err)
+	}
+	defer stream.Close()
+
+	for stream.Receive() {
+		// Progress goes to stderr so that the log lines on stdout stay parseable
+		if progress := stream.Msg().Progress; progress != nil && outputFormat == "text" {
+			fmt.Fprintf(os.Stderr, "progress %s\n", x.FormatProgress(progress))
+		}
+		for _, line := range stream.Msg().Lines {
+			if outputFormat == "json" {
+				data, err := json.Marshal(line)
+				if err != nil {
+					return fmt.Errorf("failed to encode log line: %w", err)
+				}
+				fmt.Println(string(data))
+				continue
+			}
+			fmt.Println(formatLogLine(line))
+		}
+	}
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to stream task logs: %w", err)
+	}
+	return nil
+}
+
+// formatLogLine formats a log line as its time, level, attempt and message,
+// followed by its attributes as key=value pairs sorted by key
+func formatLogLine(line *v1.LogLine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s [%d] %s", line.Time.AsTime().Local().Format(time.RFC3339), line.Level, line.Attempt, line.Message)
+
+	keys := make([]string, 0, len(line.Attributes))
+	for key := range line.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := line.Attributes[key]
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	v1 "task/pkg/gen/cloud/v1"
+
+	"connectrpc.com/connect"
+	"github.com/spf13/cobra"
+)
+
+// resultTaskCmd represents the task result command
+var resultTaskCmd = &cobra.Command{
+	Use:     "result --id [task_id]",
+	Aliases: []string{"r", "outputs"},
+	Short:   "Get the result of a specific task",
+	Long: `Retrieve and display the result a task reported when it finished: its outputs
+as key/value pairs and, when the task produced one, a JSON document.
+Tasks that are still running or did not report a result are shown with their status.
+You can specify the output format as table (default), json, or yaml.`,
+	Example: `  task result --id 123
+  task result --id 456 --output json
+  task r -i 789 -o yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetInt64("id")
+		if id <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --id flag is required and must be a positive integer")
+			cmd.Usage()
+			os.Exit(1)
+		}
+		outputFormat, _ := cmd.Flags().GetString("output")
+		if err := getTaskResult(cmd.Context(), id, outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	taskCmd.AddCommand(resultTaskCmd)
+	resultTaskCmd.Flags().Int64P("id", "i", 0, "ID of the task (required)")
+	resultTaskCmd.MarkFlagRequired("id")
+	resultTaskCmd.Flags().StringP("output", "o", "table", "Output format (table, json, yaml)")
+}
+
+// getTaskResult retrieves and prints the result of a task by its ID
+func getTaskResult(ctx context.Context, identifier int64, outputFormat string) error {
+	client, err := createClient(address)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.GetTaskResult(ctx, connect.NewRequest(&v1.GetTaskResultRequest{Id: int32(identifier)}))
+	if err != nil {
+		return fmt.Errorf("failed to retrieve task result: %w", err)
+	}
+	printOutput(resp.Msg, outputFormat)
+	return nil
+}
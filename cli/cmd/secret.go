@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	v1 "task/pkg/gen/cloud/v1"
+
+	"connectrpc.com/connect"
+	"github.com/spf13/cobra"
+)
+
+// secretCmd represents the secret command
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage the secrets kept by the server",
+	Long: `Manage the secrets kept by the server, encrypted with its SECRET_KEY.
+Tasks refer to a secret with a payload parameter or env value of secret://<name>,
+which workers resolve right before running the task. Values are never printed.`,
+}
+
+// setSecretCmd represents the secret set command
+var setSecretCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Create or update a secret",
+	Long: `Create a secret, or update the value of an existing one.
+The value is read from --from-file, or from stdin when neither --value nor --from-file is given,
+which keeps it out of the shell history. A single trailing newline read from stdin is dropped.`,
+	Example: `  task secret set smtp-password --value hunter2
+  task secret set db-url --from-file ./db-url.txt
+  echo -n hunter2 | task secret set smtp-password`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, err := readSecretValue(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			cmd.Usage()
+			os.Exit(1)
+		}
+		if err := setSecret(cmd.Context(), args[0], value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// listSecretCmd represents the secret list command
+var listSecretCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the secrets",
+	Long: `List the names of the secrets kept by the server, without their values.
+You can specify the output format as table (default), json, or yaml.`,
+	Example: `  task secret list
+  task secret ls -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		outputFormat, _ := cmd.Flags().GetString("output")
+		if err := listSecrets(cmd.Context(), outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// deleteSecretCmd represents the secret delete command
+var deleteSecretCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a secret",
+	Long:    `Delete a secret. Tasks that still refer to it fail when they are run.`,
+	Example: `  task secret delete smtp-password`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := deleteSecret(cmd.Context(), args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(setSecretCmd, listSecretCmd, deleteSecretCmd)
+	setSecretCmd.Flags().String("value", "", "Value of the secret")
+	setSecretCmd.Flags().String("from-file", "", "File the value of the secret is read from")
+	setSecretCmd.MarkFlagsMutuallyExclusive("value", "from-file")
+	listSecretCmd.Flags().StringP("output", "o", "table", "Output format (table, json, yaml)")
+
+	rootCmd.AddCommand(secretCmd)
+}
+
+// readSecretValue reads the value of the secret set command from its flags or stdin
+func readSecretValue(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("value") {
+		return cmd.Flags().GetString("value")
+	}
+	if file, _ := cmd.Flags().GetString("from-file"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		return string(data), nil
+	}
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the secret from stdin: %w", err)
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if value == "" {
+		return "", fmt.Errorf("the secret value is empty: pass --value, --from-file or the value on stdin")
+	}
+	return value, nil
+}
+
+// setSecret creates or updates a secret
+func setSecret(ctx context.Context, name, value string) error {
+	client, err := createClient(address)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.PutSecret(ctx, connect.NewRequest(&v1.PutSecretRequest{Name: name, Value: value}))
+	if err != nil {
+		return fmt.Errorf("failed to set secret: %w", err)
+	}
+	fmt.Printf("Secret %s set; refer to it as secret://%s\n", resp.Msg.Name, resp.Msg.Name)
+	return nil
+}
+
+// listSecrets retrieves and prints the secrets kept by the server
+func listSecrets(ctx context.Context, outputFormat string) error {
+	client, err := createClient(address)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.ListSecrets(ctx, connect.NewRequest(&v1.ListSecretsRequest{}))
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	printOutput(resp.Msg, outputFormat)
+	return nil
+}
+
+// deleteSecret deletes a secret
+func deleteSecret(ctx context.Context, name string) error {
+	client, err := createClient(address)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if _, err := client.DeleteSecret(ctx, connect.NewRequest(&v1.DeleteSecretRequest{Name: name})); err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+	fmt.Printf("Secret %s deleted\n", name)
+	return nil
+}
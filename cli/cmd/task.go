@@ -2,18 +2,27 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	v1 "task/pkg/gen/cloud/v1"
 	cloudv1connect "task/pkg/gen/cloud/v1/cloudv1connect"
+	"task/pkg/plugins"
+	_ "task/pkg/plugins/builtin" // Register the built-in task types
 	"task/pkg/x"
+	"text/tabwriter"
+	"time"
 
 	// Add this import
 
 	"connectrpc.com/connect"
 	"github.com/spf13/cobra"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // taskCmd represents the task command
@@ -28,20 +37,42 @@ Use subcommands to perform specific operations on tasks.`,
 
 // createTaskCmd represents the create task command
 var createTaskCmd = &cobra.Command{
-	Use:     "create [task name] --type [task type] --parameter [key=value] --description [task description]",
+	Use:     "create [task name] --type [task type] --parameter [key=value] --description [task description] --entrypoint [program] --arg [arg] --env [KEY=value]",
 	Aliases: []string{"c", "new"},
 	Short:   "Create a new task",
-	Long: `Create a new task in the system with the specified name, type, parameters, and description.
+	Long: fmt.Sprintf(`Create a new task in the system with the specified name, type, parameters, and description.
 You must provide a task name and type. Parameters and description are optional.
 
-The task type should be one of the predefined types in the system (e.g., send_email, run_query).
+The task type should be one of the types registered in the system (built-in: %s).
+Run "task types" to list the types the server accepts.
 Multiple parameters can be added by repeating the --parameter flag.
-The description flag allows you to add a detailed explanation of the task.`,
-	Example: `  task create "Send Newsletter" --type send_email --parameter recipient=user@example.com --parameter subject="Weekly Update" --description "Send weekly newsletter to subscribers"
-  task create "Generate Report" --type run_query --parameter query="SELECT * FROM sales" --parameter format=csv --description "Generate monthly sales report"
-  task c "Backup Database" --type system_backup --parameter target=/backups/db.sql --description "Perform full database backup"`,
-	Args: cobra.ExactArgs(1),
+The description flag allows you to add a detailed explanation of the task.
+The --image, --entrypoint, --arg and --env flags set how the task is executed;
+the process task type runs the entrypoint with its args and env on a worker.
+The --input-artifact flag makes an output artifact of an earlier task available to
+the task, and the --output-artifact flag declares an artifact the task produces.
+Run "task create --help-type [task type]" to show the parameters a task type accepts.`, strings.Join(plugins.Types(), ", ")),
+	Example: `  task create "Send Newsletter" --type send_email --parameter to=user@example.com --parameter subject="Weekly Update" --description "Send weekly newsletter to subscribers"
+  task create "Generate Report" --type run_query --parameter data_source=reporting --parameter query="SELECT * FROM sales" --parameter format=csv --description "Generate monthly sales report"
+  task c "Backup Database" --type system_backup --parameter target=/backups/db.sql --description "Perform full database backup"
+  task create "Train Model" --type process --entrypoint python --arg train.py --arg --epochs=10 --env SEED=42 --parameter timeout=2h
+  task create "Score Model" --type process --entrypoint python --arg score.py --input-artifact model.pt=42/model.pt --output-artifact scores.csv
+  task create --help-type send_email`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if helpType, _ := cmd.Flags().GetString("help-type"); helpType != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if helpType, _ := cmd.Flags().GetString("help-type"); helpType != "" {
+			if err := describeTaskType(helpType); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		taskName := args[0]
 		taskType, _ := cmd.Flags().GetString("type")
 		if taskType == "" {
@@ -49,9 +80,12 @@ The description flag allows you to add a detailed explanation of the task.`,
 			cmd.Usage()
 			os.Exit(1)
 		}
-		parameters, _ := cmd.Flags().GetStringToString("parameter")
-		description, _ := cmd.Flags().GetString("description")
-		addTask(taskName, taskType, parameters, description)
+		req, err := buildCreateTaskRequest(cmd, taskName, taskType)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		addTask(req)
 	},
 }
 
@@ -86,22 +120,32 @@ var listTaskCmd = &cobra.Command{
 	Long: `List all tasks in the system. This command displays a summary of all tasks,
 including their IDs, names, types, and current statuses.
 You can specify the output format as table (default), json, or yaml.
-Use --offset and --limit flags for pagination, --status for filtering by status,
-and --type for filtering by task type.`,
+Use --offset and --limit flags for pagination, --status for filtering by one or more
+comma-separated statuses, and --type for filtering by task type.
+Tasks can also be filtered by creation and update time (RFC 3339 timestamps), name prefix,
+priority range, and free-text search over the name and description.
+Results are sorted by --sort-by (created_at, updated_at, priority, name, id) in the
+--sort-order direction (asc, desc).`,
 	Example: `  task list
   task list --output json
   task ls -o yaml
   task list --offset 20 --limit 10
   task list --status running
-  task list --type email_send`,
+  task list --status queued,running
+  task list --type email_send
+  task list --created-after 2024-10-01T00:00:00Z --created-before 2024-10-08T00:00:00Z
+  task list --name-prefix nightly- --min-priority 5
+  task list --search "weekly report" --sort-by priority --sort-order asc`,
 	Args: cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
 		outputFormat, _ := cmd.Flags().GetString("output")
-		offset, _ := cmd.Flags().GetInt32("offset")
-		limit, _ := cmd.Flags().GetInt32("limit")
-		status, _ := cmd.Flags().GetString("status")
-		taskType, _ := cmd.Flags().GetString("type")
-		listTasks(outputFormat, offset, limit, status, taskType)
+		req, err := buildTaskListRequest(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			cmd.Usage()
+			os.Exit(1)
+		}
+		listTasks(outputFormat, req)
 	},
 }
 
@@ -110,19 +154,40 @@ var taskStatusCmd = &cobra.Command{
 	Use:     "status",
 	Aliases: []string{"s", "stat"},
 	Short:   "Get the status counts of all tasks",
-	Long:    `Retrieve and display the current status counts of all tasks in the system.`,
+	Long: `Retrieve and display the current status counts of all tasks in the system.
+Counts can be restricted to tasks created within a time window and broken down by task type.`,
 	Example: `  task status
-  task s`,
+  task s
+  task status --by-type
+  task status --created-after 2024-10-01T00:00:00Z --created-before 2024-10-08T00:00:00Z`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req, err := buildTaskStatusRequest(cmd)
+		if err != nil {
+			return err
+		}
+		return getTaskStatus(req)
+	},
+}
+
+// taskTypesCmd represents the task types command
+var taskTypesCmd = &cobra.Command{
+	Use:     "types",
+	Aliases: []string{"ty"},
+	Short:   "List the task types the server accepts",
+	Long:    `Retrieve and display the task types registered with the server, which are the valid values for --type.`,
+	Example: `  task types
+  task ty`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return getTaskStatus()
+		return listTaskTypes()
 	},
 }
 
 // init function to set up commands and flags
 func init() {
 
-	taskCmd.AddCommand(createTaskCmd, getTaskCmd, listTaskCmd, taskStatusCmd)
+	taskCmd.AddCommand(createTaskCmd, getTaskCmd, listTaskCmd, taskStatusCmd, taskTypesCmd)
 
 	addCommonFlags := func(cmd *cobra.Command) {
 		cmd.Flags().Int64P("id", "i", 0, "ID of the task")
@@ -136,13 +201,33 @@ func init() {
 	listTaskCmd.Flags().StringP("output", "o", "table", "Output format (table, json, yaml)")
 	listTaskCmd.Flags().Int32P("offset", "f", 0, "Offset for pagination")
 	listTaskCmd.Flags().Int32P("limit", "l", 100, "Limit for pagination")
-	listTaskCmd.Flags().StringP("status", "s", "all", "Filter by task status (queued, running, failed, succeeded,all)")
-	listTaskCmd.Flags().StringP("type", "t", "all", "Filter by task type (e.g., email_send, run_query,all)")
-
-	createTaskCmd.Flags().StringP("type", "t", "", "Type of the task (e.g., send_email, run_query)")
-	createTaskCmd.MarkFlagRequired("type")
+	listTaskCmd.Flags().StringP("status", "s", "all", "Filter by comma-separated task statuses (queued, running, failed, succeeded, unknown, all)")
+	listTaskCmd.Flags().StringP("type", "t", "all", fmt.Sprintf("Filter by task type (%s, all)", strings.Join(plugins.Types(), ", ")))
+	listTaskCmd.Flags().String("created-after", "", "Only list tasks created at or after this RFC 3339 time")
+	listTaskCmd.Flags().String("created-before", "", "Only list tasks created before this RFC 3339 time")
+	listTaskCmd.Flags().String("updated-after", "", "Only list tasks updated at or after this RFC 3339 time")
+	listTaskCmd.Flags().String("updated-before", "", "Only list tasks updated before this RFC 3339 time")
+	listTaskCmd.Flags().String("name-prefix", "", "Only list tasks whose name starts with this prefix")
+	listTaskCmd.Flags().Int32("min-priority", 0, "Only list tasks with at least this priority")
+	listTaskCmd.Flags().Int32("max-priority", 0, "Only list tasks with at most this priority")
+	listTaskCmd.Flags().StringP("search", "q", "", "Full-text search over task names and descriptions")
+	listTaskCmd.Flags().String("sort-by", "created_at", "Sort field (created_at, updated_at, priority, name, id)")
+	listTaskCmd.Flags().String("sort-order", "desc", "Sort direction (asc, desc)")
+
+	taskStatusCmd.Flags().String("created-after", "", "Only count tasks created at or after this RFC 3339 time")
+	taskStatusCmd.Flags().String("created-before", "", "Only count tasks created before this RFC 3339 time")
+	taskStatusCmd.Flags().Bool("by-type", false, "Break the counts down by task type")
+
+	createTaskCmd.Flags().StringP("type", "t", "", fmt.Sprintf("Type of the task (%s)", strings.Join(plugins.Types(), ", ")))
+	createTaskCmd.Flags().String("help-type", "", "Show the parameters of a task type instead of creating a task")
 	createTaskCmd.Flags().StringToStringP("parameter", "p", nil, "Additional parameters for the task as key=value pairs")
 	createTaskCmd.Flags().StringP("description", "d", "", "Detailed description of the task")
+	createTaskCmd.Flags().String("image", "", "Base image of the task execution environment")
+	createTaskCmd.Flags().String("entrypoint", "", "Program run by the task")
+	createTaskCmd.Flags().StringArray("arg", nil, "Argument passed to the entrypoint; repeat for each argument")
+	createTaskCmd.Flags().StringToString("env", nil, "Environment variables for the entrypoint as KEY=value pairs")
+	createTaskCmd.Flags().StringArray("input-artifact", nil, "Input artifact as name=task_id/artifact; repeat for each input")
+	createTaskCmd.Flags().StringArray("output-artifact", nil, "Name of an artifact the task produces; repeat for each output")
 
 	rootCmd.AddCommand(taskCmd)
 
@@ -161,38 +246,81 @@ func createClient(address string) (cloudv1connect.TaskManagementServiceClient, e
 	return x.CreateClient(address)
 }
 
-// addTask creates a new task and sends it to the server
-func addTask(name string, taskType string, parameters map[string]string, description string) {
-	slog.Info("Creating new task", "name", name, "type", taskType, "parameters", parameters, "description", description)
-
-	client, err := createClient(address)
-	if err != nil {
-		slog.Error("Failed to create client", "error", err)
-		return
+// buildCreateTaskRequest creates a CreateTaskRequest from the create command flags
+func buildCreateTaskRequest(cmd *cobra.Command, name, taskType string) (*v1.CreateTaskRequest, error) {
+	flags := cmd.Flags()
+	parameters, _ := flags.GetStringToString("parameter")
+	description, _ := flags.GetString("description")
+	image, _ := flags.GetString("image")
+	entrypoint, _ := flags.GetString("entrypoint")
+	args, _ := flags.GetStringArray("arg")
+	env, _ := flags.GetStringToString("env")
+	outputArtifacts, _ := flags.GetStringArray("output-artifact")
+	inputValues, _ := flags.GetStringArray("input-artifact")
+	inputArtifacts := make([]*v1.ArtifactInput, len(inputValues))
+	for i, value := range inputValues {
+		input, err := parseArtifactInput(value)
+		if err != nil {
+			return nil, err
+		}
+		inputArtifacts[i] = input
 	}
-	req := connect.NewRequest(&v1.CreateTaskRequest{
+
+	return &v1.CreateTaskRequest{
 		Name:        name,
 		Type:        taskType,
 		Description: description,
 		Payload: &v1.Payload{
 			Parameters: parameters,
 		},
-	})
+		BaseImage:       image,
+		Entrypoint:      entrypoint,
+		Args:            args,
+		Env:             env,
+		InputArtifacts:  inputArtifacts,
+		OutputArtifacts: outputArtifacts,
+	}, nil
+}
+
+// parseArtifactInput parses an input artifact given as name=task_id/artifact
+func parseArtifactInput(value string) (*v1.ArtifactInput, error) {
+	name, source, ok := strings.Cut(value, "=")
+	taskID, artifact, ok2 := strings.Cut(source, "/")
+	id, err := strconv.ParseInt(taskID, 10, 32)
+	if !ok || !ok2 || name == "" || artifact == "" || err != nil || id <= 0 {
+		return nil, fmt.Errorf("invalid input artifact %q: must be name=task_id/artifact", value)
+	}
+	return &v1.ArtifactInput{Name: name, TaskId: int32(id), Artifact: artifact}, nil
+}
+
+// addTask creates a new task and sends it to the server
+func addTask(task *v1.CreateTaskRequest) {
+	slog.Info("Creating new task", "name", task.Name, "type", task.Type, "parameters", task.Payload.Parameters, "description", task.Description)
+
+	client, err := createClient(address)
+	if err != nil {
+		slog.Error("Failed to create client", "error", err)
+		return
+	}
 
 	slog.Debug("Sending CreateTask request to server")
-	resp, err := client.CreateTask(context.Background(), req)
+	resp, err := client.CreateTask(context.Background(), connect.NewRequest(task))
 	if err != nil {
 		slog.Error("Error creating task", "error", err)
+		printFieldViolations(err)
 		return
 	}
 
 	slog.Info("Task created successfully", "id", resp.Msg.Id)
 	fmt.Printf("Task created successfully:\n")
 	fmt.Printf("  ID: %d\n", resp.Msg.Id)
-	fmt.Printf("  Name: %s\n", name)
-	fmt.Printf("  Type: %s\n", taskType)
-	fmt.Printf("  Parameters: %v\n", parameters)
-	fmt.Printf("  Description: %s\n", description)
+	fmt.Printf("  Name: %s\n", task.Name)
+	fmt.Printf("  Type: %s\n", task.Type)
+	fmt.Printf("  Parameters: %v\n", task.Payload.Parameters)
+	fmt.Printf("  Description: %s\n", task.Description)
+	if task.Entrypoint != "" {
+		fmt.Printf("  Entrypoint: %s %s\n", task.Entrypoint, strings.Join(task.Args, " "))
+	}
 }
 
 // getTask retrieves the details of a task by its ID
@@ -207,8 +335,8 @@ func getTask(identifier int64, outputFormat string) {
 }
 
 // listTasks retrieves and displays all tasks
-func listTasks(outputFormat string, offset, limit int32, status, taskType string) {
-	tasks, err := fetchTasks(offset, limit, status, taskType)
+func listTasks(outputFormat string, req *v1.TaskListRequest) {
+	tasks, err := fetchTasks(req)
 	if err != nil {
 		fmt.Printf("Error retrieving tasks: %v\n", err)
 		return
@@ -234,33 +362,115 @@ func fetchTask(identifier int64) (*v1.Task, error) {
 }
 
 // Helper function to fetch all tasks
-func fetchTasks(offset, limit int32, status, taskType string) (*v1.TaskList, error) {
+func fetchTasks(req *v1.TaskListRequest) (*v1.TaskList, error) {
 	client, err := createClient(address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
+	resp, err := client.ListTasks(context.Background(), connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
+
+// buildTaskListRequest creates a TaskListRequest from the list command flags
+func buildTaskListRequest(cmd *cobra.Command) (*v1.TaskListRequest, error) {
+	flags := cmd.Flags()
+	offset, _ := flags.GetInt32("offset")
+	limit, _ := flags.GetInt32("limit")
+	status, _ := flags.GetString("status")
+	taskType, _ := flags.GetString("type")
+	namePrefix, _ := flags.GetString("name-prefix")
+	search, _ := flags.GetString("search")
+	sortBy, _ := flags.GetString("sort-by")
+	sortOrder, _ := flags.GetString("sort-order")
+
 	req := &v1.TaskListRequest{
-		Limit:  limit,
-		Offset: offset,
+		Limit:      limit,
+		Offset:     offset,
+		NamePrefix: namePrefix,
+		Search:     search,
 	}
-	// Check if status is passed and valid, if not "all" then add to request
-	statusInt := x.GetStatusInt(strings.ToUpper(status))
-	if statusInt != -1 {
-		req.Status = &statusInt
-	} else {
-		return nil, fmt.Errorf("invalid status: %s", status)
+
+	// Check if statuses are passed and valid, "all" disables the status filter
+	statuses, err := parseStatuses(status)
+	if err != nil {
+		return nil, err
 	}
+	req.Statuses = statuses
+
 	// Check if type is passed and valid, if not "all" then add to request
 	if taskType != "all" {
 		req.Type = &taskType
 	}
 
-	resp, err := client.ListTasks(context.Background(), connect.NewRequest(req))
-	if err != nil {
-		return nil, err
+	// Priority bounds are only sent when explicitly set, since 0 is a valid priority
+	if flags.Changed("min-priority") {
+		minPriority, _ := flags.GetInt32("min-priority")
+		req.MinPriority = &minPriority
 	}
-	return resp.Msg, nil
+	if flags.Changed("max-priority") {
+		maxPriority, _ := flags.GetInt32("max-priority")
+		req.MaxPriority = &maxPriority
+	}
+
+	timeFilters := []struct {
+		flag   string
+		target **timestamppb.Timestamp
+	}{
+		{"created-after", &req.CreatedAfter},
+		{"created-before", &req.CreatedBefore},
+		{"updated-after", &req.UpdatedAfter},
+		{"updated-before", &req.UpdatedBefore},
+	}
+	for _, f := range timeFilters {
+		value, _ := flags.GetString(f.flag)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s %q: expected an RFC 3339 time such as 2024-10-01T00:00:00Z", f.flag, value)
+		}
+		*f.target = timestamppb.New(t)
+	}
+
+	sortField, ok := v1.TaskSortField_value["TASK_SORT_FIELD_"+strings.ToUpper(sortBy)]
+	if !ok {
+		return nil, fmt.Errorf("invalid --sort-by %q: must be one of created_at, updated_at, priority, name, id", sortBy)
+	}
+	req.SortBy = v1.TaskSortField(sortField)
+
+	order, ok := v1.SortOrder_value["SORT_ORDER_"+strings.ToUpper(sortOrder)]
+	if !ok {
+		return nil, fmt.Errorf("invalid --sort-order %q: must be asc or desc", sortOrder)
+	}
+	req.SortOrder = v1.SortOrder(order)
+
+	return req, nil
+}
+
+// parseStatuses converts a comma-separated list of status names into task statuses.
+// It returns nil when the list contains "all".
+func parseStatuses(value string) ([]v1.TaskStatusEnum, error) {
+	var statuses []v1.TaskStatusEnum
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		status, ok := v1.TaskStatusEnum_value[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid status: %s", name)
+		}
+		if v1.TaskStatusEnum(status) == v1.TaskStatusEnum_ALL {
+			return nil, nil
+		}
+		statuses = append(statuses, v1.TaskStatusEnum(status))
+	}
+	return statuses, nil
 }
 
 // printOutput prints the data in the specified format
@@ -302,8 +512,115 @@ func InitLogger(level string) {
 	slog.SetDefault(logger)
 }
 
+// listTaskTypes retrieves and displays the task types registered with the server
+func listTaskTypes() error {
+	client, err := createClient(address)
+	if err != nil {
+		slog.Error("Failed to create client", "error", err)
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.ListTaskTypes(context.Background(), connect.NewRequest(&v1.ListTaskTypesRequest{}))
+	if err != nil {
+		slog.Error("Error retrieving task types", "error", err)
+		return fmt.Errorf("error retrieving task types: %w", err)
+	}
+
+	fmt.Println("Task Types:")
+	for _, taskType := range resp.Msg.TaskTypes {
+		fmt.Printf("  %s\n", taskType)
+	}
+	return nil
+}
+
+// describeTaskType retrieves and displays the parameter schema of a task type
+func describeTaskType(taskType string) error {
+	client, err := createClient(address)
+	if err != nil {
+		slog.Error("Failed to create client", "error", err)
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.DescribeTaskType(context.Background(), connect.NewRequest(&v1.DescribeTaskTypeRequest{TaskType: taskType}))
+	if err != nil {
+		slog.Error("Error describing task type", "error", err)
+		return fmt.Errorf("error describing task type: %w", err)
+	}
+
+	fmt.Printf("%s: %s\n\n", resp.Msg.TaskType, resp.Msg.Description)
+	if len(resp.Msg.Parameters) == 0 {
+		fmt.Println("No declared parameters.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PARAMETER\tTYPE\tREQUIRED\tDEFAULT\tDESCRIPTION")
+		for _, p := range resp.Msg.Parameters {
+			description := p.Description
+			if p.Pattern != "" {
+				description += fmt.Sprintf(" (must match %s)", p.Pattern)
+			}
+			if p.Secret {
+				description += " (secret)"
+			}
+			paramType := strings.ToLower(strings.TrimPrefix(p.Type.String(), "PARAMETER_TYPE_"))
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\n", p.Name, paramType, p.Required, p.DefaultValue, description)
+		}
+		w.Flush()
+	}
+	if resp.Msg.AdditionalParameters {
+		fmt.Println("\nParameters that are not declared are accepted as well.")
+	}
+	return nil
+}
+
+// printFieldViolations prints the invalid fields reported by a failed request, if any
+func printFieldViolations(err error) {
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		return
+	}
+	for _, detail := range connectErr.Details() {
+		value, err := detail.Value()
+		if err != nil {
+			continue
+		}
+		if badRequest, ok := value.(*errdetails.BadRequest); ok {
+			fmt.Println("Invalid fields:")
+			for _, v := range badRequest.FieldViolations {
+				fmt.Printf("  %s: %s\n", v.Field, v.Description)
+			}
+		}
+	}
+}
+
+// buildTaskStatusRequest creates a GetStatusRequest from the status command flags
+func buildTaskStatusRequest(cmd *cobra.Command) (*v1.GetStatusRequest, error) {
+	flags := cmd.Flags()
+	req := &v1.GetStatusRequest{}
+	req.ByType, _ = flags.GetBool("by-type")
+
+	timeFilters := []struct {
+		flag   string
+		target **timestamppb.Timestamp
+	}{
+		{"created-after", &req.CreatedAfter},
+		{"created-before", &req.CreatedBefore},
+	}
+	for _, f := range timeFilters {
+		value, _ := flags.GetString(f.flag)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s %q: expected an RFC 3339 time such as 2024-10-01T00:00:00Z", f.flag, value)
+		}
+		*f.target = timestamppb.New(t)
+	}
+	return req, nil
+}
+
 // getTaskStatus retrieves and displays the status counts of all tasks
-func getTaskStatus() error {
+func getTaskStatus(req *v1.GetStatusRequest) error {
 	slog.Info("Retrieving task status counts")
 
 	client, err := createClient(address)
@@ -312,7 +629,7 @@ func getTaskStatus() error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	resp, err := client.GetStatus(context.Background(), connect.NewRequest(&v1.GetStatusRequest{}))
+	resp, err := client.GetStatus(context.Background(), connect.NewRequest(req))
 	if err != nil {
 		slog.Error("Error retrieving task status counts", "error", err)
 		return fmt.Errorf("error retrieving task status counts: %w", err)
@@ -324,6 +641,20 @@ func getTaskStatus() error {
 		fmt.Printf("  %s: %d\n", statusString, v)
 	}
 
+	if req.ByType {
+		types := make([]string, 0, len(resp.Msg.TypeCounts))
+		for taskType := range resp.Msg.TypeCounts {
+			types = append(types, taskType)
+		}
+		sort.Strings(types)
+		for _, taskType := range types {
+			fmt.Printf("%s:\n", taskType)
+			for k, v := range resp.Msg.TypeCounts[taskType].StatusCounts {
+				fmt.Printf("  %s: %d\n", x.GetStatusString(int(k)), v)
+			}
+		}
+	}
+
 	slog.Info("Task status counts retrieved successfully")
 	return nil
 }
@@ -0,0 +1,74 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Defaults of the fields of a TaskSpec that are not set.
+const (
+	// DefaultRetries is the number of times a failed task is retried by default.
+	DefaultRetries int32 = 2
+
+	// DefaultPriority is the priority of a task by default.
+	DefaultPriority int32 = 0
+
+	// DefaultTimeout is the time an attempt at a task may run by default.
+	DefaultTimeout = time.Hour
+)
+
+// Default sets the fields of the spec that are not set to their defaults.
+func (s *TaskSpec) Default() {
+	if s.Retries == nil {
+		retries := DefaultRetries
+		s.Retries = &retries
+	}
+	if s.Priority == nil {
+		priority := DefaultPriority
+		s.Priority = &priority
+	}
+	if s.Timeout == nil {
+		s.Timeout = &metav1.Duration{Duration: DefaultTimeout}
+	}
+}
+
+// GetRetries returns the number of times a failed task is retried, or DefaultRetries when it is not set.
+func (s *TaskSpec) GetRetries() int32 {
+	if s.Retries == nil {
+		return DefaultRetries
+	}
+	return *s.Retries
+}
+
+// GetPriority returns the priority of the task, or DefaultPriority when it is not set.
+func (s *TaskSpec) GetPriority() int32 {
+	if s.Priority == nil {
+		return DefaultPriority
+	}
+	return *s.Priority
+}
+
+// GetTimeout returns the time an attempt at the task may run, or DefaultTimeout when it is not set.
+func (s *TaskSpec) GetTimeout() time.Duration {
+	if s.Timeout == nil {
+		return DefaultTimeout
+	}
+	return s.Timeout.Duration
+}
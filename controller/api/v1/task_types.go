@@ -37,11 +37,20 @@ type TaskSpec struct {
 	// Status is the current status of the task.
 	Status int32 `json:"status,omitempty"`
 
-	// Retries is the number of retries attempted for this task.
-	Retries int32 `json:"retries,omitempty"`
+	// Retries is the number of times a failed task is retried before it fails for good.
+	// It defaults to DefaultRetries.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	Retries *int32 `json:"retries,omitempty"`
 
-	// Priority is the priority level of the task.
-	Priority int32 `json:"priority,omitempty"`
+	// Priority is the priority level of the task. Higher values indicate higher priority.
+	// It defaults to DefaultPriority.
+	// +kubebuilder:validation:Minimum=0
+	Priority *int32 `json:"priority,omitempty"`
+
+	// Timeout is the time an attempt at the task may run before it is stopped and fails.
+	// It defaults to DefaultTimeout.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 
 	// CreatedAt is the timestamp of when the task was created.
 	CreatedAt string `json:"created_at,omitempty"`
@@ -51,6 +60,41 @@ type TaskSpec struct {
 
 	// Description is a description of the task.
 	Description string `json:"description,omitempty"`
+
+	// BaseImage is the image of the task execution environment.
+	BaseImage string `json:"base_image,omitempty"`
+
+	// Entrypoint is the program run by the task.
+	Entrypoint string `json:"entrypoint,omitempty"`
+
+	// Args are the arguments passed to the entrypoint.
+	Args []string `json:"args,omitempty"`
+
+	// Env are the environment variables set for the entrypoint.
+	Env map[string]string `json:"env,omitempty"`
+
+	// InputArtifacts are output artifacts of earlier tasks that are downloaded before the task runs.
+	InputArtifacts []ArtifactInput `json:"input_artifacts,omitempty"`
+
+	// OutputArtifacts are the names of the artifacts uploaded after the task succeeds.
+	OutputArtifacts []string `json:"output_artifacts,omitempty"`
+
+	// TTLSecondsAfterFinished is the number of seconds after which a finished task is deleted.
+	// Finished tasks are kept when it is not set.
+	// +kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished *int32 `json:"ttl_seconds_after_finished,omitempty"`
+}
+
+// ArtifactInput makes the output artifact of an earlier task available to a task.
+type ArtifactInput struct {
+	// Name is the name the artifact is made available under.
+	Name string `json:"name"`
+
+	// TaskID is the ID of the task that produces the artifact.
+	TaskID int32 `json:"task_id"`
+
+	// Artifact is the name of the output artifact of the producing task.
+	Artifact string `json:"artifact"`
 }
 
 // Payload defines the parameters for the task.
@@ -59,17 +103,75 @@ type Payload struct {
 	Parameters map[string]string `json:"parameters,omitempty"`
 }
 
+// Condition types of a Task.
+const (
+	// ConditionRunning is True while an attempt at the task runs.
+	ConditionRunning = "Running"
+
+	// ConditionSucceeded is True once the task succeeded and False once it failed for good.
+	// It is Unknown until then.
+	ConditionSucceeded = "Succeeded"
+)
+
+// Reasons of the conditions of a Task.
+const (
+	// ReasonQueued is the reason of the conditions of a task that waits to run.
+	ReasonQueued = "Queued"
+
+	// ReasonAttemptRunning is the reason of the conditions of a task while an attempt runs.
+	ReasonAttemptRunning = "AttemptRunning"
+
+	// ReasonAttemptFailed is the reason of the Running condition of a task whose last attempt
+	// failed and that waits to be retried.
+	ReasonAttemptFailed = "AttemptFailed"
+
+	// ReasonSucceeded is the reason of the conditions of a task that succeeded.
+	ReasonSucceeded = "Succeeded"
+
+	// ReasonFailed is the reason of the conditions of a task that failed for good.
+	ReasonFailed = "Failed"
+)
+
 // TaskStatus defines the observed state of Task
 type TaskStatus struct {
 	// Status is the current status of the task.
 	Status int32 `json:"status,omitempty"`
 
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Phase is the name of the current status of the task, such as RUNNING.
+	Phase string `json:"phase,omitempty"`
+
+	// Attempts is the number of attempts at the task that were started.
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// StartTime is the time the first attempt at the task started.
+	StartTime *metav1.Time `json:"start_time,omitempty"`
+
+	// CompletionTime is the time the task succeeded or failed for good.
+	CompletionTime *metav1.Time `json:"completion_time,omitempty"`
+
+	// LastError is the error of the last attempt that failed.
+	LastError string `json:"last_error,omitempty"`
+
+	// ObservedGeneration is the generation of the task the status was last updated for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+
+	// Conditions are the latest observations of the state of the task.
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Attempts",type=integer,JSONPath=`.status.attempts`
+// +kubebuilder:printcolumn:name="Started",type=date,JSONPath=`.status.start_time`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completion_time`,priority=1
+// +kubebuilder:printcolumn:name="Last Error",type=string,JSONPath=`.status.last_error`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Task is the Schema for the tasks API
 type Task struct {
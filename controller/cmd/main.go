@@ -19,17 +19,20 @@ package main
 import (
 	"crypto/tls"
 	"flag"
-	"net/http"
+	"log/slog"
 	"os"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -38,7 +41,10 @@ import (
 
 	taskiov1 "task/controller/api/v1"
 	controller "task/controller/internal/controller"
-	"task/pkg/gen/cloud/v1/cloudv1connect"
+	"task/controller/internal/job"
+	webhooktaskv1 "task/controller/internal/webhook/v1"
+	"task/pkg/cloudclient"
+	"task/pkg/plugins/external"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -60,10 +66,23 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var secretSource string
+	var maxConcurrentReconciles int
+	var cloudConfigFile string
+	var cloudFlags cloudclient.Config
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&secretSource, "secret-source", controller.SecretSourceService,
+		"Where the secrets tasks refer to are resolved from: \"service\" for the secret store of the task service, "+
+			"or \"kubernetes\" for the Secrets in the namespace of the task.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The number of tasks reconciled at the same time. Tasks run in the background, so this does not limit how many run.")
+	flag.StringVar(&cloudConfigFile, "cloud-config", "",
+		"YAML file configuring the connection to the task service, overridden by the environment and the flags. "+
+			"Defaults to $"+cloudclient.ConfigFileEnv+".")
+	cloudFlags.BindFlags(flag.CommandLine)
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -129,6 +148,10 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "f448886c.task.io",
+		// Secrets are read as tasks run rather than watched, so that only get is needed on them
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -146,14 +169,63 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The job runner reads pod logs, which the manager client cannot
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create clientset")
+		os.Exit(1)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+
+	cloudConfig, err := cloudclient.Load(cloudConfigFile, cloudFlags)
+	if err != nil {
+		setupLog.Error(err, "unable to load the task service configuration")
+		os.Exit(1)
+	}
+	cloudClient, err := cloudConfig.NewClient()
+	if err != nil {
+		setupLog.Error(err, "unable to create the task service client")
+		os.Exit(1)
+	}
+	// A wrong URL, certificate or token fails the controller rather than every task
+	if err := cloudclient.Check(ctx, cloudClient, cloudConfig.ServerURL); err != nil {
+		setupLog.Error(err, "unable to connect to the task service")
+		os.Exit(1)
+	}
+	setupLog.Info("connected to the task service", "serverURL", cloudConfig.ServerURL)
+
+	// Register the task types of the plugins in PLUGIN_DIR
+	pluginHost, err := external.Load(ctx, slog.Default())
+	if err != nil {
+		setupLog.Error(err, "unable to load plugins")
+		os.Exit(1)
+	}
+	defer pluginHost.Close()
+
 	if err = (&controller.TaskReconciler{
-		Client:      mgr.GetClient(),
-		Scheme:      mgr.GetScheme(),
-		CloudClient: cloudv1connect.NewTaskManagementServiceClient(http.DefaultClient, "https://localhost:8080"),
+		Client:                  mgr.GetClient(),
+		APIReader:               mgr.GetAPIReader(),
+		Scheme:                  mgr.GetScheme(),
+		CloudClient:             cloudClient,
+		SecretSource:            secretSource,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Jobs: &job.Runner{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			Clientset: clientset,
+		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Task")
 		os.Exit(1)
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhooktaskv1.SetupTaskWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Task")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -166,7 +238,7 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
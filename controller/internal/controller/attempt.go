@@ -0,0 +1,258 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/plugins"
+	"task/pkg/progress"
+	"task/pkg/tasklog"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// initialBackoff is the delay before the second attempt at a task, doubled before every further attempt.
+const initialBackoff = 1 * time.Second
+
+// errInterrupted is the error of an attempt that was running when the controller stopped.
+var errInterrupted = errors.New("attempt was interrupted by a restart of the controller")
+
+// attemptOutcome is how an attempt at a task ended.
+type attemptOutcome struct {
+	attempt int32
+	message string
+	result  plugins.Result
+	err     error
+}
+
+// backoff returns the delay between a failed attempt and the next one.
+func backoff(attempt int32) time.Duration {
+	return initialBackoff * time.Duration(1<<uint(max(attempt, 1)-1))
+}
+
+// maxAttempts returns the number of attempts at a task run in the controller before it fails for good.
+func maxAttempts(task *v1.Task) int32 {
+	return task.Spec.GetRetries() + 1
+}
+
+// reconcileAttempt runs the next attempt at a task in the controller, unless an attempt is running already.
+// Attempts run in the background so that reconciles return right away. Once an attempt finishes, its outcome
+// is written to the status of the Task and the Task is reconciled again, which retries failed attempts by
+// requeueing the Task until their backoff has passed. Outcomes that could not be written are retried.
+func (r *TaskReconciler) reconcileAttempt(ctx context.Context, task *v1.Task) (ctrl.Result, error) {
+	if r.isRunning(task) {
+		return ctrl.Result{}, nil
+	}
+	if outcome, ok := r.takeOutcome(task); ok {
+		if err := r.finishAttempt(ctx, task, outcome.attempt, outcome.message, outcome.result, outcome.err); err != nil {
+			r.keepOutcome(task, outcome)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	running := meta.FindStatusCondition(task.Status.Conditions, v1.ConditionRunning)
+	switch {
+	case running != nil && running.Status == metav1.ConditionTrue:
+		interrupted, err := r.isInterrupted(ctx, task)
+		if err != nil || !interrupted {
+			return ctrl.Result{}, err
+		}
+		// The attempt the status records as running is not, so the controller stopped while it ran
+		return ctrl.Result{}, r.finishAttempt(ctx, task, task.Status.Attempts, "", plugins.Result{}, errInterrupted)
+	case running != nil && running.Reason == v1.ReasonAttemptFailed:
+		if wait := time.Until(running.LastTransitionTime.Add(backoff(task.Status.Attempts))); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+	return ctrl.Result{}, r.startAttempt(ctx, task, task.Status.Attempts+1)
+}
+
+// startAttempt records the start of an attempt in the task service and in the status of the Task,
+// so that the attempt is not started twice, and runs it in the background.
+func (r *TaskReconciler) startAttempt(ctx context.Context, task *v1.Task, attempt int32) error {
+	message := fmt.Sprintf("Running attempt %d of %d", attempt, maxAttempts(task))
+	if err := r.updateTaskStatus(ctx, int64(task.Spec.ID), cloudv1.TaskStatusEnum_RUNNING, message, nil); err != nil {
+		return err
+	}
+	setStatus(task, cloudv1.TaskStatusEnum_RUNNING, attempt, message, metav1.Now())
+	if err := r.updateResourceStatus(ctx, task); err != nil {
+		return err
+	}
+
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	key := client.ObjectKeyFromObject(task)
+	r.mu.Lock()
+	if r.running == nil {
+		r.running = make(map[client.ObjectKey]context.CancelCauseFunc)
+	}
+	r.running[key] = cancel
+	r.mu.Unlock()
+
+	go func() {
+		defer cancel(nil)
+		message, result, err := r.execute(attemptCtx, task, int(attempt))
+		// The attempts at deleted tasks are reported by the finalizer once they stopped
+		if errors.Is(context.Cause(attemptCtx), errTaskDeleted) {
+			log.FromContext(ctx).Info("Attempt stopped as the task was deleted", "attempt", attempt)
+		} else if finishErr := r.finishAttempt(ctx, task, attempt, message, result, err); finishErr != nil {
+			// The outcome is recorded by the next reconcile rather than taken for an interrupted attempt
+			log.FromContext(ctx).Error(finishErr, "Failed to record the outcome of the attempt", "attempt", attempt)
+			r.keepOutcome(task, attemptOutcome{attempt: attempt, message: message, result: result, err: err})
+		}
+
+		r.mu.Lock()
+		delete(r.running, key)
+		r.mu.Unlock()
+		r.requeue(ctx, task)
+	}()
+	return nil
+}
+
+// execute runs an attempt at the task, shipping the lines it logs and the progress it reports to the
+// task service. A panic of the plugin fails the attempt rather than the controller, and so does running
+// for longer than the timeout of the task.
+func (r *TaskReconciler) execute(ctx context.Context, task *v1.Task, attempt int) (message string, result plugins.Result, err error) {
+	ctx, cancel := context.WithTimeout(ctx, task.Spec.GetTimeout())
+	defer cancel()
+	logger := slog.New(logr.ToSlogHandler(log.FromContext(ctx)))
+	// The lines of the attempt are shipped before its status, so that they can be read once it finishes
+	shipper := tasklog.NewShipper(r.CloudClient, int64(task.Spec.ID), attempt, tasklog.DefaultFlushInterval, logger)
+	reporter := progress.NewReporter(r.CloudClient, int64(task.Spec.ID), attempt, progress.DefaultInterval, logger)
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("task panicked: %v", p)
+		}
+		// What was logged and reported is shipped even when the attempt was cancelled
+		closeCtx := context.WithoutCancel(ctx)
+		if err := reporter.Close(closeCtx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to report task progress")
+		}
+		if err := shipper.Close(closeCtx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to ship task logs")
+		}
+	}()
+	message, result, err = r.runAttempt(ctx, task, attempt, shipper, reporter)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s: %w", task.Spec.GetTimeout(), err)
+	}
+	return message, result, err
+}
+
+// finishAttempt records the outcome of an attempt in the task service and in the status of the Task.
+// Every failed attempt is reported, and the task fails for good once it has no attempts left.
+// The result of the attempt is recorded along with the final status of the task.
+func (r *TaskReconciler) finishAttempt(ctx context.Context, task *v1.Task, attempt int32, message string, result plugins.Result, runErr error) error {
+	status := cloudv1.TaskStatusEnum_SUCCEEDED
+	if runErr != nil {
+		failedMessage := fmt.Sprintf("Attempt %d failed: %v", attempt, runErr)
+		if err := r.updateTaskStatus(ctx, int64(task.Spec.ID), cloudv1.TaskStatusEnum_FAILED, failedMessage, nil); err != nil {
+			return err
+		}
+		if attempt < maxAttempts(task) {
+			return r.retryResourceStatus(ctx, task, func(task *v1.Task) {
+				setAttemptFailed(task, attempt, runErr)
+			})
+		}
+		status = cloudv1.TaskStatusEnum_FAILED
+		message = fmt.Sprintf("All %d attempts failed. Last error: %v", maxAttempts(task), runErr)
+		log.FromContext(ctx).Error(runErr, "Final failure after max attempts")
+	} else {
+		message = fmt.Sprintf("Task completed successfully on attempt %d: %s", attempt, message)
+		log.FromContext(ctx).Info(message)
+	}
+
+	if err := r.updateTaskStatus(ctx, int64(task.Spec.ID), status, message, taskResult(ctx, result)); err != nil {
+		return err
+	}
+	return r.retryResourceStatus(ctx, task, func(task *v1.Task) {
+		if runErr != nil {
+			setAttemptFailed(task, attempt, runErr)
+		}
+		setStatus(task, status, attempt, message, metav1.Now())
+	})
+}
+
+// isRunning reports whether an attempt at the task is running in the controller.
+func (r *TaskReconciler) isRunning(task *v1.Task) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.running[client.ObjectKeyFromObject(task)]
+	return ok
+}
+
+// isInterrupted reports whether the attempt the status of the task records as running was
+// interrupted by a restart of the controller. The cache may not have seen the outcome an attempt
+// of this controller wrote yet, so the status is read again from the API server.
+func (r *TaskReconciler) isInterrupted(ctx context.Context, task *v1.Task) (bool, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	latest := &v1.Task{}
+	if err := reader.Get(ctx, client.ObjectKeyFromObject(task), latest); err != nil {
+		// A deleted task is left to the finalizer
+		return false, client.IgnoreNotFound(err)
+	}
+	running := meta.FindStatusCondition(latest.Status.Conditions, v1.ConditionRunning)
+	return running != nil && running.Status == metav1.ConditionTrue && latest.Status.Attempts == task.Status.Attempts, nil
+}
+
+// keepOutcome keeps the outcome of an attempt at the task that could not be recorded.
+func (r *TaskReconciler) keepOutcome(task *v1.Task, outcome attemptOutcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.outcomes == nil {
+		r.outcomes = make(map[client.ObjectKey]attemptOutcome)
+	}
+	r.outcomes[client.ObjectKeyFromObject(task)] = outcome
+}
+
+// takeOutcome removes and returns the outcome kept for the task, if any.
+func (r *TaskReconciler) takeOutcome(task *v1.Task) (attemptOutcome, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := client.ObjectKeyFromObject(task)
+	outcome, ok := r.outcomes[key]
+	delete(r.outcomes, key)
+	return outcome, ok
+}
+
+// requeue triggers a reconcile of the task once its attempt stopped running.
+// It gives up once ctx is done, as the controller no longer receives the events then.
+func (r *TaskReconciler) requeue(ctx context.Context, task *v1.Task) {
+	if r.events == nil {
+		return
+	}
+	select {
+	case r.events <- event.GenericEvent{Object: task}:
+	case <-ctx.Done():
+	}
+}
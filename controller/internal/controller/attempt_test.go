@@ -0,0 +1,319 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+	"task/pkg/plugins"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// testTaskType is the type of the tasks run by testPlugin.
+const testTaskType = "controller-test"
+
+// testPlugin fails every attempt, or blocks until the attempt is cancelled when the block parameter is set.
+type testPlugin struct{}
+
+func (testPlugin) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	if task.Parameters["block"] == "true" {
+		<-ctx.Done()
+		return plugins.Result{}, context.Cause(ctx)
+	}
+	return plugins.Result{}, errors.New("exit status 1")
+}
+
+func init() {
+	plugins.Register(testTaskType, func() plugins.Plugin { return testPlugin{} })
+}
+
+// fakeCloudClient records the statuses reported to the task service.
+type fakeCloudClient struct {
+	cloudv1connect.TaskManagementServiceClient
+
+	mu       sync.Mutex
+	requests []*cloudv1.UpdateTaskStatusRequest
+}
+
+func (c *fakeCloudClient) UpdateTaskStatus(_ context.Context, req *connect.Request[cloudv1.UpdateTaskStatusRequest]) (*connect.Response[emptypb.Empty], error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req.Msg)
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+// reported returns the statuses and messages reported so far, such as "RUNNING: Running attempt 1 of 2".
+func (c *fakeCloudClient) reported() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reported := make([]string, len(c.requests))
+	for i, req := range c.requests {
+		reported[i] = req.Status.String() + ": " + req.Message
+	}
+	return reported
+}
+
+// newTestReconciler returns a TaskReconciler whose client serves the objects
+// and whose attempts report their statuses to a fakeCloudClient.
+func newTestReconciler(t *testing.T, objs ...client.Object) (*TaskReconciler, *fakeCloudClient) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1.AddToScheme(scheme))
+
+	cloudClient := &fakeCloudClient{}
+	return &TaskReconciler{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(&v1.Task{}).Build(),
+		Scheme:      scheme,
+		CloudClient: cloudClient,
+		events:      make(chan event.GenericEvent, 1),
+	}, cloudClient
+}
+
+// newTestTask returns a task run by testPlugin that is retried once.
+func newTestTask() *v1.Task {
+	return &v1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task-7", Namespace: "tasks", Generation: 1},
+		Spec: v1.TaskSpec{
+			ID:      7,
+			Type:    testTaskType,
+			Retries: ptr.To[int32](1),
+		},
+	}
+}
+
+// reconcileTask reconciles the task and returns its result along with the latest version of the task.
+func reconcileTask(t *testing.T, r *TaskReconciler, task *v1.Task) (ctrl.Result, *v1.Task) {
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(task)})
+	require.NoError(t, err)
+	return result, getTask(t, r, task)
+}
+
+// getTask returns the latest version of the task.
+func getTask(t *testing.T, r *TaskReconciler, task *v1.Task) *v1.Task {
+	latest := &v1.Task{}
+	require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(task), latest))
+	return latest
+}
+
+// waitForAttempt waits until the attempt running in the background stopped and requeued the task.
+func waitForAttempt(t *testing.T, r *TaskReconciler) {
+	select {
+	case <-r.events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("attempt did not stop")
+	}
+}
+
+// expireBackoff moves the failure of the last attempt at the task back by its backoff,
+// so that the next reconcile starts the next attempt.
+func expireBackoff(t *testing.T, r *TaskReconciler, task *v1.Task) {
+	task = getTask(t, r, task)
+	running := meta.FindStatusCondition(task.Status.Conditions, v1.ConditionRunning)
+	require.NotNil(t, running)
+	running.LastTransitionTime = metav1.NewTime(running.LastTransitionTime.Add(-backoff(task.Status.Attempts)))
+	require.NoError(t, r.Status().Update(context.Background(), task))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, backoff(0))
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 2*time.Second, backoff(2))
+	assert.Equal(t, 4*time.Second, backoff(3))
+}
+
+func TestReconcileNotFound(t *testing.T) {
+	r, cloudClient := newTestReconciler(t)
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "tasks", Name: "task-7"}})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Empty(t, cloudClient.reported())
+}
+
+func TestReconcileFinished(t *testing.T) {
+	for _, status := range []cloudv1.TaskStatusEnum{cloudv1.TaskStatusEnum_SUCCEEDED, cloudv1.TaskStatusEnum_FAILED} {
+		t.Run(status.String(), func(t *testing.T) {
+			task := newTestTask()
+			task.Finalizers = []string{taskFinalizer}
+			setStatus(task, status, 1, "Finished", metav1.Now())
+			r, cloudClient := newTestReconciler(t, task)
+
+			result, latest := reconcileTask(t, r, task)
+			assert.Equal(t, ctrl.Result{}, result)
+			assert.False(t, r.isRunning(task))
+			assert.Empty(t, cloudClient.reported())
+			assert.Equal(t, int32(1), latest.Status.Attempts)
+			assert.Equal(t, int32(status), latest.Status.Status)
+		})
+	}
+}
+
+func TestReconcileRetries(t *testing.T) {
+	task := newTestTask()
+	r, cloudClient := newTestReconciler(t, task)
+
+	// The first attempt runs in the background and fails
+	result, latest := reconcileTask(t, r, task)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Contains(t, latest.Finalizers, taskFinalizer)
+	waitForAttempt(t, r)
+
+	latest = getTask(t, r, task)
+	assert.Equal(t, int32(1), latest.Status.Attempts)
+	assert.Equal(t, int32(cloudv1.TaskStatusEnum_RUNNING), latest.Status.Status)
+	assert.Equal(t, "exit status 1", latest.Status.LastError)
+	assert.Equal(t, v1.ReasonAttemptFailed, meta.FindStatusCondition(latest.Status.Conditions, v1.ConditionRunning).Reason)
+
+	// The failed attempt is retried once its backoff passed
+	result, _ = reconcileTask(t, r, task)
+	assert.Greater(t, result.RequeueAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RequeueAfter, backoff(1))
+	assert.False(t, r.isRunning(task))
+
+	expireBackoff(t, r, task)
+	result, _ = reconcileTask(t, r, task)
+	assert.Equal(t, ctrl.Result{}, result)
+	waitForAttempt(t, r)
+
+	// The task fails for good after GetRetries()+1 attempts and is not run again
+	latest = getTask(t, r, task)
+	assert.Equal(t, maxAttempts(task), latest.Status.Attempts)
+	assert.Equal(t, int32(cloudv1.TaskStatusEnum_FAILED), latest.Status.Status)
+	assert.NotNil(t, latest.Status.CompletionTime)
+	assert.True(t, meta.IsStatusConditionFalse(latest.Status.Conditions, v1.ConditionSucceeded))
+
+	result, _ = reconcileTask(t, r, task)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.False(t, r.isRunning(task))
+	assert.Equal(t, []string{
+		"RUNNING: Running attempt 1 of 2",
+		"FAILED: Attempt 1 failed: exit status 1",
+		"RUNNING: Running attempt 2 of 2",
+		"FAILED: Attempt 2 failed: exit status 1",
+		"FAILED: All 2 attempts failed. Last error: exit status 1",
+	}, cloudClient.reported())
+}
+
+func TestReconcileInterrupted(t *testing.T) {
+	// The status records an attempt as running that the controller does not run, as it restarted
+	task := newTestTask()
+	task.Finalizers = []string{taskFinalizer}
+	setStatus(task, cloudv1.TaskStatusEnum_RUNNING, 1, "Running attempt 1 of 2", metav1.Now())
+	r, cloudClient := newTestReconciler(t, task)
+
+	result, latest := reconcileTask(t, r, task)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.False(t, r.isRunning(task))
+	assert.Equal(t, int32(1), latest.Status.Attempts)
+	assert.Equal(t, errInterrupted.Error(), latest.Status.LastError)
+	assert.Equal(t, v1.ReasonAttemptFailed, meta.FindStatusCondition(latest.Status.Conditions, v1.ConditionRunning).Reason)
+
+	// The interrupted attempt counts towards the attempts of the task
+	expireBackoff(t, r, task)
+	reconcileTask(t, r, task)
+	waitForAttempt(t, r)
+
+	latest = getTask(t, r, task)
+	assert.Equal(t, int32(2), latest.Status.Attempts)
+	assert.Equal(t, int32(cloudv1.TaskStatusEnum_FAILED), latest.Status.Status)
+	assert.Equal(t, []string{
+		"FAILED: Attempt 1 failed: " + errInterrupted.Error(),
+		"RUNNING: Running attempt 2 of 2",
+		"FAILED: Attempt 2 failed: exit status 1",
+		"FAILED: All 2 attempts failed. Last error: exit status 1",
+	}, cloudClient.reported())
+}
+
+func TestReconcileStaleCache(t *testing.T) {
+	// The attempt recorded its failure, but the cache still shows it running
+	stale := newTestTask()
+	stale.Finalizers = []string{taskFinalizer}
+	setStatus(stale, cloudv1.TaskStatusEnum_RUNNING, 1, "Running attempt 1 of 2", metav1.Now())
+	latest := stale.DeepCopy()
+	setAttemptFailed(latest, 1, errors.New("exit status 1"))
+	r, cloudClient := newTestReconciler(t, latest)
+	r.APIReader = r.Client
+	r.Client = fake.NewClientBuilder().WithScheme(r.Scheme).WithObjects(latest).WithStatusSubresource(&v1.Task{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if task, ok := obj.(*v1.Task); ok {
+					stale.DeepCopyInto(task)
+					return nil
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+
+	// The attempt is not taken for an interrupted one
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(stale)})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.False(t, r.isRunning(stale))
+	assert.Empty(t, cloudClient.reported())
+}
+
+func TestReconcileOutcomeNotRecorded(t *testing.T) {
+	task := newTestTask()
+	r, cloudClient := newTestReconciler(t, task)
+	// The status update that records the failed attempt fails once
+	updates := 0
+	r.Client = fake.NewClientBuilder().WithScheme(r.Scheme).WithObjects(task).WithStatusSubresource(&v1.Task{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if updates++; updates == 2 {
+					return errors.New("connection refused")
+				}
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+		}).Build()
+
+	reconcileTask(t, r, task)
+	waitForAttempt(t, r)
+	latest := getTask(t, r, task)
+	assert.Empty(t, latest.Status.LastError)
+
+	// The next reconcile records the outcome of the attempt instead of an interrupted attempt
+	result, latest := reconcileTask(t, r, task)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Equal(t, "exit status 1", latest.Status.LastError)
+	assert.Equal(t, int32(1), latest.Status.Attempts)
+	assert.Equal(t, []string{
+		"RUNNING: Running attempt 1 of 2",
+		"FAILED: Attempt 1 failed: exit status 1",
+		"FAILED: Attempt 1 failed: exit status 1",
+	}, cloudClient.reported())
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+
+	"connectrpc.com/connect"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// taskFinalizer keeps a Task until the controller reported its final status to the task service.
+const taskFinalizer = "task.io/report-status"
+
+// errTaskDeleted is the cause of the cancellation of the attempts at tasks that were deleted.
+var errTaskDeleted = errors.New("task was deleted")
+
+// reconcileDelete stops a task that is deleted before it finished and reports it as failed to the
+// task service, then removes the finalizer so that the deletion proceeds. An attempt running in the
+// controller is cancelled first, and the task is reconciled again once the attempt stopped.
+func (r *TaskReconciler) reconcileDelete(ctx context.Context, task *v1.Task) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(task, taskFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	if r.cancelAttempt(task) {
+		logger.Info("Cancelled the running attempt of the deleted task")
+		return ctrl.Result{}, nil
+	}
+	// The deletion is reported instead of an attempt whose outcome could not be recorded
+	r.takeOutcome(task)
+
+	if !isFinished(cloudv1.TaskStatusEnum(task.Status.Status)) {
+		if task.Spec.BaseImage != "" && r.Jobs != nil {
+			if err := r.Jobs.Delete(ctx, task); err != nil {
+				logger.Error(err, "Failed to delete job")
+				return ctrl.Result{}, err
+			}
+		}
+		err := r.updateTaskStatus(ctx, int64(task.Spec.ID), cloudv1.TaskStatusEnum_FAILED, "Task was deleted before it finished", nil)
+		// Tasks the task service does not know do not hold up the deletion
+		if err != nil && connect.CodeOf(err) != connect.CodeNotFound {
+			logger.Error(err, "Failed to report the deleted task as failed")
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(task, taskFinalizer)
+	if err := r.Update(ctx, task); client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to remove the finalizer of the task")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileTTL deletes a finished task once its TTLSecondsAfterFinished passed,
+// requeueing it until then. Tasks without a TTL are kept.
+func (r *TaskReconciler) reconcileTTL(ctx context.Context, task *v1.Task) (ctrl.Result, error) {
+	if task.Spec.TTLSecondsAfterFinished == nil || task.Status.CompletionTime == nil {
+		return ctrl.Result{}, nil
+	}
+	expiry := task.Status.CompletionTime.Add(time.Duration(*task.Spec.TTLSecondsAfterFinished) * time.Second)
+	if wait := time.Until(expiry); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	log.FromContext(ctx).Info("Deleting the task as its TTL after finishing passed", "ttlSecondsAfterFinished", *task.Spec.TTLSecondsAfterFinished)
+	if err := r.Delete(ctx, task, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		log.FromContext(ctx).Error(err, "Failed to delete the task")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// cancelAttempt cancels the attempt running for the deleted task in the controller, if any,
+// and reports whether there was one.
+func (r *TaskReconciler) cancelAttempt(task *v1.Task) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancel, ok := r.running[client.ObjectKeyFromObject(task)]
+	if ok {
+		cancel(errTaskDeleted)
+	}
+	return ok
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// requireDeleted checks that the task is gone, as its finalizer was removed.
+func requireDeleted(t *testing.T, r *TaskReconciler, task *v1.Task) {
+	err := r.Get(context.Background(), client.ObjectKeyFromObject(task), &v1.Task{})
+	require.True(t, apierrors.IsNotFound(err), "task was not deleted: %v", err)
+}
+
+// newFinishedTask returns a task that succeeded at time completed.
+func newFinishedTask(completed time.Time) *v1.Task {
+	task := newTestTask()
+	task.Finalizers = []string{taskFinalizer}
+	setStatus(task, cloudv1.TaskStatusEnum_SUCCEEDED, 1, "Task completed successfully", metav1.NewTime(completed))
+	return task
+}
+
+func TestReconcileAddsFinalizer(t *testing.T) {
+	task := newFinishedTask(time.Now())
+	task.Finalizers = nil
+	r, cloudClient := newTestReconciler(t, task)
+
+	result, latest := reconcileTask(t, r, task)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Equal(t, []string{taskFinalizer}, latest.Finalizers)
+	assert.Empty(t, cloudClient.reported())
+}
+
+func TestReconcileDelete(t *testing.T) {
+	tests := []struct {
+		name         string
+		task         *v1.Task
+		wantReported []string
+	}{
+		{
+			name:         "Unfinished task",
+			task:         newTestTask(),
+			wantReported: []string{"FAILED: Task was deleted before it finished"},
+		},
+		{
+			name:         "Finished task",
+			task:         newFinishedTask(time.Now()),
+			wantReported: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.Finalizers = []string{taskFinalizer}
+			tt.task.DeletionTimestamp = ptr.To(metav1.Now())
+			r, cloudClient := newTestReconciler(t, tt.task)
+
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tt.task)})
+			require.NoError(t, err)
+			assert.Equal(t, ctrl.Result{}, result)
+			assert.Equal(t, tt.wantReported, cloudClient.reported())
+			requireDeleted(t, r, tt.task)
+		})
+	}
+}
+
+func TestReconcileDeleteRunning(t *testing.T) {
+	task := newTestTask()
+	task.Spec.Payload.Parameters = map[string]string{"block": "true"}
+	r, cloudClient := newTestReconciler(t, task)
+	ctx := context.Background()
+
+	reconcileTask(t, r, task)
+	require.True(t, r.isRunning(task))
+	require.NoError(t, r.Delete(ctx, getTask(t, r, task)))
+
+	// The running attempt is cancelled and the finalizer is kept until it stopped
+	result, latest := reconcileTask(t, r, task)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Contains(t, latest.Finalizers, taskFinalizer)
+	waitForAttempt(t, r)
+	assert.False(t, r.isRunning(task))
+
+	// The cancelled attempt is not recorded as failed, only the deletion of the task is reported
+	latest = getTask(t, r, task)
+	assert.Empty(t, latest.Status.LastError)
+	assert.Equal(t, []string{"RUNNING: Running attempt 1 of 2"}, cloudClient.reported())
+
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(task)})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Equal(t, []string{
+		"RUNNING: Running attempt 1 of 2",
+		"FAILED: Task was deleted before it finished",
+	}, cloudClient.reported())
+	requireDeleted(t, r, task)
+}
+
+func TestReconcileTTL(t *testing.T) {
+	t.Run("Without TTL", func(t *testing.T) {
+		task := newFinishedTask(time.Now().Add(-time.Hour))
+		r, _ := newTestReconciler(t, task)
+
+		result, latest := reconcileTask(t, r, task)
+		assert.Equal(t, ctrl.Result{}, result)
+		assert.True(t, latest.DeletionTimestamp.IsZero())
+	})
+
+	t.Run("TTL not passed", func(t *testing.T) {
+		task := newFinishedTask(time.Now())
+		task.Spec.TTLSecondsAfterFinished = ptr.To[int32](60)
+		r, _ := newTestReconciler(t, task)
+
+		result, latest := reconcileTask(t, r, task)
+		assert.Greater(t, result.RequeueAfter, time.Duration(0))
+		assert.LessOrEqual(t, result.RequeueAfter, time.Minute)
+		assert.True(t, latest.DeletionTimestamp.IsZero())
+	})
+
+	t.Run("TTL passed", func(t *testing.T) {
+		task := newFinishedTask(time.Now().Add(-61 * time.Second))
+		task.Spec.TTLSecondsAfterFinished = ptr.To[int32](60)
+		r, cloudClient := newTestReconciler(t, task)
+
+		// The task is deleted, and its finalizer removed by the reconcile of the deletion
+		result, latest := reconcileTask(t, r, task)
+		assert.Equal(t, ctrl.Result{}, result)
+		assert.False(t, latest.DeletionTimestamp.IsZero())
+
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(task)})
+		require.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+		assert.Empty(t, cloudClient.reported())
+		requireDeleted(t, r, task)
+	})
+}
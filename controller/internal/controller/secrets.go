@@ -0,0 +1,123 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "task/controller/api/v1"
+	"task/pkg/plugins"
+	"task/pkg/secret"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SecretSourceService resolves secrets from the secret store of the task service.
+	SecretSourceService = "service"
+	// SecretSourceKubernetes resolves secrets from the Kubernetes Secrets in the namespace of the task.
+	SecretSourceKubernetes = "kubernetes"
+)
+
+// kubernetesResolver resolves secrets from the Kubernetes Secrets of the same name,
+// which hold the value under secret.KubernetesKey.
+type kubernetesResolver struct {
+	client    client.Reader
+	namespace string
+}
+
+func (r *kubernetesResolver) Resolve(ctx context.Context, names []string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		s := &corev1.Secret{}
+		if err := r.client.Get(ctx, client.ObjectKey{Namespace: r.namespace, Name: name}, s); err != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+		}
+		value, ok := s.Data[secret.KubernetesKey]
+		if !ok {
+			return nil, fmt.Errorf("secret %s has no %q key", name, secret.KubernetesKey)
+		}
+		values[name] = string(value)
+	}
+	return values, nil
+}
+
+// secretResolver returns the resolver of the secrets the task refers to.
+func (r *TaskReconciler) secretResolver(task *v1.Task) (secret.Resolver, error) {
+	switch r.SecretSource {
+	case "", SecretSourceService:
+		return secret.NewServiceResolver(r.CloudClient, int32(task.Spec.ID)), nil
+	case SecretSourceKubernetes:
+		return &kubernetesResolver{client: r.Client, namespace: task.Namespace}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret source %q", r.SecretSource)
+	}
+}
+
+// resolveSecrets returns a copy of the task with the secrets its payload parameters and env
+// refer to resolved, along with a redactor of their values. Tasks without references are
+// returned as they are.
+func (r *TaskReconciler) resolveSecrets(ctx context.Context, task *v1.Task) (*v1.Task, *secret.Redactor, error) {
+	names := secret.Refs(task.Spec.Payload.Parameters, task.Spec.Env)
+	if len(names) == 0 {
+		return task, nil, nil
+	}
+	resolver, err := r.secretResolver(task)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := resolver.Resolve(ctx, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolved := task.DeepCopy()
+	resolved.Spec.Payload.Parameters = secret.Substitute(task.Spec.Payload.Parameters, values)
+	resolved.Spec.Env = secret.Substitute(task.Spec.Env, values)
+	return resolved, secret.NewRedactor(values), nil
+}
+
+// jobRedactor returns a redactor of the values of the Kubernetes Secrets the env of a task
+// run as a Job refers to, which the kubelet resolves whatever the secret source.
+func (r *TaskReconciler) jobRedactor(ctx context.Context, task *v1.Task) (*secret.Redactor, error) {
+	names := secret.Refs(task.Spec.Env)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	resolver := &kubernetesResolver{client: r.Client, namespace: task.Namespace}
+	values, err := resolver.Resolve(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	return secret.NewRedactor(values), nil
+}
+
+// redactRun removes the values of secrets from the message, result and error of a run,
+// as they are reported to the task service.
+func redactRun(redactor *secret.Redactor, message string, result plugins.Result, err error) (string, plugins.Result, error) {
+	result.Outputs = redactor.RedactMap(result.Outputs)
+	if len(result.Data) > 0 {
+		result.Data = []byte(redactor.Redact(string(result.Data)))
+	}
+	if err != nil {
+		err = errors.New(redactor.Redact(err.Error()))
+	}
+	return redactor.Redact(message), result, err
+}
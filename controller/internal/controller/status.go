@@ -0,0 +1,101 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// setStatus records the status of the task and the number of attempts started in the status of
+// the Task, along with its conditions. The start time is set when the first attempt runs and the
+// completion time once the task is finished.
+func setStatus(task *v1.Task, status cloudv1.TaskStatusEnum, attempts int32, message string, now metav1.Time) {
+	task.Status.Status = int32(status)
+	task.Status.Phase = status.String()
+	task.Status.Attempts = max(task.Status.Attempts, attempts)
+
+	switch status {
+	case cloudv1.TaskStatusEnum_RUNNING:
+		if task.Status.StartTime == nil {
+			task.Status.StartTime = &now
+		}
+		setCondition(task, v1.ConditionRunning, metav1.ConditionTrue, v1.ReasonAttemptRunning, message)
+		setCondition(task, v1.ConditionSucceeded, metav1.ConditionUnknown, v1.ReasonAttemptRunning, message)
+	case cloudv1.TaskStatusEnum_SUCCEEDED, cloudv1.TaskStatusEnum_FAILED:
+		if task.Status.CompletionTime == nil {
+			task.Status.CompletionTime = &now
+		}
+		reason, succeeded := v1.ReasonFailed, metav1.ConditionFalse
+		if status == cloudv1.TaskStatusEnum_SUCCEEDED {
+			reason, succeeded = v1.ReasonSucceeded, metav1.ConditionTrue
+		}
+		setCondition(task, v1.ConditionRunning, metav1.ConditionFalse, reason, message)
+		setCondition(task, v1.ConditionSucceeded, succeeded, reason, message)
+	default:
+		setCondition(task, v1.ConditionRunning, metav1.ConditionFalse, v1.ReasonQueued, message)
+		setCondition(task, v1.ConditionSucceeded, metav1.ConditionUnknown, v1.ReasonQueued, message)
+	}
+}
+
+// setAttemptFailed records the error of a failed attempt in the status of the Task.
+// The task stays running until its status is set once it is retried or has failed for good.
+func setAttemptFailed(task *v1.Task, attempt int32, err error) {
+	task.Status.LastError = err.Error()
+	setCondition(task, v1.ConditionRunning, metav1.ConditionFalse, v1.ReasonAttemptFailed, fmt.Sprintf("Attempt %d failed: %v", attempt, err))
+}
+
+// setCondition sets a condition of the Task for its current generation.
+func setCondition(task *v1.Task, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: task.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// updateResourceStatus writes the status of the Task to its status subresource,
+// marking it as observed for the current generation of the Task.
+func (r *TaskReconciler) updateResourceStatus(ctx context.Context, task *v1.Task) error {
+	task.Status.ObservedGeneration = task.Generation
+	if err := r.Status().Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to update task %d resource status: %w", task.Spec.ID, err)
+	}
+	return nil
+}
+
+// retryResourceStatus applies update to the latest version of the Task and writes its status,
+// retrying when the Task changed in the meantime.
+func (r *TaskReconciler) retryResourceStatus(ctx context.Context, task *v1.Task, update func(*v1.Task)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(task), task); err != nil {
+			return err
+		}
+		update(task)
+		return r.updateResourceStatus(ctx, task)
+	})
+}
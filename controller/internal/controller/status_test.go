@@ -0,0 +1,270 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// condition is the status, reason and message of a condition of a Task.
+type condition struct {
+	Status  metav1.ConditionStatus
+	Reason  string
+	Message string
+}
+
+// conditions returns the conditions of the task by type, checking that they were set for its generation.
+func conditions(t *testing.T, task *v1.Task) map[string]condition {
+	conds := make(map[string]condition, len(task.Status.Conditions))
+	for _, cond := range task.Status.Conditions {
+		assert.Equal(t, task.Generation, cond.ObservedGeneration, cond.Type)
+		conds[cond.Type] = condition{Status: cond.Status, Reason: cond.Reason, Message: cond.Message}
+	}
+	return conds
+}
+
+func TestSetStatus(t *testing.T) {
+	started := metav1.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(started.Add(time.Minute))
+
+	tests := []struct {
+		name           string
+		status         v1.TaskStatus
+		setStatus      cloudv1.TaskStatusEnum
+		attempts       int32
+		wantAttempts   int32
+		wantStart      *metav1.Time
+		wantCompletion *metav1.Time
+		wantConditions map[string]condition
+	}{
+		{
+			name:         "Queued",
+			setStatus:    cloudv1.TaskStatusEnum_QUEUED,
+			wantAttempts: 0,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionFalse, v1.ReasonQueued, "message"},
+				v1.ConditionSucceeded: {metav1.ConditionUnknown, v1.ReasonQueued, "message"},
+			},
+		},
+		{
+			name:         "First attempt running",
+			setStatus:    cloudv1.TaskStatusEnum_RUNNING,
+			attempts:     1,
+			wantAttempts: 1,
+			wantStart:    &now,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionTrue, v1.ReasonAttemptRunning, "message"},
+				v1.ConditionSucceeded: {metav1.ConditionUnknown, v1.ReasonAttemptRunning, "message"},
+			},
+		},
+		{
+			name:         "Retry running",
+			status:       v1.TaskStatus{Attempts: 1, StartTime: &started},
+			setStatus:    cloudv1.TaskStatusEnum_RUNNING,
+			attempts:     2,
+			wantAttempts: 2,
+			wantStart:    &started,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionTrue, v1.ReasonAttemptRunning, "message"},
+				v1.ConditionSucceeded: {metav1.ConditionUnknown, v1.ReasonAttemptRunning, "message"},
+			},
+		},
+		{
+			name:           "Succeeded",
+			status:         v1.TaskStatus{Attempts: 2, StartTime: &started},
+			setStatus:      cloudv1.TaskStatusEnum_SUCCEEDED,
+			attempts:       2,
+			wantAttempts:   2,
+			wantStart:      &started,
+			wantCompletion: &now,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionFalse, v1.ReasonSucceeded, "message"},
+				v1.ConditionSucceeded: {metav1.ConditionTrue, v1.ReasonSucceeded, "message"},
+			},
+		},
+		{
+			name:           "Failed",
+			status:         v1.TaskStatus{Attempts: 3, StartTime: &started},
+			setStatus:      cloudv1.TaskStatusEnum_FAILED,
+			attempts:       3,
+			wantAttempts:   3,
+			wantStart:      &started,
+			wantCompletion: &now,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionFalse, v1.ReasonFailed, "message"},
+				v1.ConditionSucceeded: {metav1.ConditionFalse, v1.ReasonFailed, "message"},
+			},
+		},
+		{
+			name:           "Completion time is kept",
+			status:         v1.TaskStatus{Attempts: 1, StartTime: &started, CompletionTime: &started},
+			setStatus:      cloudv1.TaskStatusEnum_FAILED,
+			attempts:       1,
+			wantAttempts:   1,
+			wantStart:      &started,
+			wantCompletion: &started,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionFalse, v1.ReasonFailed, "message"},
+				v1.ConditionSucceeded: {metav1.ConditionFalse, v1.ReasonFailed, "message"},
+			},
+		},
+		{
+			name:         "Attempts never decrease",
+			status:       v1.TaskStatus{Attempts: 3},
+			setStatus:    cloudv1.TaskStatusEnum_QUEUED,
+			attempts:     1,
+			wantAttempts: 3,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionFalse, v1.ReasonQueued, "message"},
+				v1.ConditionSucceeded: {metav1.ConditionUnknown, v1.ReasonQueued, "message"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newTestTask()
+			task.Generation = 4
+			task.Status = tt.status
+
+			setStatus(task, tt.setStatus, tt.attempts, "message", now)
+			assert.Equal(t, int32(tt.setStatus), task.Status.Status)
+			assert.Equal(t, tt.setStatus.String(), task.Status.Phase)
+			assert.Equal(t, tt.wantAttempts, task.Status.Attempts)
+			assert.Equal(t, tt.wantStart, task.Status.StartTime)
+			assert.Equal(t, tt.wantCompletion, task.Status.CompletionTime)
+			assert.Equal(t, tt.wantConditions, conditions(t, task))
+		})
+	}
+}
+
+func TestSetAttemptFailed(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         cloudv1.TaskStatusEnum
+		wantConditions map[string]condition
+	}{
+		{
+			name:   "Running attempt failed",
+			status: cloudv1.TaskStatusEnum_RUNNING,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionFalse, v1.ReasonAttemptFailed, "Attempt 2 failed: exit status 1"},
+				v1.ConditionSucceeded: {metav1.ConditionUnknown, v1.ReasonAttemptRunning, "Running attempt 2 of 3"},
+			},
+		},
+		{
+			name:   "Last attempt failed",
+			status: cloudv1.TaskStatusEnum_FAILED,
+			wantConditions: map[string]condition{
+				v1.ConditionRunning:   {metav1.ConditionFalse, v1.ReasonFailed, "All 2 attempts failed"},
+				v1.ConditionSucceeded: {metav1.ConditionFalse, v1.ReasonFailed, "All 2 attempts failed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newTestTask()
+			setStatus(task, cloudv1.TaskStatusEnum_RUNNING, 2, "Running attempt 2 of 3", metav1.Now())
+			setAttemptFailed(task, 2, errors.New("exit status 1"))
+			// The final status of the task replaces the conditions of the failed attempt
+			if tt.status == cloudv1.TaskStatusEnum_FAILED {
+				setStatus(task, tt.status, 2, "All 2 attempts failed", metav1.Now())
+			}
+
+			// The status of the task is only changed by setStatus
+			assert.Equal(t, int32(tt.status), task.Status.Status)
+			assert.Equal(t, int32(2), task.Status.Attempts)
+			assert.Equal(t, "exit status 1", task.Status.LastError)
+			assert.Equal(t, tt.wantConditions, conditions(t, task))
+		})
+	}
+}
+
+func TestRetryResourceStatus(t *testing.T) {
+	conflict := apierrors.NewConflict(schema.GroupResource{Group: "task.io", Resource: "tasks"}, "task-7", errors.New("the object has been modified"))
+
+	tests := []struct {
+		name      string
+		conflicts int
+		wantErr   bool
+	}{
+		{name: "Updated"},
+		{name: "Retried on conflict", conflicts: 2},
+		{name: "Gives up on conflict", conflicts: 100, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newTestTask()
+			r, _ := newTestReconciler(t, task)
+			conflicts := tt.conflicts
+			r.Client = fake.NewClientBuilder().WithScheme(r.Scheme).WithObjects(task).WithStatusSubresource(&v1.Task{}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+						if conflicts > 0 {
+							conflicts--
+							return conflict
+						}
+						return c.SubResource(subResource).Update(ctx, obj, opts...)
+					},
+				}).Build()
+
+			// The Task changed since the reconciler read it
+			latest := getTask(t, r, task)
+			latest.Generation = 2
+			latest.Labels = map[string]string{"team": "data"}
+			require.NoError(t, r.Update(context.Background(), latest))
+
+			stale := task.DeepCopy()
+			err := r.retryResourceStatus(context.Background(), stale, func(task *v1.Task) {
+				setAttemptFailed(task, 1, errors.New("exit status 1"))
+			})
+			if tt.wantErr {
+				assert.True(t, apierrors.IsConflict(err))
+				assert.Empty(t, getTask(t, r, task).Status.LastError)
+				return
+			}
+			require.NoError(t, err)
+
+			// The update is applied to the latest version of the Task, which is observed
+			latest = getTask(t, r, task)
+			assert.Equal(t, "data", latest.Labels["team"])
+			assert.Equal(t, "exit status 1", latest.Status.LastError)
+			assert.Equal(t, int64(2), latest.Status.ObservedGeneration)
+			assert.Equal(t, map[string]condition{
+				v1.ConditionRunning: {metav1.ConditionFalse, v1.ReasonAttemptFailed, "Attempt 1 failed: exit status 1"},
+			}, conditions(t, latest))
+			assert.Equal(t, latest.ResourceVersion, stale.ResourceVersion)
+		})
+	}
+}
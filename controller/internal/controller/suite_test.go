@@ -19,6 +19,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -33,7 +34,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
-	taskiov1 "task/api/v1"
+	taskiov1 "task/controller/api/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -57,18 +58,25 @@ var _ = BeforeSuite(func() {
 
 	ctx, cancel = context.WithCancel(context.TODO())
 
+	// The BinaryAssetsDirectory is only required if you want to run the tests directly
+	// without call the makefile target test. If not informed it will look for the
+	// default path defined in controller-runtime which is /usr/local/kubebuilder/.
+	// Note that you must have the required binaries setup under the bin directory to perform
+	// the tests directly. When we run make test it will be setup and used automatically.
+	binaryAssetsDirectory := filepath.Join("..", "..", "bin", "k8s",
+		fmt.Sprintf("1.31.0-%s-%s", runtime.GOOS, runtime.GOARCH))
+	// The specs that reconcile through the API server are skipped without the binaries
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		if _, err := os.Stat(binaryAssetsDirectory); err != nil {
+			return
+		}
+	}
+
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
 		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
 		ErrorIfCRDPathMissing: true,
-
-		// The BinaryAssetsDirectory is only required if you want to run the tests directly
-		// without call the makefile target test. If not informed it will look for the
-		// default path defined in controller-runtime which is /usr/local/kubebuilder/.
-		// Note that you must have the required binaries setup under the bin directory to perform
-		// the tests directly. When we run make test it will be setup and used automatically.
-		BinaryAssetsDirectory: filepath.Join("..", "..", "bin", "k8s",
-			fmt.Sprintf("1.31.0-%s-%s", runtime.GOOS, runtime.GOARCH)),
+		BinaryAssetsDirectory: binaryAssetsDirectory,
 	}
 
 	var err error
@@ -88,9 +96,19 @@ var _ = BeforeSuite(func() {
 
 })
 
+// requireEnvtest skips the spec when the test environment could not be started.
+func requireEnvtest() {
+	if testEnv == nil {
+		Skip("envtest binaries not found, run make test or set KUBEBUILDER_ASSETS")
+	}
+}
+
 var _ = AfterSuite(func() {
-	By("tearing down the test environment")
 	cancel()
+	if testEnv == nil {
+		return
+	}
+	By("tearing down the test environment")
 	err := testEnv.Stop()
 	Expect(err).NotTo(HaveOccurred())
 })
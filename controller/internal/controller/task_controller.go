@@ -19,30 +19,73 @@ package controller
 import (
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	v1 "task/controller/api/v1"
+	"task/controller/internal/job"
+	"task/pkg/artifact"
 	cloudv1 "task/pkg/gen/cloud/v1"
 	cloudv1connect "task/pkg/gen/cloud/v1/cloudv1connect"
 	"task/pkg/plugins"
+	_ "task/pkg/plugins/builtin" // Register the built-in task types
+	"task/pkg/progress"
+	"task/pkg/secret"
+	"task/pkg/tasklog"
 
 	"connectrpc.com/connect"
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // TaskReconciler reconciles a Task object
 type TaskReconciler struct {
 	client.Client
+	// APIReader reads from the API server rather than the cache of Client, defaulting to Client.
+	// It tells attempts that were interrupted from attempts whose outcome the cache has not seen yet.
+	APIReader   client.Reader
 	Scheme      *runtime.Scheme
 	CloudClient cloudv1connect.TaskManagementServiceClient
+	// Jobs runs the tasks that have a base image as Kubernetes Jobs.
+	Jobs *job.Runner
+	// SecretSource is where the secrets tasks refer to are resolved from:
+	// SecretSourceService, the default, or SecretSourceKubernetes.
+	SecretSource string
+	// MaxConcurrentReconciles is the number of tasks reconciled at the same time, 1 by default.
+	// Attempts run in the background, so it does not bound the number of running tasks.
+	MaxConcurrentReconciles int
+
+	// mu guards running and outcomes.
+	mu sync.Mutex
+	// running holds the cancel functions of the attempts running in the controller, by task.
+	running map[client.ObjectKey]context.CancelCauseFunc
+	// outcomes holds the outcomes of the attempts that stopped running but could not be recorded, by task.
+	outcomes map[client.ObjectKey]attemptOutcome
+	// events triggers a reconcile of the tasks whose attempt stopped running.
+	events chan event.GenericEvent
 }
 
 // +kubebuilder:rbac:groups=task.io,resources=tasks,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=task.io,resources=tasks/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=task.io,resources=tasks/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
 
 // Reconcile is part of the main Kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -55,76 +98,146 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	task := &v1.Task{}
 	err := r.Get(ctx, req.NamespacedName, task)
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// The task was deleted, so there is nothing left to reconcile
+			return ctrl.Result{}, nil
+		}
 		log.FromContext(ctx).Error(err, "Failed to get task")
 		return ctrl.Result{}, err
 	}
 
-	maxAttempts := 3
-	initialBackoff := 1 * time.Second
-
-	var finalStatus cloudv1.TaskStatusEnum
-	var finalMessage string
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		// Update status to Running for each attempt
-		runningMessage := fmt.Sprintf("Running attempt %d of %d", attempt, maxAttempts)
-		if err := r.updateTaskStatus(ctx, int64(task.Spec.ID), cloudv1.TaskStatusEnum_RUNNING, runningMessage); err != nil {
-			log.FromContext(ctx).Error(err, "Failed to update task status to Running")
+	if !task.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, task)
+	}
+	if controllerutil.AddFinalizer(task, taskFinalizer) {
+		if err := r.Update(ctx, task); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to add the finalizer of the task")
 			return ctrl.Result{}, err
 		}
+	}
 
-		_, message, err := processWorkflowUpdate(ctx, task)
-
-		if err != nil {
-			failedMessage := fmt.Sprintf("Attempt %d failed: %v", attempt, err)
-			if err := r.updateTaskStatus(ctx, int64(task.Spec.ID), cloudv1.TaskStatusEnum_FAILED, failedMessage); err != nil {
-				log.FromContext(ctx).Error(err, "Failed to update task status to Failed")
-				return ctrl.Result{}, err
-			}
-
-			if attempt == maxAttempts {
-				finalStatus = cloudv1.TaskStatusEnum_FAILED
-				finalMessage = fmt.Sprintf("All %d attempts failed. Last error: %v", maxAttempts, err)
-				log.FromContext(ctx).Error(fmt.Errorf(finalMessage), "Final failure after max attempts")
-			} else {
-				// Wait before the next attempt
-				select {
-				case <-ctx.Done():
-					return ctrl.Result{}, ctx.Err()
-				case <-time.After(initialBackoff * time.Duration(1<<uint(attempt-1))):
-				}
-				continue
-			}
-		} else {
-			finalStatus = cloudv1.TaskStatusEnum_SUCCEEDED
-			finalMessage = fmt.Sprintf("Task completed successfully on attempt %d: %s", attempt, message)
-			log.FromContext(ctx).Info(finalMessage)
-			break
-		}
+	// Finished tasks are not run again when the status of their resource is updated
+	if isFinished(cloudv1.TaskStatusEnum(task.Status.Status)) {
+		return r.reconcileTTL(ctx, task)
 	}
 
-	// Send final status update
-	if err := r.updateTaskStatus(ctx, int64(task.Spec.ID), finalStatus, finalMessage); err != nil {
-		log.FromContext(ctx).Error(err, "Failed to send final task status update")
-		return ctrl.Result{}, err
+	// Tasks with a base image run in a container instead of in the controller
+	if task.Spec.BaseImage != "" {
+		return r.reconcileJob(ctx, task)
 	}
 
-	return ctrl.Result{}, nil
+	return r.reconcileAttempt(ctx, task)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *TaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.events = make(chan event.GenericEvent)
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.Task{}).
+		Owns(&batchv1.Job{}).
+		WatchesRawSource(source.Channel(r.events, &handler.EnqueueRequestForObject{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
+// reconcileJob runs the task as a Job and reports the status of the Job whenever it changes.
+// The Task owns the Job, so every change of the Job triggers a new reconcile.
+func (r *TaskReconciler) reconcileJob(ctx context.Context, task *v1.Task) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	if r.Jobs == nil {
+		return ctrl.Result{}, fmt.Errorf("task %d has a base image but no job runner is configured", task.Spec.ID)
+	}
+
+	j, err := r.Jobs.Ensure(ctx, task)
+	if err != nil {
+		logger.Error(err, "Failed to ensure job")
+		return ctrl.Result{}, err
+	}
+
+	status, message := job.Status(j)
+	attempts := job.Attempts(j)
+	if int32(status) == task.Status.Status && attempts == task.Status.Attempts {
+		return ctrl.Result{}, nil
+	}
+
+	if isFinished(status) {
+		logs, err := r.Jobs.Logs(ctx, j)
+		if err != nil {
+			// The status is reported without the logs rather than not at all
+			logger.Error(err, "Failed to fetch job logs")
+		}
+		redactor, err := r.jobRedactor(ctx, task)
+		if err != nil {
+			// Logs that may hold the values of secrets are dropped rather than shipped
+			logger.Error(err, "Failed to resolve job secrets, dropping job logs")
+			logs = ""
+		}
+		logs = redactor.Redact(logs)
+		logger.Info("Job finished", "job", j.Name, "status", status.String(), "logs", logs)
+		if err := r.shipJobLogs(ctx, task, j, logs); err != nil {
+			logger.Error(err, "Failed to ship job logs")
+		}
+	}
+
+	if err := r.updateTaskStatus(ctx, int64(task.Spec.ID), status, message, nil); err != nil {
+		logger.Error(err, "Failed to update task status")
+		return ctrl.Result{}, err
+	}
+	if task.Status.StartTime == nil && j.Status.StartTime != nil {
+		task.Status.StartTime = j.Status.StartTime.DeepCopy()
+	}
+	setStatus(task, status, attempts, message, jobTime(j, status))
+	if status == cloudv1.TaskStatusEnum_FAILED {
+		task.Status.LastError = message
+	}
+	if err := r.updateResourceStatus(ctx, task); err != nil {
+		logger.Error(err, "Failed to update task resource status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// shipJobLogs ships the logs of the most recent pod of a finished Job as the log lines of
+// the attempt that pod ran, counting the pods of the Job that finished.
+func (r *TaskReconciler) shipJobLogs(ctx context.Context, task *v1.Task, j *batchv1.Job, logs string) error {
+	if logs == "" {
+		return nil
+	}
+	attempt := max(1, int(job.Attempts(j)))
+	shipper := tasklog.NewShipper(r.CloudClient, int64(task.Spec.ID), attempt, tasklog.DefaultFlushInterval, nil)
+	// The logs were already logged by the controller, so they are only shipped
+	logger := slog.New(shipper.Handler(slog.NewTextHandler(io.Discard, nil)))
+	for _, line := range strings.Split(strings.TrimRight(logs, "\n"), "\n") {
+		logger.Info(line, "pod", j.Name)
+	}
+	return shipper.Close(ctx)
+}
+
+// jobTime returns the time the Job reached the status of its task: the time it started or completed,
+// falling back to now when the Job does not record it.
+func jobTime(j *batchv1.Job, status cloudv1.TaskStatusEnum) metav1.Time {
+	switch {
+	case status == cloudv1.TaskStatusEnum_RUNNING && j.Status.StartTime != nil:
+		return *j.Status.StartTime
+	case isFinished(status) && j.Status.CompletionTime != nil:
+		return *j.Status.CompletionTime
+	}
+	return metav1.Now()
+}
+
+// isFinished reports whether status is a final task status.
+func isFinished(status cloudv1.TaskStatusEnum) bool {
+	return status == cloudv1.TaskStatusEnum_SUCCEEDED || status == cloudv1.TaskStatusEnum_FAILED
+}
+
 // updateTaskStatus updates the status of a task using the Task Management Service.
-func (r *TaskReconciler) updateTaskStatus(ctx context.Context, taskID int64, status cloudv1.TaskStatusEnum, message string) error {
+// The result is recorded along with the status when it is not nil.
+func (r *TaskReconciler) updateTaskStatus(ctx context.Context, taskID int64, status cloudv1.TaskStatusEnum, message string, result *cloudv1.TaskResult) error {
 	_, err := r.CloudClient.UpdateTaskStatus(ctx, connect.NewRequest(&cloudv1.UpdateTaskStatusRequest{
 		Id:      int32(taskID),
 		Status:  status,
 		Message: message,
+		Result:  result,
 	}))
 	if err != nil {
 		return fmt.Errorf("failed to update task %d status: %w", taskID, err)
@@ -133,8 +246,62 @@ func (r *TaskReconciler) updateTaskStatus(ctx context.Context, taskID int64, sta
 	return nil
 }
 
+// taskResult converts the result of a plugin run to the result reported to the task service,
+// cut down to the limits of the service. It returns nil when the run produced no outputs or data.
+func taskResult(ctx context.Context, result plugins.Result) *cloudv1.TaskResult {
+	limited, cut := result.Limit()
+	if cut {
+		log.FromContext(ctx).Info("Task result exceeds the limits of the task service and was cut down")
+	}
+	if len(limited.Outputs) == 0 && len(limited.Data) == 0 {
+		return nil
+	}
+	return &cloudv1.TaskResult{Outputs: limited.Outputs, Data: string(limited.Data)}
+}
+
+// runAttempt runs an attempt at the task. The secrets the task refers to are resolved first,
+// and their values are redacted from the logs, message, result and error of the attempt.
+// Tasks that declare artifacts run in a workspace the inputs are downloaded to first,
+// and the attempt fails unless all outputs are uploaded.
+func (r *TaskReconciler) runAttempt(ctx context.Context, task *v1.Task, attempt int, shipper *tasklog.Shipper, reporter *progress.Reporter) (string, plugins.Result, error) {
+	resolved, redactor, err := r.resolveSecrets(ctx, task)
+	if err != nil {
+		return "", plugins.Result{}, err
+	}
+	task = resolved
+
+	if len(task.Spec.InputArtifacts) == 0 && len(task.Spec.OutputArtifacts) == 0 {
+		_, message, result, err := processWorkflowUpdate(ctx, task, attempt, shipper, reporter, redactor, nil)
+		return redactRun(redactor, message, result, err)
+	}
+
+	inputs := make([]artifact.Input, len(task.Spec.InputArtifacts))
+	for i, input := range task.Spec.InputArtifacts {
+		inputs[i] = artifact.Input{Name: input.Name, TaskID: int64(input.TaskID), Artifact: input.Artifact}
+	}
+	workspace, err := artifact.NewWorkspace(ctx, r.CloudClient, inputs)
+	if err != nil {
+		return "", plugins.Result{}, err
+	}
+	defer workspace.Close()
+
+	_, message, result, err := processWorkflowUpdate(ctx, task, attempt, shipper, reporter, redactor, workspace)
+	if err != nil {
+		return redactRun(redactor, message, result, err)
+	}
+	if err := workspace.Upload(ctx, int64(task.Spec.ID), task.Spec.OutputArtifacts); err != nil {
+		return redactRun(redactor, message, result, err)
+	}
+	return redactRun(redactor, message, result, nil)
+}
+
 // processWorkflowUpdate handles different types of responses and returns the workflow state.
-func processWorkflowUpdate(ctx context.Context, task *v1.Task) (cloudv1.TaskStatusEnum, string, error) {
+// The result of the run is returned even when the run failed, as plugins report outputs such as exit codes.
+// The lines the plugin logs are shipped to the task service by shipper as well as logged by the controller,
+// with the values of secrets redacted by redactor, which is nil for tasks without secrets.
+// The progress the plugin reports is sent to the task service by reporter, redacted as well.
+// The plugin is given the directories of workspace, which is nil for tasks without artifacts.
+func processWorkflowUpdate(ctx context.Context, task *v1.Task, attempt int, shipper *tasklog.Shipper, reporter *progress.Reporter, redactor *secret.Redactor, workspace *artifact.Workspace) (cloudv1.TaskStatusEnum, string, plugins.Result, error) {
 	response := task
 
 	startTime := time.Now()
@@ -153,14 +320,32 @@ func processWorkflowUpdate(ctx context.Context, task *v1.Task) (cloudv1.TaskStat
 
 	plugin, err := plugins.NewPlugin(response.Spec.Type)
 	if err != nil {
-		return cloudv1.TaskStatusEnum_FAILED, fmt.Sprintf("Failed to create plugin: %v", err), err
+		return cloudv1.TaskStatusEnum_FAILED, fmt.Sprintf("Failed to create plugin: %v", err), plugins.Result{}, err
 	}
 
-	// Add retry logic for running the task
-	runErr := plugin.Run(response.Spec.Payload.Parameters)
+	logger := slog.New(redactor.Handler(shipper.Handler(logr.ToSlogHandler(log.FromContext(ctx))))).With("task_id", response.Spec.ID, "attempt", attempt)
+	taskContext := plugins.TaskContext{
+		TaskID:     int64(response.Spec.ID),
+		Attempt:    attempt,
+		Parameters: response.Spec.Payload.Parameters,
+		BaseImage:  response.Spec.BaseImage,
+		Entrypoint: response.Spec.Entrypoint,
+		Args:       response.Spec.Args,
+		Env:        response.Spec.Env,
+		Logger:     logger,
+		Progress: func(percent int, message string) {
+			reporter.Report(percent, redactor.Redact(message))
+		},
+	}
+	if workspace != nil {
+		taskContext.InputDir = workspace.InputDir
+		taskContext.OutputDir = workspace.OutputDir
+	}
+	result, runErr := plugin.Run(ctx, taskContext)
 	if runErr != nil {
-		return cloudv1.TaskStatusEnum_FAILED, fmt.Sprintf("Error running task: %v", runErr), runErr
+		return cloudv1.TaskStatusEnum_FAILED, fmt.Sprintf("Error running task: %v", runErr), result, runErr
 	}
 
-	return cloudv1.TaskStatusEnum_SUCCEEDED, "Task completed successfully", nil
+	logger.Info("Task run finished", "outputs", result.Outputs, "metrics", result.Metrics)
+	return cloudv1.TaskStatusEnum_SUCCEEDED, "Task completed successfully", result, nil
 }
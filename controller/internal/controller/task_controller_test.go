@@ -27,7 +27,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	taskiov1 "task/api/v1"
+	taskiov1 "task/controller/api/v1"
 )
 
 var _ = Describe("Task Controller", func() {
@@ -43,6 +43,7 @@ var _ = Describe("Task Controller", func() {
 		task := &taskiov1.Task{}
 
 		BeforeEach(func() {
+			requireEnvtest()
 			By("creating the custom resource for the Kind Task")
 			err := k8sClient.Get(ctx, typeNamespacedName, task)
 			if err != nil && errors.IsNotFound(err) {
@@ -58,6 +59,7 @@ var _ = Describe("Task Controller", func() {
 		})
 
 		AfterEach(func() {
+			requireEnvtest()
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
 			resource := &taskiov1.Task{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
@@ -69,8 +71,9 @@ var _ = Describe("Task Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &TaskReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				CloudClient: &fakeCloudClient{},
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
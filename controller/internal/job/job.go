@@ -0,0 +1,228 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package job runs tasks that have a base image as Kubernetes Jobs.
+package job
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/secret"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ContainerName is the name of the container that runs the task in the Job pod.
+	ContainerName = "task"
+	// TaskIDLabel labels the Jobs with the ID of their task.
+	TaskIDLabel = "task.io/task-id"
+	// jobNameLabel is set on the pods of a Job by the Job controller.
+	jobNameLabel = "batch.kubernetes.io/job-name"
+	// defaultLogTailLines is the number of log lines fetched when Runner.LogTailLines is 0.
+	defaultLogTailLines = 100
+)
+
+// Runner runs tasks as batch/v1 Jobs owned by their Task.
+type Runner struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Clientset lists the pods of Jobs and fetches their logs, which the controller-runtime client
+	// cannot read. Pods are read uncached, so that the controller needs no watch on them.
+	Clientset kubernetes.Interface
+	// LogTailLines is the number of log lines fetched from the pod of a finished Job.
+	LogTailLines int64
+}
+
+// Ensure returns the Job of the task, creating it when it does not exist yet.
+func (r *Runner) Ensure(ctx context.Context, task *v1.Task) (*batchv1.Job, error) {
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: task.Name}, job)
+	if err == nil {
+		return job, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	job = newJob(task)
+	if err := controllerutil.SetControllerReference(task, job, r.Scheme); err != nil {
+		return nil, fmt.Errorf("failed to set job owner: %w", err)
+	}
+	if err := r.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return job, nil
+}
+
+// Delete deletes the Job of the task along with its pods, which stops the task.
+// It does nothing when the task has no Job.
+func (r *Runner) Delete(ctx context.Context, task *v1.Task) error {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: task.Namespace, Name: task.Name}}
+	if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	return nil
+}
+
+// newJob builds the Job that runs the entrypoint of the task in its base image.
+func newJob(task *v1.Task) *batchv1.Job {
+	labels := map[string]string{TaskIDLabel: strconv.Itoa(int(task.Spec.ID))}
+
+	env := []corev1.EnvVar{{Name: "TASK_ID", Value: strconv.Itoa(int(task.Spec.ID))}}
+	names := make([]string, 0, len(task.Spec.Env))
+	for name := range task.Spec.Env {
+		names = append(names, name)
+	}
+	// Sorted so that the pod template does not change between reconciles
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, envVar(name, task.Spec.Env[name]))
+	}
+
+	container := corev1.Container{
+		Name:  ContainerName,
+		Image: task.Spec.BaseImage,
+		Args:  task.Spec.Args,
+		Env:   env,
+	}
+	// Without an entrypoint the image's own entrypoint is run
+	if task.Spec.Entrypoint != "" {
+		container.Command = []string{task.Spec.Entrypoint}
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      task.Name,
+			Namespace: task.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			// Jobs are retried as often as tasks run by plugins, and each pod is an attempt limited by the timeout
+			BackoffLimit: ptr.To(task.Spec.GetRetries()),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:         corev1.RestartPolicyNever,
+					ActiveDeadlineSeconds: ptr.To(int64(math.Ceil(task.Spec.GetTimeout().Seconds()))),
+					Containers:            []corev1.Container{container},
+				},
+			},
+		},
+	}
+}
+
+// envVar builds the variable of an env value of the task. References to secrets are
+// resolved by the kubelet from the Kubernetes Secret of the same name, so that their
+// values never appear in the Job.
+func envVar(name, value string) corev1.EnvVar {
+	ref, ok := secret.ParseRef(value)
+	if !ok {
+		return corev1.EnvVar{Name: name, Value: value}
+	}
+	return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ref},
+			Key:                  secret.KubernetesKey,
+		},
+	}}
+}
+
+// Status maps the conditions of a Job to the status of its task and a message describing it.
+func Status(job *batchv1.Job) (cloudv1.TaskStatusEnum, string) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return cloudv1.TaskStatusEnum_SUCCEEDED, "Job completed successfully"
+		case batchv1.JobFailed:
+			return cloudv1.TaskStatusEnum_FAILED, fmt.Sprintf("Job failed: %s: %s", cond.Reason, cond.Message)
+		case batchv1.JobSuspended:
+			return cloudv1.TaskStatusEnum_QUEUED, "Job is suspended"
+		}
+	}
+	if job.Status.Active > 0 {
+		return cloudv1.TaskStatusEnum_RUNNING, fmt.Sprintf("Job is running attempt %d of %d", job.Status.Failed+1, ptr.Deref(job.Spec.BackoffLimit, v1.DefaultRetries)+1)
+	}
+	return cloudv1.TaskStatusEnum_QUEUED, "Job is waiting for its pod"
+}
+
+// Attempts returns the number of pods of the Job that were started, each of which is an attempt at its task.
+func Attempts(job *batchv1.Job) int32 {
+	return job.Status.Active + job.Status.Failed + job.Status.Succeeded
+}
+
+// Logs returns the tail of the logs of the most recent pod of the Job.
+func (r *Runner) Logs(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods, err := r.Clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{jobNameLabel: job.Name}).String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list job pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", nil
+	}
+	latest := latestPod(pods.Items)
+
+	tail := r.LogTailLines
+	if tail == 0 {
+		tail = defaultLogTailLines
+	}
+	stream, err := r.Clientset.CoreV1().Pods(latest.Namespace).GetLogs(latest.Name, &corev1.PodLogOptions{
+		Container: ContainerName,
+		TailLines: &tail,
+	}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of pod %s: %w", latest.Name, err)
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of pod %s: %w", latest.Name, err)
+	}
+	return string(data), nil
+}
+
+// latestPod returns the most recently created of the pods, which must not be empty.
+func latestPod(pods []corev1.Pod) corev1.Pod {
+	latest := pods[0]
+	for _, pod := range pods[1:] {
+		if latest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			latest = pod
+		}
+	}
+	return latest
+}
@@ -0,0 +1,252 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestRunner returns a Runner whose clientset serves the pods, which the Runner reads uncached.
+func newTestRunner(t *testing.T, objs ...client.Object) *Runner {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1.AddToScheme(scheme))
+
+	var pods []runtime.Object
+	for _, obj := range objs {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pods = append(pods, pod)
+		}
+	}
+	return &Runner{
+		Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Scheme:    scheme,
+		Clientset: kubefake.NewSimpleClientset(pods...),
+	}
+}
+
+func newTestTask() *v1.Task {
+	return &v1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task-7", Namespace: "tasks", UID: "7a5c"},
+		Spec: v1.TaskSpec{
+			ID:         7,
+			Type:       "process",
+			BaseImage:  "python:3.12",
+			Entrypoint: "python",
+			Args:       []string{"train.py", "--epochs=10"},
+			Env:        map[string]string{"SEED": "42", "MODE": "fast"},
+		},
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	task := newTestTask()
+	r := newTestRunner(t, task)
+	ctx := context.Background()
+
+	job, err := r.Ensure(ctx, task)
+	require.NoError(t, err)
+
+	assert.Equal(t, "task-7", job.Name)
+	assert.Equal(t, "tasks", job.Namespace)
+	assert.Equal(t, "7", job.Labels[TaskIDLabel])
+	assert.Equal(t, v1.DefaultRetries, *job.Spec.BackoffLimit)
+	require.Len(t, job.OwnerReferences, 1)
+	assert.Equal(t, "Task", job.OwnerReferences[0].Kind)
+	assert.Equal(t, task.UID, job.OwnerReferences[0].UID)
+	assert.True(t, *job.OwnerReferences[0].Controller)
+
+	pod := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, pod.RestartPolicy)
+	assert.Equal(t, int64(v1.DefaultTimeout.Seconds()), *pod.ActiveDeadlineSeconds)
+	require.Len(t, pod.Containers, 1)
+	container := pod.Containers[0]
+	assert.Equal(t, "python:3.12", container.Image)
+	assert.Equal(t, []string{"python"}, container.Command)
+	assert.Equal(t, []string{"train.py", "--epochs=10"}, container.Args)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "TASK_ID", Value: "7"},
+		{Name: "MODE", Value: "fast"},
+		{Name: "SEED", Value: "42"},
+	}, container.Env)
+
+	// The Job is only created once
+	again, err := r.Ensure(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, job.UID, again.UID)
+	jobs := &batchv1.JobList{}
+	require.NoError(t, r.List(ctx, jobs))
+	assert.Len(t, jobs.Items, 1)
+}
+
+func TestDelete(t *testing.T) {
+	task := newTestTask()
+	r := newTestRunner(t, task)
+	ctx := context.Background()
+
+	_, err := r.Ensure(ctx, task)
+	require.NoError(t, err)
+	require.NoError(t, r.Delete(ctx, task))
+
+	err = r.Get(ctx, client.ObjectKey{Namespace: "tasks", Name: "task-7"}, &batchv1.Job{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Deleting a task without a Job does nothing
+	require.NoError(t, r.Delete(ctx, task))
+}
+
+func TestEnsureImageEntrypoint(t *testing.T) {
+	task := newTestTask()
+	task.Spec.Entrypoint = ""
+	r := newTestRunner(t, task)
+
+	job, err := r.Ensure(context.Background(), task)
+	require.NoError(t, err)
+	assert.Nil(t, job.Spec.Template.Spec.Containers[0].Command)
+}
+
+func TestEnsureSecretEnv(t *testing.T) {
+	task := newTestTask()
+	task.Spec.Env["API_TOKEN"] = "secret://api-token"
+	r := newTestRunner(t, task)
+
+	job, err := r.Ensure(context.Background(), task)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.EnvVar{
+		Name: "API_TOKEN",
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "api-token"},
+			Key:                  "value",
+		}},
+	}, job.Spec.Template.Spec.Containers[0].Env[1])
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      batchv1.JobStatus
+		wantStatus  cloudv1.TaskStatusEnum
+		wantMessage string
+	}{
+		{
+			name:        "Pending",
+			wantStatus:  cloudv1.TaskStatusEnum_QUEUED,
+			wantMessage: "Job is waiting for its pod",
+		},
+		{
+			name:        "Running",
+			status:      batchv1.JobStatus{Active: 1, Failed: 1},
+			wantStatus:  cloudv1.TaskStatusEnum_RUNNING,
+			wantMessage: "Job is running attempt 2 of 3",
+		},
+		{
+			name: "Complete",
+			status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobSuccessCriteriaMet, Status: corev1.ConditionTrue},
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			}},
+			wantStatus:  cloudv1.TaskStatusEnum_SUCCEEDED,
+			wantMessage: "Job completed successfully",
+		},
+		{
+			name: "Failed",
+			status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+			}},
+			wantStatus:  cloudv1.TaskStatusEnum_FAILED,
+			wantMessage: "Job failed: BackoffLimitExceeded: Job has reached the specified backoff limit",
+		},
+		{
+			name: "Suspended",
+			status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobSuspended, Status: corev1.ConditionTrue},
+			}},
+			wantStatus:  cloudv1.TaskStatusEnum_QUEUED,
+			wantMessage: "Job is suspended",
+		},
+		{
+			name: "Resumed",
+			status: batchv1.JobStatus{Active: 1, Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobSuspended, Status: corev1.ConditionFalse},
+			}},
+			wantStatus:  cloudv1.TaskStatusEnum_RUNNING,
+			wantMessage: "Job is running attempt 1 of 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := Status(&batchv1.Job{Status: tt.status})
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantMessage, message)
+		})
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	assert.Equal(t, int32(0), Attempts(&batchv1.Job{}))
+	assert.Equal(t, int32(2), Attempts(&batchv1.Job{Status: batchv1.JobStatus{Active: 1, Failed: 1}}))
+	assert.Equal(t, int32(3), Attempts(&batchv1.Job{Status: batchv1.JobStatus{Failed: 2, Succeeded: 1}}))
+	assert.Equal(t, int32(3), Attempts(&batchv1.Job{Status: batchv1.JobStatus{Failed: 3}}))
+}
+
+func TestLogs(t *testing.T) {
+	newPod := func(name string, created time.Time) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "tasks",
+			Labels:            map[string]string{jobNameLabel: "task-7"},
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+	}
+	now := time.Now().Truncate(time.Second)
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "task-7", Namespace: "tasks"}}
+
+	t.Run("No pods", func(t *testing.T) {
+		logs, err := newTestRunner(t).Logs(context.Background(), job)
+		require.NoError(t, err)
+		assert.Empty(t, logs)
+	})
+
+	t.Run("Fetches the log tail", func(t *testing.T) {
+		r := newTestRunner(t, newPod("task-7-first", now.Add(-time.Minute)), newPod("task-7-retry", now))
+		r.LogTailLines = 20
+
+		logs, err := r.Logs(context.Background(), job)
+		require.NoError(t, err)
+		// The fake clientset serves the same logs for every pod
+		assert.Equal(t, "fake logs", logs)
+
+		actions := r.Clientset.(*kubefake.Clientset).Actions()
+		require.Len(t, actions, 2)
+		list := actions[0].(clienttesting.ListAction)
+		assert.Equal(t, "pods", list.GetResource().Resource)
+		assert.Equal(t, jobNameLabel+"=task-7", list.GetListRestrictions().Labels.String())
+		action := actions[1].(clienttesting.GenericAction)
+		assert.Equal(t, "log", action.GetSubresource())
+		opts := action.GetValue().(*corev1.PodLogOptions)
+		assert.Equal(t, ContainerName, opts.Container)
+		assert.Equal(t, int64(20), *opts.TailLines)
+	})
+
+	t.Run("Retried pods", func(t *testing.T) {
+		pods := []corev1.Pod{*newPod("task-7-first", now.Add(-time.Minute)), *newPod("task-7-retry", now), *newPod("task-7-second", now.Add(-time.Second))}
+		assert.Equal(t, "task-7-retry", latestPod(pods).Name)
+	})
+}
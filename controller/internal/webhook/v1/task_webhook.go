@@ -0,0 +1,198 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	taskv1 "task/controller/api/v1"
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/plugins"
+	_ "task/pkg/plugins/builtin" // Register the built-in task types
+	"task/pkg/protorules"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var tasklog = logf.Log.WithName("task-resource")
+
+// SetupTaskWebhookWithManager registers the webhook for Task in the manager.
+func SetupTaskWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&taskv1.Task{}).
+		WithValidator(&TaskCustomValidator{}).
+		WithDefaulter(&TaskCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-task-io-v1-task,mutating=true,failurePolicy=fail,sideEffects=None,groups=task.io,resources=tasks,verbs=create;update,versions=v1,name=mtask-v1.kb.io,admissionReviewVersions=v1
+
+// TaskCustomDefaulter sets the retries, priority and timeout of Tasks that do not set them
+// to the defaults of the API, so that the values a task runs with are visible on the resource.
+type TaskCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &TaskCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Task.
+func (d *TaskCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	task, ok := obj.(*taskv1.Task)
+	if !ok {
+		return fmt.Errorf("expected a Task object but got %T", obj)
+	}
+	tasklog.Info("Defaulting for Task", "name", task.GetName())
+
+	task.Spec.Default()
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-task-io-v1-task,mutating=false,failurePolicy=fail,sideEffects=None,groups=task.io,resources=tasks,verbs=create;update,versions=v1,name=vtask-v1.kb.io,admissionReviewVersions=v1
+
+// TaskCustomValidator rejects Tasks that the task service would reject when they are created through it:
+// the spec is checked against the validate.rules of CreateTaskRequest in cloud.proto, the retries and
+// priority against those of Task, and the type and parameters against the registered plugins.
+type TaskCustomValidator struct{}
+
+var _ webhook.CustomValidator = &TaskCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Task.
+func (v *TaskCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	task, ok := obj.(*taskv1.Task)
+	if !ok {
+		return nil, fmt.Errorf("expected a Task object but got %T", obj)
+	}
+	tasklog.Info("Validation for Task upon creation", "name", task.GetName())
+
+	return nil, validateTask(task)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Task.
+// Only changes to the spec are validated, so that Tasks created before a rule was added can still be
+// updated by the controller, such as to remove their finalizer.
+func (v *TaskCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	task, ok := newObj.(*taskv1.Task)
+	if !ok {
+		return nil, fmt.Errorf("expected a Task object for the newObj but got %T", newObj)
+	}
+	oldTask, ok := oldObj.(*taskv1.Task)
+	if !ok {
+		return nil, fmt.Errorf("expected a Task object for the oldObj but got %T", oldObj)
+	}
+	tasklog.Info("Validation for Task upon update", "name", task.GetName())
+
+	if task.DeletionTimestamp != nil || equality.Semantic.DeepEqual(oldTask.Spec, task.Spec) {
+		return nil, nil
+	}
+	return nil, validateTask(task)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Task.
+func (v *TaskCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateTask returns an Invalid error listing every field of the spec that breaks a rule, or nil.
+func validateTask(task *taskv1.Task) error {
+	specPath := field.NewPath("spec")
+	var allErrs field.ErrorList
+
+	for _, v := range protorules.Validate(createTaskRequest(&task.Spec)) {
+		allErrs = append(allErrs, violationError(specPath, v))
+	}
+	limits := &cloudv1.Task{Retries: task.Spec.GetRetries(), Priority: task.Spec.GetPriority()}
+	for _, name := range []protoreflect.Name{"retries", "priority"} {
+		for _, v := range protorules.ValidateField(limits, name) {
+			allErrs = append(allErrs, violationError(specPath, v))
+		}
+	}
+	if task.Spec.Timeout != nil && task.Spec.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("timeout"), task.Spec.Timeout.Duration.String(), "must be greater than 0"))
+	}
+	allErrs = append(allErrs, validatePlugin(specPath, &task.Spec)...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(taskv1.GroupVersion.WithKind("Task").GroupKind(), task.Name, allErrs)
+}
+
+// validatePlugin checks that a plugin is registered for the type of the task and that the parameters
+// of its payload match the schema of the plugin, as the task service does on creation.
+func validatePlugin(specPath *field.Path, spec *taskv1.TaskSpec) field.ErrorList {
+	if spec.Type == "" {
+		// Reported by the rules of the type
+		return nil
+	}
+	if !plugins.IsRegistered(spec.Type) {
+		return field.ErrorList{field.NotSupported(specPath.Child("type"), spec.Type, plugins.Types())}
+	}
+	schema, err := plugins.Describe(spec.Type)
+	if err != nil {
+		return field.ErrorList{field.InternalError(specPath.Child("type"), err)}
+	}
+
+	parametersPath := specPath.Child("payload", "parameters")
+	err = schema.Validate(spec.Payload.Parameters)
+	var validationErr *plugins.ValidationError
+	if !errors.As(err, &validationErr) {
+		if err != nil {
+			return field.ErrorList{field.Invalid(parametersPath, field.OmitValueType{}, err.Error())}
+		}
+		return nil
+	}
+	var allErrs field.ErrorList
+	for _, f := range validationErr.Fields {
+		allErrs = append(allErrs, field.Invalid(parametersPath.Child(f.Parameter), field.OmitValueType{}, f.Message))
+	}
+	return allErrs
+}
+
+// createTaskRequest returns the request that creates a task with the spec in the task service.
+func createTaskRequest(spec *taskv1.TaskSpec) *cloudv1.CreateTaskRequest {
+	inputs := make([]*cloudv1.ArtifactInput, len(spec.InputArtifacts))
+	for i, input := range spec.InputArtifacts {
+		inputs[i] = &cloudv1.ArtifactInput{Name: input.Name, TaskId: input.TaskID, Artifact: input.Artifact}
+	}
+	return &cloudv1.CreateTaskRequest{
+		Name:            spec.Name,
+		Type:            spec.Type,
+		Payload:         &cloudv1.Payload{Parameters: spec.Payload.Parameters},
+		Description:     spec.Description,
+		BaseImage:       spec.BaseImage,
+		Entrypoint:      spec.Entrypoint,
+		Args:            spec.Args,
+		Env:             spec.Env,
+		InputArtifacts:  inputs,
+		OutputArtifacts: spec.OutputArtifacts,
+	}
+}
+
+// violationError returns the error of a violation of the rules of a field of the spec. The fields of
+// the spec have the JSON names of the fields of the proto messages, so the paths are the same.
+func violationError(specPath *field.Path, v protorules.Violation) *field.Error {
+	return field.Invalid(specPath.Child(v.Field), field.OmitValueType{}, v.Description)
+}
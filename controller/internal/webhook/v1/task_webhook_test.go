@@ -0,0 +1,236 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"connectrpc.com/connect"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	taskv1 "task/controller/api/v1"
+	interfaces "task/server/repository/interface"
+	"task/server/route"
+	// TODO (user): Add any additional imports if needed
+)
+
+// noRepos gives the task service no repositories, as it validates tasks before it stores them.
+type noRepos struct{}
+
+func (noRepos) TaskRepo() interfaces.TaskRepo               { return nil }
+func (noRepos) TaskHistoryRepo() interfaces.TaskHistoryRepo { return nil }
+func (noRepos) WorkflowRepo() interfaces.WorkflowRepo       { return nil }
+func (noRepos) ExecutionRepo() interfaces.ExecutionRepo     { return nil }
+func (noRepos) TaskLogRepo() interfaces.TaskLogRepo         { return nil }
+func (noRepos) SecretRepo() interfaces.SecretRepo           { return nil }
+
+// newTask returns a Task that passes validation.
+func newTask(name string) *taskv1.Task {
+	return &taskv1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: taskv1.TaskSpec{
+			ID:   1,
+			Name: "welcome_email",
+			Type: "send_email",
+			Payload: taskv1.Payload{
+				Parameters: map[string]string{"to": "user@example.com"},
+			},
+			Env:             map[string]string{"LANG": "en"},
+			InputArtifacts:  []taskv1.ArtifactInput{{Name: "template.html", TaskID: 2, Artifact: "template.html"}},
+			OutputArtifacts: []string{"receipt.json"},
+		},
+	}
+}
+
+// fieldErrors returns the fields and details of an Invalid error.
+func fieldErrors(err error) map[string]string {
+	Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an Invalid error, got %v", err)
+	statusErr, ok := err.(apierrors.APIStatus)
+	Expect(ok).To(BeTrue())
+	fields := map[string]string{}
+	for _, cause := range statusErr.Status().Details.Causes {
+		fields[cause.Field] = cause.Message
+	}
+	return fields
+}
+
+var _ = Describe("Task Webhook", func() {
+	var (
+		obj       *taskv1.Task
+		oldObj    *taskv1.Task
+		validator TaskCustomValidator
+		defaulter TaskCustomDefaulter
+	)
+
+	BeforeEach(func() {
+		obj = newTask("task-1")
+		oldObj = newTask("task-1")
+		validator = TaskCustomValidator{}
+		defaulter = TaskCustomDefaulter{}
+	})
+
+	Context("When creating Task under Defaulting Webhook", func() {
+		It("Should set the retries, priority and timeout that are not set", func() {
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Retries).To(HaveValue(Equal(taskv1.DefaultRetries)))
+			Expect(obj.Spec.Priority).To(HaveValue(Equal(taskv1.DefaultPriority)))
+			Expect(obj.Spec.Timeout).To(HaveValue(Equal(metav1.Duration{Duration: taskv1.DefaultTimeout})))
+		})
+
+		It("Should keep the values that are set", func() {
+			obj.Spec.Retries = ptr.To[int32](0)
+			obj.Spec.Priority = ptr.To[int32](5)
+			obj.Spec.Timeout = &metav1.Duration{Duration: time.Minute}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Retries).To(HaveValue(Equal(int32(0))))
+			Expect(obj.Spec.Priority).To(HaveValue(Equal(int32(5))))
+			Expect(obj.Spec.Timeout).To(HaveValue(Equal(metav1.Duration{Duration: time.Minute})))
+		})
+	})
+
+	Context("When creating or updating Task under Validating Webhook", func() {
+		It("Should admit a valid task", func() {
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny an unknown type", func() {
+			obj.Spec.Type = "mine_bitcoin"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(fieldErrors(err)).To(HaveKeyWithValue("spec.type", ContainSubstring(`Unsupported value: "mine_bitcoin"`)))
+		})
+
+		It("Should deny a malformed payload", func() {
+			obj.Spec.Payload.Parameters = map[string]string{"subject": "Hello", "bad key": "x"}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(fieldErrors(err)).To(And(
+				HaveKeyWithValue("spec.payload.parameters.to", ContainSubstring("is required")),
+				HaveKeyWithValue("spec.payload.parameters.bad key", ContainSubstring("key must match the pattern")),
+			))
+		})
+
+		It("Should deny the fields that break the rules of cloud.proto", func() {
+			obj.Spec.Name = "welcome email"
+			obj.Spec.Env = map[string]string{"1LANG": "en"}
+			obj.Spec.InputArtifacts[0].TaskID = 0
+			obj.Spec.Retries = ptr.To[int32](11)
+			obj.Spec.Priority = ptr.To[int32](-1)
+			obj.Spec.Timeout = &metav1.Duration{}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(fieldErrors(err)).To(And(
+				HaveKeyWithValue("spec.name", ContainSubstring("must match the pattern")),
+				HaveKeyWithValue("spec.env.1LANG", ContainSubstring("key must match the pattern")),
+				HaveKeyWithValue("spec.input_artifacts[0].task_id", ContainSubstring("must be greater than 0")),
+				HaveKeyWithValue("spec.retries", ContainSubstring("must be less than or equal to 10")),
+				HaveKeyWithValue("spec.priority", ContainSubstring("must be greater than or equal to 0")),
+				HaveKeyWithValue("spec.timeout", ContainSubstring("must be greater than 0")),
+			))
+		})
+
+		It("Should admit updates that leave an invalid spec unchanged", func() {
+			oldObj.Spec.Type = "mine_bitcoin"
+			obj.Spec.Type = "mine_bitcoin"
+			obj.Finalizers = []string{"task.io/report-status"}
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny updates that make the spec invalid", func() {
+			obj.Spec.Type = "mine_bitcoin"
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().To(HaveOccurred())
+		})
+
+		It("Should deny the same fields as the task service", func() {
+			server := route.NewTaskServer(noRepos{}, nil, 0, nil)
+			invalid := map[string]func(spec *taskv1.TaskSpec){
+				"name with a space":    func(spec *taskv1.TaskSpec) { spec.Name = "welcome email" },
+				"long parameter value": func(spec *taskv1.TaskSpec) { spec.Payload.Parameters["body"] = strings.Repeat("a", 1025) },
+				"malformed env key":    func(spec *taskv1.TaskSpec) { spec.Env["1LANG"] = "en" },
+				"several invalid fields": func(spec *taskv1.TaskSpec) {
+					spec.Name = "welcome email"
+					spec.Env["LANG-CODE"] = "en"
+					spec.OutputArtifacts = []string{".receipt"}
+				},
+			}
+			for name, invalidate := range invalid {
+				obj := newTask("task-1")
+				invalidate(&obj.Spec)
+
+				_, err := validator.ValidateCreate(ctx, obj)
+				var webhookFields []string
+				for field := range fieldErrors(err) {
+					webhookFields = append(webhookFields, strings.TrimPrefix(field, "spec."))
+				}
+
+				_, err = server.CreateTask(ctx, connect.NewRequest(createTaskRequest(&obj.Spec)))
+				Expect(connect.CodeOf(err)).To(Equal(connect.CodeInvalidArgument), name)
+				var serverFields []string
+				for _, detail := range err.(*connect.Error).Details() {
+					value, detailErr := detail.Value()
+					Expect(detailErr).NotTo(HaveOccurred())
+					for _, v := range value.(*errdetails.BadRequest).FieldViolations {
+						serverFields = append(serverFields, v.Field)
+					}
+				}
+				Expect(webhookFields).NotTo(BeEmpty(), name)
+				Expect(webhookFields).To(ConsistOf(serverFields), name)
+			}
+		})
+	})
+
+	Context("When applying Task through the API server", func() {
+		BeforeEach(func() {
+			requireEnvtest()
+		})
+
+		It("Should default and admit a valid task", func() {
+			task := newTask("task-valid")
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, task))).To(Succeed())
+			})
+
+			created := &taskv1.Task{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(task), created)).To(Succeed())
+			Expect(created.Spec.Retries).To(HaveValue(Equal(taskv1.DefaultRetries)))
+			Expect(created.Spec.Priority).To(HaveValue(Equal(taskv1.DefaultPriority)))
+			Expect(created.Spec.Timeout).To(HaveValue(Equal(metav1.Duration{Duration: taskv1.DefaultTimeout})))
+		})
+
+		It("Should reject an unknown type", func() {
+			task := newTask("task-unknown-type")
+			task.Spec.Type = "mine_bitcoin"
+			err := k8sClient.Create(ctx, task)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an Invalid error, got %v", err)
+			Expect(err.Error()).To(ContainSubstring("spec.type"))
+		})
+
+		It("Should reject a malformed payload", func() {
+			task := newTask("task-malformed-payload")
+			task.Spec.Payload.Parameters = map[string]string{"to": ""}
+			err := k8sClient.Create(ctx, task)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an Invalid error, got %v", err)
+			Expect(err.Error()).To(ContainSubstring("spec.payload.parameters.to"))
+		})
+	})
+})
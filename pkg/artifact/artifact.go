@@ -0,0 +1,107 @@
+// Package artifact stores the files tasks take as input and produce as output.
+//
+// Artifacts are kept in a Store under a key derived from the task that produced
+// them. Workers never talk to the store directly: the task service hands out
+// short-lived URLs that allow uploading or downloading one artifact until they
+// expire, and the Workspace moves artifacts between those URLs and the
+// directories a task run works in.
+package artifact
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"task/pkg/config"
+)
+
+const (
+	// StoreLocal keeps artifacts on the filesystem of the server, which serves them itself.
+	StoreLocal = "local"
+	// StoreS3 keeps artifacts in a bucket of an S3-compatible service.
+	StoreS3 = "s3"
+
+	// MaxNameLength is the length of the longest artifact name.
+	MaxNameLength = 255
+)
+
+// ErrNotFound is returned when there is no artifact under a key.
+var ErrNotFound = errors.New("artifact not found")
+
+// namePattern matches valid artifact names, which are used as file names.
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]*$`)
+
+// Info describes a stored artifact.
+type Info struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Store keeps artifacts and signs the URLs that clients upload and download them with.
+type Store interface {
+	// SignURL returns a URL that allows a single method, GET or PUT, on the artifact
+	// at key until expiry has passed.
+	SignURL(ctx context.Context, method, key string, expiry time.Duration) (string, error)
+	// Stat describes the artifact at key, or returns ErrNotFound.
+	Stat(ctx context.Context, key string) (Info, error)
+	// List describes the artifacts whose keys start with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]Info, error)
+}
+
+// Key returns the key of the artifact a task produced under the name.
+func Key(taskID int64, name string) string {
+	return fmt.Sprintf("%s%s", Prefix(taskID), name)
+}
+
+// Prefix returns the prefix of the keys of the artifacts a task produced.
+func Prefix(taskID int64) string {
+	return fmt.Sprintf("tasks/%d/", taskID)
+}
+
+// ValidateName checks that name can be used as the name of an artifact.
+func ValidateName(name string) error {
+	if len(name) > MaxNameLength || !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid artifact name %q: must be 1 to %d letters, digits, dots, underscores or dashes, not starting with a dot or dash", name, MaxNameLength)
+	}
+	return nil
+}
+
+// NewStore creates the store selected by the configuration.
+func NewStore(cfg config.ArtifactConfig) (Store, error) {
+	switch cfg.Store {
+	case StoreLocal:
+		key := []byte(cfg.SigningKey)
+		if len(key) == 0 {
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("failed to generate artifact signing key: %w", err)
+			}
+		}
+		return NewLocalStore(cfg.LocalDir, cfg.PublicURL, key, cfg.MaxBytes)
+	case StoreS3:
+		return NewS3Store(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Bucket:          cfg.S3Bucket,
+			Region:          cfg.S3Region,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			UseSSL:          cfg.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported artifact store %q: must be %s or %s", cfg.Store, StoreLocal, StoreS3)
+	}
+}
+
+// validMethod checks that a URL can be signed for method.
+func validMethod(method string) error {
+	if method != http.MethodGet && method != http.MethodPut {
+		return fmt.Errorf("unsupported artifact method %q: must be GET or PUT", method)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package artifact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalPath is the path the server serves the artifacts of a LocalStore at.
+const LocalPath = "/artifacts/"
+
+// uploadPattern names the temporary files uploads are written to before they are renamed into place.
+const uploadPattern = ".upload-*"
+
+// LocalStore keeps artifacts in a directory on the server and serves them itself.
+// Its URLs point at the server and carry an HMAC signature of the method, key and
+// expiry, which ServeHTTP checks before serving the request.
+type LocalStore struct {
+	dir      string
+	baseURL  string
+	key      []byte
+	maxBytes int64
+}
+
+// NewLocalStore creates a LocalStore keeping artifacts in dir, whose URLs start with
+// baseURL and are signed with key. Uploads are limited to maxBytes.
+func NewLocalStore(dir, baseURL string, key []byte, maxBytes int64) (*LocalStore, error) {
+	if len(key) == 0 {
+		return nil, errors.New("the local artifact store requires a signing key")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid artifact base URL %q: %w", baseURL, err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), key: key, maxBytes: maxBytes}, nil
+}
+
+// SignURL returns a URL of the server that allows method on the artifact at key until expiry has passed.
+func (s *LocalStore) SignURL(_ context.Context, method, key string, expiry time.Duration) (string, error) {
+	if err := validMethod(method); err != nil {
+		return "", err
+	}
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign(method, key, expires)}}
+	return s.baseURL + LocalPath + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// Stat describes the artifact at key.
+func (s *LocalStore) Stat(_ context.Context, key string) (Info, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return Info{}, err
+	}
+	fi, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && fi.IsDir()) {
+		return Info{}, ErrNotFound
+	}
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to stat artifact: %w", err)
+	}
+	return localInfo(key, fi), nil
+}
+
+// List describes the artifacts whose keys start with prefix. Prefixes end at a
+// directory, such as the prefix returned by Prefix.
+func (s *LocalStore) List(_ context.Context, prefix string) ([]Info, error) {
+	root, err := s.path(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return nil, err
+	}
+	var infos []Info
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ok, _ := path.Match(uploadPattern, d.Name()); ok {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, localInfo(filepath.ToSlash(rel), fi))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// ServeHTTP serves the downloads and uploads of signed URLs.
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, LocalPath)
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if err := s.verify(method, key, r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	p, err := s.path(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch method {
+	case http.MethodGet:
+		f, err := os.Open(p)
+		if err != nil {
+			http.Error(w, ErrNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil || fi.IsDir() {
+			http.Error(w, ErrNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", contentType(key))
+		http.ServeContent(w, r, path.Base(key), fi.ModTime(), f)
+	case http.MethodPut:
+		if err := s.write(p, http.MaxBytesReader(w, r.Body, s.maxBytes)); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, fmt.Sprintf("artifact is larger than %d bytes", s.maxBytes), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// write stores the upload at p, replacing any earlier artifact only once the upload is complete.
+func (s *LocalStore) write(p string, body io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), uploadPattern)
+	if err != nil {
+		return fmt.Errorf("failed to create artifact: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("failed to store artifact: %w", err)
+	}
+	return nil
+}
+
+// verify checks the expiry and signature of a signed URL.
+func (s *LocalStore) verify(method, key string, query url.Values) error {
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errors.New("the artifact URL is not signed")
+	}
+	if time.Now().Unix() > unix {
+		return errors.New("the artifact URL has expired")
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(s.sign(method, key, expires))) {
+		return errors.New("the artifact URL signature is invalid")
+	}
+	return nil
+}
+
+// sign returns the signature of a URL allowing method on key until expires.
+func (s *LocalStore) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%s\n%s", method, key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path returns the file the artifact at key is kept in.
+func (s *LocalStore) path(key string) (string, error) {
+	p := filepath.FromSlash(key)
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("invalid artifact key %q", key)
+	}
+	return filepath.Join(s.dir, p), nil
+}
+
+// localInfo describes the file of the artifact at key.
+func localInfo(key string, fi fs.FileInfo) Info {
+	return Info{Key: key, Size: fi.Size(), ContentType: contentType(key), ModTime: fi.ModTime()}
+}
+
+// contentType guesses the content type of an artifact from the extension of its key.
+func contentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,150 @@
+package artifact
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLocalStore returns a LocalStore served by a test server, with uploads limited to 16 bytes.
+func newTestLocalStore(t *testing.T) (*LocalStore, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(nil)
+	store, err := NewLocalStore(t.TempDir(), "http://"+srv.Listener.Addr().String(), []byte("secret"), 16)
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.Handle(LocalPath, store)
+	srv.Config.Handler = mux
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return store, srv
+}
+
+func do(t *testing.T, method, u, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, u, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestLocalStore(t)
+	key := Key(7, "report.csv")
+
+	_, err := store.Stat(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	put, err := store.SignURL(ctx, http.MethodPut, key, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, do(t, http.MethodPut, put, "a,b\n1,2\n").StatusCode)
+
+	info, err := store.Stat(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, key, info.Key)
+	assert.Equal(t, int64(8), info.Size)
+	assert.Equal(t, "text/csv; charset=utf-8", info.ContentType)
+
+	get, err := store.SignURL(ctx, http.MethodGet, key, time.Minute)
+	require.NoError(t, err)
+	resp := do(t, http.MethodGet, get, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(data))
+
+	infos, err := store.List(ctx, Prefix(7))
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, key, infos[0].Key)
+
+	infos, err = store.List(ctx, Prefix(8))
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+}
+
+func TestLocalStoreRejects(t *testing.T) {
+	ctx := context.Background()
+	store, srv := newTestLocalStore(t)
+	key := Key(7, "report.csv")
+
+	put, err := store.SignURL(ctx, http.MethodPut, key, time.Minute)
+	require.NoError(t, err)
+
+	t.Run("Method the URL was not signed for", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(t, http.MethodGet, put, "").StatusCode)
+	})
+
+	t.Run("Tampered key", func(t *testing.T) {
+		tampered := strings.Replace(put, "/tasks/7/", "/tasks/8/", 1)
+		assert.Equal(t, http.StatusForbidden, do(t, http.MethodPut, tampered, "data").StatusCode)
+	})
+
+	t.Run("Expired URL", func(t *testing.T) {
+		expired, err := store.SignURL(ctx, http.MethodPut, key, -time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, do(t, http.MethodPut, expired, "data").StatusCode)
+	})
+
+	t.Run("Unsigned URL", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(t, http.MethodPut, srv.URL+LocalPath+key, "data").StatusCode)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, do(t, http.MethodPut, put, strings.Repeat("x", 17)).StatusCode)
+		_, err := store.Stat(ctx, key)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Missing artifact", func(t *testing.T) {
+		get, err := store.SignURL(ctx, http.MethodGet, key, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, get, "").StatusCode)
+	})
+
+	t.Run("Key outside the directory", func(t *testing.T) {
+		_, err := store.SignURL(ctx, http.MethodGet, "../secret", time.Minute)
+		assert.ErrorContains(t, err, "invalid artifact key")
+		_, err = store.Stat(ctx, "/etc/passwd")
+		assert.ErrorContains(t, err, "invalid artifact key")
+	})
+
+	t.Run("Unsupported method", func(t *testing.T) {
+		_, err := store.SignURL(ctx, http.MethodDelete, key, time.Minute)
+		assert.ErrorContains(t, err, "unsupported artifact method")
+	})
+}
+
+func TestLocalStoreSignURL(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "https://tasks.example.com/", []byte("secret"), 1)
+	require.NoError(t, err)
+
+	u, err := store.SignURL(context.Background(), http.MethodGet, Key(7, "my report.csv"), time.Minute)
+	require.NoError(t, err)
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+	assert.Equal(t, "tasks.example.com", parsed.Host)
+	assert.Equal(t, "/artifacts/tasks/7/my report.csv", parsed.Path)
+	assert.NotEmpty(t, parsed.Query().Get("signature"))
+	assert.NotEmpty(t, parsed.Query().Get("expires"))
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"report.csv", "model_v2.pt", "_data", "a"} {
+		assert.NoError(t, ValidateName(name), name)
+	}
+	for _, name := range []string{"", ".hidden", "-flag", "dir/file", "../file", "with space", strings.Repeat("a", MaxNameLength+1)} {
+		assert.Error(t, ValidateName(name), name)
+	}
+}
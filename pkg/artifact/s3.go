@@ -0,0 +1,88 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options configure an S3Store.
+type S3Options struct {
+	// Endpoint is the host, and optional port, of the service, such as s3.amazonaws.com or localhost:9000.
+	Endpoint        string
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+	// Transport is used for the requests of the store, and defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// S3Store keeps artifacts in a bucket of an S3-compatible service, such as AWS S3
+// or MinIO. Its URLs are presigned URLs of the service, so transfers do not pass
+// through the task service.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store creates an S3Store for the bucket. The bucket must already exist.
+func NewS3Store(opts S3Options) (*S3Store, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("the s3 artifact store requires a bucket")
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(opts.AccessKeyID, opts.SecretAccessKey, ""),
+		Secure:    opts.UseSSL,
+		Region:    opts.Region,
+		Transport: opts.Transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+	return &S3Store{client: client, bucket: opts.Bucket}, nil
+}
+
+// SignURL returns a presigned URL that allows method on the object at key until expiry has passed.
+func (s *S3Store) SignURL(ctx context.Context, method, key string, expiry time.Duration) (string, error) {
+	if err := validMethod(method); err != nil {
+		return "", err
+	}
+	u, err := s.client.Presign(ctx, method, s.bucket, key, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign artifact URL: %w", err)
+	}
+	return u.String(), nil
+}
+
+// Stat describes the object at key.
+func (s *S3Store) Stat(ctx context.Context, key string) (Info, error) {
+	obj, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return Info{}, ErrNotFound
+		}
+		return Info{}, fmt.Errorf("failed to stat artifact: %w", err)
+	}
+	return Info{Key: obj.Key, Size: obj.Size, ContentType: obj.ContentType, ModTime: obj.LastModified}, nil
+}
+
+// List describes the objects whose keys start with prefix.
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Info, error) {
+	var infos []Info
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list artifacts: %w", obj.Err)
+		}
+		infos = append(infos, Info{Key: obj.Key, Size: obj.Size, ContentType: obj.ContentType, ModTime: obj.LastModified})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
@@ -0,0 +1,98 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestS3Store returns an S3Store talking to a stand-in for S3 that holds tasks/7/report.csv.
+func newTestS3Store(t *testing.T) (*S3Store, *httptest.Server) {
+	t.Helper()
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/artifacts/tasks/7/report.csv":
+			w.Header().Set("Content-Length", "8")
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+			w.Header().Set("ETag", `"etag"`)
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet && r.URL.Path == "/artifacts/" && r.URL.Query().Get("list-type") == "2":
+			var contents string
+			if strings.HasPrefix("tasks/7/report.csv", r.URL.Query().Get("prefix")) {
+				contents = fmt.Sprintf(`<Contents><Key>tasks/7/report.csv</Key><Size>8</Size><LastModified>%s</LastModified><ETag>"etag"</ETag></Contents>`, modTime.Format(time.RFC3339))
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>artifacts</Name><Prefix>%s</Prefix><IsTruncated>false</IsTruncated>%s</ListBucketResult>`, r.URL.Query().Get("prefix"), contents)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	store, err := NewS3Store(S3Options{
+		Endpoint:        strings.TrimPrefix(srv.URL, "http://"),
+		Bucket:          "artifacts",
+		Region:          "us-east-1",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	return store, srv
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	store, srv := newTestS3Store(t)
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := store.Stat(ctx, Key(7, "report.csv"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(8), info.Size)
+		assert.Equal(t, "text/csv", info.ContentType)
+
+		_, err = store.Stat(ctx, Key(7, "missing.csv"))
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		infos, err := store.List(ctx, Prefix(7))
+		require.NoError(t, err)
+		require.Len(t, infos, 1)
+		assert.Equal(t, "tasks/7/report.csv", infos[0].Key)
+		assert.Equal(t, int64(8), infos[0].Size)
+
+		infos, err = store.List(ctx, Prefix(8))
+		require.NoError(t, err)
+		assert.Empty(t, infos)
+	})
+
+	t.Run("SignURL", func(t *testing.T) {
+		u, err := store.SignURL(ctx, http.MethodPut, Key(7, "report.csv"), time.Minute)
+		require.NoError(t, err)
+		parsed, err := url.Parse(u)
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimPrefix(srv.URL, "http://"), parsed.Host)
+		assert.Equal(t, "/artifacts/tasks/7/report.csv", parsed.Path)
+		assert.Equal(t, "60", parsed.Query().Get("X-Amz-Expires"))
+		assert.NotEmpty(t, parsed.Query().Get("X-Amz-Signature"))
+
+		_, err = store.SignURL(ctx, http.MethodDelete, Key(7, "report.csv"), time.Minute)
+		assert.ErrorContains(t, err, "unsupported artifact method")
+	})
+}
+
+func TestNewS3StoreRequiresBucket(t *testing.T) {
+	_, err := NewS3Store(S3Options{Endpoint: "localhost:9000"})
+	assert.ErrorContains(t, err, "requires a bucket")
+}
@@ -0,0 +1,179 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+)
+
+// Input is an input artifact of a task: the output artifact of an earlier task,
+// made available under a name of its own.
+type Input struct {
+	Name     string
+	TaskID   int64
+	Artifact string
+}
+
+// Workspace holds the artifacts of a task run in a temporary directory: the inputs
+// downloaded before the run, and the outputs the run writes.
+type Workspace struct {
+	// InputDir holds the input artifacts, as files named after the inputs.
+	InputDir string
+	// OutputDir is where the run writes its output artifacts, as files named after the outputs.
+	OutputDir string
+
+	root   string
+	client cloudv1connect.TaskManagementServiceClient
+}
+
+// NewWorkspace creates a workspace and downloads the inputs into its InputDir, through
+// URLs handed out by the task service. The workspace must be closed to remove its files.
+func NewWorkspace(ctx context.Context, client cloudv1connect.TaskManagementServiceClient, inputs []Input) (*Workspace, error) {
+	root, err := os.MkdirTemp("", "task-artifacts-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create artifact workspace: %w", err)
+	}
+	w := &Workspace{
+		InputDir:  filepath.Join(root, "inputs"),
+		OutputDir: filepath.Join(root, "outputs"),
+		root:      root,
+		client:    client,
+	}
+	for _, dir := range []string{w.InputDir, w.OutputDir} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			w.Close()
+			return nil, fmt.Errorf("failed to create artifact workspace: %w", err)
+		}
+	}
+
+	for _, input := range inputs {
+		if err := w.download(ctx, input); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Upload uploads the outputs from OutputDir, through URLs handed out by the task service.
+// Every output must have been written by the run.
+func (w *Workspace) Upload(ctx context.Context, taskID int64, outputs []string) error {
+	for _, name := range outputs {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+		f, err := os.Open(filepath.Join(w.OutputDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("output artifact %s was not written to the output directory", name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open output artifact %s: %w", name, err)
+		}
+		err = w.upload(ctx, taskID, name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close removes the workspace and its files.
+func (w *Workspace) Close() error {
+	return os.RemoveAll(w.root)
+}
+
+// download fetches an input artifact into InputDir.
+func (w *Workspace) download(ctx context.Context, input Input) error {
+	if err := ValidateName(input.Name); err != nil {
+		return err
+	}
+	resp, err := w.client.GetArtifactDownloadURL(ctx, connect.NewRequest(&cloudv1.GetArtifactDownloadURLRequest{
+		Id:   int32(input.TaskID),
+		Name: input.Artifact,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to get the URL of input artifact %s: %w", input.Name, err)
+	}
+
+	body, err := Open(ctx, resp.Msg)
+	if err != nil {
+		return fmt.Errorf("failed to download input artifact %s: %w", input.Name, err)
+	}
+	defer body.Close()
+
+	f, err := os.Create(filepath.Join(w.InputDir, input.Name))
+	if err != nil {
+		return fmt.Errorf("failed to create input artifact %s: %w", input.Name, err)
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to download input artifact %s: %w", input.Name, err)
+	}
+	return f.Close()
+}
+
+// upload sends an output artifact to the store.
+func (w *Workspace) upload(ctx context.Context, taskID int64, name string, f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat output artifact %s: %w", name, err)
+	}
+	resp, err := w.client.GetArtifactUploadURL(ctx, connect.NewRequest(&cloudv1.GetArtifactUploadURLRequest{
+		Id:   int32(taskID),
+		Name: name,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to get the URL of output artifact %s: %w", name, err)
+	}
+
+	body, err := transfer(ctx, resp.Msg, f, fi.Size())
+	if err != nil {
+		return fmt.Errorf("failed to upload output artifact %s: %w", name, err)
+	}
+	return body.Close()
+}
+
+// Open downloads the artifact a download URL points to and returns its contents.
+func Open(ctx context.Context, u *cloudv1.ArtifactURL) (io.ReadCloser, error) {
+	return transfer(ctx, u, nil, 0)
+}
+
+// transfer sends the request described by an artifact URL, with the body of the given
+// size for uploads, and returns the response body.
+func transfer(ctx context.Context, u *cloudv1.ArtifactURL, body io.Reader, size int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, u.Method, u.Url, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+		if f, ok := body.(*os.File); ok {
+			req.Header.Set("Content-Type", contentType(f.Name()))
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s %s: %s", u.Method, resp.Status, bytes.TrimSpace(message))
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,101 @@
+package artifact
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient hands out URLs of a LocalStore, as the task service does.
+type fakeClient struct {
+	cloudv1connect.TaskManagementServiceClient
+	store *LocalStore
+}
+
+func (c *fakeClient) GetArtifactUploadURL(ctx context.Context, req *connect.Request[cloudv1.GetArtifactUploadURLRequest]) (*connect.Response[cloudv1.ArtifactURL], error) {
+	return c.sign(ctx, http.MethodPut, int64(req.Msg.Id), req.Msg.Name)
+}
+
+func (c *fakeClient) GetArtifactDownloadURL(ctx context.Context, req *connect.Request[cloudv1.GetArtifactDownloadURLRequest]) (*connect.Response[cloudv1.ArtifactURL], error) {
+	if _, err := c.store.Stat(ctx, Key(int64(req.Msg.Id), req.Msg.Name)); err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+	return c.sign(ctx, http.MethodGet, int64(req.Msg.Id), req.Msg.Name)
+}
+
+func (c *fakeClient) sign(ctx context.Context, method string, id int64, name string) (*connect.Response[cloudv1.ArtifactURL], error) {
+	u, err := c.store.SignURL(ctx, method, Key(id, name), time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&cloudv1.ArtifactURL{Url: u, Method: method}), nil
+}
+
+func TestWorkspace(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestLocalStore(t)
+	client := &fakeClient{store: store}
+
+	// Task 1 produces report.csv and an empty marker
+	producer, err := NewWorkspace(ctx, client, nil)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(producer.OutputDir, "report.csv"), []byte("a,b\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(producer.OutputDir, "done"), nil, 0o644))
+	require.NoError(t, producer.Upload(ctx, 1, []string{"report.csv", "done"}))
+	require.NoError(t, producer.Close())
+	assert.NoDirExists(t, producer.OutputDir)
+
+	// Task 2 takes report.csv as its input
+	consumer, err := NewWorkspace(ctx, client, []Input{{Name: "input.csv", TaskID: 1, Artifact: "report.csv"}})
+	require.NoError(t, err)
+	defer consumer.Close()
+	data, err := os.ReadFile(filepath.Join(consumer.InputDir, "input.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n", string(data))
+
+	u, err := client.GetArtifactDownloadURL(ctx, connect.NewRequest(&cloudv1.GetArtifactDownloadURLRequest{Id: 1, Name: "done"}))
+	require.NoError(t, err)
+	body, err := Open(ctx, u.Msg)
+	require.NoError(t, err)
+	defer body.Close()
+	data, err = io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestWorkspaceErrors(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestLocalStore(t)
+	client := &fakeClient{store: store}
+
+	t.Run("Missing input", func(t *testing.T) {
+		_, err := NewWorkspace(ctx, client, []Input{{Name: "input.csv", TaskID: 1, Artifact: "report.csv"}})
+		assert.ErrorContains(t, err, "failed to get the URL of input artifact input.csv")
+	})
+
+	t.Run("Output not written", func(t *testing.T) {
+		w, err := NewWorkspace(ctx, client, nil)
+		require.NoError(t, err)
+		defer w.Close()
+		assert.EqualError(t, w.Upload(ctx, 1, []string{"report.csv"}), "output artifact report.csv was not written to the output directory")
+	})
+
+	t.Run("Output too large", func(t *testing.T) {
+		w, err := NewWorkspace(ctx, client, nil)
+		require.NoError(t, err)
+		defer w.Close()
+		require.NoError(t, os.WriteFile(filepath.Join(w.OutputDir, "big.bin"), make([]byte, 17), 0o644))
+		assert.ErrorContains(t, w.Upload(ctx, 1, []string{"big.bin"}), "413 Request Entity Too Large")
+	})
+}
@@ -0,0 +1,254 @@
+// Package cloudclient connects the controller and the agent to the task service.
+//
+// The connection is configured, from lowest to highest precedence, by defaults, a YAML config file,
+// environment variables and command line flags:
+//
+//	server_url: https://task.example.com   # TASK_SERVER_URL, --server-url
+//	ca_file: /etc/task/ca.crt              # TASK_CA_FILE, --ca-file
+//	cert_file: /etc/task/tls.crt           # TASK_CERT_FILE, --cert-file
+//	key_file: /etc/task/tls.key            # TASK_KEY_FILE, --key-file
+//	token_file: /var/run/secrets/token     # TASK_TOKEN_FILE, --token-file
+//	token: ...                             # TASK_TOKEN
+//	namespace: tasks                       # TASK_NAMESPACE, --namespace of the agent
+//	kubeconfig: ~/.kube/config             # --kubeconfig of the agent
+//
+// Without a kubeconfig the agent falls back to the files listed in KUBECONFIG, or ~/.kube/config.
+// The config file is read from --cloud-config or TASK_CLOUD_CONFIG.
+package cloudclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultServerURL is the URL of the task service when none is configured.
+	DefaultServerURL = "http://localhost:8080"
+	// DefaultNamespace is the namespace the agent creates Task resources in when none is configured.
+	DefaultNamespace = "default"
+	// DefaultCheckTimeout bounds the connectivity check run at startup.
+	DefaultCheckTimeout = 10 * time.Second
+	// ConfigFileEnv names the config file when no --cloud-config flag is given.
+	ConfigFileEnv = "TASK_CLOUD_CONFIG"
+)
+
+// Config configures the connection to the task service and the Kubernetes cluster of the agent.
+type Config struct {
+	// ServerURL is the base URL of the task service.
+	ServerURL string `yaml:"server_url" envconfig:"TASK_SERVER_URL"`
+	// CAFile is a PEM file of the certificate authorities the certificate of the server is verified
+	// with, instead of the system roots.
+	CAFile string `yaml:"ca_file" envconfig:"TASK_CA_FILE"`
+	// CertFile and KeyFile are the PEM files of the client certificate presented to the server.
+	CertFile string `yaml:"cert_file" envconfig:"TASK_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" envconfig:"TASK_KEY_FILE"`
+	// Token is the bearer token sent to the server. It is left out of the logged configuration.
+	Token string `yaml:"token" envconfig:"TASK_TOKEN" json:"-"`
+	// TokenFile is a file holding the bearer token. It is read for every request, so that
+	// rotated tokens, such as projected service account tokens, are picked up.
+	TokenFile string `yaml:"token_file" envconfig:"TASK_TOKEN_FILE"`
+
+	// Namespace is the namespace the agent creates Task resources in.
+	Namespace string `yaml:"namespace" envconfig:"TASK_NAMESPACE"`
+	// Kubeconfig is the kubeconfig the agent uses outside of a cluster. It is not read from KUBECONFIG,
+	// which may list several files and is read by the Kubernetes client when Kubeconfig is empty.
+	Kubeconfig string `yaml:"kubeconfig" ignored:"true"`
+}
+
+// FlagSet is implemented by the flag sets of the flag and github.com/spf13/pflag packages.
+type FlagSet interface {
+	StringVar(p *string, name string, value string, usage string)
+}
+
+// BindFlags registers the flags of the connection to the task service, which are set in c.
+// Flags that are not given leave their field empty, so that the config file and the
+// environment apply to them when c is passed to Load.
+func (c *Config) BindFlags(fs FlagSet) {
+	fs.StringVar(&c.ServerURL, "server-url", "", "URL of the task service (default "+DefaultServerURL+")")
+	fs.StringVar(&c.CAFile, "ca-file", "", "PEM file of the CAs that verify the certificate of the task service")
+	fs.StringVar(&c.CertFile, "cert-file", "", "PEM file of the client certificate presented to the task service")
+	fs.StringVar(&c.KeyFile, "key-file", "", "PEM file of the key of the client certificate")
+	fs.StringVar(&c.TokenFile, "token-file", "", "File holding the bearer token sent to the task service")
+}
+
+// Load returns the configuration read from the config file at path, or at TASK_CLOUD_CONFIG when
+// path is empty, overridden by the environment and then by the fields set in flags.
+func Load(path string, flags Config) (Config, error) {
+	cfg := Config{ServerURL: DefaultServerURL, Namespace: DefaultNamespace}
+
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	if err := envconfig.Process("", &cfg); err != nil {
+		return Config{}, fmt.Errorf("error loading environment variables: %w", err)
+	}
+	cfg.override(flags)
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// override sets the fields of c that are set in o.
+func (c *Config) override(o Config) {
+	set := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	set(&c.ServerURL, o.ServerURL)
+	set(&c.CAFile, o.CAFile)
+	set(&c.CertFile, o.CertFile)
+	set(&c.KeyFile, o.KeyFile)
+	set(&c.Token, o.Token)
+	set(&c.TokenFile, o.TokenFile)
+	set(&c.Namespace, o.Namespace)
+	set(&c.Kubeconfig, o.Kubeconfig)
+}
+
+// Validate checks that the configuration describes a usable connection.
+func (c Config) Validate() error {
+	u, err := url.Parse(c.ServerURL)
+	if err != nil {
+		return fmt.Errorf("invalid server URL %q: %w", c.ServerURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid server URL %q: must be an http:// or https:// URL", c.ServerURL)
+	}
+	if u.Scheme == "http" && (c.CAFile != "" || c.CertFile != "") {
+		return fmt.Errorf("TLS files are configured but the server URL %q is not https://", c.ServerURL)
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("the client certificate and key files must be configured together")
+	}
+	if c.Token != "" && c.TokenFile != "" {
+		return errors.New("a bearer token and a token file cannot both be configured")
+	}
+	if c.Namespace == "" {
+		return errors.New("namespace must not be empty")
+	}
+	return nil
+}
+
+// HTTPClient returns the HTTP client that connects to the task service with the configured
+// certificates and bearer token. It has no timeout, as the agent streams events from the server.
+func (c Config) HTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.CAFile != "" || c.CertFile != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if c.CAFile != "" {
+			pem, err := os.ReadFile(c.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if c.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	var rt http.RoundTripper = transport
+	if c.Token != "" || c.TokenFile != "" {
+		if _, err := c.token(); err != nil {
+			return nil, err
+		}
+		rt = &bearerTransport{config: c, next: transport}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+// NewClient returns a client of the task service built from the configuration.
+func (c Config) NewClient(opts ...connect.ClientOption) (cloudv1connect.TaskManagementServiceClient, error) {
+	httpClient, err := c.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return cloudv1connect.NewTaskManagementServiceClient(httpClient, c.ServerURL, opts...), nil
+}
+
+// token returns the configured bearer token, reading it from the token file when one is configured.
+func (c Config) token() (string, error) {
+	if c.TokenFile == "" {
+		return c.Token, nil
+	}
+	data, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", c.TokenFile)
+	}
+	return token, nil
+}
+
+// bearerTransport sets the Authorization header of the requests to the configured bearer token.
+type bearerTransport struct {
+	config Config
+	next   http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.config.token()
+	if err != nil {
+		return nil, err
+	}
+	// A RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.next.RoundTrip(req)
+}
+
+// Check sends a heartbeat to the task service within DefaultCheckTimeout, so that a wrong URL,
+// certificate or token is reported at startup rather than once the first task arrives.
+func Check(ctx context.Context, client cloudv1connect.TaskManagementServiceClient, serverURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultCheckTimeout)
+	defer cancel()
+
+	_, err := client.Heartbeat(ctx, connect.NewRequest(&cloudv1.HeartbeatRequest{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}))
+	if err == nil {
+		return nil
+	}
+	switch connect.CodeOf(err) {
+	case connect.CodeUnauthenticated, connect.CodePermissionDenied:
+		return fmt.Errorf("task service at %s rejected the credentials: %w", serverURL, err)
+	}
+	return fmt.Errorf("failed to reach the task service at %s: %w", serverURL, err)
+}
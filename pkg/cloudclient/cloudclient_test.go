@@ -0,0 +1,175 @@
+package cloudclient
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv(ConfigFileEnv, "")
+		cfg, err := Load("", Config{})
+		require.NoError(t, err)
+		assert.Equal(t, Config{ServerURL: DefaultServerURL, Namespace: DefaultNamespace}, cfg)
+	})
+
+	t.Run("flags override the environment, which overrides the file", func(t *testing.T) {
+		path := writeFile(t, "cloud.yaml", `
+server_url: https://file.example.com
+namespace: file
+kubeconfig: /file/kubeconfig
+token: file-token
+`)
+		t.Setenv(ConfigFileEnv, path)
+		t.Setenv("TASK_NAMESPACE", "env")
+		t.Setenv("KUBECONFIG", "/env/kubeconfig")
+
+		cfg, err := Load("", Config{Namespace: "flag"})
+		require.NoError(t, err)
+		assert.Equal(t, Config{
+			ServerURL:  "https://file.example.com",
+			Namespace:  "flag",
+			Kubeconfig: "/file/kubeconfig",
+			Token:      "file-token",
+		}, cfg)
+	})
+
+	t.Run("KUBECONFIG is left to the Kubernetes client", func(t *testing.T) {
+		t.Setenv(ConfigFileEnv, "")
+		t.Setenv("KUBECONFIG", "/env/kubeconfig:/env/other")
+
+		cfg, err := Load("", Config{})
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Kubeconfig)
+
+		cfg, err = Load("", Config{Kubeconfig: "/flag/kubeconfig"})
+		require.NoError(t, err)
+		assert.Equal(t, "/flag/kubeconfig", cfg.Kubeconfig)
+	})
+
+	t.Run("unknown field in file", func(t *testing.T) {
+		path := writeFile(t, "cloud.yaml", "server: https://task.example.com\n")
+		_, err := Load(path, Config{})
+		assert.ErrorContains(t, err, "failed to parse config file")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), Config{})
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "valid", config: Config{ServerURL: "https://task.example.com", CAFile: "ca.crt", CertFile: "tls.crt", KeyFile: "tls.key", Namespace: "tasks"}},
+		{name: "not a URL", config: Config{ServerURL: "localhost:8080", Namespace: "tasks"}, wantErr: "must be an http:// or https:// URL"},
+		{name: "TLS without https", config: Config{ServerURL: "http://task.example.com", CAFile: "ca.crt", Namespace: "tasks"}, wantErr: "is not https://"},
+		{name: "cert without key", config: Config{ServerURL: "https://task.example.com", CertFile: "tls.crt", Namespace: "tasks"}, wantErr: "must be configured together"},
+		{name: "token and token file", config: Config{ServerURL: "https://task.example.com", Token: "t", TokenFile: "token", Namespace: "tasks"}, wantErr: "cannot both be configured"},
+		{name: "no namespace", config: Config{ServerURL: "https://task.example.com"}, wantErr: "namespace must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// heartbeatServer accepts the heartbeats that carry the token, or all heartbeats when token is empty.
+type heartbeatServer struct {
+	cloudv1connect.UnimplementedTaskManagementServiceHandler
+	token string
+}
+
+func (s *heartbeatServer) Heartbeat(_ context.Context, req *connect.Request[cloudv1.HeartbeatRequest]) (*connect.Response[cloudv1.HeartbeatResponse], error) {
+	if s.token != "" && req.Header().Get("Authorization") != "Bearer "+s.token {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("invalid token"))
+	}
+	return connect.NewResponse(&cloudv1.HeartbeatResponse{}), nil
+}
+
+func newTestServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle(cloudv1connect.NewTaskManagementServiceHandler(&heartbeatServer{token: token}))
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// caFile writes the certificate of the test server to a PEM file.
+func caFile(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	return writeFile(t, "ca.crt", string(cert))
+}
+
+func TestCheck(t *testing.T) {
+	srv := newTestServer(t, "s3cret")
+	ca := caFile(t, srv)
+	ctx := context.Background()
+
+	t.Run("token file", func(t *testing.T) {
+		cfg := Config{ServerURL: srv.URL, CAFile: ca, TokenFile: writeFile(t, "token", "s3cret\n"), Namespace: DefaultNamespace}
+		client, err := cfg.NewClient()
+		require.NoError(t, err)
+		assert.NoError(t, Check(ctx, client, cfg.ServerURL))
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		cfg := Config{ServerURL: srv.URL, CAFile: ca, Token: "guess", Namespace: DefaultNamespace}
+		client, err := cfg.NewClient()
+		require.NoError(t, err)
+		assert.ErrorContains(t, Check(ctx, client, cfg.ServerURL), "rejected the credentials")
+	})
+
+	t.Run("unknown CA", func(t *testing.T) {
+		cfg := Config{ServerURL: srv.URL, Token: "s3cret", Namespace: DefaultNamespace}
+		client, err := cfg.NewClient()
+		require.NoError(t, err)
+		assert.ErrorContains(t, Check(ctx, client, cfg.ServerURL), "failed to reach the task service")
+	})
+}
+
+func TestHTTPClient(t *testing.T) {
+	t.Run("empty token file", func(t *testing.T) {
+		cfg := Config{ServerURL: DefaultServerURL, TokenFile: writeFile(t, "token", "\n")}
+		_, err := cfg.HTTPClient()
+		assert.ErrorContains(t, err, "is empty")
+	})
+
+	t.Run("CA file without certificates", func(t *testing.T) {
+		cfg := Config{ServerURL: "https://task.example.com", CAFile: writeFile(t, "ca.crt", "not a certificate")}
+		_, err := cfg.HTTPClient()
+		assert.ErrorContains(t, err, "no certificates found")
+	})
+}
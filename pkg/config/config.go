@@ -1,6 +1,9 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Config holds the application configuration
 type Config struct {
@@ -8,10 +11,20 @@ type Config struct {
 	WorkerCount int    `envconfig:"WORKER_COUNT" default:"100"`
 	Database    DatabaseConfig
 	OAuth2      OAuth2Config
+	Artifacts   ArtifactConfig
+	// SecretKey is the base64 encoded 32 byte key the secrets kept by the server are
+	// encrypted with. The secret RPCs fail while it is not set.
+	SecretKey string `envconfig:"SECRET_KEY" json:"-"`
+	// WorkerToken is the bearer token workers present to resolve the values of secrets and
+	// upload artifacts. ResolveSecrets and GetArtifactUploadURL reject every call while it is not set.
+	WorkerToken string `envconfig:"WORKER_TOKEN" json:"-"`
 }
 
 // DatabaseConfig holds the database connection configuration
 type DatabaseConfig struct {
+	// DSN is a full database URL. When set it takes precedence over the individual
+	// connection settings, and its scheme selects the database: postgres, mysql or sqlite.
+	DSN          string `envconfig:"DB_DSN"`
 	Username     string `envconfig:"DB_USERNAME"`
 	Password     string `envconfig:"DB_PASSWORD"`
 	Host         string `envconfig:"DB_HOST"`
@@ -19,6 +32,10 @@ type DatabaseConfig struct {
 	Database     string `envconfig:"DB_DATABASE"`
 	SSLMode      string `envconfig:"DB_SSL_MODE" default:"require"`
 	PoolMaxConns int    `envconfig:"DB_POOL_MAX_CONNS" default:"1"`
+	// ReplicaDSN optionally points at a read replica used for listings, status counts and history.
+	ReplicaDSN string `envconfig:"DB_REPLICA_DSN"`
+	// ReplicaMaxStaleness is the replication lag up to which reads are served by the replica.
+	ReplicaMaxStaleness time.Duration `envconfig:"DB_REPLICA_MAX_STALENESS" default:"5s"`
 }
 
 // OAuth2Config holds the OAuth2 configuration
@@ -29,6 +46,33 @@ type OAuth2Config struct {
 	ClientSecret string `envconfig:"OAUTH2_CLIENT_SECRET"`
 }
 
+// ArtifactConfig holds the artifact store configuration
+type ArtifactConfig struct {
+	// Store selects the backend that keeps artifacts: local or s3.
+	Store string `envconfig:"ARTIFACT_STORE" default:"local"`
+	// URLExpiry is how long the upload and download URLs handed out by the server stay valid.
+	URLExpiry time.Duration `envconfig:"ARTIFACT_URL_EXPIRY" default:"15m"`
+
+	// LocalDir is the directory the local backend keeps artifacts in.
+	LocalDir string `envconfig:"ARTIFACT_LOCAL_DIR" default:"artifacts"`
+	// PublicURL is the base URL clients reach the server at, which the URLs of the local backend point to.
+	PublicURL string `envconfig:"ARTIFACT_PUBLIC_URL" default:"http://localhost:8080"`
+	// SigningKey signs the URLs of the local backend. A random key is used when empty,
+	// so that URLs handed out before a restart stop working. Like the S3 secret, it is
+	// left out of the logged configuration.
+	SigningKey string `envconfig:"ARTIFACT_SIGNING_KEY" json:"-"`
+	// MaxBytes bounds the size of an artifact uploaded to the local backend.
+	MaxBytes int64 `envconfig:"ARTIFACT_MAX_BYTES" default:"1073741824"`
+
+	// S3Endpoint is the host, and optional port, of an S3-compatible service such as MinIO.
+	S3Endpoint        string `envconfig:"ARTIFACT_S3_ENDPOINT" default:"s3.amazonaws.com"`
+	S3Bucket          string `envconfig:"ARTIFACT_S3_BUCKET"`
+	S3Region          string `envconfig:"ARTIFACT_S3_REGION" default:"us-east-1"`
+	S3AccessKeyID     string `envconfig:"ARTIFACT_S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `envconfig:"ARTIFACT_S3_SECRET_ACCESS_KEY" json:"-"`
+	S3UseSSL          bool   `envconfig:"ARTIFACT_S3_USE_SSL" default:"true"`
+}
+
 // ToMigrationUri returns a string for the migration package with the correct prefix
 func (d DatabaseConfig) ToMigrationUri() string {
 	return fmt.Sprintf("pgx5://%s:%s@%s:%s/%s?sslmode=%s",
@@ -41,8 +85,12 @@ func (d DatabaseConfig) ToMigrationUri() string {
 	)
 }
 
-// ToDbConnectionUri returns a connection URI for the pgx package
+// ToDbConnectionUri returns the database connection URI, built as a PostgreSQL URI
+// from the individual settings unless a DSN is configured
 func (d DatabaseConfig) ToDbConnectionUri() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
 	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		d.Username,
 		d.Password,
@@ -20,14 +20,27 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TaskManagementService_CreateTask_FullMethodName       = "/cloud.v1.TaskManagementService/CreateTask"
-	TaskManagementService_GetTask_FullMethodName          = "/cloud.v1.TaskManagementService/GetTask"
-	TaskManagementService_ListTasks_FullMethodName        = "/cloud.v1.TaskManagementService/ListTasks"
-	TaskManagementService_GetTaskHistory_FullMethodName   = "/cloud.v1.TaskManagementService/GetTaskHistory"
-	TaskManagementService_UpdateTaskStatus_FullMethodName = "/cloud.v1.TaskManagementService/UpdateTaskStatus"
-	TaskManagementService_GetStatus_FullMethodName        = "/cloud.v1.TaskManagementService/GetStatus"
-	TaskManagementService_Heartbeat_FullMethodName        = "/cloud.v1.TaskManagementService/Heartbeat"
-	TaskManagementService_PullEvents_FullMethodName       = "/cloud.v1.TaskManagementService/PullEvents"
+	TaskManagementService_CreateTask_FullMethodName             = "/cloud.v1.TaskManagementService/CreateTask"
+	TaskManagementService_GetTask_FullMethodName                = "/cloud.v1.TaskManagementService/GetTask"
+	TaskManagementService_ListTasks_FullMethodName              = "/cloud.v1.TaskManagementService/ListTasks"
+	TaskManagementService_GetTaskHistory_FullMethodName         = "/cloud.v1.TaskManagementService/GetTaskHistory"
+	TaskManagementService_GetTaskResult_FullMethodName          = "/cloud.v1.TaskManagementService/GetTaskResult"
+	TaskManagementService_ListArtifacts_FullMethodName          = "/cloud.v1.TaskManagementService/ListArtifacts"
+	TaskManagementService_GetArtifactUploadURL_FullMethodName   = "/cloud.v1.TaskManagementService/GetArtifactUploadURL"
+	TaskManagementService_GetArtifactDownloadURL_FullMethodName = "/cloud.v1.TaskManagementService/GetArtifactDownloadURL"
+	TaskManagementService_AppendTaskLogs_FullMethodName         = "/cloud.v1.TaskManagementService/AppendTaskLogs"
+	TaskManagementService_StreamTaskLogs_FullMethodName         = "/cloud.v1.TaskManagementService/StreamTaskLogs"
+	TaskManagementService_ReportProgress_FullMethodName         = "/cloud.v1.TaskManagementService/ReportProgress"
+	TaskManagementService_PutSecret_FullMethodName              = "/cloud.v1.TaskManagementService/PutSecret"
+	TaskManagementService_ListSecrets_FullMethodName            = "/cloud.v1.TaskManagementService/ListSecrets"
+	TaskManagementService_DeleteSecret_FullMethodName           = "/cloud.v1.TaskManagementService/DeleteSecret"
+	TaskManagementService_ResolveSecrets_FullMethodName         = "/cloud.v1.TaskManagementService/ResolveSecrets"
+	TaskManagementService_UpdateTaskStatus_FullMethodName       = "/cloud.v1.TaskManagementService/UpdateTaskStatus"
+	TaskManagementService_GetStatus_FullMethodName              = "/cloud.v1.TaskManagementService/GetStatus"
+	TaskManagementService_ListTaskTypes_FullMethodName          = "/cloud.v1.TaskManagementService/ListTaskTypes"
+	TaskManagementService_DescribeTaskType_FullMethodName       = "/cloud.v1.TaskManagementService/DescribeTaskType"
+	TaskManagementService_Heartbeat_FullMethodName              = "/cloud.v1.TaskManagementService/Heartbeat"
+	TaskManagementService_PullEvents_FullMethodName             = "/cloud.v1.TaskManagementService/PullEvents"
 )
 
 // TaskManagementServiceClient is the client API for TaskManagementService service.
@@ -48,12 +61,51 @@ type TaskManagementServiceClient interface {
 	// Retrieves the execution history of the specified task.
 	// Returns a GetTaskHistoryResponse containing a list of historical status updates.
 	GetTaskHistory(ctx context.Context, in *GetTaskHistoryRequest, opts ...grpc.CallOption) (*GetTaskHistoryResponse, error)
+	// Retrieves the result the specified task reported when it finished.
+	// Returns a GetTaskResultResponse containing the outputs and JSON data of the task.
+	GetTaskResult(ctx context.Context, in *GetTaskResultRequest, opts ...grpc.CallOption) (*GetTaskResultResponse, error)
+	// Lists the artifacts the specified task declared and stored.
+	// Returns a ListArtifactsResponse containing the stored output artifacts and the declared ones.
+	ListArtifacts(ctx context.Context, in *ListArtifactsRequest, opts ...grpc.CallOption) (*ListArtifactsResponse, error)
+	// Hands out a URL that uploads an output artifact of the specified task.
+	// Returns an ArtifactURL the worker sends the artifact to before it expires.
+	GetArtifactUploadURL(ctx context.Context, in *GetArtifactUploadURLRequest, opts ...grpc.CallOption) (*ArtifactURL, error)
+	// Hands out a URL that downloads a stored output artifact of the specified task.
+	// Returns an ArtifactURL the artifact can be fetched from before it expires.
+	GetArtifactDownloadURL(ctx context.Context, in *GetArtifactDownloadURLRequest, opts ...grpc.CallOption) (*ArtifactURL, error)
+	// Appends log lines logged by a worker while running the specified task.
+	// Returns an empty response once the lines are stored.
+	AppendTaskLogs(ctx context.Context, in *AppendTaskLogsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Streams the log lines of the specified task, oldest first.
+	// With follow set, the stream stays open for new lines until the task has finished.
+	StreamTaskLogs(ctx context.Context, in *StreamTaskLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamTaskLogsResponse], error)
+	// Reports the progress of the specified task, for the worker running it.
+	// Returns a ReportProgressResponse telling whether the report was recorded or throttled.
+	ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error)
+	// Sets the value of a secret, creating the secret when it does not exist.
+	// Returns the Secret without its value.
+	PutSecret(ctx context.Context, in *PutSecretRequest, opts ...grpc.CallOption) (*Secret, error)
+	// Lists the secrets kept by the task service.
+	// Returns a ListSecretsResponse containing the secrets without their values.
+	ListSecrets(ctx context.Context, in *ListSecretsRequest, opts ...grpc.CallOption) (*ListSecretsResponse, error)
+	// Deletes a secret.
+	// Returns an empty response once the secret is deleted.
+	DeleteSecret(ctx context.Context, in *DeleteSecretRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Resolves secrets the specified task refers to, for the worker running it.
+	// Returns a ResolveSecretsResponse containing the values of the secrets.
+	ResolveSecrets(ctx context.Context, in *ResolveSecretsRequest, opts ...grpc.CallOption) (*ResolveSecretsResponse, error)
 	// Updates the status of the specified task.
 	// Returns an empty response to confirm the update was processed.
 	UpdateTaskStatus(ctx context.Context, in *UpdateTaskStatusRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Retrieves the count of tasks for each status.
 	// Returns a GetStatusResponse containing a map of status counts.
 	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// Lists the task types the server accepts.
+	// Returns a ListTaskTypesResponse containing the sorted task type names.
+	ListTaskTypes(ctx context.Context, in *ListTaskTypesRequest, opts ...grpc.CallOption) (*ListTaskTypesResponse, error)
+	// Describes the parameters a task type accepts.
+	// Returns a DescribeTaskTypeResponse containing the parameter schema of the task type.
+	DescribeTaskType(ctx context.Context, in *DescribeTaskTypeRequest, opts ...grpc.CallOption) (*DescribeTaskTypeResponse, error)
 	// Sends a heartbeat signal to indicate the service is alive.
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
 	// Pulls events related to task execution.
@@ -108,6 +160,125 @@ func (c *taskManagementServiceClient) GetTaskHistory(ctx context.Context, in *Ge
 	return out, nil
 }
 
+func (c *taskManagementServiceClient) GetTaskResult(ctx context.Context, in *GetTaskResultRequest, opts ...grpc.CallOption) (*GetTaskResultResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTaskResultResponse)
+	err := c.cc.Invoke(ctx, TaskManagementService_GetTaskResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) ListArtifacts(ctx context.Context, in *ListArtifactsRequest, opts ...grpc.CallOption) (*ListArtifactsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListArtifactsResponse)
+	err := c.cc.Invoke(ctx, TaskManagementService_ListArtifacts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) GetArtifactUploadURL(ctx context.Context, in *GetArtifactUploadURLRequest, opts ...grpc.CallOption) (*ArtifactURL, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ArtifactURL)
+	err := c.cc.Invoke(ctx, TaskManagementService_GetArtifactUploadURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) GetArtifactDownloadURL(ctx context.Context, in *GetArtifactDownloadURLRequest, opts ...grpc.CallOption) (*ArtifactURL, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ArtifactURL)
+	err := c.cc.Invoke(ctx, TaskManagementService_GetArtifactDownloadURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) AppendTaskLogs(ctx context.Context, in *AppendTaskLogsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TaskManagementService_AppendTaskLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) StreamTaskLogs(ctx context.Context, in *StreamTaskLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamTaskLogsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TaskManagementService_ServiceDesc.Streams[0], TaskManagementService_StreamTaskLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTaskLogsRequest, StreamTaskLogsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TaskManagementService_StreamTaskLogsClient = grpc.ServerStreamingClient[StreamTaskLogsResponse]
+
+func (c *taskManagementServiceClient) ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportProgressResponse)
+	err := c.cc.Invoke(ctx, TaskManagementService_ReportProgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) PutSecret(ctx context.Context, in *PutSecretRequest, opts ...grpc.CallOption) (*Secret, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Secret)
+	err := c.cc.Invoke(ctx, TaskManagementService_PutSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) ListSecrets(ctx context.Context, in *ListSecretsRequest, opts ...grpc.CallOption) (*ListSecretsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSecretsResponse)
+	err := c.cc.Invoke(ctx, TaskManagementService_ListSecrets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) DeleteSecret(ctx context.Context, in *DeleteSecretRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TaskManagementService_DeleteSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) ResolveSecrets(ctx context.Context, in *ResolveSecretsRequest, opts ...grpc.CallOption) (*ResolveSecretsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResolveSecretsResponse)
+	err := c.cc.Invoke(ctx, TaskManagementService_ResolveSecrets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *taskManagementServiceClient) UpdateTaskStatus(ctx context.Context, in *UpdateTaskStatusRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -128,6 +299,26 @@ func (c *taskManagementServiceClient) GetStatus(ctx context.Context, in *GetStat
 	return out, nil
 }
 
+func (c *taskManagementServiceClient) ListTaskTypes(ctx context.Context, in *ListTaskTypesRequest, opts ...grpc.CallOption) (*ListTaskTypesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTaskTypesResponse)
+	err := c.cc.Invoke(ctx, TaskManagementService_ListTaskTypes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskManagementServiceClient) DescribeTaskType(ctx context.Context, in *DescribeTaskTypeRequest, opts ...grpc.CallOption) (*DescribeTaskTypeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DescribeTaskTypeResponse)
+	err := c.cc.Invoke(ctx, TaskManagementService_DescribeTaskType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *taskManagementServiceClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(HeartbeatResponse)
@@ -140,7 +331,7 @@ func (c *taskManagementServiceClient) Heartbeat(ctx context.Context, in *Heartbe
 
 func (c *taskManagementServiceClient) PullEvents(ctx context.Context, in *PullEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PullEventsResponse], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &TaskManagementService_ServiceDesc.Streams[0], TaskManagementService_PullEvents_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &TaskManagementService_ServiceDesc.Streams[1], TaskManagementService_PullEvents_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -175,12 +366,51 @@ type TaskManagementServiceServer interface {
 	// Retrieves the execution history of the specified task.
 	// Returns a GetTaskHistoryResponse containing a list of historical status updates.
 	GetTaskHistory(context.Context, *GetTaskHistoryRequest) (*GetTaskHistoryResponse, error)
+	// Retrieves the result the specified task reported when it finished.
+	// Returns a GetTaskResultResponse containing the outputs and JSON data of the task.
+	GetTaskResult(context.Context, *GetTaskResultRequest) (*GetTaskResultResponse, error)
+	// Lists the artifacts the specified task declared and stored.
+	// Returns a ListArtifactsResponse containing the stored output artifacts and the declared ones.
+	ListArtifacts(context.Context, *ListArtifactsRequest) (*ListArtifactsResponse, error)
+	// Hands out a URL that uploads an output artifact of the specified task.
+	// Returns an ArtifactURL the worker sends the artifact to before it expires.
+	GetArtifactUploadURL(context.Context, *GetArtifactUploadURLRequest) (*ArtifactURL, error)
+	// Hands out a URL that downloads a stored output artifact of the specified task.
+	// Returns an ArtifactURL the artifact can be fetched from before it expires.
+	GetArtifactDownloadURL(context.Context, *GetArtifactDownloadURLRequest) (*ArtifactURL, error)
+	// Appends log lines logged by a worker while running the specified task.
+	// Returns an empty response once the lines are stored.
+	AppendTaskLogs(context.Context, *AppendTaskLogsRequest) (*emptypb.Empty, error)
+	// Streams the log lines of the specified task, oldest first.
+	// With follow set, the stream stays open for new lines until the task has finished.
+	StreamTaskLogs(*StreamTaskLogsRequest, grpc.ServerStreamingServer[StreamTaskLogsResponse]) error
+	// Reports the progress of the specified task, for the worker running it.
+	// Returns a ReportProgressResponse telling whether the report was recorded or throttled.
+	ReportProgress(context.Context, *ReportProgressRequest) (*ReportProgressResponse, error)
+	// Sets the value of a secret, creating the secret when it does not exist.
+	// Returns the Secret without its value.
+	PutSecret(context.Context, *PutSecretRequest) (*Secret, error)
+	// Lists the secrets kept by the task service.
+	// Returns a ListSecretsResponse containing the secrets without their values.
+	ListSecrets(context.Context, *ListSecretsRequest) (*ListSecretsResponse, error)
+	// Deletes a secret.
+	// Returns an empty response once the secret is deleted.
+	DeleteSecret(context.Context, *DeleteSecretRequest) (*emptypb.Empty, error)
+	// Resolves secrets the specified task refers to, for the worker running it.
+	// Returns a ResolveSecretsResponse containing the values of the secrets.
+	ResolveSecrets(context.Context, *ResolveSecretsRequest) (*ResolveSecretsResponse, error)
 	// Updates the status of the specified task.
 	// Returns an empty response to confirm the update was processed.
 	UpdateTaskStatus(context.Context, *UpdateTaskStatusRequest) (*emptypb.Empty, error)
 	// Retrieves the count of tasks for each status.
 	// Returns a GetStatusResponse containing a map of status counts.
 	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// Lists the task types the server accepts.
+	// Returns a ListTaskTypesResponse containing the sorted task type names.
+	ListTaskTypes(context.Context, *ListTaskTypesRequest) (*ListTaskTypesResponse, error)
+	// Describes the parameters a task type accepts.
+	// Returns a DescribeTaskTypeResponse containing the parameter schema of the task type.
+	DescribeTaskType(context.Context, *DescribeTaskTypeRequest) (*DescribeTaskTypeResponse, error)
 	// Sends a heartbeat signal to indicate the service is alive.
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
 	// Pulls events related to task execution.
@@ -207,12 +437,51 @@ func (UnimplementedTaskManagementServiceServer) ListTasks(context.Context, *Task
 func (UnimplementedTaskManagementServiceServer) GetTaskHistory(context.Context, *GetTaskHistoryRequest) (*GetTaskHistoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTaskHistory not implemented")
 }
+func (UnimplementedTaskManagementServiceServer) GetTaskResult(context.Context, *GetTaskResultRequest) (*GetTaskResultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTaskResult not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) ListArtifacts(context.Context, *ListArtifactsRequest) (*ListArtifactsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListArtifacts not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) GetArtifactUploadURL(context.Context, *GetArtifactUploadURLRequest) (*ArtifactURL, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArtifactUploadURL not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) GetArtifactDownloadURL(context.Context, *GetArtifactDownloadURLRequest) (*ArtifactURL, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArtifactDownloadURL not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) AppendTaskLogs(context.Context, *AppendTaskLogsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendTaskLogs not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) StreamTaskLogs(*StreamTaskLogsRequest, grpc.ServerStreamingServer[StreamTaskLogsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTaskLogs not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) ReportProgress(context.Context, *ReportProgressRequest) (*ReportProgressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportProgress not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) PutSecret(context.Context, *PutSecretRequest) (*Secret, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutSecret not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) ListSecrets(context.Context, *ListSecretsRequest) (*ListSecretsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSecrets not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) DeleteSecret(context.Context, *DeleteSecretRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSecret not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) ResolveSecrets(context.Context, *ResolveSecretsRequest) (*ResolveSecretsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveSecrets not implemented")
+}
 func (UnimplementedTaskManagementServiceServer) UpdateTaskStatus(context.Context, *UpdateTaskStatusRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateTaskStatus not implemented")
 }
 func (UnimplementedTaskManagementServiceServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
 }
+func (UnimplementedTaskManagementServiceServer) ListTaskTypes(context.Context, *ListTaskTypesRequest) (*ListTaskTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTaskTypes not implemented")
+}
+func (UnimplementedTaskManagementServiceServer) DescribeTaskType(context.Context, *DescribeTaskTypeRequest) (*DescribeTaskTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeTaskType not implemented")
+}
 func (UnimplementedTaskManagementServiceServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
 }
@@ -312,6 +581,197 @@ func _TaskManagementService_GetTaskHistory_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TaskManagementService_GetTaskResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).GetTaskResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_GetTaskResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).GetTaskResult(ctx, req.(*GetTaskResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_ListArtifacts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArtifactsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).ListArtifacts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_ListArtifacts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).ListArtifacts(ctx, req.(*ListArtifactsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_GetArtifactUploadURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArtifactUploadURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).GetArtifactUploadURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_GetArtifactUploadURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).GetArtifactUploadURL(ctx, req.(*GetArtifactUploadURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_GetArtifactDownloadURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArtifactDownloadURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).GetArtifactDownloadURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_GetArtifactDownloadURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).GetArtifactDownloadURL(ctx, req.(*GetArtifactDownloadURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_AppendTaskLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendTaskLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).AppendTaskLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_AppendTaskLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).AppendTaskLogs(ctx, req.(*AppendTaskLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_StreamTaskLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTaskLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TaskManagementServiceServer).StreamTaskLogs(m, &grpc.GenericServerStream[StreamTaskLogsRequest, StreamTaskLogsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TaskManagementService_StreamTaskLogsServer = grpc.ServerStreamingServer[StreamTaskLogsResponse]
+
+func _TaskManagementService_ReportProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportProgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).ReportProgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_ReportProgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).ReportProgress(ctx, req.(*ReportProgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_PutSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).PutSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_PutSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).PutSecret(ctx, req.(*PutSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_ListSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSecretsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).ListSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_ListSecrets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).ListSecrets(ctx, req.(*ListSecretsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_DeleteSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).DeleteSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_DeleteSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).DeleteSecret(ctx, req.(*DeleteSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_ResolveSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveSecretsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).ResolveSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_ResolveSecrets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).ResolveSecrets(ctx, req.(*ResolveSecretsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TaskManagementService_UpdateTaskStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateTaskStatusRequest)
 	if err := dec(in); err != nil {
@@ -348,6 +808,42 @@ func _TaskManagementService_GetStatus_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TaskManagementService_ListTaskTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTaskTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).ListTaskTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_ListTaskTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).ListTaskTypes(ctx, req.(*ListTaskTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskManagementService_DescribeTaskType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeTaskTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskManagementServiceServer).DescribeTaskType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskManagementService_DescribeTaskType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskManagementServiceServer).DescribeTaskType(ctx, req.(*DescribeTaskTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TaskManagementService_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(HeartbeatRequest)
 	if err := dec(in); err != nil {
@@ -400,6 +896,46 @@ var TaskManagementService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTaskHistory",
 			Handler:    _TaskManagementService_GetTaskHistory_Handler,
 		},
+		{
+			MethodName: "GetTaskResult",
+			Handler:    _TaskManagementService_GetTaskResult_Handler,
+		},
+		{
+			MethodName: "ListArtifacts",
+			Handler:    _TaskManagementService_ListArtifacts_Handler,
+		},
+		{
+			MethodName: "GetArtifactUploadURL",
+			Handler:    _TaskManagementService_GetArtifactUploadURL_Handler,
+		},
+		{
+			MethodName: "GetArtifactDownloadURL",
+			Handler:    _TaskManagementService_GetArtifactDownloadURL_Handler,
+		},
+		{
+			MethodName: "AppendTaskLogs",
+			Handler:    _TaskManagementService_AppendTaskLogs_Handler,
+		},
+		{
+			MethodName: "ReportProgress",
+			Handler:    _TaskManagementService_ReportProgress_Handler,
+		},
+		{
+			MethodName: "PutSecret",
+			Handler:    _TaskManagementService_PutSecret_Handler,
+		},
+		{
+			MethodName: "ListSecrets",
+			Handler:    _TaskManagementService_ListSecrets_Handler,
+		},
+		{
+			MethodName: "DeleteSecret",
+			Handler:    _TaskManagementService_DeleteSecret_Handler,
+		},
+		{
+			MethodName: "ResolveSecrets",
+			Handler:    _TaskManagementService_ResolveSecrets_Handler,
+		},
 		{
 			MethodName: "UpdateTaskStatus",
 			Handler:    _TaskManagementService_UpdateTaskStatus_Handler,
@@ -408,12 +944,25 @@ var TaskManagementService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetStatus",
 			Handler:    _TaskManagementService_GetStatus_Handler,
 		},
+		{
+			MethodName: "ListTaskTypes",
+			Handler:    _TaskManagementService_ListTaskTypes_Handler,
+		},
+		{
+			MethodName: "DescribeTaskType",
+			Handler:    _TaskManagementService_DescribeTaskType_Handler,
+		},
 		{
 			MethodName: "Heartbeat",
 			Handler:    _TaskManagementService_Heartbeat_Handler,
 		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTaskLogs",
+			Handler:       _TaskManagementService_StreamTaskLogs_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "PullEvents",
 			Handler:       _TaskManagementService_PullEvents_Handler,
@@ -19,7 +19,7 @@ import (
 // generated with a version of connect newer than the one compiled into your binary. You can fix the
 // problem by either regenerating this code with an older version of connect or updating the connect
 // version compiled into your binary.
-const _ = connect.IsAtLeastVersion0_1_0
+const _ = connect.IsAtLeastVersion1_13_0
 
 const (
 	// TaskManagementServiceName is the fully-qualified name of the TaskManagementService service.
@@ -46,12 +46,51 @@ const (
 	// TaskManagementServiceGetTaskHistoryProcedure is the fully-qualified name of the
 	// TaskManagementService's GetTaskHistory RPC.
 	TaskManagementServiceGetTaskHistoryProcedure = "/cloud.v1.TaskManagementService/GetTaskHistory"
+	// TaskManagementServiceGetTaskResultProcedure is the fully-qualified name of the
+	// TaskManagementService's GetTaskResult RPC.
+	TaskManagementServiceGetTaskResultProcedure = "/cloud.v1.TaskManagementService/GetTaskResult"
+	// TaskManagementServiceListArtifactsProcedure is the fully-qualified name of the
+	// TaskManagementService's ListArtifacts RPC.
+	TaskManagementServiceListArtifactsProcedure = "/cloud.v1.TaskManagementService/ListArtifacts"
+	// TaskManagementServiceGetArtifactUploadURLProcedure is the fully-qualified name of the
+	// TaskManagementService's GetArtifactUploadURL RPC.
+	TaskManagementServiceGetArtifactUploadURLProcedure = "/cloud.v1.TaskManagementService/GetArtifactUploadURL"
+	// TaskManagementServiceGetArtifactDownloadURLProcedure is the fully-qualified name of the
+	// TaskManagementService's GetArtifactDownloadURL RPC.
+	TaskManagementServiceGetArtifactDownloadURLProcedure = "/cloud.v1.TaskManagementService/GetArtifactDownloadURL"
+	// TaskManagementServiceAppendTaskLogsProcedure is the fully-qualified name of the
+	// TaskManagementService's AppendTaskLogs RPC.
+	TaskManagementServiceAppendTaskLogsProcedure = "/cloud.v1.TaskManagementService/AppendTaskLogs"
+	// TaskManagementServiceStreamTaskLogsProcedure is the fully-qualified name of the
+	// TaskManagementService's StreamTaskLogs RPC.
+	TaskManagementServiceStreamTaskLogsProcedure = "/cloud.v1.TaskManagementService/StreamTaskLogs"
+	// TaskManagementServiceReportProgressProcedure is the fully-qualified name of the
+	// TaskManagementService's ReportProgress RPC.
+	TaskManagementServiceReportProgressProcedure = "/cloud.v1.TaskManagementService/ReportProgress"
+	// TaskManagementServicePutSecretProcedure is the fully-qualified name of the
+	// TaskManagementService's PutSecret RPC.
+	TaskManagementServicePutSecretProcedure = "/cloud.v1.TaskManagementService/PutSecret"
+	// TaskManagementServiceListSecretsProcedure is the fully-qualified name of the
+	// TaskManagementService's ListSecrets RPC.
+	TaskManagementServiceListSecretsProcedure = "/cloud.v1.TaskManagementService/ListSecrets"
+	// TaskManagementServiceDeleteSecretProcedure is the fully-qualified name of the
+	// TaskManagementService's DeleteSecret RPC.
+	TaskManagementServiceDeleteSecretProcedure = "/cloud.v1.TaskManagementService/DeleteSecret"
+	// TaskManagementServiceResolveSecretsProcedure is the fully-qualified name of the
+	// TaskManagementService's ResolveSecrets RPC.
+	TaskManagementServiceResolveSecretsProcedure = "/cloud.v1.TaskManagementService/ResolveSecrets"
 	// TaskManagementServiceUpdateTaskStatusProcedure is the fully-qualified name of the
 	// TaskManagementService's UpdateTaskStatus RPC.
 	TaskManagementServiceUpdateTaskStatusProcedure = "/cloud.v1.TaskManagementService/UpdateTaskStatus"
 	// TaskManagementServiceGetStatusProcedure is the fully-qualified name of the
 	// TaskManagementService's GetStatus RPC.
 	TaskManagementServiceGetStatusProcedure = "/cloud.v1.TaskManagementService/GetStatus"
+	// TaskManagementServiceListTaskTypesProcedure is the fully-qualified name of the
+	// TaskManagementService's ListTaskTypes RPC.
+	TaskManagementServiceListTaskTypesProcedure = "/cloud.v1.TaskManagementService/ListTaskTypes"
+	// TaskManagementServiceDescribeTaskTypeProcedure is the fully-qualified name of the
+	// TaskManagementService's DescribeTaskType RPC.
+	TaskManagementServiceDescribeTaskTypeProcedure = "/cloud.v1.TaskManagementService/DescribeTaskType"
 	// TaskManagementServiceHeartbeatProcedure is the fully-qualified name of the
 	// TaskManagementService's Heartbeat RPC.
 	TaskManagementServiceHeartbeatProcedure = "/cloud.v1.TaskManagementService/Heartbeat"
@@ -60,6 +99,32 @@ const (
 	TaskManagementServicePullEventsProcedure = "/cloud.v1.TaskManagementService/PullEvents"
 )
 
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	taskManagementServiceServiceDescriptor                      = v1.File_cloud_v1_cloud_proto.Services().ByName("TaskManagementService")
+	taskManagementServiceCreateTaskMethodDescriptor             = taskManagementServiceServiceDescriptor.Methods().ByName("CreateTask")
+	taskManagementServiceGetTaskMethodDescriptor                = taskManagementServiceServiceDescriptor.Methods().ByName("GetTask")
+	taskManagementServiceListTasksMethodDescriptor              = taskManagementServiceServiceDescriptor.Methods().ByName("ListTasks")
+	taskManagementServiceGetTaskHistoryMethodDescriptor         = taskManagementServiceServiceDescriptor.Methods().ByName("GetTaskHistory")
+	taskManagementServiceGetTaskResultMethodDescriptor          = taskManagementServiceServiceDescriptor.Methods().ByName("GetTaskResult")
+	taskManagementServiceListArtifactsMethodDescriptor          = taskManagementServiceServiceDescriptor.Methods().ByName("ListArtifacts")
+	taskManagementServiceGetArtifactUploadURLMethodDescriptor   = taskManagementServiceServiceDescriptor.Methods().ByName("GetArtifactUploadURL")
+	taskManagementServiceGetArtifactDownloadURLMethodDescriptor = taskManagementServiceServiceDescriptor.Methods().ByName("GetArtifactDownloadURL")
+	taskManagementServiceAppendTaskLogsMethodDescriptor         = taskManagementServiceServiceDescriptor.Methods().ByName("AppendTaskLogs")
+	taskManagementServiceStreamTaskLogsMethodDescriptor         = taskManagementServiceServiceDescriptor.Methods().ByName("StreamTaskLogs")
+	taskManagementServiceReportProgressMethodDescriptor         = taskManagementServiceServiceDescriptor.Methods().ByName("ReportProgress")
+	taskManagementServicePutSecretMethodDescriptor              = taskManagementServiceServiceDescriptor.Methods().ByName("PutSecret")
+	taskManagementServiceListSecretsMethodDescriptor            = taskManagementServiceServiceDescriptor.Methods().ByName("ListSecrets")
+	taskManagementServiceDeleteSecretMethodDescriptor           = taskManagementServiceServiceDescriptor.Methods().ByName("DeleteSecret")
+	taskManagementServiceResolveSecretsMethodDescriptor         = taskManagementServiceServiceDescriptor.Methods().ByName("ResolveSecrets")
+	taskManagementServiceUpdateTaskStatusMethodDescriptor       = taskManagementServiceServiceDescriptor.Methods().ByName("UpdateTaskStatus")
+	taskManagementServiceGetStatusMethodDescriptor              = taskManagementServiceServiceDescriptor.Methods().ByName("GetStatus")
+	taskManagementServiceListTaskTypesMethodDescriptor          = taskManagementServiceServiceDescriptor.Methods().ByName("ListTaskTypes")
+	taskManagementServiceDescribeTaskTypeMethodDescriptor       = taskManagementServiceServiceDescriptor.Methods().ByName("DescribeTaskType")
+	taskManagementServiceHeartbeatMethodDescriptor              = taskManagementServiceServiceDescriptor.Methods().ByName("Heartbeat")
+	taskManagementServicePullEventsMethodDescriptor             = taskManagementServiceServiceDescriptor.Methods().ByName("PullEvents")
+)
+
 // TaskManagementServiceClient is a client for the cloud.v1.TaskManagementService service.
 type TaskManagementServiceClient interface {
 	// Creates a new task based on the provided request.
@@ -74,12 +139,51 @@ type TaskManagementServiceClient interface {
 	// Retrieves the execution history of the specified task.
 	// Returns a GetTaskHistoryResponse containing a list of historical status updates.
 	GetTaskHistory(context.Context, *connect.Request[v1.GetTaskHistoryRequest]) (*connect.Response[v1.GetTaskHistoryResponse], error)
+	// Retrieves the result the specified task reported when it finished.
+	// Returns a GetTaskResultResponse containing the outputs and JSON data of the task.
+	GetTaskResult(context.Context, *connect.Request[v1.GetTaskResultRequest]) (*connect.Response[v1.GetTaskResultResponse], error)
+	// Lists the artifacts the specified task declared and stored.
+	// Returns a ListArtifactsResponse containing the stored output artifacts and the declared ones.
+	ListArtifacts(context.Context, *connect.Request[v1.ListArtifactsRequest]) (*connect.Response[v1.ListArtifactsResponse], error)
+	// Hands out a URL that uploads an output artifact of the specified task.
+	// Returns an ArtifactURL the worker sends the artifact to before it expires.
+	GetArtifactUploadURL(context.Context, *connect.Request[v1.GetArtifactUploadURLRequest]) (*connect.Response[v1.ArtifactURL], error)
+	// Hands out a URL that downloads a stored output artifact of the specified task.
+	// Returns an ArtifactURL the artifact can be fetched from before it expires.
+	GetArtifactDownloadURL(context.Context, *connect.Request[v1.GetArtifactDownloadURLRequest]) (*connect.Response[v1.ArtifactURL], error)
+	// Appends log lines logged by a worker while running the specified task.
+	// Returns an empty response once the lines are stored.
+	AppendTaskLogs(context.Context, *connect.Request[v1.AppendTaskLogsRequest]) (*connect.Response[emptypb.Empty], error)
+	// Streams the log lines of the specified task, oldest first.
+	// With follow set, the stream stays open for new lines until the task has finished.
+	StreamTaskLogs(context.Context, *connect.Request[v1.StreamTaskLogsRequest]) (*connect.ServerStreamForClient[v1.StreamTaskLogsResponse], error)
+	// Reports the progress of the specified task, for the worker running it.
+	// Returns a ReportProgressResponse telling whether the report was recorded or throttled.
+	ReportProgress(context.Context, *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error)
+	// Sets the value of a secret, creating the secret when it does not exist.
+	// Returns the Secret without its value.
+	PutSecret(context.Context, *connect.Request[v1.PutSecretRequest]) (*connect.Response[v1.Secret], error)
+	// Lists the secrets kept by the task service.
+	// Returns a ListSecretsResponse containing the secrets without their values.
+	ListSecrets(context.Context, *connect.Request[v1.ListSecretsRequest]) (*connect.Response[v1.ListSecretsResponse], error)
+	// Deletes a secret.
+	// Returns an empty response once the secret is deleted.
+	DeleteSecret(context.Context, *connect.Request[v1.DeleteSecretRequest]) (*connect.Response[emptypb.Empty], error)
+	// Resolves secrets the specified task refers to, for the worker running it.
+	// Returns a ResolveSecretsResponse containing the values of the secrets.
+	ResolveSecrets(context.Context, *connect.Request[v1.ResolveSecretsRequest]) (*connect.Response[v1.ResolveSecretsResponse], error)
 	// Updates the status of the specified task.
 	// Returns an empty response to confirm the update was processed.
 	UpdateTaskStatus(context.Context, *connect.Request[v1.UpdateTaskStatusRequest]) (*connect.Response[emptypb.Empty], error)
 	// Retrieves the count of tasks for each status.
 	// Returns a GetStatusResponse containing a map of status counts.
 	GetStatus(context.Context, *connect.Request[v1.GetStatusRequest]) (*connect.Response[v1.GetStatusResponse], error)
+	// Lists the task types the server accepts.
+	// Returns a ListTaskTypesResponse containing the sorted task type names.
+	ListTaskTypes(context.Context, *connect.Request[v1.ListTaskTypesRequest]) (*connect.Response[v1.ListTaskTypesResponse], error)
+	// Describes the parameters a task type accepts.
+	// Returns a DescribeTaskTypeResponse containing the parameter schema of the task type.
+	DescribeTaskType(context.Context, *connect.Request[v1.DescribeTaskTypeRequest]) (*connect.Response[v1.DescribeTaskTypeResponse], error)
 	// Sends a heartbeat signal to indicate the service is alive.
 	Heartbeat(context.Context, *connect.Request[v1.HeartbeatRequest]) (*connect.Response[v1.HeartbeatResponse], error)
 	// Pulls events related to task execution.
@@ -99,56 +203,155 @@ func NewTaskManagementServiceClient(httpClient connect.HTTPClient, baseURL strin
 		createTask: connect.NewClient[v1.CreateTaskRequest, v1.CreateTaskResponse](
 			httpClient,
 			baseURL+TaskManagementServiceCreateTaskProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServiceCreateTaskMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 		getTask: connect.NewClient[v1.GetTaskRequest, v1.Task](
 			httpClient,
 			baseURL+TaskManagementServiceGetTaskProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServiceGetTaskMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 		listTasks: connect.NewClient[v1.TaskListRequest, v1.TaskList](
 			httpClient,
 			baseURL+TaskManagementServiceListTasksProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServiceListTasksMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 		getTaskHistory: connect.NewClient[v1.GetTaskHistoryRequest, v1.GetTaskHistoryResponse](
 			httpClient,
 			baseURL+TaskManagementServiceGetTaskHistoryProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServiceGetTaskHistoryMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getTaskResult: connect.NewClient[v1.GetTaskResultRequest, v1.GetTaskResultResponse](
+			httpClient,
+			baseURL+TaskManagementServiceGetTaskResultProcedure,
+			connect.WithSchema(taskManagementServiceGetTaskResultMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listArtifacts: connect.NewClient[v1.ListArtifactsRequest, v1.ListArtifactsResponse](
+			httpClient,
+			baseURL+TaskManagementServiceListArtifactsProcedure,
+			connect.WithSchema(taskManagementServiceListArtifactsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getArtifactUploadURL: connect.NewClient[v1.GetArtifactUploadURLRequest, v1.ArtifactURL](
+			httpClient,
+			baseURL+TaskManagementServiceGetArtifactUploadURLProcedure,
+			connect.WithSchema(taskManagementServiceGetArtifactUploadURLMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getArtifactDownloadURL: connect.NewClient[v1.GetArtifactDownloadURLRequest, v1.ArtifactURL](
+			httpClient,
+			baseURL+TaskManagementServiceGetArtifactDownloadURLProcedure,
+			connect.WithSchema(taskManagementServiceGetArtifactDownloadURLMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		appendTaskLogs: connect.NewClient[v1.AppendTaskLogsRequest, emptypb.Empty](
+			httpClient,
+			baseURL+TaskManagementServiceAppendTaskLogsProcedure,
+			connect.WithSchema(taskManagementServiceAppendTaskLogsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		streamTaskLogs: connect.NewClient[v1.StreamTaskLogsRequest, v1.StreamTaskLogsResponse](
+			httpClient,
+			baseURL+TaskManagementServiceStreamTaskLogsProcedure,
+			connect.WithSchema(taskManagementServiceStreamTaskLogsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		reportProgress: connect.NewClient[v1.ReportProgressRequest, v1.ReportProgressResponse](
+			httpClient,
+			baseURL+TaskManagementServiceReportProgressProcedure,
+			connect.WithSchema(taskManagementServiceReportProgressMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		putSecret: connect.NewClient[v1.PutSecretRequest, v1.Secret](
+			httpClient,
+			baseURL+TaskManagementServicePutSecretProcedure,
+			connect.WithSchema(taskManagementServicePutSecretMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listSecrets: connect.NewClient[v1.ListSecretsRequest, v1.ListSecretsResponse](
+			httpClient,
+			baseURL+TaskManagementServiceListSecretsProcedure,
+			connect.WithSchema(taskManagementServiceListSecretsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteSecret: connect.NewClient[v1.DeleteSecretRequest, emptypb.Empty](
+			httpClient,
+			baseURL+TaskManagementServiceDeleteSecretProcedure,
+			connect.WithSchema(taskManagementServiceDeleteSecretMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		resolveSecrets: connect.NewClient[v1.ResolveSecretsRequest, v1.ResolveSecretsResponse](
+			httpClient,
+			baseURL+TaskManagementServiceResolveSecretsProcedure,
+			connect.WithSchema(taskManagementServiceResolveSecretsMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 		updateTaskStatus: connect.NewClient[v1.UpdateTaskStatusRequest, emptypb.Empty](
 			httpClient,
 			baseURL+TaskManagementServiceUpdateTaskStatusProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServiceUpdateTaskStatusMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 		getStatus: connect.NewClient[v1.GetStatusRequest, v1.GetStatusResponse](
 			httpClient,
 			baseURL+TaskManagementServiceGetStatusProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServiceGetStatusMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listTaskTypes: connect.NewClient[v1.ListTaskTypesRequest, v1.ListTaskTypesResponse](
+			httpClient,
+			baseURL+TaskManagementServiceListTaskTypesProcedure,
+			connect.WithSchema(taskManagementServiceListTaskTypesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		describeTaskType: connect.NewClient[v1.DescribeTaskTypeRequest, v1.DescribeTaskTypeResponse](
+			httpClient,
+			baseURL+TaskManagementServiceDescribeTaskTypeProcedure,
+			connect.WithSchema(taskManagementServiceDescribeTaskTypeMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 		heartbeat: connect.NewClient[v1.HeartbeatRequest, v1.HeartbeatResponse](
 			httpClient,
 			baseURL+TaskManagementServiceHeartbeatProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServiceHeartbeatMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 		pullEvents: connect.NewClient[v1.PullEventsRequest, v1.PullEventsResponse](
 			httpClient,
 			baseURL+TaskManagementServicePullEventsProcedure,
-			opts...,
+			connect.WithSchema(taskManagementServicePullEventsMethodDescriptor),
+			connect.WithClientOptions(opts...),
 		),
 	}
 }
 
 // taskManagementServiceClient implements TaskManagementServiceClient.
 type taskManagementServiceClient struct {
-	createTask       *connect.Client[v1.CreateTaskRequest, v1.CreateTaskResponse]
-	getTask          *connect.Client[v1.GetTaskRequest, v1.Task]
-	listTasks        *connect.Client[v1.TaskListRequest, v1.TaskList]
-	getTaskHistory   *connect.Client[v1.GetTaskHistoryRequest, v1.GetTaskHistoryResponse]
-	updateTaskStatus *connect.Client[v1.UpdateTaskStatusRequest, emptypb.Empty]
-	getStatus        *connect.Client[v1.GetStatusRequest, v1.GetStatusResponse]
-	heartbeat        *connect.Client[v1.HeartbeatRequest, v1.HeartbeatResponse]
-	pullEvents       *connect.Client[v1.PullEventsRequest, v1.PullEventsResponse]
+	createTask             *connect.Client[v1.CreateTaskRequest, v1.CreateTaskResponse]
+	getTask                *connect.Client[v1.GetTaskRequest, v1.Task]
+	listTasks              *connect.Client[v1.TaskListRequest, v1.TaskList]
+	getTaskHistory         *connect.Client[v1.GetTaskHistoryRequest, v1.GetTaskHistoryResponse]
+	getTaskResult          *connect.Client[v1.GetTaskResultRequest, v1.GetTaskResultResponse]
+	listArtifacts          *connect.Client[v1.ListArtifactsRequest, v1.ListArtifactsResponse]
+	getArtifactUploadURL   *connect.Client[v1.GetArtifactUploadURLRequest, v1.ArtifactURL]
+	getArtifactDownloadURL *connect.Client[v1.GetArtifactDownloadURLRequest, v1.ArtifactURL]
+	appendTaskLogs         *connect.Client[v1.AppendTaskLogsRequest, emptypb.Empty]
+	streamTaskLogs         *connect.Client[v1.StreamTaskLogsRequest, v1.StreamTaskLogsResponse]
+	reportProgress         *connect.Client[v1.ReportProgressRequest, v1.ReportProgressResponse]
+	putSecret              *connect.Client[v1.PutSecretRequest, v1.Secret]
+	listSecrets            *connect.Client[v1.ListSecretsRequest, v1.ListSecretsResponse]
+	deleteSecret           *connect.Client[v1.DeleteSecretRequest, emptypb.Empty]
+	resolveSecrets         *connect.Client[v1.ResolveSecretsRequest, v1.ResolveSecretsResponse]
+	updateTaskStatus       *connect.Client[v1.UpdateTaskStatusRequest, emptypb.Empty]
+	getStatus              *connect.Client[v1.GetStatusRequest, v1.GetStatusResponse]
+	listTaskTypes          *connect.Client[v1.ListTaskTypesRequest, v1.ListTaskTypesResponse]
+	describeTaskType       *connect.Client[v1.DescribeTaskTypeRequest, v1.DescribeTaskTypeResponse]
+	heartbeat              *connect.Client[v1.HeartbeatRequest, v1.HeartbeatResponse]
+	pullEvents             *connect.Client[v1.PullEventsRequest, v1.PullEventsResponse]
 }
 
 // CreateTask calls cloud.v1.TaskManagementService.CreateTask.
@@ -171,6 +374,61 @@ func (c *taskManagementServiceClient) GetTaskHistory(ctx context.Context, req *c
 	return c.getTaskHistory.CallUnary(ctx, req)
 }
 
+// GetTaskResult calls cloud.v1.TaskManagementService.GetTaskResult.
+func (c *taskManagementServiceClient) GetTaskResult(ctx context.Context, req *connect.Request[v1.GetTaskResultRequest]) (*connect.Response[v1.GetTaskResultResponse], error) {
+	return c.getTaskResult.CallUnary(ctx, req)
+}
+
+// ListArtifacts calls cloud.v1.TaskManagementService.ListArtifacts.
+func (c *taskManagementServiceClient) ListArtifacts(ctx context.Context, req *connect.Request[v1.ListArtifactsRequest]) (*connect.Response[v1.ListArtifactsResponse], error) {
+	return c.listArtifacts.CallUnary(ctx, req)
+}
+
+// GetArtifactUploadURL calls cloud.v1.TaskManagementService.GetArtifactUploadURL.
+func (c *taskManagementServiceClient) GetArtifactUploadURL(ctx context.Context, req *connect.Request[v1.GetArtifactUploadURLRequest]) (*connect.Response[v1.ArtifactURL], error) {
+	return c.getArtifactUploadURL.CallUnary(ctx, req)
+}
+
+// GetArtifactDownloadURL calls cloud.v1.TaskManagementService.GetArtifactDownloadURL.
+func (c *taskManagementServiceClient) GetArtifactDownloadURL(ctx context.Context, req *connect.Request[v1.GetArtifactDownloadURLRequest]) (*connect.Response[v1.ArtifactURL], error) {
+	return c.getArtifactDownloadURL.CallUnary(ctx, req)
+}
+
+// AppendTaskLogs calls cloud.v1.TaskManagementService.AppendTaskLogs.
+func (c *taskManagementServiceClient) AppendTaskLogs(ctx context.Context, req *connect.Request[v1.AppendTaskLogsRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.appendTaskLogs.CallUnary(ctx, req)
+}
+
+// StreamTaskLogs calls cloud.v1.TaskManagementService.StreamTaskLogs.
+func (c *taskManagementServiceClient) StreamTaskLogs(ctx context.Context, req *connect.Request[v1.StreamTaskLogsRequest]) (*connect.ServerStreamForClient[v1.StreamTaskLogsResponse], error) {
+	return c.streamTaskLogs.CallServerStream(ctx, req)
+}
+
+// ReportProgress calls cloud.v1.TaskManagementService.ReportProgress.
+func (c *taskManagementServiceClient) ReportProgress(ctx context.Context, req *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error) {
+	return c.reportProgress.CallUnary(ctx, req)
+}
+
+// PutSecret calls cloud.v1.TaskManagementService.PutSecret.
+func (c *taskManagementServiceClient) PutSecret(ctx context.Context, req *connect.Request[v1.PutSecretRequest]) (*connect.Response[v1.Secret], error) {
+	return c.putSecret.CallUnary(ctx, req)
+}
+
+// ListSecrets calls cloud.v1.TaskManagementService.ListSecrets.
+func (c *taskManagementServiceClient) ListSecrets(ctx context.Context, req *connect.Request[v1.ListSecretsRequest]) (*connect.Response[v1.ListSecretsResponse], error) {
+	return c.listSecrets.CallUnary(ctx, req)
+}
+
+// DeleteSecret calls cloud.v1.TaskManagementService.DeleteSecret.
+func (c *taskManagementServiceClient) DeleteSecret(ctx context.Context, req *connect.Request[v1.DeleteSecretRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.deleteSecret.CallUnary(ctx, req)
+}
+
+// ResolveSecrets calls cloud.v1.TaskManagementService.ResolveSecrets.
+func (c *taskManagementServiceClient) ResolveSecrets(ctx context.Context, req *connect.Request[v1.ResolveSecretsRequest]) (*connect.Response[v1.ResolveSecretsResponse], error) {
+	return c.resolveSecrets.CallUnary(ctx, req)
+}
+
 // UpdateTaskStatus calls cloud.v1.TaskManagementService.UpdateTaskStatus.
 func (c *taskManagementServiceClient) UpdateTaskStatus(ctx context.Context, req *connect.Request[v1.UpdateTaskStatusRequest]) (*connect.Response[emptypb.Empty], error) {
 	return c.updateTaskStatus.CallUnary(ctx, req)
@@ -181,6 +439,16 @@ func (c *taskManagementServiceClient) GetStatus(ctx context.Context, req *connec
 	return c.getStatus.CallUnary(ctx, req)
 }
 
+// ListTaskTypes calls cloud.v1.TaskManagementService.ListTaskTypes.
+func (c *taskManagementServiceClient) ListTaskTypes(ctx context.Context, req *connect.Request[v1.ListTaskTypesRequest]) (*connect.Response[v1.ListTaskTypesResponse], error) {
+	return c.listTaskTypes.CallUnary(ctx, req)
+}
+
+// DescribeTaskType calls cloud.v1.TaskManagementService.DescribeTaskType.
+func (c *taskManagementServiceClient) DescribeTaskType(ctx context.Context, req *connect.Request[v1.DescribeTaskTypeRequest]) (*connect.Response[v1.DescribeTaskTypeResponse], error) {
+	return c.describeTaskType.CallUnary(ctx, req)
+}
+
 // Heartbeat calls cloud.v1.TaskManagementService.Heartbeat.
 func (c *taskManagementServiceClient) Heartbeat(ctx context.Context, req *connect.Request[v1.HeartbeatRequest]) (*connect.Response[v1.HeartbeatResponse], error) {
 	return c.heartbeat.CallUnary(ctx, req)
@@ -205,12 +473,51 @@ type TaskManagementServiceHandler interface {
 	// Retrieves the execution history of the specified task.
 	// Returns a GetTaskHistoryResponse containing a list of historical status updates.
 	GetTaskHistory(context.Context, *connect.Request[v1.GetTaskHistoryRequest]) (*connect.Response[v1.GetTaskHistoryResponse], error)
+	// Retrieves the result the specified task reported when it finished.
+	// Returns a GetTaskResultResponse containing the outputs and JSON data of the task.
+	GetTaskResult(context.Context, *connect.Request[v1.GetTaskResultRequest]) (*connect.Response[v1.GetTaskResultResponse], error)
+	// Lists the artifacts the specified task declared and stored.
+	// Returns a ListArtifactsResponse containing the stored output artifacts and the declared ones.
+	ListArtifacts(context.Context, *connect.Request[v1.ListArtifactsRequest]) (*connect.Response[v1.ListArtifactsResponse], error)
+	// Hands out a URL that uploads an output artifact of the specified task.
+	// Returns an ArtifactURL the worker sends the artifact to before it expires.
+	GetArtifactUploadURL(context.Context, *connect.Request[v1.GetArtifactUploadURLRequest]) (*connect.Response[v1.ArtifactURL], error)
+	// Hands out a URL that downloads a stored output artifact of the specified task.
+	// Returns an ArtifactURL the artifact can be fetched from before it expires.
+	GetArtifactDownloadURL(context.Context, *connect.Request[v1.GetArtifactDownloadURLRequest]) (*connect.Response[v1.ArtifactURL], error)
+	// Appends log lines logged by a worker while running the specified task.
+	// Returns an empty response once the lines are stored.
+	AppendTaskLogs(context.Context, *connect.Request[v1.AppendTaskLogsRequest]) (*connect.Response[emptypb.Empty], error)
+	// Streams the log lines of the specified task, oldest first.
+	// With follow set, the stream stays open for new lines until the task has finished.
+	StreamTaskLogs(context.Context, *connect.Request[v1.StreamTaskLogsRequest], *connect.ServerStream[v1.StreamTaskLogsResponse]) error
+	// Reports the progress of the specified task, for the worker running it.
+	// Returns a ReportProgressResponse telling whether the report was recorded or throttled.
+	ReportProgress(context.Context, *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error)
+	// Sets the value of a secret, creating the secret when it does not exist.
+	// Returns the Secret without its value.
+	PutSecret(context.Context, *connect.Request[v1.PutSecretRequest]) (*connect.Response[v1.Secret], error)
+	// Lists the secrets kept by the task service.
+	// Returns a ListSecretsResponse containing the secrets without their values.
+	ListSecrets(context.Context, *connect.Request[v1.ListSecretsRequest]) (*connect.Response[v1.ListSecretsResponse], error)
+	// Deletes a secret.
+	// Returns an empty response once the secret is deleted.
+	DeleteSecret(context.Context, *connect.Request[v1.DeleteSecretRequest]) (*connect.Response[emptypb.Empty], error)
+	// Resolves secrets the specified task refers to, for the worker running it.
+	// Returns a ResolveSecretsResponse containing the values of the secrets.
+	ResolveSecrets(context.Context, *connect.Request[v1.ResolveSecretsRequest]) (*connect.Response[v1.ResolveSecretsResponse], error)
 	// Updates the status of the specified task.
 	// Returns an empty response to confirm the update was processed.
 	UpdateTaskStatus(context.Context, *connect.Request[v1.UpdateTaskStatusRequest]) (*connect.Response[emptypb.Empty], error)
 	// Retrieves the count of tasks for each status.
 	// Returns a GetStatusResponse containing a map of status counts.
 	GetStatus(context.Context, *connect.Request[v1.GetStatusRequest]) (*connect.Response[v1.GetStatusResponse], error)
+	// Lists the task types the server accepts.
+	// Returns a ListTaskTypesResponse containing the sorted task type names.
+	ListTaskTypes(context.Context, *connect.Request[v1.ListTaskTypesRequest]) (*connect.Response[v1.ListTaskTypesResponse], error)
+	// Describes the parameters a task type accepts.
+	// Returns a DescribeTaskTypeResponse containing the parameter schema of the task type.
+	DescribeTaskType(context.Context, *connect.Request[v1.DescribeTaskTypeRequest]) (*connect.Response[v1.DescribeTaskTypeResponse], error)
 	// Sends a heartbeat signal to indicate the service is alive.
 	Heartbeat(context.Context, *connect.Request[v1.HeartbeatRequest]) (*connect.Response[v1.HeartbeatResponse], error)
 	// Pulls events related to task execution.
@@ -226,42 +533,128 @@ func NewTaskManagementServiceHandler(svc TaskManagementServiceHandler, opts ...c
 	taskManagementServiceCreateTaskHandler := connect.NewUnaryHandler(
 		TaskManagementServiceCreateTaskProcedure,
 		svc.CreateTask,
-		opts...,
+		connect.WithSchema(taskManagementServiceCreateTaskMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	taskManagementServiceGetTaskHandler := connect.NewUnaryHandler(
 		TaskManagementServiceGetTaskProcedure,
 		svc.GetTask,
-		opts...,
+		connect.WithSchema(taskManagementServiceGetTaskMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	taskManagementServiceListTasksHandler := connect.NewUnaryHandler(
 		TaskManagementServiceListTasksProcedure,
 		svc.ListTasks,
-		opts...,
+		connect.WithSchema(taskManagementServiceListTasksMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	taskManagementServiceGetTaskHistoryHandler := connect.NewUnaryHandler(
 		TaskManagementServiceGetTaskHistoryProcedure,
 		svc.GetTaskHistory,
-		opts...,
+		connect.WithSchema(taskManagementServiceGetTaskHistoryMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceGetTaskResultHandler := connect.NewUnaryHandler(
+		TaskManagementServiceGetTaskResultProcedure,
+		svc.GetTaskResult,
+		connect.WithSchema(taskManagementServiceGetTaskResultMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceListArtifactsHandler := connect.NewUnaryHandler(
+		TaskManagementServiceListArtifactsProcedure,
+		svc.ListArtifacts,
+		connect.WithSchema(taskManagementServiceListArtifactsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceGetArtifactUploadURLHandler := connect.NewUnaryHandler(
+		TaskManagementServiceGetArtifactUploadURLProcedure,
+		svc.GetArtifactUploadURL,
+		connect.WithSchema(taskManagementServiceGetArtifactUploadURLMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceGetArtifactDownloadURLHandler := connect.NewUnaryHandler(
+		TaskManagementServiceGetArtifactDownloadURLProcedure,
+		svc.GetArtifactDownloadURL,
+		connect.WithSchema(taskManagementServiceGetArtifactDownloadURLMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceAppendTaskLogsHandler := connect.NewUnaryHandler(
+		TaskManagementServiceAppendTaskLogsProcedure,
+		svc.AppendTaskLogs,
+		connect.WithSchema(taskManagementServiceAppendTaskLogsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceStreamTaskLogsHandler := connect.NewServerStreamHandler(
+		TaskManagementServiceStreamTaskLogsProcedure,
+		svc.StreamTaskLogs,
+		connect.WithSchema(taskManagementServiceStreamTaskLogsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceReportProgressHandler := connect.NewUnaryHandler(
+		TaskManagementServiceReportProgressProcedure,
+		svc.ReportProgress,
+		connect.WithSchema(taskManagementServiceReportProgressMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServicePutSecretHandler := connect.NewUnaryHandler(
+		TaskManagementServicePutSecretProcedure,
+		svc.PutSecret,
+		connect.WithSchema(taskManagementServicePutSecretMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceListSecretsHandler := connect.NewUnaryHandler(
+		TaskManagementServiceListSecretsProcedure,
+		svc.ListSecrets,
+		connect.WithSchema(taskManagementServiceListSecretsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceDeleteSecretHandler := connect.NewUnaryHandler(
+		TaskManagementServiceDeleteSecretProcedure,
+		svc.DeleteSecret,
+		connect.WithSchema(taskManagementServiceDeleteSecretMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceResolveSecretsHandler := connect.NewUnaryHandler(
+		TaskManagementServiceResolveSecretsProcedure,
+		svc.ResolveSecrets,
+		connect.WithSchema(taskManagementServiceResolveSecretsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	taskManagementServiceUpdateTaskStatusHandler := connect.NewUnaryHandler(
 		TaskManagementServiceUpdateTaskStatusProcedure,
 		svc.UpdateTaskStatus,
-		opts...,
+		connect.WithSchema(taskManagementServiceUpdateTaskStatusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	taskManagementServiceGetStatusHandler := connect.NewUnaryHandler(
 		TaskManagementServiceGetStatusProcedure,
 		svc.GetStatus,
-		opts...,
+		connect.WithSchema(taskManagementServiceGetStatusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceListTaskTypesHandler := connect.NewUnaryHandler(
+		TaskManagementServiceListTaskTypesProcedure,
+		svc.ListTaskTypes,
+		connect.WithSchema(taskManagementServiceListTaskTypesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskManagementServiceDescribeTaskTypeHandler := connect.NewUnaryHandler(
+		TaskManagementServiceDescribeTaskTypeProcedure,
+		svc.DescribeTaskType,
+		connect.WithSchema(taskManagementServiceDescribeTaskTypeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	taskManagementServiceHeartbeatHandler := connect.NewUnaryHandler(
 		TaskManagementServiceHeartbeatProcedure,
 		svc.Heartbeat,
-		opts...,
+		connect.WithSchema(taskManagementServiceHeartbeatMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	taskManagementServicePullEventsHandler := connect.NewServerStreamHandler(
 		TaskManagementServicePullEventsProcedure,
 		svc.PullEvents,
-		opts...,
+		connect.WithSchema(taskManagementServicePullEventsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
 	)
 	return "/cloud.v1.TaskManagementService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -273,10 +666,36 @@ func NewTaskManagementServiceHandler(svc TaskManagementServiceHandler, opts ...c
 			taskManagementServiceListTasksHandler.ServeHTTP(w, r)
 		case TaskManagementServiceGetTaskHistoryProcedure:
 			taskManagementServiceGetTaskHistoryHandler.ServeHTTP(w, r)
+		case TaskManagementServiceGetTaskResultProcedure:
+			taskManagementServiceGetTaskResultHandler.ServeHTTP(w, r)
+		case TaskManagementServiceListArtifactsProcedure:
+			taskManagementServiceListArtifactsHandler.ServeHTTP(w, r)
+		case TaskManagementServiceGetArtifactUploadURLProcedure:
+			taskManagementServiceGetArtifactUploadURLHandler.ServeHTTP(w, r)
+		case TaskManagementServiceGetArtifactDownloadURLProcedure:
+			taskManagementServiceGetArtifactDownloadURLHandler.ServeHTTP(w, r)
+		case TaskManagementServiceAppendTaskLogsProcedure:
+			taskManagementServiceAppendTaskLogsHandler.ServeHTTP(w, r)
+		case TaskManagementServiceStreamTaskLogsProcedure:
+			taskManagementServiceStreamTaskLogsHandler.ServeHTTP(w, r)
+		case TaskManagementServiceReportProgressProcedure:
+			taskManagementServiceReportProgressHandler.ServeHTTP(w, r)
+		case TaskManagementServicePutSecretProcedure:
+			taskManagementServicePutSecretHandler.ServeHTTP(w, r)
+		case TaskManagementServiceListSecretsProcedure:
+			taskManagementServiceListSecretsHandler.ServeHTTP(w, r)
+		case TaskManagementServiceDeleteSecretProcedure:
+			taskManagementServiceDeleteSecretHandler.ServeHTTP(w, r)
+		case TaskManagementServiceResolveSecretsProcedure:
+			taskManagementServiceResolveSecretsHandler.ServeHTTP(w, r)
 		case TaskManagementServiceUpdateTaskStatusProcedure:
 			taskManagementServiceUpdateTaskStatusHandler.ServeHTTP(w, r)
 		case TaskManagementServiceGetStatusProcedure:
 			taskManagementServiceGetStatusHandler.ServeHTTP(w, r)
+		case TaskManagementServiceListTaskTypesProcedure:
+			taskManagementServiceListTaskTypesHandler.ServeHTTP(w, r)
+		case TaskManagementServiceDescribeTaskTypeProcedure:
+			taskManagementServiceDescribeTaskTypeHandler.ServeHTTP(w, r)
 		case TaskManagementServiceHeartbeatProcedure:
 			taskManagementServiceHeartbeatHandler.ServeHTTP(w, r)
 		case TaskManagementServicePullEventsProcedure:
@@ -306,6 +725,50 @@ func (UnimplementedTaskManagementServiceHandler) GetTaskHistory(context.Context,
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.GetTaskHistory is not implemented"))
 }
 
+func (UnimplementedTaskManagementServiceHandler) GetTaskResult(context.Context, *connect.Request[v1.GetTaskResultRequest]) (*connect.Response[v1.GetTaskResultResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.GetTaskResult is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) ListArtifacts(context.Context, *connect.Request[v1.ListArtifactsRequest]) (*connect.Response[v1.ListArtifactsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.ListArtifacts is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) GetArtifactUploadURL(context.Context, *connect.Request[v1.GetArtifactUploadURLRequest]) (*connect.Response[v1.ArtifactURL], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.GetArtifactUploadURL is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) GetArtifactDownloadURL(context.Context, *connect.Request[v1.GetArtifactDownloadURLRequest]) (*connect.Response[v1.ArtifactURL], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.GetArtifactDownloadURL is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) AppendTaskLogs(context.Context, *connect.Request[v1.AppendTaskLogsRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.AppendTaskLogs is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) StreamTaskLogs(context.Context, *connect.Request[v1.StreamTaskLogsRequest], *connect.ServerStream[v1.StreamTaskLogsResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.StreamTaskLogs is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) ReportProgress(context.Context, *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.ReportProgress is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) PutSecret(context.Context, *connect.Request[v1.PutSecretRequest]) (*connect.Response[v1.Secret], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.PutSecret is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) ListSecrets(context.Context, *connect.Request[v1.ListSecretsRequest]) (*connect.Response[v1.ListSecretsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.ListSecrets is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) DeleteSecret(context.Context, *connect.Request[v1.DeleteSecretRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.DeleteSecret is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) ResolveSecrets(context.Context, *connect.Request[v1.ResolveSecretsRequest]) (*connect.Response[v1.ResolveSecretsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.ResolveSecrets is not implemented"))
+}
+
 func (UnimplementedTaskManagementServiceHandler) UpdateTaskStatus(context.Context, *connect.Request[v1.UpdateTaskStatusRequest]) (*connect.Response[emptypb.Empty], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.UpdateTaskStatus is not implemented"))
 }
@@ -314,6 +777,14 @@ func (UnimplementedTaskManagementServiceHandler) GetStatus(context.Context, *con
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.GetStatus is not implemented"))
 }
 
+func (UnimplementedTaskManagementServiceHandler) ListTaskTypes(context.Context, *connect.Request[v1.ListTaskTypesRequest]) (*connect.Response[v1.ListTaskTypesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.ListTaskTypes is not implemented"))
+}
+
+func (UnimplementedTaskManagementServiceHandler) DescribeTaskType(context.Context, *connect.Request[v1.DescribeTaskTypeRequest]) (*connect.Response[v1.DescribeTaskTypeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.DescribeTaskType is not implemented"))
+}
+
 func (UnimplementedTaskManagementServiceHandler) Heartbeat(context.Context, *connect.Request[v1.HeartbeatRequest]) (*connect.Response[v1.HeartbeatResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("cloud.v1.TaskManagementService.Heartbeat is not implemented"))
 }
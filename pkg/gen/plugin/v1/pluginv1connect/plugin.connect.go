@@ -0,0 +1,145 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: plugin/v1/plugin.proto
+
+package pluginv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	http "net/http"
+	strings "strings"
+	v1 "task/pkg/gen/plugin/v1"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// PluginServiceName is the fully-qualified name of the PluginService service.
+	PluginServiceName = "plugin.v1.PluginService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// PluginServiceDescribeProcedure is the fully-qualified name of the PluginService's Describe RPC.
+	PluginServiceDescribeProcedure = "/plugin.v1.PluginService/Describe"
+	// PluginServiceRunProcedure is the fully-qualified name of the PluginService's Run RPC.
+	PluginServiceRunProcedure = "/plugin.v1.PluginService/Run"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	pluginServiceServiceDescriptor        = v1.File_plugin_v1_plugin_proto.Services().ByName("PluginService")
+	pluginServiceDescribeMethodDescriptor = pluginServiceServiceDescriptor.Methods().ByName("Describe")
+	pluginServiceRunMethodDescriptor      = pluginServiceServiceDescriptor.Methods().ByName("Run")
+)
+
+// PluginServiceClient is a client for the plugin.v1.PluginService service.
+type PluginServiceClient interface {
+	// Describes the task type implemented by the plugin.
+	Describe(context.Context, *connect.Request[v1.DescribeRequest]) (*connect.Response[v1.DescribeResponse], error)
+	// Runs a task, streaming its log records followed by its result.
+	Run(context.Context, *connect.Request[v1.RunRequest]) (*connect.ServerStreamForClient[v1.RunResponse], error)
+}
+
+// NewPluginServiceClient constructs a client for the plugin.v1.PluginService service. By default,
+// it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and
+// sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC()
+// or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewPluginServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) PluginServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &pluginServiceClient{
+		describe: connect.NewClient[v1.DescribeRequest, v1.DescribeResponse](
+			httpClient,
+			baseURL+PluginServiceDescribeProcedure,
+			connect.WithSchema(pluginServiceDescribeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		run: connect.NewClient[v1.RunRequest, v1.RunResponse](
+			httpClient,
+			baseURL+PluginServiceRunProcedure,
+			connect.WithSchema(pluginServiceRunMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// pluginServiceClient implements PluginServiceClient.
+type pluginServiceClient struct {
+	describe *connect.Client[v1.DescribeRequest, v1.DescribeResponse]
+	run      *connect.Client[v1.RunRequest, v1.RunResponse]
+}
+
+// Describe calls plugin.v1.PluginService.Describe.
+func (c *pluginServiceClient) Describe(ctx context.Context, req *connect.Request[v1.DescribeRequest]) (*connect.Response[v1.DescribeResponse], error) {
+	return c.describe.CallUnary(ctx, req)
+}
+
+// Run calls plugin.v1.PluginService.Run.
+func (c *pluginServiceClient) Run(ctx context.Context, req *connect.Request[v1.RunRequest]) (*connect.ServerStreamForClient[v1.RunResponse], error) {
+	return c.run.CallServerStream(ctx, req)
+}
+
+// PluginServiceHandler is an implementation of the plugin.v1.PluginService service.
+type PluginServiceHandler interface {
+	// Describes the task type implemented by the plugin.
+	Describe(context.Context, *connect.Request[v1.DescribeRequest]) (*connect.Response[v1.DescribeResponse], error)
+	// Runs a task, streaming its log records followed by its result.
+	Run(context.Context, *connect.Request[v1.RunRequest], *connect.ServerStream[v1.RunResponse]) error
+}
+
+// NewPluginServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewPluginServiceHandler(svc PluginServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	pluginServiceDescribeHandler := connect.NewUnaryHandler(
+		PluginServiceDescribeProcedure,
+		svc.Describe,
+		connect.WithSchema(pluginServiceDescribeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	pluginServiceRunHandler := connect.NewServerStreamHandler(
+		PluginServiceRunProcedure,
+		svc.Run,
+		connect.WithSchema(pluginServiceRunMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/plugin.v1.PluginService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case PluginServiceDescribeProcedure:
+			pluginServiceDescribeHandler.ServeHTTP(w, r)
+		case PluginServiceRunProcedure:
+			pluginServiceRunHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedPluginServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedPluginServiceHandler struct{}
+
+func (UnimplementedPluginServiceHandler) Describe(context.Context, *connect.Request[v1.DescribeRequest]) (*connect.Response[v1.DescribeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("plugin.v1.PluginService.Describe is not implemented"))
+}
+
+func (UnimplementedPluginServiceHandler) Run(context.Context, *connect.Request[v1.RunRequest], *connect.ServerStream[v1.RunResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("plugin.v1.PluginService.Run is not implemented"))
+}
@@ -2,6 +2,7 @@ package K8s
 
 import (
 	"context"
+	"fmt"
 	"os"
 	v1 "task/controller/api/v1"
 
@@ -30,8 +31,12 @@ func NewK8sClient(kubeconfigPath string) (*K8s, error) {
 		if err != nil {
 			return nil, err
 		}
-	} else { // Local config
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	} else { // Local config, from the files listed in $KUBECONFIG or ~/.kube/config when no path is given
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		if kubeconfigPath != "" {
+			loadingRules.ExplicitPath = kubeconfigPath
+		}
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
 		if err != nil {
 			return nil, err
 		}
@@ -56,6 +61,15 @@ func NewK8sClient(kubeconfigPath string) (*K8s, error) {
 	return k, nil
 }
 
+// CheckConnection asks the API server for its version, which fails when the cluster cannot be reached
+// or rejects the credentials of the client
+func (k *K8s) CheckConnection() error {
+	if _, err := k.client.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("failed to reach the Kubernetes API server: %w", err)
+	}
+	return nil
+}
+
 // CreateTask creates a new Task resource in the Kubernetes cluster
 func (k *K8s) CreateTask(task *v1.Task) (*v1.Task, error) {
 	tasksClient := k.client.RESTClient().
@@ -0,0 +1,13 @@
+// Package builtin registers the task types that ship with the task service.
+// Binaries that create or run tasks import it for its side effects:
+//
+//	import _ "task/pkg/plugins/builtin"
+package builtin
+
+import (
+	_ "task/pkg/plugins/email"
+	_ "task/pkg/plugins/httprequest"
+	_ "task/pkg/plugins/process"
+	_ "task/pkg/plugins/query"
+	_ "task/pkg/plugins/wasm"
+)
@@ -0,0 +1,118 @@
+package builtin
+
+import (
+	"errors"
+	"reflect"
+	"task/pkg/plugins"
+	"task/pkg/plugins/email"
+	"task/pkg/plugins/httprequest"
+	"task/pkg/plugins/process"
+	"task/pkg/plugins/query"
+	"task/pkg/plugins/wasm"
+	"testing"
+)
+
+func TestNewPlugin(t *testing.T) {
+	tests := []struct {
+		name       string
+		pluginType string
+		want       plugins.Plugin
+		wantErr    bool
+	}{
+		{
+			name:       "SEND_EMAIL plugin",
+			pluginType: email.PLUGIN_NAME,
+			want:       &email.Email{},
+			wantErr:    false,
+		},
+		{
+			name:       "RUN_QUERY plugin",
+			pluginType: query.PLUGIN_NAME,
+			want:       &query.Query{},
+			wantErr:    false,
+		},
+		{
+			name:       "HTTP_REQUEST plugin",
+			pluginType: httprequest.PLUGIN_NAME,
+			want:       &httprequest.HTTPRequest{},
+			wantErr:    false,
+		},
+		{
+			name:       "PROCESS plugin",
+			pluginType: process.PLUGIN_NAME,
+			want:       &process.Process{},
+			wantErr:    false,
+		},
+		{
+			name:       "WASM plugin",
+			pluginType: wasm.PLUGIN_NAME,
+			want:       &wasm.Wasm{},
+			wantErr:    false,
+		},
+		{
+			name:       "Unknown plugin type",
+			pluginType: "UNKNOWN",
+			want:       nil,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugins.NewPlugin(tt.pluginType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPlugin() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got == nil {
+				t.Errorf("NewPlugin() returned nil, want %T", tt.want)
+				return
+			}
+			if _, ok := got.(plugins.Plugin); !ok {
+				t.Errorf("NewPlugin() returned %T, which does not implement Plugin interface", got)
+			}
+		})
+	}
+}
+
+func TestTypes(t *testing.T) {
+	got := plugins.Types()
+	want := []string{httprequest.PLUGIN_NAME, process.PLUGIN_NAME, query.PLUGIN_NAME, email.PLUGIN_NAME, wasm.PLUGIN_NAME}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Types() = %v, want %v", got, want)
+	}
+}
+
+func TestSchemas(t *testing.T) {
+	for _, pluginType := range plugins.Types() {
+		t.Run(pluginType, func(t *testing.T) {
+			schema, err := plugins.Describe(pluginType)
+			if err != nil {
+				t.Fatalf("Describe() error = %v", err)
+			}
+			if schema.Description == "" {
+				t.Errorf("Describe() returned no description")
+			}
+
+			// The declared defaults must be valid values, and patterns must compile
+			params := map[string]string{}
+			for _, p := range schema.Parameters {
+				if p.Default != "" {
+					params[p.Name] = p.Default
+				}
+			}
+			err = schema.Validate(params)
+			var validationErr *plugins.ValidationError
+			if errors.As(err, &validationErr) {
+				for _, f := range validationErr.Fields {
+					if f.Message != "is required" {
+						t.Errorf("Default of %s is invalid: %s", f.Parameter, f.Message)
+					}
+				}
+			}
+		})
+	}
+}
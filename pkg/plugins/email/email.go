@@ -1,24 +1,204 @@
 package email
 
 import (
-	"os"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
 	"strconv"
+	"strings"
+	"task/pkg/plugins"
 	"time"
+
+	"github.com/kelseyhightower/envconfig"
 )
 
 var PLUGIN_NAME = "send_email"
 
+// STARTTLS modes of Config.StartTLS
+const (
+	StartTLSRequired      = "required"
+	StartTLSOpportunistic = "opportunistic"
+	StartTLSDisabled      = "disabled"
+)
+
+// Config holds the SMTP server settings used by the send_email plugin
+type Config struct {
+	Host     string `envconfig:"SMTP_HOST" default:"localhost"`
+	Port     int    `envconfig:"SMTP_PORT" default:"587"`
+	Username string `envconfig:"SMTP_USERNAME"`
+	Password string `envconfig:"SMTP_PASSWORD"`
+	// From is the sender address used when a task does not set the from parameter.
+	From string `envconfig:"SMTP_FROM"`
+	// StartTLS is required, opportunistic or disabled.
+	StartTLS string        `envconfig:"SMTP_STARTTLS" default:"required"`
+	Timeout  time.Duration `envconfig:"SMTP_TIMEOUT" default:"30s"`
+	// AttachmentDir is the directory attachment paths are resolved in.
+	// Attachments can only be referenced by URL when it is unset.
+	AttachmentDir string `envconfig:"SMTP_ATTACHMENT_DIR"`
+	// AttachmentAllowedHosts are the host names attachments can be downloaded from.
+	// Attachments can only be referenced by path when it is empty.
+	AttachmentAllowedHosts []string `envconfig:"SMTP_ATTACHMENT_ALLOWED_HOSTS"`
+	// MaxAttachmentBytes limits the size of each attachment.
+	MaxAttachmentBytes int64 `envconfig:"SMTP_MAX_ATTACHMENT_BYTES" default:"10485760"`
+	// TLSConfig overrides the TLS settings used for STARTTLS, such as the trusted roots.
+	TLSConfig *tls.Config `ignored:"true"`
+}
+
+// Email sends an email over SMTP. The task parameters are:
+//
+//   - to, cc, bcc: comma-separated recipient addresses; at least one recipient is required
+//   - from: the sender address, defaulting to SMTP_FROM
+//   - subject, body: text/template templates rendered with the task parameters
+//   - html_body: an optional html/template template rendered with the task parameters
+//   - attachments: comma-separated http(s) URLs on SMTP_ATTACHMENT_ALLOWED_HOSTS or paths within SMTP_ATTACHMENT_DIR
 type Email struct {
+	// Config is loaded from the environment on the first run when nil.
+	Config *Config
+}
+
+func init() {
+	plugins.Register(PLUGIN_NAME, func() plugins.Plugin { return &Email{} })
+}
+
+// Schema declares the parameters of send_email. Any other parameter can be used in the templates.
+func (e *Email) Schema() plugins.Schema {
+	return plugins.Schema{
+		Description: "Sends an email over SMTP",
+		Parameters: []plugins.Parameter{
+			{Name: "to", Type: plugins.ParameterString, Required: true, Description: "Comma-separated recipient addresses"},
+			{Name: "cc", Type: plugins.ParameterString, Description: "Comma-separated carbon copy addresses"},
+			{Name: "bcc", Type: plugins.ParameterString, Description: "Comma-separated blind carbon copy addresses"},
+			{Name: "from", Type: plugins.ParameterString, Description: "Sender address, defaulting to SMTP_FROM"},
+			{Name: "subject", Type: plugins.ParameterString, Description: "text/template template of the subject"},
+			{Name: "body", Type: plugins.ParameterString, Description: "text/template template of the plain text body"},
+			{Name: "html_body", Type: plugins.ParameterString, Description: "html/template template of the HTML body"},
+			{Name: "attachments", Type: plugins.ParameterString, Description: "Comma-separated http(s) URLs on SMTP_ATTACHMENT_ALLOWED_HOSTS or paths within SMTP_ATTACHMENT_DIR"},
+		},
+		AdditionalParameters: true,
+	}
+}
+
+func (e *Email) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	if e.Config == nil {
+		var cfg Config
+		if err := envconfig.Process("", &cfg); err != nil {
+			return plugins.Result{}, fmt.Errorf("failed to load SMTP configuration: %w", err)
+		}
+		e.Config = &cfg
+	}
+	cfg := e.Config
+
+	msg, err := newMessage(ctx, cfg, task.Parameters)
+	if err != nil {
+		return plugins.Result{}, err
+	}
+	data, err := msg.bytes()
+	if err != nil {
+		return plugins.Result{}, err
+	}
+
+	task.Logger.Info("Sending email", "recipients", len(msg.recipients()), "attachments", len(msg.attachments), "message_id", msg.id)
+	if err := send(ctx, cfg, msg.from.Address, msg.recipients(), data); err != nil {
+		return plugins.Result{}, err
+	}
+
+	return plugins.Result{
+		Outputs: map[string]string{"message_id": msg.id},
+		Metrics: map[string]float64{
+			"recipients": float64(len(msg.recipients())),
+			"size_bytes": float64(len(data)),
+		},
+	}, nil
+}
+
+// send delivers data from the sender to the recipients through the configured SMTP server.
+func send(ctx context.Context, cfg *Config, from string, recipients []string, data []byte) error {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+	// Abort the conversation when the task is cancelled or times out
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	err = converse(conn, cfg, from, recipients, data)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return fmt.Errorf("failed to send email: %w", ctxErr)
+	}
+	return err
 }
 
-func (e *Email) Run(parameters map[string]string) error {
-	// Get timeout from TASK_TIME_OUT env variable or use 10 seconds as default
-	timeout := 10
-	if timeoutStr := os.Getenv("TASK_TIME_OUT"); timeoutStr != "" {
-		if parsedTimeout, err := strconv.Atoi(timeoutStr); err == nil {
-			timeout = parsedTimeout
+// converse runs the SMTP conversation that delivers one message over conn.
+func converse(conn net.Conn, cfg *Config, from string, recipients []string, data []byte) error {
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer c.Close()
+
+	switch ok, _ := c.Extension("STARTTLS"); {
+	case ok && cfg.StartTLS != StartTLSDisabled:
+		tlsConfig := &tls.Config{ServerName: cfg.Host}
+		if cfg.TLSConfig != nil {
+			tlsConfig = cfg.TLSConfig.Clone()
+			if tlsConfig.ServerName == "" {
+				tlsConfig.ServerName = cfg.Host
+			}
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	case !ok && cfg.StartTLS == StartTLSRequired:
+		return fmt.Errorf("SMTP server %s does not support STARTTLS", cfg.Host)
+	}
+
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
 		}
 	}
-	time.Sleep(time.Duration(timeout) * time.Second)
-	return nil
+
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message data: %w", err)
+	}
+	return c.Quit()
+}
+
+// parseAddressList parses a comma-separated list of addresses, which may be empty.
+func parseAddressList(param, list string) ([]*mail.Address, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	addrs, err := mail.ParseAddressList(list)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s parameter: %w", param, err)
+	}
+	return addrs, nil
 }
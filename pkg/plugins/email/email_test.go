@@ -1,31 +1,413 @@
 package email
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"task/pkg/plugins"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestEmail_Run(t *testing.T) {
-	e := &Email{}
+// smtpStub is an in-process SMTP server that records the messages it receives.
+type smtpStub struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	// stall makes the server stop responding after the greeting.
+	stall bool
 
-	testCases := []struct {
-		name       string
-		parameters map[string]string
-	}{
-		{"Valid parameters", map[string]string{"key": "value"}},
-		{"Empty parameters", map[string]string{}},
-		{"Multiple parameters", map[string]string{"key1": "value1", "key2": "value2"}},
-	}
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpts    []string
+	data     []byte
+	startTLS bool
+}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
+// newSMTPStub starts an SMTP stub that offers STARTTLS and AUTH PLAIN when tlsConfig is set.
+func newSMTPStub(t *testing.T, tlsConfig *tls.Config) *smtpStub {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpStub{listener: l, tlsConfig: tlsConfig}
+	t.Cleanup(func() { l.Close() })
 
-			err := e.Run(tc.parameters)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
 
-			// Test for no error
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 stub ESMTP")
+	if s.stall {
+		io.Copy(io.Discard, conn)
+		return
+	}
+
+	secure := false
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if s.tlsConfig != nil && !secure {
+				tp.PrintfLine("250-stub")
+				tp.PrintfLine("250-STARTTLS")
+			} else {
+				tp.PrintfLine("250-stub")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, tp, secure = tlsConn, textproto.NewConn(tlsConn), true
+			s.mu.Lock()
+			s.startTLS = true
+			s.mu.Unlock()
+		case "AUTH":
+			_, initial, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(initial)
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			s.mu.Unlock()
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			s.mu.Unlock()
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 send data")
+			data, err := tp.ReadDotBytes()
 			if err != nil {
-				t.Errorf("Expected no error, got %v", err)
+				return
+			}
+			s.mu.Lock()
+			s.data = data
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+// message parses the last message received by the stub.
+func (s *smtpStub) message(t *testing.T) *mail.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, err := mail.ReadMessage(strings.NewReader(string(s.data)))
+	require.NoError(t, err)
+	return msg
+}
+
+// newTLSConfigs returns a server TLS config with a self-signed certificate for 127.0.0.1
+// and a client TLS config that trusts it.
+func newTLSConfigs(t *testing.T) (server, client *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtp stub"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		&tls.Config{RootCAs: pool}
+}
+
+func newTaskContext(parameters map[string]string) plugins.TaskContext {
+	return plugins.TaskContext{TaskID: 1, Attempt: 1, Parameters: parameters, Logger: slog.Default()}
+}
+
+func TestEmail_Run(t *testing.T) {
+	serverTLS, clientTLS := newTLSConfigs(t)
+	stub := newSMTPStub(t, serverTLS)
+
+	e := &Email{Config: &Config{
+		Host:      "127.0.0.1",
+		Port:      stub.port(),
+		Username:  "mailer",
+		Password:  "secret",
+		From:      "Task Service <tasks@example.com>",
+		StartTLS:  StartTLSRequired,
+		Timeout:   5 * time.Second,
+		TLSConfig: clientTLS,
+	}}
+
+	result, err := e.Run(context.Background(), newTaskContext(map[string]string{
+		"to":        "Ada <ada@example.com>, bob@example.com",
+		"cc":        "carol@example.com",
+		"bcc":       "audit@example.com",
+		"name":      "Ada & Bob",
+		"subject":   "Report for {{.name}}",
+		"body":      "Hello {{.name}}",
+		"html_body": "<p>Hello {{.name}}</p>",
+	}))
+	require.NoError(t, err)
+
+	stub.mu.Lock()
+	assert.True(t, stub.startTLS)
+	assert.Equal(t, "\x00mailer\x00secret", stub.auth)
+	assert.Equal(t, "tasks@example.com", stub.from)
+	assert.Equal(t, []string{"ada@example.com", "bob@example.com", "carol@example.com", "audit@example.com"}, stub.rcpts)
+	stub.mu.Unlock()
+
+	msg := stub.message(t)
+	assert.Equal(t, result.Outputs["message_id"], msg.Header.Get("Message-ID"))
+	assert.Regexp(t, `^<[0-9a-f]{32}\.\d+@example\.com>$`, result.Outputs["message_id"])
+	assert.Equal(t, float64(4), result.Metrics["recipients"])
+	assert.Equal(t, `"Ada" <ada@example.com>, <bob@example.com>`, msg.Header.Get("To"))
+	assert.Equal(t, "<carol@example.com>", msg.Header.Get("Cc"))
+	assert.Empty(t, msg.Header.Get("Bcc"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Report for Ada & Bob", subject)
+
+	parts := readParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	assert.Equal(t, "Hello Ada & Bob", parts["text/plain"])
+	assert.Equal(t, "<p>Hello Ada &amp; Bob</p>", parts["text/html"])
+}
+
+func TestEmail_RunAttachments(t *testing.T) {
+	stub := newSMTPStub(t, nil)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "report.csv"), []byte("id,total\n1,42\n"), 0o600))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer server.Close()
+
+	e := &Email{Config: &Config{
+		Host:                   "127.0.0.1",
+		Port:                   stub.port(),
+		From:                   "tasks@example.com",
+		StartTLS:               StartTLSOpportunistic,
+		AttachmentDir:          dir,
+		AttachmentAllowedHosts: []string{"127.0.0.1"},
+		MaxAttachmentBytes:     1024,
+	}}
+
+	_, err := e.Run(context.Background(), newTaskContext(map[string]string{
+		"to":          "ada@example.com",
+		"subject":     "Monthly report",
+		"body":        "See attached",
+		"attachments": "report.csv, " + server.URL + "/files/invoice.pdf",
+	}))
+	require.NoError(t, err)
+
+	msg := stub.message(t)
+	parts := readParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	assert.Equal(t, "See attached", parts["text/plain"])
+	assert.Equal(t, "id,total\n1,42\n", parts["report.csv"])
+	assert.Equal(t, "%PDF-1.4", parts["invoice.pdf"])
+}
+
+func TestEmail_RunErrors(t *testing.T) {
+	plain := newSMTPStub(t, nil)
+	stalled := newSMTPStub(t, nil)
+	stalled.stall = true
+	// Redirects to the same server by another host name
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1)+"/internal", http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	tests := []struct {
+		name    string
+		config  Config
+		params  map[string]string
+		wantErr string
+	}{
+		{
+			name:    "No recipients",
+			params:  map[string]string{"subject": "hi"},
+			wantErr: "no recipients",
+		},
+		{
+			name:    "Invalid sender",
+			config:  Config{From: "-"},
+			params:  map[string]string{"to": "ada@example.com", "from": ""},
+			wantErr: "invalid from parameter",
+		},
+		{
+			name:    "Missing template parameter",
+			params:  map[string]string{"to": "ada@example.com", "body": "Hello {{.name}}"},
+			wantErr: "failed to render body template",
+		},
+		{
+			name:    "Path attachment without attachment dir",
+			params:  map[string]string{"to": "ada@example.com", "attachments": "/etc/passwd"},
+			wantErr: "require SMTP_ATTACHMENT_DIR",
+		},
+		{
+			name:    "Path attachment outside attachment dir",
+			config:  Config{AttachmentDir: t.TempDir()},
+			params:  map[string]string{"to": "ada@example.com", "attachments": "../secret.txt"},
+			wantErr: "must be relative to SMTP_ATTACHMENT_DIR",
+		},
+		{
+			name:    "URL attachment without allowed hosts",
+			params:  map[string]string{"to": "ada@example.com", "attachments": redirect.URL + "/report.csv"},
+			wantErr: "require SMTP_ATTACHMENT_ALLOWED_HOSTS",
+		},
+		{
+			name:    "URL attachment from another host",
+			config:  Config{AttachmentAllowedHosts: []string{"files.example.com"}},
+			params:  map[string]string{"to": "ada@example.com", "attachments": redirect.URL + "/report.csv"},
+			wantErr: "host 127.0.0.1 is not in SMTP_ATTACHMENT_ALLOWED_HOSTS",
+		},
+		{
+			name:    "URL attachment redirected to another host",
+			config:  Config{AttachmentAllowedHosts: []string{"127.0.0.1"}},
+			params:  map[string]string{"to": "ada@example.com", "attachments": redirect.URL + "/report.csv"},
+			wantErr: "redirect to host localhost that is not in SMTP_ATTACHMENT_ALLOWED_HOSTS",
+		},
+		{
+			name:    "STARTTLS required but not offered",
+			config:  Config{StartTLS: StartTLSRequired},
+			params:  map[string]string{"to": "ada@example.com"},
+			wantErr: "does not support STARTTLS",
+		},
+		{
+			name:    "Server stops responding",
+			config:  Config{Port: stalled.port(), Timeout: 50 * time.Millisecond},
+			params:  map[string]string{"to": "ada@example.com"},
+			wantErr: context.DeadlineExceeded.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			cfg.Host = "127.0.0.1"
+			if cfg.Port == 0 {
+				cfg.Port = plain.port()
+			}
+			if cfg.From == "" {
+				cfg.From = "tasks@example.com"
+			}
+			if cfg.StartTLS == "" {
+				cfg.StartTLS = StartTLSDisabled
 			}
 
+			_, err := (&Email{Config: &cfg}).Run(context.Background(), newTaskContext(tt.params))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
 		})
 	}
 }
+
+func TestEmail_RunCancelled(t *testing.T) {
+	stub := newSMTPStub(t, nil)
+	stub.stall = true
+	e := &Email{Config: &Config{Host: "127.0.0.1", Port: stub.port(), From: "tasks@example.com", StartTLS: StartTLSDisabled}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := e.Run(ctx, newTaskContext(map[string]string{"to": "ada@example.com"}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected Run to return promptly after the deadline, took %v", time.Since(start))
+	}
+}
+
+// readParts decodes a MIME body into its leaf parts, keyed by attachment filename
+// or, for inline bodies, by media type.
+func readParts(t *testing.T, contentType string, body io.Reader) map[string]string {
+	parts := map[string]string{}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := io.ReadAll(quotedprintable.NewReader(body))
+		require.NoError(t, err)
+		parts[mediaType] = string(data)
+		return parts
+	}
+
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := r.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		require.NoError(t, err)
+
+		partType := part.Header.Get("Content-Type")
+		if filename := part.FileName(); filename != "" {
+			data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+			require.NoError(t, err)
+			parts[filename] = string(data)
+			continue
+		}
+		for k, v := range readParts(t, partType, part) {
+			parts[k] = v
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// message is an email ready to be encoded.
+type message struct {
+	id          string
+	from        *mail.Address
+	to          []*mail.Address
+	cc          []*mail.Address
+	bcc         []*mail.Address
+	subject     string
+	textBody    string
+	htmlBody    string
+	attachments []attachment
+
+	// altBoundary separates the text and HTML bodies of a message that has both.
+	altBoundary string
+}
+
+// attachment is a file attached to a message.
+type attachment struct {
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// newMessage builds the message described by the task parameters, rendering its
+// templates and fetching its attachments.
+func newMessage(ctx context.Context, cfg *Config, params map[string]string) (*message, error) {
+	var err error
+	msg := &message{}
+
+	from := params["from"]
+	if from == "" {
+		from = cfg.From
+	}
+	if from == "" {
+		return nil, errors.New("no sender: set the from parameter or SMTP_FROM")
+	}
+	if msg.from, err = mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid from parameter: %w", err)
+	}
+
+	if msg.to, err = parseAddressList("to", params["to"]); err != nil {
+		return nil, err
+	}
+	if msg.cc, err = parseAddressList("cc", params["cc"]); err != nil {
+		return nil, err
+	}
+	if msg.bcc, err = parseAddressList("bcc", params["bcc"]); err != nil {
+		return nil, err
+	}
+	if len(msg.recipients()) == 0 {
+		return nil, errors.New("no recipients: set at least one of the to, cc or bcc parameters")
+	}
+
+	if msg.subject, err = renderText("subject", params["subject"], params); err != nil {
+		return nil, err
+	}
+	if msg.textBody, err = renderText("body", params["body"], params); err != nil {
+		return nil, err
+	}
+	if msg.htmlBody, err = renderHTML("html_body", params["html_body"], params); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range strings.Split(params["attachments"], ",") {
+		if ref = strings.TrimSpace(ref); ref == "" {
+			continue
+		}
+		a, err := fetchAttachment(ctx, cfg, ref)
+		if err != nil {
+			return nil, err
+		}
+		msg.attachments = append(msg.attachments, a)
+	}
+
+	if msg.id, err = newMessageID(msg.from.Address); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// recipients returns the envelope recipients of the message, including blind copies.
+func (m *message) recipients() []string {
+	var rcpts []string
+	for _, list := range [][]*mail.Address{m.to, m.cc, m.bcc} {
+		for _, addr := range list {
+			rcpts = append(rcpts, addr.Address)
+		}
+	}
+	return rcpts
+}
+
+// bytes encodes the message in MIME format. Blind copy recipients are left out of the headers.
+func (m *message) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", m.from.String())
+	if len(m.to) > 0 {
+		header.Set("To", joinAddresses(m.to))
+	}
+	if len(m.cc) > 0 {
+		header.Set("Cc", joinAddresses(m.cc))
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", m.id)
+	header.Set("MIME-Version", "1.0")
+	m.altBoundary = multipart.NewWriter(io.Discard).Boundary()
+
+	if len(m.attachments) == 0 {
+		writeHeader(&buf, m.bodyHeader(header))
+		if err := m.writeBody(&buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	header.Set("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	writeHeader(&buf, header)
+
+	part, err := mixed.CreatePart(m.bodyHeader(textproto.MIMEHeader{}))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.writeBody(part); err != nil {
+		return nil, err
+	}
+	for _, a := range m.attachments {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, a.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// bodyHeader adds the content headers of the message body to header.
+func (m *message) bodyHeader(header textproto.MIMEHeader) textproto.MIMEHeader {
+	switch {
+	case m.htmlBody != "" && m.textBody != "":
+		header.Set("Content-Type", "multipart/alternative; boundary="+m.altBoundary)
+	case m.htmlBody != "":
+		header.Set("Content-Type", "text/html; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+	default:
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+	}
+	return header
+}
+
+// writeBody writes the message body described by bodyHeader to w.
+func (m *message) writeBody(w io.Writer) error {
+	if m.htmlBody == "" || m.textBody == "" {
+		return writeQuotedPrintable(w, m.textBody+m.htmlBody)
+	}
+
+	alt := multipart.NewWriter(w)
+	if err := alt.SetBoundary(m.altBoundary); err != nil {
+		return err
+	}
+	for _, body := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.textBody},
+		{"text/html; charset=utf-8", m.htmlBody},
+	} {
+		part, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeQuotedPrintable(part, body.content); err != nil {
+			return err
+		}
+	}
+	return alt.Close()
+}
+
+// renderText renders a text/template with the task parameters as its data.
+func renderText(name, text string, params map[string]string) (string, error) {
+	tmpl, err := texttemplate.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// renderHTML renders an html/template with the task parameters as its data,
+// escaping the parameter values for HTML.
+func renderHTML(name, text string, params map[string]string) (string, error) {
+	tmpl, err := htmltemplate.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// fetchAttachment loads an attachment referenced by an http(s) URL or by a path within cfg.AttachmentDir.
+func fetchAttachment(ctx context.Context, cfg *Config, ref string) (attachment, error) {
+	if u, err := url.Parse(ref); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return downloadAttachment(ctx, cfg, u)
+	}
+
+	if cfg.AttachmentDir == "" {
+		return attachment{}, fmt.Errorf("attachment %s: path attachments require SMTP_ATTACHMENT_DIR", ref)
+	}
+	if !filepath.IsLocal(ref) {
+		return attachment{}, fmt.Errorf("attachment %s: path must be relative to SMTP_ATTACHMENT_DIR", ref)
+	}
+	f, err := os.Open(filepath.Join(cfg.AttachmentDir, ref))
+	if err != nil {
+		return attachment{}, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	defer f.Close()
+
+	data, err := readLimited(f, cfg.MaxAttachmentBytes, ref)
+	if err != nil {
+		return attachment{}, err
+	}
+	return attachment{filename: filepath.Base(ref), contentType: contentType(ref, data), data: data}, nil
+}
+
+// downloadAttachment fetches an attachment over HTTP from one of cfg.AttachmentAllowedHosts.
+func downloadAttachment(ctx context.Context, cfg *Config, u *url.URL) (attachment, error) {
+	if len(cfg.AttachmentAllowedHosts) == 0 {
+		return attachment{}, fmt.Errorf("attachment %s: URL attachments require SMTP_ATTACHMENT_ALLOWED_HOSTS", u.Redacted())
+	}
+	if !slices.Contains(cfg.AttachmentAllowedHosts, u.Hostname()) {
+		return attachment{}, fmt.Errorf("attachment %s: host %s is not in SMTP_ATTACHMENT_ALLOWED_HOSTS", u.Redacted(), u.Hostname())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return attachment{}, fmt.Errorf("failed to create attachment request: %w", err)
+	}
+	// Redirects are only followed to the allowed hosts
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if !slices.Contains(cfg.AttachmentAllowedHosts, req.URL.Hostname()) {
+			return fmt.Errorf("redirect to host %s that is not in SMTP_ATTACHMENT_ALLOWED_HOSTS", req.URL.Hostname())
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return attachment{}, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return attachment{}, fmt.Errorf("failed to download attachment %s: %s", u, resp.Status)
+	}
+
+	data, err := readLimited(resp.Body, cfg.MaxAttachmentBytes, u.String())
+	if err != nil {
+		return attachment{}, err
+	}
+	filename := path.Base(u.Path)
+	if filename == "/" || filename == "." {
+		filename = "attachment"
+	}
+	ct := resp.Header.Get("Content-Type")
+	if ct == "" {
+		ct = contentType(filename, data)
+	}
+	return attachment{filename: filename, contentType: ct, data: data}, nil
+}
+
+// readLimited reads r, failing when it holds more than limit bytes. A limit of zero disables the check.
+func readLimited(r io.Reader, limit int64, ref string) ([]byte, error) {
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s: %w", ref, err)
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return nil, fmt.Errorf("attachment %s exceeds the limit of %d bytes", ref, limit)
+	}
+	return data, nil
+}
+
+// contentType guesses the MIME type of an attachment from its name and content.
+func contentType(name string, data []byte) string {
+	if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(data)
+}
+
+// newMessageID returns a unique Message-ID in the domain of the sender address.
+func newMessageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	return fmt.Sprintf("<%s.%d@%s>", hex.EncodeToString(b), time.Now().UnixNano(), domain), nil
+}
+
+func joinAddresses(addrs []*mail.Address) string {
+	s := make([]string, len(addrs))
+	for i, addr := range addrs {
+		s[i] = addr.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, key := range []string{"From", "To", "Cc", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "Content-Transfer-Encoding"} {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, text string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
@@ -0,0 +1,171 @@
+// Package external runs plugins as separate executables that the worker talks to
+// over gRPC on a unix socket, so that task types can be added without rebuilding the
+// worker and a plugin that crashes does not take the worker down with it.
+//
+// Workers call Load to discover the plugins in PLUGIN_DIR and register their task
+// types. Plugin executables call Serve from their main function:
+//
+//	func main() {
+//		if err := external.Serve("greet", &Greet{}); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package external
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"task/pkg/plugins"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+const (
+	// ProtocolVersion is the version of the plugin protocol implemented by this package.
+	// It is increased when a change to the protocol is not backward compatible.
+	ProtocolVersion = 1
+
+	// MagicCookieKey and MagicCookieValue are set in the environment of the plugins
+	// so that a plugin run by hand explains what it is instead of waiting for a worker.
+	MagicCookieKey   = "TASK_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "3f5e5b1c6fd84c2b8d0a6b0f5d47a7e1"
+
+	// ProtocolVersionsKey lists the protocol versions supported by the worker, separated by commas.
+	ProtocolVersionsKey = "TASK_PLUGIN_PROTOCOL_VERSIONS"
+	// SocketKey is the path of the unix socket the plugin must listen on.
+	SocketKey = "TASK_PLUGIN_SOCKET"
+)
+
+// supportedVersions are the protocol versions the worker can talk.
+var supportedVersions = []int{ProtocolVersion}
+
+// Config holds the worker settings of out-of-process plugins
+type Config struct {
+	// Dir is the directory the plugin executables are discovered from.
+	// Discovery is disabled when it is empty.
+	Dir string `envconfig:"PLUGIN_DIR"`
+	// StartTimeout bounds the time a plugin gets to complete the handshake.
+	StartTimeout time.Duration `envconfig:"PLUGIN_START_TIMEOUT" default:"10s"`
+	// HealthTimeout bounds the health check made before each run.
+	HealthTimeout time.Duration `envconfig:"PLUGIN_HEALTH_TIMEOUT" default:"5s"`
+	// StopTimeout is how long a plugin gets to exit once stopped before it is killed.
+	StopTimeout time.Duration `envconfig:"PLUGIN_STOP_TIMEOUT" default:"5s"`
+}
+
+// Host owns the plugin processes started by Discover.
+type Host struct {
+	socketDir string
+	processes []*process
+}
+
+// Load discovers the plugins in the directory configured by PLUGIN_DIR.
+// It returns an empty Host when PLUGIN_DIR is not set.
+func Load(ctx context.Context, logger *slog.Logger) (*Host, error) {
+	var cfg Config
+	if err := envconfig.Process("", &cfg); err != nil {
+		return nil, fmt.Errorf("failed to load plugin configuration: %w", err)
+	}
+	return Discover(ctx, cfg, logger)
+}
+
+// Discover starts every executable in cfg.Dir as a plugin and registers the task type
+// it implements. Plugins that fail to start, or implement a task type that is already
+// registered, are logged and skipped so that one broken plugin does not stop the worker.
+// The plugins keep running until the Host is closed and are restarted when they crash.
+func Discover(ctx context.Context, cfg Config, logger *slog.Logger) (*Host, error) {
+	h := &Host{}
+	if cfg.Dir == "" {
+		return h, nil
+	}
+
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+	h.socketDir, err = os.MkdirTemp("", "task-plugins-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin socket directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(cfg.Dir, entry.Name())
+		if !isExecutable(path, entry) {
+			continue
+		}
+		p := newProcess(path, filepath.Join(h.socketDir, fmt.Sprintf("%d.sock", len(h.processes))), cfg, logger)
+		taskType, err := h.register(ctx, p)
+		if err != nil {
+			logger.Error("Skipping plugin", "path", path, "error", err)
+			p.close()
+			continue
+		}
+		h.processes = append(h.processes, p)
+		logger.Info("Registered plugin", "path", path, "task_type", taskType)
+	}
+	return h, nil
+}
+
+// register starts the plugin and registers the task type it describes.
+func (h *Host) register(ctx context.Context, p *process) (string, error) {
+	if err := p.start(ctx); err != nil {
+		return "", err
+	}
+	taskType, schema, err := p.describe(ctx)
+	if err != nil {
+		return "", err
+	}
+	if plugins.IsRegistered(taskType) {
+		return "", fmt.Errorf("task type %s is already registered", taskType)
+	}
+	plugins.Register(taskType, func() plugins.Plugin {
+		return &plugin{process: p, schema: schema}
+	})
+	return taskType, nil
+}
+
+// Types returns the sorted task types of the discovered plugins.
+func (h *Host) Types() []string {
+	types := make([]string, 0, len(h.processes))
+	for _, p := range h.processes {
+		types = append(types, p.taskType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Close stops the plugins. Their task types stay registered but fail to run.
+func (h *Host) Close() error {
+	var wg sync.WaitGroup
+	for _, p := range h.processes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.close()
+		}()
+	}
+	wg.Wait()
+	if h.socketDir != "" {
+		return os.RemoveAll(h.socketDir)
+	}
+	return nil
+}
+
+// isExecutable reports whether the directory entry is a visible regular file that can be executed.
+func isExecutable(path string, entry os.DirEntry) bool {
+	if strings.HasPrefix(entry.Name(), ".") {
+		return false
+	}
+	// Follow symlinks so that plugins can be linked into the directory
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
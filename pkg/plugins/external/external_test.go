@@ -0,0 +1,294 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"task/pkg/plugins"
+	"testing"
+	"time"
+
+	pluginv1 "task/pkg/gen/plugin/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain makes the test binary act as a plugin when it is launched by writePlugin.
+func TestMain(m *testing.M) {
+	switch os.Getenv("EXTERNAL_TEST_MODE") {
+	case "":
+		os.Exit(m.Run())
+	case "serve":
+		if err := Serve(os.Getenv("EXTERNAL_TEST_TYPE"), testPlugin{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "future":
+		fmt.Printf("99|unix|%s\n", os.Getenv(SocketKey))
+		io.Copy(io.Discard, os.Stdin)
+	case "silent":
+		io.Copy(io.Discard, os.Stdin)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "missing configuration")
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+// testPlugin greets the name parameter, or misbehaves as asked by the action parameter.
+type testPlugin struct{}
+
+func (testPlugin) Schema() plugins.Schema {
+	return plugins.Schema{
+		Description: "Greets someone",
+		Parameters: []plugins.Parameter{
+			{Name: "name", Type: plugins.ParameterString, Required: true, Description: "Who to greet"},
+			{Name: "action", Type: plugins.ParameterString, Pattern: "fail|panic|crash|wait"},
+			{Name: "repeat", Type: plugins.ParameterInt, Default: "1"},
+		},
+	}
+}
+
+func (testPlugin) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	switch task.Parameters["action"] {
+	case "fail":
+		return plugins.Result{}, errors.New("greeting failed")
+	case "panic":
+		panic("boom")
+	case "crash":
+		os.Exit(3)
+	case "wait":
+		<-ctx.Done()
+		return plugins.Result{}, ctx.Err()
+	}
+
+	task.Logger.With("task_id", task.TaskID).WithGroup("greeting").Info("Greeting", "name", task.Parameters["name"])
+	return plugins.Result{
+		Outputs: map[string]string{"greeting": "hello " + task.Parameters["name"], "entrypoint": task.Entrypoint},
+		Data:    json.RawMessage(`{"greeted":true}`),
+		Metrics: map[string]float64{"attempt": float64(task.Attempt)},
+	}, nil
+}
+
+// writePlugin writes an executable to dir that runs the test binary as a plugin in the given mode.
+func writePlugin(t *testing.T, dir, name, mode, taskType string) string {
+	t.Helper()
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	script := fmt.Sprintf("#!/bin/sh\nEXTERNAL_TEST_MODE=%s EXTERNAL_TEST_TYPE=%s exec %q\n", mode, taskType, exe)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+// typeSeq keeps the task types registered by the tests unique when they are run repeatedly.
+var typeSeq atomic.Int64
+
+// uniqueType returns a task type named after name that is not registered yet.
+func uniqueType(name string) string {
+	return fmt.Sprintf("ext-%s-%d", name, typeSeq.Add(1))
+}
+
+func newTestConfig(dir string) Config {
+	return Config{Dir: dir, StartTimeout: 10 * time.Second, HealthTimeout: 5 * time.Second, StopTimeout: 5 * time.Second}
+}
+
+// discover discovers a single plugin serving a new task type named after name.
+func discover(t *testing.T, name string) (*Host, string) {
+	t.Helper()
+	taskType := uniqueType(name)
+	dir := t.TempDir()
+	writePlugin(t, dir, "greet", "serve", taskType)
+
+	h, err := Discover(context.Background(), newTestConfig(dir), slog.Default())
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+	require.Equal(t, []string{taskType}, h.Types())
+	return h, taskType
+}
+
+func newTaskContext(params map[string]string) plugins.TaskContext {
+	return plugins.TaskContext{TaskID: 1, Attempt: 2, Parameters: params, Entrypoint: "greet", Logger: slog.Default()}
+}
+
+func runPlugin(t *testing.T, ctx context.Context, taskType string, task plugins.TaskContext) (plugins.Result, error) {
+	t.Helper()
+	plugin, err := plugins.NewPlugin(taskType)
+	require.NoError(t, err)
+	return plugin.Run(ctx, task)
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	taskType, hidden, taken := uniqueType("discover"), uniqueType("hidden"), uniqueType("taken")
+	writePlugin(t, dir, "greet", "serve", taskType)
+	writePlugin(t, dir, ".hidden", "serve", hidden)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("plugins"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "lib"), 0o755))
+	// Plugins that fail to start or implement a registered task type are skipped
+	writePlugin(t, dir, "broken", "fail", "")
+	plugins.Register(taken, func() plugins.Plugin { return testPlugin{} })
+	writePlugin(t, dir, "taken", "serve", taken)
+
+	h, err := Discover(context.Background(), newTestConfig(dir), slog.Default())
+	require.NoError(t, err)
+	defer h.Close()
+
+	assert.Equal(t, []string{taskType}, h.Types())
+	assert.False(t, plugins.IsRegistered(hidden))
+
+	schema, err := plugins.Describe(taskType)
+	require.NoError(t, err)
+	assert.Equal(t, testPlugin{}.Schema(), schema)
+}
+
+func TestDiscoverDisabled(t *testing.T) {
+	h, err := Discover(context.Background(), Config{}, slog.Default())
+	require.NoError(t, err)
+	assert.Empty(t, h.Types())
+	assert.NoError(t, h.Close())
+
+	_, err = Discover(context.Background(), Config{Dir: filepath.Join(t.TempDir(), "missing")}, slog.Default())
+	assert.ErrorContains(t, err, "failed to read plugin directory")
+}
+
+func TestRun(t *testing.T) {
+	_, taskType := discover(t, "run")
+
+	task := newTaskContext(map[string]string{"name": "Ada"})
+	var buf bytes.Buffer
+	task.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+	result, err := runPlugin(t, context.Background(), taskType, task)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"greeting": "hello Ada", "entrypoint": "greet"}, result.Outputs)
+	assert.JSONEq(t, `{"greeted":true}`, string(result.Data))
+	assert.Equal(t, map[string]float64{"attempt": 2}, result.Metrics)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "Greeting", record["msg"])
+	assert.Equal(t, "1", record["task_id"])
+	assert.Equal(t, "Ada", record["greeting.name"])
+}
+
+func TestRunErrors(t *testing.T) {
+	_, taskType := discover(t, "errors")
+	run := func(ctx context.Context, action string) error {
+		_, err := runPlugin(t, ctx, taskType, newTaskContext(map[string]string{"name": "Ada", "action": action}))
+		return err
+	}
+
+	t.Run("Task error", func(t *testing.T) {
+		assert.EqualError(t, run(context.Background(), "fail"), "greeting failed")
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		assert.EqualError(t, run(context.Background(), "panic"), "plugin panicked: boom")
+		// The plugin keeps serving
+		assert.NoError(t, run(context.Background(), ""))
+	})
+
+	t.Run("Crash", func(t *testing.T) {
+		assert.EqualError(t, run(context.Background(), "crash"), "plugin exited while running the task: exit status 3")
+		// The plugin is restarted for the next run
+		assert.NoError(t, run(context.Background(), ""))
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, run(ctx, "wait"), context.DeadlineExceeded)
+		assert.NoError(t, run(context.Background(), ""))
+	})
+}
+
+func TestClose(t *testing.T) {
+	h, taskType := discover(t, "close")
+	require.NoError(t, h.Close())
+
+	_, err := runPlugin(t, context.Background(), taskType, newTaskContext(map[string]string{"name": "Ada"}))
+	assert.ErrorIs(t, err, errClosed)
+}
+
+func TestHandshake(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr string
+	}{
+		{mode: "future", wantErr: "plugin uses unsupported protocol version 99, supported versions are 1"},
+		{mode: "silent", wantErr: "plugin did not complete the handshake within 500ms"},
+		{mode: "fail", wantErr: "plugin exited before the handshake: exit status 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writePlugin(t, dir, "plugin", tt.mode, "")
+			cfg := newTestConfig(dir)
+			cfg.StartTimeout = 500 * time.Millisecond
+
+			p := newProcess(path, filepath.Join(dir, "plugin.sock"), cfg, slog.Default())
+			err := p.start(context.Background())
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, p.current)
+		})
+	}
+}
+
+func TestServeNotLaunched(t *testing.T) {
+	t.Setenv(MagicCookieKey, "")
+	assert.ErrorIs(t, Serve("greet", testPlugin{}), ErrNotLaunched)
+}
+
+func TestNegotiate(t *testing.T) {
+	assert.NoError(t, negotiate("1"))
+	assert.NoError(t, negotiate("2, 1"))
+	assert.EqualError(t, negotiate("2,3"), `the worker supports plugin protocol versions "2,3" but the plugin uses version 1`)
+	assert.Error(t, negotiate(""))
+}
+
+func TestStreamHandler(t *testing.T) {
+	var records []*pluginv1.LogRecord
+	logger := slog.New(&streamHandler{send: func(resp *pluginv1.RunResponse) error {
+		records = append(records, resp.GetLog())
+		return nil
+	}})
+
+	logger.With("task_id", 7).WithGroup("http").With("method", "GET").Warn("Request failed", "status", 503, slog.Group("retry", "after", time.Second))
+	logger.Debug("Done", slog.Group("empty"))
+
+	require.Len(t, records, 2)
+	assert.Equal(t, int32(slog.LevelWarn), records[0].Level)
+	assert.Equal(t, "Request failed", records[0].Message)
+	assert.Equal(t, map[string]string{
+		"task_id":          "7",
+		"http.method":      "GET",
+		"http.status":      "503",
+		"http.retry.after": "1s",
+	}, records[0].Attrs)
+	assert.Equal(t, int32(slog.LevelDebug), records[1].Level)
+	assert.Empty(t, records[1].Attrs)
+}
+
+func TestConvertSchema(t *testing.T) {
+	schema := testPlugin{}.Schema()
+	schema.Parameters = append(schema.Parameters, plugins.Parameter{Name: "token", Type: plugins.ParameterString, Secret: true})
+	assert.Equal(t, schema, convertSchemaFromProto(convertSchemaToProto(schema)))
+
+	// Types added by newer plugins are treated as strings
+	resp := &pluginv1.DescribeResponse{Parameters: []*pluginv1.Parameter{{Name: "when", Type: pluginv1.ParameterType(42)}}}
+	assert.Equal(t, plugins.ParameterString, convertSchemaFromProto(resp).Parameters[0].Type)
+	assert.True(t, strings.HasPrefix(pluginv1.PluginService_ServiceDesc.ServiceName, "plugin.v1."))
+}
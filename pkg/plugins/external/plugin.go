@@ -0,0 +1,94 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	pluginv1 "task/pkg/gen/plugin/v1"
+	"task/pkg/plugins"
+)
+
+// plugin runs tasks in a plugin process.
+type plugin struct {
+	process *process
+	schema  plugins.Schema
+}
+
+// Schema returns the schema the plugin described when it was discovered.
+func (p *plugin) Schema() plugins.Schema {
+	return p.schema
+}
+
+func (p *plugin) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	inst, err := p.process.instance(ctx)
+	if err != nil {
+		return plugins.Result{}, err
+	}
+
+	// Cancel the run once ctx is done rather than passing its deadline to the plugin,
+	// so that ctx is always done by the time the plugin sees the cancellation
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	stream, err := inst.client.Run(runCtx, &pluginv1.RunRequest{
+		TaskId:     task.TaskID,
+		Attempt:    int32(task.Attempt),
+		Parameters: task.Parameters,
+		BaseImage:  task.BaseImage,
+		Entrypoint: task.Entrypoint,
+		Args:       task.Args,
+		Env:        task.Env,
+	})
+	if err != nil {
+		return plugins.Result{}, fmt.Errorf("failed to run plugin: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return plugins.Result{}, ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return plugins.Result{}, errors.New("plugin ended the run without a result")
+			}
+			if exitErr := inst.exitError(); exitErr != nil {
+				return plugins.Result{}, exitErr
+			}
+			return plugins.Result{}, fmt.Errorf("plugin run failed: %w", err)
+		}
+
+		switch event := resp.Event.(type) {
+		case *pluginv1.RunResponse_Log:
+			logRecord(ctx, task.Logger, event.Log)
+		case *pluginv1.RunResponse_Result:
+			if event.Result.Error != "" {
+				if ctx.Err() != nil {
+					return plugins.Result{}, ctx.Err()
+				}
+				return plugins.Result{}, errors.New(event.Result.Error)
+			}
+			return plugins.Result{Outputs: event.Result.Outputs, Data: event.Result.Data, Metrics: event.Result.Metrics}, nil
+		}
+	}
+}
+
+// logRecord logs a record of the plugin with the task logger.
+func logRecord(ctx context.Context, logger *slog.Logger, record *pluginv1.LogRecord) {
+	keys := make([]string, 0, len(record.Attrs))
+	for key := range record.Attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, len(keys))
+	for i, key := range keys {
+		attrs[i] = slog.String(key, record.Attrs[key])
+	}
+	logger.LogAttrs(ctx, slog.Level(record.Level), record.Message, attrs...)
+}
@@ -0,0 +1,275 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pluginv1 "task/pkg/gen/plugin/v1"
+	"task/pkg/plugins"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// errClosed is returned when running a plugin after its Host was closed.
+var errClosed = errors.New("plugin host is closed")
+
+// process is a plugin executable, which is started again when it has crashed.
+type process struct {
+	path     string
+	socket   string
+	cfg      Config
+	logger   *slog.Logger
+	taskType string
+
+	mu      sync.Mutex
+	closed  bool
+	current *instance
+}
+
+// instance is a running plugin process.
+type instance struct {
+	cmd    *exec.Cmd
+	stdin  io.Closer
+	conn   *grpc.ClientConn
+	client pluginv1.PluginServiceClient
+	health healthpb.HealthClient
+	// exited is closed once the process has exited and waitErr is set.
+	exited  chan struct{}
+	waitErr error
+}
+
+func newProcess(path, socket string, cfg Config, logger *slog.Logger) *process {
+	return &process{
+		path:   path,
+		socket: socket,
+		cfg:    cfg,
+		logger: logger.With("plugin", path),
+	}
+}
+
+// start launches the plugin and waits for the handshake and a successful health check.
+// The caller must hold p.mu or own p exclusively.
+func (p *process) start(ctx context.Context) error {
+	os.Remove(p.socket)
+
+	cmd := exec.Command(p.path)
+	cmd.Env = append(os.Environ(),
+		MagicCookieKey+"="+MagicCookieValue,
+		ProtocolVersionsKey+"="+joinVersions(supportedVersions),
+		SocketKey+"="+p.socket,
+	)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stdin: %w", err)
+	}
+	stdout, stdoutW := io.Pipe()
+	stderr, stderrW := io.Pipe()
+	cmd.Stdout, cmd.Stderr = stdoutW, stderrW
+	// Bounds the wait for the output of processes that the plugin left behind
+	cmd.WaitDelay = p.cfg.StopTimeout
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin: %w", err)
+	}
+	inst := &instance{cmd: cmd, stdin: stdin, exited: make(chan struct{})}
+	p.current = inst
+
+	go p.logOutput(bufio.NewScanner(stderr), stderr, "stderr")
+	handshake := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		if scanner.Scan() {
+			handshake <- scanner.Text()
+		}
+		close(handshake)
+		p.logOutput(scanner, stdout, "stdout")
+	}()
+	go func() {
+		inst.waitErr = cmd.Wait()
+		stdoutW.Close()
+		stderrW.Close()
+		close(inst.exited)
+	}()
+
+	if err := p.handshake(ctx, inst, handshake); err != nil {
+		p.stop()
+		return err
+	}
+	return nil
+}
+
+// handshake reads the handshake line of the plugin, connects to its socket and checks its health.
+func (p *process) handshake(ctx context.Context, inst *instance, lines <-chan string) error {
+	timer := time.NewTimer(p.cfg.StartTimeout)
+	defer timer.Stop()
+
+	var line string
+	select {
+	case l, ok := <-lines:
+		if !ok {
+			select {
+			case <-inst.exited:
+				return fmt.Errorf("plugin exited before the handshake: %v", inst.waitErr)
+			case <-timer.C:
+				return errors.New("plugin closed its stdout before the handshake")
+			}
+		}
+		line = l
+	case <-timer.C:
+		return fmt.Errorf("plugin did not complete the handshake within %s", p.cfg.StartTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	parts := strings.Split(line, "|")
+	if len(parts) != 3 {
+		return fmt.Errorf("plugin wrote an invalid handshake %q", line)
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil || !slices.Contains(supportedVersions, version) {
+		return fmt.Errorf("plugin uses unsupported protocol version %s, supported versions are %s", parts[0], joinVersions(supportedVersions))
+	}
+	if parts[1] != "unix" || parts[2] != p.socket {
+		return fmt.Errorf("plugin listens on %s %s instead of the unix socket %s", parts[1], parts[2], p.socket)
+	}
+
+	conn, err := grpc.NewClient("unix://"+p.socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to connect to plugin: %w", err)
+	}
+	inst.conn = conn
+	inst.client = pluginv1.NewPluginServiceClient(conn)
+	inst.health = healthpb.NewHealthClient(conn)
+	return p.checkHealth(ctx, inst)
+}
+
+// checkHealth checks that the plugin instance is running and serving.
+func (p *process) checkHealth(ctx context.Context, inst *instance) error {
+	select {
+	case <-inst.exited:
+		return fmt.Errorf("plugin exited: %v", inst.waitErr)
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.HealthTimeout)
+	defer cancel()
+	resp, err := inst.health.Check(ctx, &healthpb.HealthCheckRequest{Service: pluginv1.PluginService_ServiceDesc.ServiceName})
+	if err != nil {
+		return fmt.Errorf("plugin health check failed: %w", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("plugin is %s", resp.Status)
+	}
+	return nil
+}
+
+// describe returns the name and schema of the task type implemented by the plugin.
+func (p *process) describe(ctx context.Context) (string, plugins.Schema, error) {
+	resp, err := p.current.client.Describe(ctx, &pluginv1.DescribeRequest{})
+	if err != nil {
+		return "", plugins.Schema{}, fmt.Errorf("failed to describe plugin: %w", err)
+	}
+	if resp.TaskType == "" {
+		return "", plugins.Schema{}, errors.New("plugin did not name its task type")
+	}
+	p.taskType = resp.TaskType
+	return resp.TaskType, convertSchemaFromProto(resp), nil
+}
+
+// instance returns the running plugin instance, restarting the plugin when it is not healthy.
+func (p *process) instance(ctx context.Context) (*instance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errClosed
+	}
+	var err error
+	if p.current != nil {
+		if err = p.checkHealth(ctx, p.current); err == nil {
+			return p.current, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	p.logger.Warn("Restarting plugin", "error", err)
+	p.stop()
+	if err := p.start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to restart plugin: %w", err)
+	}
+	return p.current, nil
+}
+
+// stop closes the connection and stdin of the plugin, which makes it exit, and kills
+// it after StopTimeout. The caller must hold p.mu or own p exclusively.
+func (p *process) stop() {
+	inst := p.current
+	if inst == nil {
+		return
+	}
+	p.current = nil
+
+	if inst.conn != nil {
+		inst.conn.Close()
+	}
+	inst.stdin.Close()
+	select {
+	case <-inst.exited:
+	case <-time.After(p.cfg.StopTimeout):
+		p.logger.Warn("Killing plugin that did not stop", "timeout", p.cfg.StopTimeout)
+		inst.cmd.Process.Kill()
+		<-inst.exited
+	}
+	os.Remove(p.socket)
+}
+
+// close stops the plugin for good.
+func (p *process) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	p.stop()
+}
+
+// exitError returns why the instance exited if it exits shortly, so that a run that
+// failed because the plugin crashed reports the crash rather than the broken stream.
+func (inst *instance) exitError() error {
+	select {
+	case <-inst.exited:
+		return fmt.Errorf("plugin exited while running the task: %v", inst.waitErr)
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+// logOutput logs the lines the plugin writes to r, which scanner reads from.
+func (p *process) logOutput(scanner *bufio.Scanner, r io.Reader, stream string) {
+	for scanner.Scan() {
+		p.logger.Info("Plugin output", "stream", stream, "line", scanner.Text())
+	}
+	// Keep draining after a line that is too long, so that the plugin does not block
+	io.Copy(io.Discard, r)
+}
+
+func joinVersions(versions []int) string {
+	s := make([]string, len(versions))
+	for i, v := range versions {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,67 @@
+package external
+
+import (
+	pluginv1 "task/pkg/gen/plugin/v1"
+	"task/pkg/plugins"
+)
+
+// parameterTypes maps the parameter types of the plugins package to the plugin protocol.
+var parameterTypes = map[plugins.ParameterType]pluginv1.ParameterType{
+	plugins.ParameterString:   pluginv1.ParameterType_PARAMETER_TYPE_STRING,
+	plugins.ParameterInt:      pluginv1.ParameterType_PARAMETER_TYPE_INT,
+	plugins.ParameterFloat:    pluginv1.ParameterType_PARAMETER_TYPE_FLOAT,
+	plugins.ParameterBool:     pluginv1.ParameterType_PARAMETER_TYPE_BOOL,
+	plugins.ParameterDuration: pluginv1.ParameterType_PARAMETER_TYPE_DURATION,
+}
+
+// convertSchemaToProto converts a schema to a Describe response without the task type.
+func convertSchemaToProto(schema plugins.Schema) *pluginv1.DescribeResponse {
+	params := make([]*pluginv1.Parameter, len(schema.Parameters))
+	for i, p := range schema.Parameters {
+		params[i] = &pluginv1.Parameter{
+			Name:         p.Name,
+			Type:         parameterTypes[p.Type],
+			Required:     p.Required,
+			DefaultValue: p.Default,
+			Pattern:      p.Pattern,
+			Secret:       p.Secret,
+			Description:  p.Description,
+		}
+	}
+	return &pluginv1.DescribeResponse{
+		Description:          schema.Description,
+		Parameters:           params,
+		AdditionalParameters: schema.AdditionalParameters,
+	}
+}
+
+// convertSchemaFromProto converts a Describe response to a schema.
+// Parameters of an unspecified or unknown type are strings.
+func convertSchemaFromProto(resp *pluginv1.DescribeResponse) plugins.Schema {
+	types := make(map[pluginv1.ParameterType]plugins.ParameterType, len(parameterTypes))
+	for t, pt := range parameterTypes {
+		types[pt] = t
+	}
+
+	params := make([]plugins.Parameter, len(resp.Parameters))
+	for i, p := range resp.Parameters {
+		t, ok := types[p.Type]
+		if !ok {
+			t = plugins.ParameterString
+		}
+		params[i] = plugins.Parameter{
+			Name:        p.Name,
+			Type:        t,
+			Description: p.Description,
+			Required:    p.Required,
+			Default:     p.DefaultValue,
+			Pattern:     p.Pattern,
+			Secret:      p.Secret,
+		}
+	}
+	return plugins.Schema{
+		Description:          resp.Description,
+		Parameters:           params,
+		AdditionalParameters: resp.AdditionalParameters,
+	}
+}
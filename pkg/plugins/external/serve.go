@@ -0,0 +1,210 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	pluginv1 "task/pkg/gen/plugin/v1"
+	"task/pkg/plugins"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ErrNotLaunched is returned by Serve when the executable was not launched by a worker.
+var ErrNotLaunched = errors.New("this executable is a task plugin: copy it to the PLUGIN_DIR of a worker instead of running it")
+
+// Serve serves plugin as the implementation of the task type to the worker that
+// launched the executable. It returns once the worker stops the plugin, by closing
+// its standard input or sending SIGTERM.
+func Serve(taskType string, plugin plugins.Plugin) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return ErrNotLaunched
+	}
+	if err := negotiate(os.Getenv(ProtocolVersionsKey)); err != nil {
+		return err
+	}
+	socket := os.Getenv(SocketKey)
+	if socket == "" {
+		return fmt.Errorf("%s is not set", SocketKey)
+	}
+
+	// The worker decides when the plugin stops, not an interrupt of its terminal
+	signal.Ignore(os.Interrupt)
+
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on plugin socket: %w", err)
+	}
+
+	srv := grpc.NewServer()
+	pluginv1.RegisterPluginServiceServer(srv, &pluginServer{taskType: taskType, plugin: plugin})
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(pluginv1.PluginService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthSrv)
+
+	go func() {
+		io.Copy(io.Discard, os.Stdin)
+		srv.Stop()
+	}()
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, syscall.SIGTERM)
+	go func() {
+		<-terminate
+		srv.Stop()
+	}()
+
+	// Complete the handshake now that the socket accepts connections
+	fmt.Printf("%d|unix|%s\n", ProtocolVersion, socket)
+	return srv.Serve(lis)
+}
+
+// negotiate checks that the worker supports the protocol version of the plugin.
+func negotiate(versions string) error {
+	for _, v := range strings.Split(versions, ",") {
+		if version, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && version == ProtocolVersion {
+			return nil
+		}
+	}
+	return fmt.Errorf("the worker supports plugin protocol versions %q but the plugin uses version %d", versions, ProtocolVersion)
+}
+
+// pluginServer serves a plugin over the plugin protocol.
+type pluginServer struct {
+	pluginv1.UnimplementedPluginServiceServer
+	taskType string
+	plugin   plugins.Plugin
+}
+
+func (s *pluginServer) Describe(ctx context.Context, req *pluginv1.DescribeRequest) (*pluginv1.DescribeResponse, error) {
+	schema := plugins.Schema{AdditionalParameters: true}
+	if d, ok := s.plugin.(plugins.Describer); ok {
+		schema = d.Schema()
+	}
+	resp := convertSchemaToProto(schema)
+	resp.TaskType = s.taskType
+	return resp, nil
+}
+
+func (s *pluginServer) Run(req *pluginv1.RunRequest, stream pluginv1.PluginService_RunServer) error {
+	// Log records are sent from any goroutine of the plugin
+	var mu sync.Mutex
+	send := func(resp *pluginv1.RunResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(resp)
+	}
+
+	result, err := s.run(stream.Context(), plugins.TaskContext{
+		TaskID:     req.TaskId,
+		Attempt:    int(req.Attempt),
+		Parameters: req.Parameters,
+		BaseImage:  req.BaseImage,
+		Entrypoint: req.Entrypoint,
+		Args:       req.Args,
+		Env:        req.Env,
+		Logger:     slog.New(&streamHandler{send: send}),
+	})
+
+	runResult := &pluginv1.RunResult{Outputs: result.Outputs, Data: result.Data, Metrics: result.Metrics}
+	if err != nil {
+		runResult = &pluginv1.RunResult{Error: err.Error()}
+	}
+	return send(&pluginv1.RunResponse{Event: &pluginv1.RunResponse_Result{Result: runResult}})
+}
+
+// run runs the plugin, turning a panic into an error so that the plugin keeps serving.
+func (s *pluginServer) run(ctx context.Context, task plugins.TaskContext) (result plugins.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin panicked: %v", r)
+		}
+	}()
+	return s.plugin.Run(ctx, task)
+}
+
+// streamHandler is a slog.Handler that sends the records to the worker, which
+// logs them with the task logger. The worker's logger decides which levels are kept.
+type streamHandler struct {
+	send   func(*pluginv1.RunResponse) error
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *streamHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *streamHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]string, len(h.attrs)+r.NumAttrs())
+	for _, a := range h.attrs {
+		addAttr(attrs, "", a)
+	}
+	prefix := ""
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(attrs, prefix, a)
+		return true
+	})
+	return h.send(&pluginv1.RunResponse{Event: &pluginv1.RunResponse_Log{Log: &pluginv1.LogRecord{
+		Level:   int32(r.Level),
+		Message: r.Message,
+		Attrs:   attrs,
+	}}})
+}
+
+func (h *streamHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefix := ""
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+	h2 := *h
+	h2.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		// Qualify the attributes with the current groups, which later groups do not apply to
+		a.Key = prefix + a.Key
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+func (h *streamHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(slices.Clone(h.groups), name)
+	return &h2
+}
+
+// addAttr adds the attribute to attrs, flattening groups into dotted keys.
+func addAttr(attrs map[string]string, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(attrs, prefix, ga)
+		}
+		return
+	}
+	attrs[prefix+a.Key] = a.Value.String()
+}
@@ -0,0 +1,346 @@
+package httprequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"task/pkg/plugins"
+	"text/template"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+var PLUGIN_NAME = "http_request"
+
+// headerParamPrefix prefixes the task parameters that set request headers.
+// header_X-Request-Source=tasks sets the X-Request-Source header.
+const headerParamPrefix = "header_"
+
+// Config holds the worker settings of the http_request plugin
+type Config struct {
+	// AllowedHosts restricts requests to these host names when not empty.
+	AllowedHosts []string `envconfig:"HTTP_REQUEST_ALLOWED_HOSTS"`
+	// MaxTimeout bounds the timeout parameter of each request.
+	MaxTimeout time.Duration `envconfig:"HTTP_REQUEST_MAX_TIMEOUT" default:"5m"`
+	// MaxOutputBytes is the length the response body is truncated to in the task output.
+	MaxOutputBytes int `envconfig:"HTTP_REQUEST_MAX_OUTPUT_BYTES" default:"4096"`
+	// Client sends the requests; http.DefaultClient is used when nil.
+	// Redirects are only followed to AllowedHosts either way.
+	Client *http.Client `ignored:"true"`
+}
+
+// HTTPRequest calls an HTTP endpoint. The task parameters are:
+//
+//   - url: the URL to call
+//   - method: the HTTP method, defaulting to GET
+//   - header_<Name>: sets the request header <Name>
+//   - body: a text/template template rendered with the task parameters
+//   - expected_status: comma-separated status codes or ranges that mean success, defaulting to 200-299
+//   - timeout: the timeout of each attempt, defaulting to 30s
+//   - retry_on_status: comma-separated status codes or ranges that are retried, such as 502-504
+//   - max_retries: how often a request is retried, defaulting to 3
+//   - retry_backoff: the delay before the first retry, doubled for each further retry, defaulting to 1s
+type HTTPRequest struct {
+	// Config is loaded from the environment on the first run when nil.
+	Config *Config
+}
+
+func init() {
+	plugins.Register(PLUGIN_NAME, func() plugins.Plugin { return &HTTPRequest{} })
+}
+
+// statusSetPattern matches comma-separated status codes or ranges.
+const statusSetPattern = `\s*[0-9]{3}(\s*-\s*[0-9]{3})?\s*(,\s*[0-9]{3}(\s*-\s*[0-9]{3})?\s*)*`
+
+// Schema declares the parameters of http_request. Parameters named header_<Name> set request
+// headers, and any other parameter can be used in the body template.
+func (h *HTTPRequest) Schema() plugins.Schema {
+	return plugins.Schema{
+		Description: "Calls an HTTP endpoint",
+		Parameters: []plugins.Parameter{
+			{Name: "url", Type: plugins.ParameterString, Required: true, Pattern: `https?://.+`, Description: "http:// or https:// URL to call"},
+			{Name: "method", Type: plugins.ParameterString, Default: "GET", Pattern: "[A-Za-z]+", Description: "HTTP method"},
+			{Name: "body", Type: plugins.ParameterString, Description: "text/template template of the request body"},
+			{Name: "expected_status", Type: plugins.ParameterString, Default: "200-299", Pattern: statusSetPattern, Description: "Status codes or ranges that mean success"},
+			{Name: "timeout", Type: plugins.ParameterDuration, Default: "30s", Description: "Timeout of each attempt"},
+			{Name: "retry_on_status", Type: plugins.ParameterString, Pattern: statusSetPattern, Description: "Status codes or ranges that are retried"},
+			{Name: "max_retries", Type: plugins.ParameterInt, Description: "Number of retries, defaulting to 3 when retry_on_status is set and 0 otherwise"},
+			{Name: "retry_backoff", Type: plugins.ParameterDuration, Default: "1s", Description: "Delay before the first retry, doubled for each further retry"},
+			{Name: headerParamPrefix + "Authorization", Type: plugins.ParameterString, Secret: true, Description: "Authorization header, such as secret://api-token"},
+			{Name: headerParamPrefix + "Proxy-Authorization", Type: plugins.ParameterString, Secret: true, Description: "Proxy-Authorization header, such as secret://proxy-token"},
+		},
+		AdditionalParameters: true,
+	}
+}
+
+// request is a parsed http_request task.
+type request struct {
+	method         string
+	url            *url.URL
+	header         http.Header
+	body           string
+	expectedStatus statusSet
+	retryOnStatus  statusSet
+	maxRetries     int
+	retryBackoff   time.Duration
+	timeout        time.Duration
+}
+
+func (h *HTTPRequest) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	if h.Config == nil {
+		var cfg Config
+		if err := envconfig.Process("", &cfg); err != nil {
+			return plugins.Result{}, fmt.Errorf("failed to load http_request configuration: %w", err)
+		}
+		h.Config = &cfg
+	}
+
+	req, err := parseRequest(h.Config, task.Parameters)
+	if err != nil {
+		return plugins.Result{}, err
+	}
+
+	start := time.Now()
+	var resp response
+	attempt := 0
+	for {
+		attempt++
+		task.Logger.Info("Sending HTTP request", "method", req.method, "url", req.url.Redacted(), "attempt", attempt)
+		resp, err = h.send(ctx, req)
+		retry := err != nil || req.retryOnStatus.contains(resp.status)
+		if !retry || attempt > req.maxRetries || ctx.Err() != nil {
+			break
+		}
+
+		backoff := req.retryBackoff * time.Duration(1<<(attempt-1))
+		task.Logger.Warn("Retrying HTTP request", "status", resp.status, "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return plugins.Result{}, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+	if err != nil {
+		return plugins.Result{}, err
+	}
+
+	result := plugins.Result{
+		Outputs: map[string]string{
+			"status_code":    strconv.Itoa(resp.status),
+			"body":           string(resp.body),
+			"body_truncated": strconv.FormatBool(resp.size > int64(len(resp.body))),
+		},
+		Metrics: map[string]float64{
+			"attempts":         float64(attempt),
+			"duration_seconds": time.Since(start).Seconds(),
+			"body_bytes":       float64(resp.size),
+		},
+	}
+	// A complete JSON response body is also kept as the data of the result
+	if resp.size == int64(len(resp.body)) && json.Valid(resp.body) {
+		result.Data = json.RawMessage(resp.body)
+	}
+	if !req.expectedStatus.contains(resp.status) {
+		return result, fmt.Errorf("unexpected status %d from %s %s", resp.status, req.method, req.url.Redacted())
+	}
+	return result, nil
+}
+
+// response is the outcome of one attempt at a request.
+type response struct {
+	status int
+	// body holds at most Config.MaxOutputBytes of the response body.
+	body []byte
+	// size is the full length of the response body.
+	size int64
+}
+
+// send performs one attempt at the request.
+func (h *HTTPRequest) send(ctx context.Context, req request) (response, error) {
+	ctx, cancel := context.WithTimeout(ctx, req.timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, req.url.String(), strings.NewReader(req.body))
+	if err != nil {
+		return response{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header = req.header.Clone()
+
+	client := *http.DefaultClient
+	if h.Config.Client != nil {
+		client = *h.Config.Client
+	}
+	client.CheckRedirect = h.checkRedirect(client.CheckRedirect)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return response{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Only a prefix of the body is kept; the rest is drained so the connection can be reused
+	var body bytes.Buffer
+	n, err := io.Copy(&body, io.LimitReader(resp.Body, int64(h.Config.MaxOutputBytes)))
+	if err == nil {
+		var rest int64
+		rest, err = io.Copy(io.Discard, resp.Body)
+		n += rest
+	}
+	if err != nil {
+		return response{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	return response{status: resp.StatusCode, body: body.Bytes(), size: n}, nil
+}
+
+// checkRedirect wraps the redirect policy of a client so that redirects are
+// only followed to the allowed hosts, like the request itself.
+func (h *HTTPRequest) checkRedirect(next func(req *http.Request, via []*http.Request) error) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if err := checkHost(h.Config, req.URL); err != nil {
+			return fmt.Errorf("redirect refused: %w", err)
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+// checkHost fails when u is not on one of the allowed hosts.
+func checkHost(cfg *Config, u *url.URL) error {
+	if len(cfg.AllowedHosts) > 0 && !slices.Contains(cfg.AllowedHosts, u.Hostname()) {
+		return fmt.Errorf("host %s is not in HTTP_REQUEST_ALLOWED_HOSTS", u.Hostname())
+	}
+	return nil
+}
+
+// parseRequest builds the request described by the task parameters.
+func parseRequest(cfg *Config, params map[string]string) (request, error) {
+	req := request{
+		method:       strings.ToUpper(params["method"]),
+		header:       http.Header{},
+		timeout:      30 * time.Second,
+		retryBackoff: time.Second,
+	}
+	if req.method == "" {
+		req.method = http.MethodGet
+	}
+
+	var err error
+	if params["url"] == "" {
+		return request{}, errors.New("the url parameter is required")
+	}
+	if req.url, err = url.Parse(params["url"]); err != nil {
+		return request{}, fmt.Errorf("invalid url parameter: %w", err)
+	}
+	if req.url.Scheme != "http" && req.url.Scheme != "https" {
+		return request{}, fmt.Errorf("invalid url parameter: scheme must be http or https")
+	}
+	if err := checkHost(cfg, req.url); err != nil {
+		return request{}, err
+	}
+
+	for key, value := range params {
+		if name, ok := strings.CutPrefix(key, headerParamPrefix); ok && name != "" {
+			req.header.Set(name, value)
+		}
+	}
+
+	tmpl, err := template.New("body").Option("missingkey=error").Parse(params["body"])
+	if err != nil {
+		return request{}, fmt.Errorf("invalid body template: %w", err)
+	}
+	var body strings.Builder
+	if err := tmpl.Execute(&body, params); err != nil {
+		return request{}, fmt.Errorf("failed to render body template: %w", err)
+	}
+	req.body = body.String()
+
+	expected := params["expected_status"]
+	if expected == "" {
+		expected = "200-299"
+	}
+	if req.expectedStatus, err = parseStatusSet("expected_status", expected); err != nil {
+		return request{}, err
+	}
+	if req.retryOnStatus, err = parseStatusSet("retry_on_status", params["retry_on_status"]); err != nil {
+		return request{}, err
+	}
+
+	// Requests are only retried when retry_on_status is set, unless max_retries says otherwise
+	if len(req.retryOnStatus) > 0 {
+		req.maxRetries = 3
+	}
+	if value := params["max_retries"]; value != "" {
+		if req.maxRetries, err = strconv.Atoi(value); err != nil || req.maxRetries < 0 {
+			return request{}, fmt.Errorf("invalid max_retries %q", value)
+		}
+	}
+	for _, d := range []struct {
+		param  string
+		target *time.Duration
+	}{
+		{"timeout", &req.timeout},
+		{"retry_backoff", &req.retryBackoff},
+	} {
+		value := params[d.param]
+		if value == "" {
+			continue
+		}
+		if *d.target, err = time.ParseDuration(value); err != nil || *d.target < 0 {
+			return request{}, fmt.Errorf("invalid %s %q", d.param, value)
+		}
+	}
+	// A zero timeout would expire every attempt before it is sent
+	if req.timeout == 0 {
+		return request{}, fmt.Errorf("invalid timeout %q: must be positive", params["timeout"])
+	}
+	if cfg.MaxTimeout > 0 && req.timeout > cfg.MaxTimeout {
+		req.timeout = cfg.MaxTimeout
+	}
+	return req, nil
+}
+
+// statusSet is a set of HTTP status code ranges.
+type statusSet [][2]int
+
+// parseStatusSet parses a comma-separated list of status codes and ranges such as "200,202-204".
+func parseStatusSet(param, value string) (statusSet, error) {
+	var set statusSet
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(item, "-")
+		if !isRange {
+			high = low
+		}
+		lo, err1 := strconv.Atoi(strings.TrimSpace(low))
+		hi, err2 := strconv.Atoi(strings.TrimSpace(high))
+		if err1 != nil || err2 != nil || lo < 100 || hi > 599 || lo > hi {
+			return nil, fmt.Errorf("invalid %s %q: expected status codes or ranges such as 200,202-204", param, item)
+		}
+		set = append(set, [2]int{lo, hi})
+	}
+	return set, nil
+}
+
+func (s statusSet) contains(status int) bool {
+	for _, r := range s {
+		if status >= r[0] && status <= r[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package httprequest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"task/pkg/plugins"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTaskContext(parameters map[string]string) plugins.TaskContext {
+	return plugins.TaskContext{TaskID: 1, Attempt: 1, Parameters: parameters, Logger: slog.Default()}
+}
+
+func newTestPlugin() *HTTPRequest {
+	return &HTTPRequest{Config: &Config{MaxTimeout: time.Minute, MaxOutputBytes: 16}}
+}
+
+func TestRun(t *testing.T) {
+	var got struct {
+		method, contentType, auth, body string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.method, got.contentType, got.auth, got.body = r.Method, r.Header.Get("Content-Type"), r.Header.Get("Authorization"), string(body)
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"job":"a1b2c3","queued":true,"position":12}`)
+	}))
+	defer server.Close()
+
+	result, err := newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{
+		"url":                  server.URL + "/jobs",
+		"method":               "post",
+		"header_Content-Type":  "application/json",
+		"header_Authorization": "Bearer token",
+		"report":               "weekly",
+		"body":                 `{"report":"{{.report}}"}`,
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, got.method)
+	assert.Equal(t, "application/json", got.contentType)
+	assert.Equal(t, "Bearer token", got.auth)
+	assert.Equal(t, `{"report":"weekly"}`, got.body)
+
+	assert.Equal(t, "202", result.Outputs["status_code"])
+	assert.Equal(t, `{"job":"a1b2c3",`, result.Outputs["body"])
+	assert.Equal(t, "true", result.Outputs["body_truncated"])
+	assert.Nil(t, result.Data)
+	assert.Equal(t, float64(44), result.Metrics["body_bytes"])
+	assert.Equal(t, float64(1), result.Metrics["attempts"])
+}
+
+func TestRunJSONData(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	body = `{"ok":true}`
+	result, err := newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{"url": server.URL}))
+	require.NoError(t, err)
+	assert.JSONEq(t, body, string(result.Data))
+
+	body = "ok"
+	result, err = newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{"url": server.URL}))
+	require.NoError(t, err)
+	assert.Nil(t, result.Data)
+}
+
+func TestRunExpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "not found")
+	}))
+	defer server.Close()
+
+	result, err := newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{"url": server.URL}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 404")
+	assert.Equal(t, "404", result.Outputs["status_code"])
+	assert.Equal(t, "not found", result.Outputs["body"])
+	assert.Equal(t, "false", result.Outputs["body_truncated"])
+
+	_, err = newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{"url": server.URL, "expected_status": "200,404"}))
+	assert.NoError(t, err)
+}
+
+func TestRunRetryOnStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	result, err := newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{
+		"url":             server.URL,
+		"retry_on_status": "502-504",
+		"retry_backoff":   "1ms",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, float64(3), result.Metrics["attempts"])
+	assert.Equal(t, "ok", result.Outputs["body"])
+
+	// Retries stop after max_retries and the last status is reported
+	calls.Store(0)
+	_, err = newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{
+		"url":             server.URL,
+		"retry_on_status": "503",
+		"retry_backoff":   "1ms",
+		"max_retries":     "1",
+	}))
+	assert.ErrorContains(t, err, "unexpected status 503")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRunTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := newTestPlugin().Run(context.Background(), newTaskContext(map[string]string{"url": server.URL, "timeout": "20ms"}))
+	assert.ErrorContains(t, err, "context deadline exceeded")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRunRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/report", http.StatusFound)
+		case "/internal":
+			// The same server by a host name that is not allowed
+			http.Redirect(w, r, strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1)+"/report", http.StatusFound)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	h := newTestPlugin()
+	h.Config.AllowedHosts = []string{"127.0.0.1"}
+
+	result, err := h.Run(context.Background(), newTaskContext(map[string]string{"url": server.URL + "/moved"}))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Outputs["body"])
+
+	_, err = h.Run(context.Background(), newTaskContext(map[string]string{"url": server.URL + "/internal"}))
+	assert.ErrorContains(t, err, "redirect refused: host localhost is not in HTTP_REQUEST_ALLOWED_HOSTS")
+}
+
+func TestParseRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"Missing URL", map[string]string{}, "the url parameter is required"},
+		{"Unsupported scheme", map[string]string{"url": "file:///etc/passwd"}, "scheme must be http or https"},
+		{"Host not allowed", map[string]string{"url": "http://metadata.internal/"}, "not in HTTP_REQUEST_ALLOWED_HOSTS"},
+		{"Invalid expected status", map[string]string{"url": "http://api.internal/", "expected_status": "2xx"}, "invalid expected_status"},
+		{"Invalid status range", map[string]string{"url": "http://api.internal/", "retry_on_status": "504-502"}, "invalid retry_on_status"},
+		{"Invalid timeout", map[string]string{"url": "http://api.internal/", "timeout": "soon"}, "invalid timeout"},
+		{"Zero timeout", map[string]string{"url": "http://api.internal/", "timeout": "0s"}, "must be positive"},
+		{"Missing template parameter", map[string]string{"url": "http://api.internal/", "body": "{{.id}}"}, "failed to render body template"},
+	}
+
+	cfg := &Config{AllowedHosts: []string{"api.internal"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRequest(cfg, tt.params)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	req, err := parseRequest(&Config{MaxTimeout: time.Second}, map[string]string{"url": "http://api.internal/", "timeout": "1h"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, req.timeout)
+	assert.Equal(t, 0, req.maxRetries)
+	assert.Equal(t, http.MethodGet, req.method)
+}
@@ -1,24 +1,155 @@
 package plugins
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"task/pkg/plugins/email"
-	"task/pkg/plugins/query"
+	"log/slog"
+	"sort"
+	"sync"
 )
 
-// Plugin interface defines the Run method for plugins
+// Plugin interface defines the Run method for plugins.
+// Run must return promptly with the context's error once ctx is done.
 type Plugin interface {
+	Run(ctx context.Context, task TaskContext) (Result, error)
+}
+
+// TaskContext describes the task a plugin is run for.
+type TaskContext struct {
+	// TaskID is the ID of the task in the task service.
+	TaskID int64
+	// Attempt is the 1-based number of the current attempt at running the task.
+	Attempt int
+	// Parameters are the payload parameters of the task.
+	Parameters map[string]string
+	// BaseImage is the image of the task execution environment, if any.
+	BaseImage string
+	// Entrypoint is the program run by the task, if any.
+	Entrypoint string
+	// Args are the arguments passed to the entrypoint.
+	Args []string
+	// Env are the environment variables set for the entrypoint.
+	Env map[string]string
+	// InputDir holds the input artifacts of the task, as files named after the inputs.
+	// It is empty when the task declares no artifacts.
+	InputDir string
+	// OutputDir is where the task writes its output artifacts, as files named after
+	// the outputs. It is empty when the task declares no artifacts.
+	OutputDir string
+	// Logger is annotated with the task ID and attempt and must not be nil.
+	Logger *slog.Logger
+	// Progress receives the progress of the task: the percentage of the work done and a
+	// short message describing what the task is doing. It is nil when nothing listens,
+	// so plugins report through ReportProgress.
+	Progress func(percent int, message string)
+}
+
+// ReportProgress reports the progress of the task when Progress is set. Workers only
+// send the latest progress every so often, so plugins can report as often as they like.
+func (t TaskContext) ReportProgress(percent int, message string) {
+	if t.Progress != nil {
+		t.Progress(percent, message)
+	}
+}
+
+// Result is the outcome of a successful plugin run.
+type Result struct {
+	// Outputs are named values produced by the task.
+	Outputs map[string]string
+	// Data is an optional JSON document produced by the task.
+	Data json.RawMessage
+	// Metrics are named measurements taken while running the task.
+	Metrics map[string]float64
+}
+
+// LegacyPlugin is the plugin interface used before Run took a context and returned a Result.
+type LegacyPlugin interface {
 	Run(parameters map[string]string) error
 }
 
+// AdaptLegacy wraps a LegacyPlugin so that it can be registered as a Plugin.
+// The legacy plugin cannot be interrupted, so when ctx is done the adapter returns
+// the context's error right away and leaves the plugin to finish in the background.
+func AdaptLegacy(plugin LegacyPlugin) Plugin {
+	return legacyAdapter{plugin: plugin}
+}
+
+type legacyAdapter struct {
+	plugin LegacyPlugin
+}
+
+func (a legacyAdapter) Run(ctx context.Context, task TaskContext) (Result, error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- a.plugin.Run(task.Parameters)
+	}()
+
+	select {
+	case err := <-done:
+		return Result{}, err
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// Factory creates a new instance of a plugin
+type Factory func() Plugin
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a plugin available under the given task type name.
+// It is meant to be called from the init function of the plugin package and
+// panics if the name is empty, the factory is nil or the name is already registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" {
+		panic("plugins: Register called with an empty name")
+	}
+	if factory == nil {
+		panic("plugins: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("plugins: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
 // NewPlugin returns a Plugin interface based on the provided type
 func NewPlugin(pluginType string) (Plugin, error) {
-	switch pluginType {
-	case email.PLUGIN_NAME:
-		return &email.Email{}, nil // Assuming Email is a struct that implements Plugin
-	case query.PLUGIN_NAME:
-		return &query.Query{}, nil // Assuming Query is a struct that implements Plugin
-	default:
+	registryMu.RLock()
+	factory, ok := registry[pluginType]
+	registryMu.RUnlock()
+
+	if !ok {
 		return nil, fmt.Errorf("unknown plugin type: %s", pluginType)
 	}
+	return factory(), nil
+}
+
+// IsRegistered reports whether a plugin is registered for the task type.
+func IsRegistered(pluginType string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	_, ok := registry[pluginType]
+	return ok
+}
+
+// Types returns the sorted names of all registered task types.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for name := range registry {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
 }
@@ -1,55 +1,108 @@
 package plugins
 
 import (
-	"task/pkg/plugins/email"
-	"task/pkg/plugins/query"
+	"context"
+	"errors"
+	"reflect"
 	"testing"
+	"time"
 )
 
-func TestNewPlugin(t *testing.T) {
+type fakePlugin struct{}
+
+func (f *fakePlugin) Run(ctx context.Context, task TaskContext) (Result, error) {
+	return Result{}, nil
+}
+
+type fakeLegacyPlugin struct {
+	delay time.Duration
+	err   error
+	got   map[string]string
+}
+
+func (f *fakeLegacyPlugin) Run(parameters map[string]string) error {
+	f.got = parameters
+	time.Sleep(f.delay)
+	return f.err
+}
+
+func TestRegister(t *testing.T) {
+	Register("test_fake", func() Plugin { return &fakePlugin{} })
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "test_fake")
+		registryMu.Unlock()
+	})
+
+	if !IsRegistered("test_fake") {
+		t.Errorf("IsRegistered() = false, want true")
+	}
+	if IsRegistered("UNKNOWN") {
+		t.Errorf("IsRegistered(UNKNOWN) = true, want false")
+	}
+
+	got, err := NewPlugin("test_fake")
+	if err != nil {
+		t.Fatalf("NewPlugin() error = %v", err)
+	}
+	if _, ok := got.(*fakePlugin); !ok {
+		t.Errorf("NewPlugin() returned %T, want *fakePlugin", got)
+	}
+
+	if types := Types(); !reflect.DeepEqual(types, []string{"test_fake"}) {
+		t.Errorf("Types() = %v, want [test_fake]", types)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	Register("test_dup", func() Plugin { return &fakePlugin{} })
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "test_dup")
+		registryMu.Unlock()
+	})
+
 	tests := []struct {
 		name       string
 		pluginType string
-		want       Plugin
-		wantErr    bool
+		factory    Factory
 	}{
-		{
-			name:       "SEND_EMAIL plugin",
-			pluginType: email.PLUGIN_NAME,
-			want:       &email.Email{},
-			wantErr:    false,
-		},
-		{
-			name:       "RUN_QUERY plugin",
-			pluginType: query.PLUGIN_NAME,
-			want:       &query.Query{},
-			wantErr:    false,
-		},
-		{
-			name:       "Unknown plugin type",
-			pluginType: "UNKNOWN",
-			want:       nil,
-			wantErr:    true,
-		},
+		{"Empty name", "", func() Plugin { return &fakePlugin{} }},
+		{"Nil factory", "test_nil", nil},
+		{"Duplicate name", "test_dup", func() Plugin { return &fakePlugin{} }},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewPlugin(tt.pluginType)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("NewPlugin() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if tt.wantErr {
-				return
-			}
-			if got == nil {
-				t.Errorf("NewPlugin() returned nil, want %T", tt.want)
-				return
-			}
-			if _, ok := got.(Plugin); !ok {
-				t.Errorf("NewPlugin() returned %T, which does not implement Plugin interface", got)
-			}
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Register() did not panic")
+				}
+			}()
+			Register(tt.pluginType, tt.factory)
 		})
 	}
 }
+
+func TestAdaptLegacy(t *testing.T) {
+	t.Run("Passes parameters and error", func(t *testing.T) {
+		legacy := &fakeLegacyPlugin{err: errors.New("boom")}
+		_, err := AdaptLegacy(legacy).Run(context.Background(), TaskContext{Parameters: map[string]string{"key": "value"}})
+		if err == nil || err.Error() != "boom" {
+			t.Errorf("Run() error = %v, want boom", err)
+		}
+		if !reflect.DeepEqual(legacy.got, map[string]string{"key": "value"}) {
+			t.Errorf("legacy plugin got parameters %v", legacy.got)
+		}
+	})
+
+	t.Run("Returns when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := AdaptLegacy(&fakeLegacyPlugin{delay: time.Second}).Run(ctx, TaskContext{})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Run() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package process
+
+import (
+	"bytes"
+	"log/slog"
+)
+
+// maxLineBytes is the length at which a line without a newline is logged anyway.
+const maxLineBytes = 64 * 1024
+
+// lineLogger is an io.Writer that logs each line written to it, so that the
+// output of a process is streamed to the task logger while it runs.
+type lineLogger struct {
+	logger *slog.Logger
+	stream string
+	buf    []byte
+	lines  int
+}
+
+func newLineLogger(logger *slog.Logger, stream string) *lineLogger {
+	return &lineLogger{logger: logger, stream: stream}
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.log(l.buf[:i])
+		l.buf = l.buf[i+1:]
+	}
+	for len(l.buf) >= maxLineBytes {
+		l.log(l.buf[:maxLineBytes])
+		l.buf = l.buf[maxLineBytes:]
+	}
+	// Release the output that has been logged
+	if len(l.buf) == 0 {
+		l.buf = nil
+	}
+	return len(p), nil
+}
+
+// Flush logs the last line when the output does not end with a newline.
+func (l *lineLogger) Flush() {
+	if len(l.buf) > 0 {
+		l.log(l.buf)
+		l.buf = nil
+	}
+}
+
+func (l *lineLogger) log(line []byte) {
+	l.lines++
+	l.logger.Info("Process output", "stream", l.stream, "line", string(bytes.TrimSuffix(line, []byte("\r"))))
+}
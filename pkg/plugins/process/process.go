@@ -0,0 +1,213 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"task/pkg/plugins"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+var PLUGIN_NAME = "process"
+
+const (
+	// InputDirEnv names the directory holding the input artifacts of the task in the process environment.
+	InputDirEnv = "TASK_INPUT_DIR"
+	// OutputDirEnv names the directory the process writes the output artifacts of the task to.
+	OutputDirEnv = "TASK_OUTPUT_DIR"
+	// AnyEntrypoint in PROCESS_ALLOWED_ENTRYPOINTS lets tasks run any program.
+	AnyEntrypoint = "*"
+)
+
+// Config holds the worker settings of the process plugin
+type Config struct {
+	// AllowedEntrypoints are the programs tasks can run, or AnyEntrypoint.
+	// Process tasks are rejected when it is empty.
+	AllowedEntrypoints []string `envconfig:"PROCESS_ALLOWED_ENTRYPOINTS"`
+	// WorkDir is the working directory of the processes, defaulting to the one of the worker.
+	WorkDir string `envconfig:"PROCESS_WORK_DIR"`
+	// InheritEnv passes the environment of the worker to the processes.
+	// Otherwise they only get PATH and the env of the task.
+	InheritEnv bool `envconfig:"PROCESS_INHERIT_ENV"`
+	// MaxTimeout bounds the wall-clock time of each process; tasks can only lower it.
+	MaxTimeout time.Duration `envconfig:"PROCESS_MAX_TIMEOUT" default:"1h"`
+	// MaxMemoryBytes bounds the address space of each process; tasks can only lower it.
+	// Zero disables the limit.
+	MaxMemoryBytes int64 `envconfig:"PROCESS_MAX_MEMORY_BYTES" default:"1073741824"`
+	// KillDelay is how long a process that is stopped gets to exit after SIGTERM before it is killed.
+	KillDelay time.Duration `envconfig:"PROCESS_KILL_DELAY" default:"10s"`
+}
+
+// Process runs the entrypoint of the task with its args and env on the worker.
+// The process fails the task when it exits with a non-zero code. Tasks with artifacts find
+// their directories in TASK_INPUT_DIR and TASK_OUTPUT_DIR. The task parameters are:
+//
+//   - timeout: an optional wall-clock limit lower than PROCESS_MAX_TIMEOUT, such as 5m
+//   - max_memory_bytes: an optional address space limit lower than PROCESS_MAX_MEMORY_BYTES
+type Process struct {
+	// Config is loaded from the environment on the first run when nil.
+	Config *Config
+}
+
+func init() {
+	plugins.Register(PLUGIN_NAME, func() plugins.Plugin { return &Process{} })
+}
+
+// Schema declares the parameters of process. The program is set by the entrypoint, args and env of the task.
+func (p *Process) Schema() plugins.Schema {
+	return plugins.Schema{
+		Description: "Runs the entrypoint of the task with its args and env on the worker",
+		Parameters: []plugins.Parameter{
+			{Name: "timeout", Type: plugins.ParameterDuration, Description: "Wall-clock limit, lowering PROCESS_MAX_TIMEOUT"},
+			{Name: "max_memory_bytes", Type: plugins.ParameterInt, Pattern: "[1-9][0-9]*", Description: "Address space limit, lowering PROCESS_MAX_MEMORY_BYTES"},
+		},
+	}
+}
+
+// limits are the resource limits a process is started with.
+type limits struct {
+	timeout        time.Duration
+	maxMemoryBytes int64
+}
+
+func (p *Process) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	if p.Config == nil {
+		var cfg Config
+		if err := envconfig.Process("", &cfg); err != nil {
+			return plugins.Result{}, fmt.Errorf("failed to load process configuration: %w", err)
+		}
+		p.Config = &cfg
+	}
+	cfg := p.Config
+
+	if task.Entrypoint == "" {
+		return plugins.Result{}, fmt.Errorf("the process task type requires an entrypoint")
+	}
+	if len(cfg.AllowedEntrypoints) == 0 {
+		return plugins.Result{}, fmt.Errorf("process tasks are disabled on this worker: PROCESS_ALLOWED_ENTRYPOINTS is not set")
+	}
+	if !slices.Contains(cfg.AllowedEntrypoints, AnyEntrypoint) && !slices.Contains(cfg.AllowedEntrypoints, task.Entrypoint) {
+		return plugins.Result{}, fmt.Errorf("entrypoint %s is not in PROCESS_ALLOWED_ENTRYPOINTS", task.Entrypoint)
+	}
+	lim, err := parseLimits(cfg, task.Parameters)
+	if err != nil {
+		return plugins.Result{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lim.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, task.Entrypoint, task.Args...)
+	cmd.Dir = cfg.WorkDir
+	cmd.Env = environ(cfg.InheritEnv, task.Env)
+	if task.InputDir != "" {
+		cmd.Env = append(cmd.Env, InputDirEnv+"="+task.InputDir)
+	}
+	if task.OutputDir != "" {
+		cmd.Env = append(cmd.Env, OutputDirEnv+"="+task.OutputDir)
+	}
+	stdout := newLineLogger(task.Logger, "stdout")
+	stderr := newLineLogger(task.Logger, "stderr")
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	configure(cmd, cfg.KillDelay)
+	if err := applyLimits(cmd, lim); err != nil {
+		return plugins.Result{}, fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+
+	task.Logger.Info("Starting process", "entrypoint", task.Entrypoint, "args", len(task.Args), "timeout", lim.timeout, "max_memory_bytes", lim.maxMemoryBytes)
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return plugins.Result{}, fmt.Errorf("failed to start process: %w", err)
+	}
+
+	waitErr := cmd.Wait()
+	killGroup(cmd)
+	stdout.Flush()
+	stderr.Flush()
+
+	state := cmd.ProcessState
+	result := plugins.Result{
+		Outputs: map[string]string{"exit_code": strconv.Itoa(state.ExitCode())},
+		Metrics: map[string]float64{
+			"duration_seconds": time.Since(start).Seconds(),
+			"output_lines":     float64(stdout.lines + stderr.lines),
+		},
+	}
+	if rss := maxRSS(state); rss > 0 {
+		result.Metrics["max_rss_bytes"] = float64(rss)
+	}
+
+	if err := exitError(ctx, state, waitErr, lim); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// exitError maps the way the process ended to the error of the task, which is nil when it exited with 0.
+func exitError(ctx context.Context, state *os.ProcessState, waitErr error, lim limits) error {
+	switch {
+	case state != nil && state.Success():
+		// A process that exited with 0 but left its output open in a child is not a failure
+		if waitErr != nil && !errors.Is(waitErr, exec.ErrWaitDelay) {
+			return fmt.Errorf("failed to wait for process: %w", waitErr)
+		}
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("process did not finish within %s", lim.timeout)
+	case ctx.Err() != nil:
+		return fmt.Errorf("process was stopped: %w", ctx.Err())
+	case state != nil && state.ExitCode() >= 0:
+		return fmt.Errorf("process exited with code %d", state.ExitCode())
+	case state != nil:
+		return fmt.Errorf("process terminated: %s", state)
+	default:
+		return fmt.Errorf("failed to wait for process: %w", waitErr)
+	}
+}
+
+// parseLimits returns the limits of the process, lowered by the task parameters.
+func parseLimits(cfg *Config, params map[string]string) (limits, error) {
+	lim := limits{timeout: cfg.MaxTimeout, maxMemoryBytes: cfg.MaxMemoryBytes}
+	if value := params["timeout"]; value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return limits{}, fmt.Errorf("invalid timeout %q", value)
+		}
+		lim.timeout = min(lim.timeout, d)
+	}
+	if value := params["max_memory_bytes"]; value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			return limits{}, fmt.Errorf("invalid max_memory_bytes %q", value)
+		}
+		if lim.maxMemoryBytes == 0 || n < lim.maxMemoryBytes {
+			lim.maxMemoryBytes = n
+		}
+	}
+	if lim.timeout <= 0 {
+		return limits{}, fmt.Errorf("PROCESS_MAX_TIMEOUT must be positive")
+	}
+	return lim, nil
+}
+
+// environ returns the environment of the process: PATH or the whole environment of the worker,
+// overridden by the env of the task.
+func environ(inherit bool, env map[string]string) []string {
+	var result []string
+	if inherit {
+		result = os.Environ()
+	} else if path, ok := os.LookupEnv("PATH"); ok {
+		result = append(result, "PATH="+path)
+	}
+	// Later entries take precedence in os/exec
+	for key, value := range env {
+		result = append(result, key+"="+value)
+	}
+	return result
+}
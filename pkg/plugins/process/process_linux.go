@@ -0,0 +1,87 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// configure runs the process in its own process group, so that stopping it also stops its children.
+// Stopping sends SIGTERM to the group; the process is killed when it has not exited after killDelay.
+func configure(cmd *exec.Cmd, killDelay time.Duration) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = killDelay
+}
+
+// killGroup kills the processes the process left behind in its group.
+func killGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// limitsHelperEnv marks the worker binary started by applyLimits, which sets the
+// limits of the process and then execs its program.
+const limitsHelperEnv = "TASK_PROCESS_LIMITS_HELPER"
+
+func init() {
+	if os.Getenv(limitsHelperEnv) == "" {
+		return
+	}
+	os.Unsetenv(limitsHelperEnv)
+	err := execWithLimits(os.Args[1:])
+	fmt.Fprintf(os.Stderr, "failed to start process: %v\n", err)
+	os.Exit(127)
+}
+
+// applyLimits makes the process start with the memory limit as RLIMIT_AS, which children inherit.
+// os/exec cannot set rlimits, so cmd starts the worker binary instead, which sets the limit
+// on itself and then execs the program. The arguments are the limit, the program path and its argv.
+func applyLimits(cmd *exec.Cmd, lim limits) error {
+	// The program was not found, which cmd.Start reports
+	if lim.maxMemoryBytes <= 0 || cmd.Err != nil {
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd.Args = append([]string{cmd.Args[0], strconv.FormatInt(lim.maxMemoryBytes, 10), cmd.Path}, cmd.Args...)
+	cmd.Path = self
+	cmd.Env = append(cmd.Env, limitsHelperEnv+"=1")
+	return nil
+}
+
+// execWithLimits sets RLIMIT_AS and replaces the current process with the program, as started by applyLimits.
+func execWithLimits(args []string) error {
+	if len(args) < 3 {
+		return errors.New("missing limits helper arguments")
+	}
+	n, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid memory limit %q", args[0])
+	}
+	if err := unix.Setrlimit(unix.RLIMIT_AS, &unix.Rlimit{Cur: n, Max: n}); err != nil {
+		return fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+	return unix.Exec(args[1], args[2:], os.Environ())
+}
+
+// maxRSS returns the peak resident set size of the process in bytes.
+func maxRSS(state *os.ProcessState) int64 {
+	if state == nil {
+		return 0
+	}
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// Linux reports kilobytes
+		return usage.Maxrss * 1024
+	}
+	return 0
+}
@@ -0,0 +1,24 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMemoryLimit(t *testing.T) {
+	// The limit is set before the program starts, and inherited by its children
+	task := newTaskContext("sh", "-c", `grep "Max address space" /proc/$$/limits /proc/self/limits`)
+	task.Parameters = map[string]string{"max_memory_bytes": "268435456"}
+	output := captureOutput(&task)
+
+	result, err := newTestPlugin().Run(context.Background(), task)
+	require.NoError(t, err)
+	require.Len(t, output()["stdout"], 2)
+	for _, line := range output()["stdout"] {
+		assert.Regexp(t, `limits:Max address space\s+268435456\s+268435456\s+bytes`, line)
+	}
+	assert.Greater(t, result.Metrics["max_rss_bytes"], float64(0))
+}
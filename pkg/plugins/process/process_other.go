@@ -0,0 +1,27 @@
+//go:build !linux
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// configure kills the process when it is stopped. Process groups are only used on Linux.
+func configure(cmd *exec.Cmd, killDelay time.Duration) {
+	cmd.WaitDelay = killDelay
+}
+
+// killGroup is a no-op without process groups.
+func killGroup(cmd *exec.Cmd) {}
+
+// applyLimits is a no-op: memory limits are only enforced on Linux.
+func applyLimits(cmd *exec.Cmd, lim limits) error {
+	return nil
+}
+
+// maxRSS is not reported outside of Linux.
+func maxRSS(state *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,210 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"task/pkg/plugins"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTaskContext(entrypoint string, args ...string) plugins.TaskContext {
+	return plugins.TaskContext{TaskID: 1, Attempt: 1, Entrypoint: entrypoint, Args: args, Logger: slog.Default()}
+}
+
+func newTestPlugin() *Process {
+	return &Process{Config: &Config{AllowedEntrypoints: []string{AnyEntrypoint}, MaxTimeout: time.Minute, KillDelay: time.Second}}
+}
+
+// captureOutput makes the task log as JSON and returns the lines logged for each stream.
+func captureOutput(task *plugins.TaskContext) func() map[string][]string {
+	var buf bytes.Buffer
+	task.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	return func() map[string][]string {
+		lines := map[string][]string{}
+		for _, record := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry struct {
+				Stream string `json:"stream"`
+				Line   string `json:"line"`
+			}
+			if json.Unmarshal([]byte(record), &entry) == nil && entry.Stream != "" {
+				lines[entry.Stream] = append(lines[entry.Stream], entry.Line)
+			}
+		}
+		return lines
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("PROCESS_TEST_SECRET", "secret")
+
+	task := newTaskContext("sh", "-c", `echo "hello $GREETING"; echo "${PROCESS_TEST_SECRET:-unset}"; echo oops >&2; printf 'no newline'`)
+	task.Env = map[string]string{"GREETING": "world"}
+	output := captureOutput(&task)
+
+	result, err := newTestPlugin().Run(context.Background(), task)
+	require.NoError(t, err)
+	assert.Equal(t, "0", result.Outputs["exit_code"])
+	assert.Equal(t, float64(4), result.Metrics["output_lines"])
+	assert.Equal(t, map[string][]string{
+		"stdout": {"hello world", "unset", "no newline"},
+		"stderr": {"oops"},
+	}, output())
+}
+
+func TestRunInheritEnv(t *testing.T) {
+	t.Setenv("PROCESS_TEST_SECRET", "secret")
+
+	task := newTaskContext("sh", "-c", `echo "$PROCESS_TEST_SECRET"`)
+	output := captureOutput(&task)
+
+	p := newTestPlugin()
+	p.Config.InheritEnv = true
+	_, err := p.Run(context.Background(), task)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"secret"}, output()["stdout"])
+}
+
+func TestRunArtifactDirs(t *testing.T) {
+	task := newTaskContext("sh", "-c", `cp "$TASK_INPUT_DIR/in.txt" "$TASK_OUTPUT_DIR/out.txt"`)
+	task.InputDir, task.OutputDir = t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(task.InputDir, "in.txt"), []byte("data"), 0o644))
+
+	_, err := newTestPlugin().Run(context.Background(), task)
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(task.OutputDir, "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+}
+
+func TestRunExitCode(t *testing.T) {
+	result, err := newTestPlugin().Run(context.Background(), newTaskContext("sh", "-c", "exit 3"))
+	assert.EqualError(t, err, "process exited with code 3")
+	assert.Equal(t, "3", result.Outputs["exit_code"])
+}
+
+func TestRunTimeout(t *testing.T) {
+	task := newTaskContext("sh", "-c", "sleep 10")
+	task.Parameters = map[string]string{"timeout": "100ms"}
+
+	start := time.Now()
+	_, err := newTestPlugin().Run(context.Background(), task)
+	assert.EqualError(t, err, "process did not finish within 100ms")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	_, err := newTestPlugin().Run(ctx, newTaskContext("sleep", "10"))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    plugins.TaskContext
+		config  func(*Config)
+		wantErr string
+	}{
+		{
+			name:    "Missing entrypoint",
+			task:    newTaskContext(""),
+			wantErr: "requires an entrypoint",
+		},
+		{
+			name:    "No allowed entrypoints",
+			task:    newTaskContext("sh", "-c", "true"),
+			config:  func(cfg *Config) { cfg.AllowedEntrypoints = nil },
+			wantErr: "PROCESS_ALLOWED_ENTRYPOINTS is not set",
+		},
+		{
+			name:    "Entrypoint not allowed",
+			task:    newTaskContext("sh", "-c", "true"),
+			config:  func(cfg *Config) { cfg.AllowedEntrypoints = []string{"python"} },
+			wantErr: "entrypoint sh is not in PROCESS_ALLOWED_ENTRYPOINTS",
+		},
+		{
+			name:    "Unknown program",
+			task:    newTaskContext("no-such-program-for-process-test"),
+			wantErr: "failed to start process",
+		},
+		{
+			name: "Invalid timeout",
+			task: func() plugins.TaskContext {
+				task := newTaskContext("true")
+				task.Parameters = map[string]string{"timeout": "soon"}
+				return task
+			}(),
+			wantErr: `invalid timeout "soon"`,
+		},
+		{
+			name: "Invalid memory limit",
+			task: func() plugins.TaskContext {
+				task := newTaskContext("true")
+				task.Parameters = map[string]string{"max_memory_bytes": "-1"}
+				return task
+			}(),
+			wantErr: `invalid max_memory_bytes "-1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlugin()
+			if tt.config != nil {
+				tt.config(p.Config)
+			}
+			_, err := p.Run(context.Background(), tt.task)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	cfg := &Config{MaxTimeout: time.Hour, MaxMemoryBytes: 1 << 30}
+
+	lim, err := parseLimits(cfg, map[string]string{"timeout": "2h", "max_memory_bytes": "1048576"})
+	require.NoError(t, err)
+	assert.Equal(t, limits{timeout: time.Hour, maxMemoryBytes: 1 << 20}, lim, "tasks can only lower the limits")
+
+	lim, err = parseLimits(&Config{MaxTimeout: time.Hour}, map[string]string{"max_memory_bytes": "1048576"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<20), lim.maxMemoryBytes, "tasks can set a limit when the worker has none")
+}
+
+func TestLineLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLineLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})), "stdout")
+
+	l.Write([]byte("first\r\nsec"))
+	l.Write([]byte("ond\n"))
+	l.Write([]byte(strings.Repeat("x", maxLineBytes+1)))
+	l.Flush()
+
+	assert.Equal(t, 4, l.lines)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "line=first")
+	assert.Contains(t, lines[1], "line=second")
+	assert.True(t, strings.HasSuffix(lines[2], "line="+strings.Repeat("x", maxLineBytes)), "long lines are split")
+	assert.True(t, strings.HasSuffix(lines[3], "line=x"))
+}
@@ -0,0 +1,147 @@
+package query
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// progressRows is the number of rows exported between two calls to the progress function.
+const progressRows = 1000
+
+// exporter writes the rows of a query result to w and returns the number of rows written.
+// It calls progress with the number of rows written so far every progressRows rows.
+type exporter func(w io.Writer, rows *sql.Rows, maxRows int, progress func(rows int)) (int, error)
+
+// exporters maps the supported values of the format parameter to their exporters.
+var exporters = map[string]exporter{
+	"csv":  exportCSV,
+	"json": exportJSON,
+}
+
+// exportCSV writes a header line with the column names followed by one line per row.
+// NULL values are written as empty fields.
+func exportCSV(w io.Writer, rows *sql.Rows, maxRows int, progress func(rows int)) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read result columns: %w", err)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return 0, fmt.Errorf("failed to write result: %w", err)
+	}
+	record := make([]string, len(columns))
+	n, err := scanRows(rows, len(columns), maxRows, progress, func(values []interface{}) error {
+		for i, v := range values {
+			record[i] = formatValue(v)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return 0, err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return 0, fmt.Errorf("failed to write result: %w", err)
+	}
+	return n, nil
+}
+
+// exportJSON writes a JSON array holding one object per row, keyed by column name.
+func exportJSON(w io.Writer, rows *sql.Rows, maxRows int, progress func(rows int)) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read result columns: %w", err)
+	}
+
+	separator := "["
+	n, err := scanRows(rows, len(columns), maxRows, progress, func(values []interface{}) error {
+		row := make(map[string]interface{}, len(columns))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			row[columns[i]] = v
+		}
+		data, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, separator+"\n"); err != nil {
+			return err
+		}
+		separator = ","
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		_, err = io.WriteString(w, "[]\n")
+	} else {
+		_, err = io.WriteString(w, "\n]\n")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write result: %w", err)
+	}
+	return n, nil
+}
+
+// scanRows calls write with the values of each row, failing when there are more than maxRows rows.
+// It calls progress every progressRows rows.
+func scanRows(rows *sql.Rows, columns, maxRows int, progress func(rows int), write func(values []interface{}) error) (int, error) {
+	values := make([]interface{}, columns)
+	pointers := make([]interface{}, columns)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	n := 0
+	for rows.Next() {
+		if maxRows > 0 && n == maxRows {
+			return 0, fmt.Errorf("query returned more than %d rows", maxRows)
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return 0, fmt.Errorf("failed to read result row: %w", err)
+		}
+		if err := write(values); err != nil {
+			return 0, fmt.Errorf("failed to write result: %w", err)
+		}
+		n++
+		if n%progressRows == 0 {
+			progress(n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read result: %w", err)
+	}
+	return n, nil
+}
+
+// formatValue formats a scanned column value for CSV.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -1,24 +1,283 @@
 package query
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"task/pkg/config"
+	"task/pkg/plugins"
 	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/kelseyhightower/envconfig"
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
 )
 
 var PLUGIN_NAME = "run_query"
 
+// dataSourceEnvPrefix prefixes the environment variables that name data sources.
+// QUERY_DATASOURCE_REPORTING=postgres://... configures the data source "reporting".
+const dataSourceEnvPrefix = "QUERY_DATASOURCE_"
+
+// Config holds the worker settings of the run_query plugin
+type Config struct {
+	// DataSources maps data source names to database URLs.
+	DataSources map[string]string `ignored:"true"`
+	// StatementTimeout bounds each query; tasks can only lower it.
+	StatementTimeout time.Duration `envconfig:"QUERY_STATEMENT_TIMEOUT" default:"30s"`
+	// ResultDir is the directory query results are exported to.
+	ResultDir string `envconfig:"QUERY_RESULT_DIR" default:"."`
+	// MaxRows limits the number of rows exported by a query.
+	MaxRows int `envconfig:"QUERY_MAX_ROWS" default:"100000"`
+	// ChaosMode replaces query execution with a simulation that sleeps for
+	// TASK_TIME_OUT seconds and fails at ChaosFailureRate, for load testing.
+	ChaosMode        bool    `envconfig:"QUERY_CHAOS_MODE"`
+	ChaosFailureRate float64 `envconfig:"QUERY_CHAOS_FAILURE_RATE" default:"0.2"`
+}
+
+// LoadConfig loads the plugin configuration, including the data sources, from the environment.
+func LoadConfig() (*Config, error) {
+	var cfg Config
+	if err := envconfig.Process("", &cfg); err != nil {
+		return nil, fmt.Errorf("failed to load query configuration: %w", err)
+	}
+	cfg.DataSources = map[string]string{}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(key, dataSourceEnvPrefix); ok && name != "" {
+			cfg.DataSources[strings.ToLower(name)] = value
+		}
+	}
+	return &cfg, nil
+}
+
+// Query runs a read-only SQL query against a named data source and exports the result.
+// The task parameters are:
+//
+//   - data_source: the name of a data source configured on the worker
+//   - query: the SQL query to run
+//   - format: csv or json, defaulting to json
+//   - output: the file the result is written to, relative to QUERY_RESULT_DIR;
+//     defaults to task-<id>-attempt-<attempt>.<format>
+//   - timeout: an optional statement timeout lower than QUERY_STATEMENT_TIMEOUT, such as 5s
+//   - artifact: an output artifact of the task the result is written to instead of output
 type Query struct {
+	// Config is loaded from the environment on the first run when nil.
+	Config *Config
+}
+
+func init() {
+	plugins.Register(PLUGIN_NAME, func() plugins.Plugin { return &Query{} })
+}
+
+// Schema declares the parameters of run_query.
+func (q *Query) Schema() plugins.Schema {
+	return plugins.Schema{
+		Description: "Runs a read-only SQL query against a named data source and exports the result",
+		Parameters: []plugins.Parameter{
+			{Name: "data_source", Type: plugins.ParameterString, Required: true, Description: "Name of a data source configured on the worker"},
+			{Name: "query", Type: plugins.ParameterString, Required: true, Description: "SQL query to run"},
+			{Name: "format", Type: plugins.ParameterString, Default: "json", Pattern: "csv|json", Description: "Export format"},
+			{Name: "output", Type: plugins.ParameterString, Description: "Result file relative to QUERY_RESULT_DIR"},
+			{Name: "timeout", Type: plugins.ParameterDuration, Description: "Statement timeout, lowering QUERY_STATEMENT_TIMEOUT"},
+			{Name: "artifact", Type: plugins.ParameterString, Description: "Output artifact of the task the result is written to instead of output"},
+		},
+	}
 }
 
 var seededRand *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
 
-// run_query executes a query and fails 20% of the time
-func (q *Query) Run(parameters map[string]string) error {
-	if seededRand.Float64() < 0.2 { // 20% chance to fail
-		return fmt.Errorf("query failed")
+func (q *Query) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	if q.Config == nil {
+		cfg, err := LoadConfig()
+		if err != nil {
+			return plugins.Result{}, err
+		}
+		q.Config = cfg
+	}
+	if q.Config.ChaosMode {
+		return runChaos(ctx, q.Config, task)
+	}
+
+	params := task.Parameters
+	if strings.TrimSpace(params["query"]) == "" {
+		return plugins.Result{}, fmt.Errorf("the query parameter is required")
+	}
+	dbURL, ok := q.Config.DataSources[params["data_source"]]
+	if !ok {
+		return plugins.Result{}, fmt.Errorf("unknown data source %q", params["data_source"])
+	}
+
+	format := params["format"]
+	if format == "" {
+		format = "json"
+	}
+	export, ok := exporters[format]
+	if !ok {
+		return plugins.Result{}, fmt.Errorf("unsupported format %q: must be csv or json", format)
+	}
+
+	output := params["output"]
+	if output == "" {
+		output = fmt.Sprintf("task-%d-attempt-%d.%s", task.TaskID, task.Attempt, format)
+	}
+	if !filepath.IsLocal(output) {
+		return plugins.Result{}, fmt.Errorf("output %s must be relative to QUERY_RESULT_DIR", output)
+	}
+
+	timeout := q.Config.StatementTimeout
+	if value := params["timeout"]; value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return plugins.Result{}, fmt.Errorf("invalid timeout %q", value)
+		}
+		timeout = min(timeout, d)
+	}
+
+	path := filepath.Join(q.Config.ResultDir, output)
+	if name := params["artifact"]; name != "" {
+		if task.OutputDir == "" {
+			return plugins.Result{}, fmt.Errorf("artifact %s is not an output artifact of the task", name)
+		}
+		if !filepath.IsLocal(name) || filepath.Base(name) != name {
+			return plugins.Result{}, fmt.Errorf("invalid artifact name %q", name)
+		}
+		path = filepath.Join(task.OutputDir, name)
+	}
+
+	start := time.Now()
+	task.Logger.Info("Running query", "data_source", params["data_source"], "format", format, "output", path)
+	task.ReportProgress(0, "Running query")
+	rows, err := runQuery(ctx, dbURL, params["query"], timeout, q.Config.MaxRows, path, export, func(rows int) {
+		task.ReportProgress(exportPercent(rows, q.Config.MaxRows), fmt.Sprintf("Exported %d rows", rows))
+	})
+	if err != nil {
+		return plugins.Result{}, err
+	}
+	task.ReportProgress(100, fmt.Sprintf("Exported %d rows", rows))
+
+	return plugins.Result{
+		Outputs: map[string]string{
+			"result_path": path,
+			"row_count":   strconv.Itoa(rows),
+		},
+		Metrics: map[string]float64{
+			"rows":             float64(rows),
+			"duration_seconds": time.Since(start).Seconds(),
+		},
+	}, nil
+}
+
+// exportPercent estimates the progress of an export that has written rows rows. The number
+// of rows of a result is not known until it has been read, so the estimate is measured
+// against maxRows, and held below 100 percent until the export is done.
+func exportPercent(rows, maxRows int) int {
+	if maxRows <= 0 {
+		return 0
+	}
+	return min(99, rows*100/maxRows)
+}
+
+// runQuery runs query read-only against the database at dbURL and exports the rows to path.
+// The progress function is called as rows are exported.
+func runQuery(ctx context.Context, dbURL, query string, timeout time.Duration, maxRows int, path string, export exporter, progress func(rows int)) (int, error) {
+	driver, dsn, err := config.ParseDatabaseURL(dbURL)
+	if err != nil {
+		return 0, err
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open data source: %w", err)
+	}
+	defer db.Close()
+
+	// The deadline also bounds databases without a server side statement timeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to data source: %w", err)
+	}
+	defer conn.Close()
+
+	tx, err := beginReadOnly(ctx, conn, driver, timeout)
+	if err != nil {
+		return 0, err
+	}
+	// The transaction is read-only, so it is always rolled back
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("query did not finish within %s: %w", timeout, ctx.Err())
+		}
+		return 0, fmt.Errorf("failed to run query: %w", err)
+	}
+	defer rows.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create result directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create result file: %w", err)
+	}
+	n, err := export(f, rows, maxRows, progress)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write result file: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(path)
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("query did not finish within %s: %w", timeout, ctx.Err())
+		}
+		return 0, err
+	}
+	return n, nil
+}
+
+// beginReadOnly starts a read-only transaction on conn with the statement timeout applied.
+func beginReadOnly(ctx context.Context, conn *sql.Conn, driver string, timeout time.Duration) (*sql.Tx, error) {
+	ms := timeout.Milliseconds()
+	switch driver {
+	case config.DriverMySQL:
+		// Applies to the SELECT statements of this session
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", ms)); err != nil {
+			return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+		}
+	case config.DriverSQLite:
+		// The driver ignores the read-only transaction option; query_only rejects every write on the connection
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			return nil, fmt.Errorf("failed to enable read-only mode: %w", err)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	if driver == config.DriverPostgres {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+// runChaos simulates a query that takes TASK_TIME_OUT seconds and fails at cfg.ChaosFailureRate.
+func runChaos(ctx context.Context, cfg *Config, task plugins.TaskContext) (plugins.Result, error) {
+	if seededRand.Float64() < cfg.ChaosFailureRate {
+		return plugins.Result{}, fmt.Errorf("query failed")
 	}
 
 	// Get timeout from TASK_TIME_OUT env variable or use 10 seconds as default
@@ -28,6 +287,19 @@ func (q *Query) Run(parameters map[string]string) error {
 			timeout = parsedTimeout
 		}
 	}
-	time.Sleep(time.Duration(timeout) * time.Second)
-	return nil
+	task.Logger.Debug("Simulating query in chaos mode", "timeout", timeout)
+
+	// The simulated query reports its progress every second
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for elapsed := 0; elapsed < timeout; elapsed++ {
+		task.ReportProgress(elapsed*100/timeout, fmt.Sprintf("Simulating query, %ds of %ds", elapsed, timeout))
+		select {
+		case <-ctx.Done():
+			return plugins.Result{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	task.ReportProgress(100, "Simulated query finished")
+	return plugins.Result{}, nil
 }
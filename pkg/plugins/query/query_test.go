@@ -1,46 +1,226 @@
 package query
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"task/pkg/config"
+	"task/pkg/plugins"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func newTaskContext(parameters map[string]string) plugins.TaskContext {
+	return plugins.TaskContext{TaskID: 1, Attempt: 1, Parameters: parameters, Logger: slog.Default()}
+}
+
+// newTestQuery returns a Query with a SQLite data source named "sales" holding three orders.
+func newTestQuery(t *testing.T) *Query {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sales.db")
+	db, err := sql.Open(config.DriverSQLite, path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT, total REAL, note TEXT);
+		INSERT INTO orders VALUES (1, 'ada', 12.5, NULL), (2, 'bob', 30, 'rush, "fragile"'), (3, 'ada', 7.25, 'gift')`)
+	require.NoError(t, err)
+
+	return &Query{Config: &Config{
+		DataSources:      map[string]string{"sales": "sqlite://" + path},
+		StatementTimeout: 5 * time.Second,
+		ResultDir:        filepath.Join(dir, "results"),
+		MaxRows:          100,
+	}}
+}
+
 func TestRun(t *testing.T) {
-	q := &Query{}
+	q := newTestQuery(t)
 
-	// Test case for successful execution
-	t.Run("Successful execution", func(t *testing.T) {
-		start := time.Now()
-		err := q.Run(map[string]string{"success": "true"})
-		duration := time.Since(start)
+	t.Run("JSON export", func(t *testing.T) {
+		result, err := q.Run(context.Background(), newTaskContext(map[string]string{
+			"data_source": "sales",
+			"query":       "SELECT customer, SUM(total) AS total FROM orders GROUP BY customer ORDER BY customer",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(q.Config.ResultDir, "task-1-attempt-1.json"), result.Outputs["result_path"])
+		assert.Equal(t, "2", result.Outputs["row_count"])
+		assert.Equal(t, float64(2), result.Metrics["rows"])
+
+		data, err := os.ReadFile(result.Outputs["result_path"])
+		require.NoError(t, err)
+		var rows []map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &rows))
+		assert.Equal(t, []map[string]interface{}{
+			{"customer": "ada", "total": 19.75},
+			{"customer": "bob", "total": float64(30)},
+		}, rows)
+	})
 
-		if err != nil {
-			t.Fatalf("Expected no error, got %v", err)
-		}
+	t.Run("CSV export", func(t *testing.T) {
+		result, err := q.Run(context.Background(), newTaskContext(map[string]string{
+			"data_source": "sales",
+			"query":       "SELECT id, note FROM orders ORDER BY id",
+			"format":      "csv",
+			"output":      "exports/orders.csv",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(q.Config.ResultDir, "exports", "orders.csv"), result.Outputs["result_path"])
 
-		if duration < 3*time.Second {
-			t.Errorf("Expected execution time of at least 3 seconds, got %v", duration)
-		}
+		data, err := os.ReadFile(result.Outputs["result_path"])
+		require.NoError(t, err)
+		assert.Equal(t, "id,note\n1,\n2,\"rush, \"\"fragile\"\"\"\n3,gift\n", string(data))
 	})
 
-	// Test case for failure
-	t.Run("Failure", func(t *testing.T) {
-		maxRetries := 10
-		failureOccurred := false
-
-		for i := 0; i < maxRetries; i++ {
-			err := q.Run(map[string]string{})
-			if err != nil {
-				failureOccurred = true
-				if err.Error() != "query failed" {
-					t.Errorf("Expected 'query failed', got %v", err)
-				}
-				break
-			}
-		}
-
-		if !failureOccurred {
-			t.Errorf("Expected at least one failure, but all attempts succeeded")
-		}
+	t.Run("Artifact export", func(t *testing.T) {
+		task := newTaskContext(map[string]string{
+			"data_source": "sales",
+			"query":       "SELECT id FROM orders ORDER BY id",
+			"format":      "csv",
+			"artifact":    "orders.csv",
+		})
+		task.OutputDir = t.TempDir()
+		result, err := q.Run(context.Background(), task)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(task.OutputDir, "orders.csv"), result.Outputs["result_path"])
+
+		data, err := os.ReadFile(result.Outputs["result_path"])
+		require.NoError(t, err)
+		assert.Equal(t, "id\n1\n2\n3\n", string(data))
+	})
+
+	t.Run("Empty result", func(t *testing.T) {
+		result, err := q.Run(context.Background(), newTaskContext(map[string]string{
+			"data_source": "sales",
+			"query":       "SELECT id FROM orders WHERE total > 100",
+		}))
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(result.Outputs["result_path"])
+		require.NoError(t, err)
+		assert.JSONEq(t, "[]", string(data))
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	q := newTestQuery(t)
+
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{
+			name:    "Writes are rejected",
+			params:  map[string]string{"data_source": "sales", "query": "DELETE FROM orders"},
+			wantErr: "readonly database",
+		},
+		{
+			name:    "Missing query",
+			params:  map[string]string{"data_source": "sales"},
+			wantErr: "the query parameter is required",
+		},
+		{
+			name:    "Unknown data source",
+			params:  map[string]string{"data_source": "billing", "query": "SELECT 1"},
+			wantErr: `unknown data source "billing"`,
+		},
+		{
+			name:    "Unsupported format",
+			params:  map[string]string{"data_source": "sales", "query": "SELECT 1", "format": "xml"},
+			wantErr: `unsupported format "xml"`,
+		},
+		{
+			name:    "Output outside the result dir",
+			params:  map[string]string{"data_source": "sales", "query": "SELECT 1", "output": "../orders.json"},
+			wantErr: "must be relative to QUERY_RESULT_DIR",
+		},
+		{
+			name:    "Artifact without output artifacts",
+			params:  map[string]string{"data_source": "sales", "query": "SELECT 1", "artifact": "orders.json"},
+			wantErr: "is not an output artifact of the task",
+		},
+		{
+			name:    "Too many rows",
+			params:  map[string]string{"data_source": "sales", "query": "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 101) SELECT i FROM n"},
+			wantErr: "more than 100 rows",
+		},
+		{
+			name: "Statement timeout",
+			params: map[string]string{
+				"data_source": "sales",
+				"query":       "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT COUNT(*) FROM n",
+				"timeout":     "50ms",
+			},
+			wantErr: "did not finish within 50ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := q.Run(context.Background(), newTaskContext(tt.params))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	// Failed exports leave no partial result behind
+	_, err := os.Stat(filepath.Join(q.Config.ResultDir, "task-1-attempt-1.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunChaos(t *testing.T) {
+	t.Setenv("TASK_TIME_OUT", "0")
+
+	_, err := (&Query{Config: &Config{ChaosMode: true, ChaosFailureRate: 1}}).Run(context.Background(), newTaskContext(nil))
+	assert.EqualError(t, err, "query failed")
+
+	_, err = (&Query{Config: &Config{ChaosMode: true, ChaosFailureRate: 0}}).Run(context.Background(), newTaskContext(nil))
+	assert.NoError(t, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("QUERY_DATASOURCE_REPORTING", "postgres://reader@db/reporting")
+	t.Setenv("QUERY_STATEMENT_TIMEOUT", "5s")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://reader@db/reporting", cfg.DataSources["reporting"])
+	assert.Equal(t, 5*time.Second, cfg.StatementTimeout)
+	assert.False(t, cfg.ChaosMode)
+}
+
+func TestRunProgress(t *testing.T) {
+	q := newTestQuery(t)
+	q.Config.MaxRows = 4000
+	var reports []string
+	task := newTaskContext(map[string]string{
+		"data_source": "sales",
+		"query":       "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2500) SELECT i FROM n",
+		"format":      "csv",
 	})
+	task.Progress = func(percent int, message string) {
+		reports = append(reports, fmt.Sprintf("%d%% %s", percent, message))
+	}
+
+	_, err := q.Run(context.Background(), task)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"0% Running query",
+		"25% Exported 1000 rows",
+		"50% Exported 2000 rows",
+		"100% Exported 2500 rows",
+	}, reports)
+}
+
+func TestExportPercent(t *testing.T) {
+	assert.Equal(t, 0, exportPercent(500, 0))
+	assert.Equal(t, 50, exportPercent(500, 1000))
+	assert.Equal(t, 99, exportPercent(1000, 1000))
 }
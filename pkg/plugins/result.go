@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+)
+
+// Limits of the result the task service stores for a task. Results reported by
+// workers are cut down to them with Limit, and the server rejects larger ones.
+const (
+	// MaxOutputs is the number of outputs stored per task.
+	MaxOutputs = 64
+	// MaxOutputNameLength is the length in bytes of an output name.
+	MaxOutputNameLength = 128
+	// MaxOutputValueLength is the length in bytes of an output value.
+	MaxOutputValueLength = 4096
+	// MaxDataBytes is the size of the JSON document of a result.
+	MaxDataBytes = 64 * 1024
+)
+
+// Limit returns a copy of the result that fits the limits of the task service, and
+// whether anything was dropped: the outputs past MaxOutputs in name order, the
+// outputs with longer names and the data when it is too large or not valid JSON.
+// Values are truncated to MaxOutputValueLength on a UTF-8 boundary.
+func (r Result) Limit() (Result, bool) {
+	limited := Result{Metrics: r.Metrics}
+	cut := false
+
+	names := make([]string, 0, len(r.Outputs))
+	for name := range r.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" || len(name) > MaxOutputNameLength || len(limited.Outputs) == MaxOutputs {
+			cut = true
+			continue
+		}
+		value := r.Outputs[name]
+		if len(value) > MaxOutputValueLength {
+			value = truncateUTF8(value, MaxOutputValueLength)
+			cut = true
+		}
+		if limited.Outputs == nil {
+			limited.Outputs = make(map[string]string, min(len(r.Outputs), MaxOutputs))
+		}
+		limited.Outputs[name] = value
+	}
+
+	if len(r.Data) > 0 {
+		if len(r.Data) <= MaxDataBytes && json.Valid(r.Data) {
+			limited.Data = r.Data
+		} else {
+			cut = true
+		}
+	}
+	return limited, cut
+}
+
+// truncateUTF8 returns the longest prefix of s that is at most n bytes long
+// and does not end in the middle of a UTF-8 sequence.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
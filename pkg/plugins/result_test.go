@@ -0,0 +1,101 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResultLimit(t *testing.T) {
+	result := Result{
+		Outputs: map[string]string{"exit_code": "0", "stdout": "ok"},
+		Data:    json.RawMessage(`{"rows":3}`),
+		Metrics: map[string]float64{"duration_seconds": 1.5},
+	}
+	got, cut := result.Limit()
+	if cut || !reflect.DeepEqual(got, result) {
+		t.Errorf("Limit() = %v, %v, want the result unchanged", got, cut)
+	}
+
+	if got, cut := (Result{}).Limit(); cut || got.Outputs != nil || got.Data != nil {
+		t.Errorf("Limit() of an empty result = %v, %v", got, cut)
+	}
+}
+
+func TestResultLimitCuts(t *testing.T) {
+	outputs := map[string]string{
+		strings.Repeat("n", MaxOutputNameLength+1): "too long a name",
+		"body": strings.Repeat("é", MaxOutputValueLength), // 2 bytes per rune
+	}
+	for i := 0; len(outputs) < MaxOutputs+5; i++ {
+		outputs[fmt.Sprintf("out_%03d", i)] = "x"
+	}
+
+	tests := []struct {
+		name   string
+		result Result
+		check  func(t *testing.T, got Result)
+	}{
+		{
+			name:   "Outputs",
+			result: Result{Outputs: outputs},
+			check: func(t *testing.T, got Result) {
+				if len(got.Outputs) != MaxOutputs {
+					t.Errorf("got %d outputs, want %d", len(got.Outputs), MaxOutputs)
+				}
+				// Outputs are kept in name order
+				if _, ok := got.Outputs["body"]; !ok {
+					t.Errorf("body output was dropped")
+				}
+				if _, ok := got.Outputs["out_063"]; ok {
+					t.Errorf("out_063 output was kept")
+				}
+				if body := got.Outputs["body"]; len(body) != MaxOutputValueLength || !strings.HasPrefix(outputs["body"], body) {
+					t.Errorf("body was truncated to %d bytes", len(body))
+				}
+			},
+		},
+		{
+			name:   "Invalid data",
+			result: Result{Data: json.RawMessage(`{"rows":`)},
+			check: func(t *testing.T, got Result) {
+				if got.Data != nil {
+					t.Errorf("Data = %s, want nil", got.Data)
+				}
+			},
+		},
+		{
+			name:   "Large data",
+			result: Result{Data: json.RawMessage(`"` + strings.Repeat("x", MaxDataBytes) + `"`)},
+			check: func(t *testing.T, got Result) {
+				if got.Data != nil {
+					t.Errorf("Data has %d bytes, want nil", len(got.Data))
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cut := tt.result.Limit()
+			if !cut {
+				t.Errorf("Limit() did not report a cut")
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("héllo", 2); got != "h" {
+		t.Errorf("truncateUTF8() = %q, want %q", got, "h")
+	}
+	if got := truncateUTF8("héllo", 3); got != "hé" {
+		t.Errorf("truncateUTF8() = %q, want %q", got, "hé")
+	}
+	if got := truncateUTF8("hi", 5); got != "hi" {
+		t.Errorf("truncateUTF8() = %q, want %q", got, "hi")
+	}
+}
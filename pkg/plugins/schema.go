@@ -0,0 +1,174 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/pkg/secret"
+)
+
+// ParameterType is the type of the value of a task parameter.
+type ParameterType string
+
+const (
+	ParameterString   ParameterType = "string"
+	ParameterInt      ParameterType = "int"
+	ParameterFloat    ParameterType = "float"
+	ParameterBool     ParameterType = "bool"
+	ParameterDuration ParameterType = "duration"
+)
+
+// Parameter declares a payload parameter of a task type.
+type Parameter struct {
+	Name        string
+	Type        ParameterType
+	Description string
+	// Required parameters must be set to a non-empty value.
+	Required bool
+	// Default is the value the plugin uses when the parameter is not set, if it does not
+	// depend on the worker configuration. It is informational.
+	Default string
+	// Pattern is a regular expression the whole value must match, if set.
+	Pattern string
+	// Secret parameters only accept secret://<name> references, so that their values
+	// are never stored or displayed with the task.
+	Secret bool
+}
+
+// Schema declares the parameters of a task type.
+type Schema struct {
+	Description string
+	Parameters  []Parameter
+	// AdditionalParameters accepts parameters that are not declared,
+	// such as values rendered into templates.
+	AdditionalParameters bool
+}
+
+// Describer is implemented by plugins that declare the parameters they accept.
+type Describer interface {
+	Schema() Schema
+}
+
+// Describe returns the schema of the task type. Plugins that do not implement
+// Describer accept any parameters.
+func Describe(pluginType string) (Schema, error) {
+	plugin, err := NewPlugin(pluginType)
+	if err != nil {
+		return Schema{}, err
+	}
+	if d, ok := plugin.(Describer); ok {
+		return d.Schema(), nil
+	}
+	return Schema{AdditionalParameters: true}, nil
+}
+
+// FieldError describes why a parameter is invalid.
+type FieldError struct {
+	Parameter string
+	Message   string
+}
+
+// ValidationError lists the invalid parameters of a payload.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Parameter + ": " + f.Message
+	}
+	return "invalid parameters: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the parameters against the schema and returns a *ValidationError
+// listing every invalid parameter. Empty values count as not set.
+func (s Schema) Validate(params map[string]string) error {
+	var fields []FieldError
+	declared := make(map[string]bool, len(s.Parameters))
+	for _, p := range s.Parameters {
+		declared[p.Name] = true
+		value := params[p.Name]
+		if strings.TrimSpace(value) == "" {
+			if p.Required {
+				fields = append(fields, FieldError{Parameter: p.Name, Message: "is required"})
+			}
+			continue
+		}
+		// The value of a secret is only known to the worker, so the plugin checks it when run
+		if _, ok := secret.ParseRef(value); ok {
+			continue
+		}
+		if p.Secret {
+			fields = append(fields, FieldError{Parameter: p.Name, Message: "must refer to a secret, such as " + secret.Ref("name")})
+			continue
+		}
+		if msg := p.check(value); msg != "" {
+			fields = append(fields, FieldError{Parameter: p.Name, Message: msg})
+		}
+	}
+
+	var unknown []string
+	for name := range params {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		if p, ok := s.secretFold(name); ok {
+			// Plugins may not tell the names apart, such as http_request with header names
+			fields = append(fields, FieldError{Parameter: name, Message: "must be written " + p.Name + ", which only accepts secrets"})
+		} else if !s.AdditionalParameters {
+			fields = append(fields, FieldError{Parameter: name, Message: "is not a parameter of this task type"})
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// secretFold returns the secret parameter whose name equals name under case folding.
+func (s Schema) secretFold(name string) (Parameter, bool) {
+	for _, p := range s.Parameters {
+		if p.Secret && strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Parameter{}, false
+}
+
+// check returns why value is invalid for the parameter, or an empty string when it is valid.
+func (p Parameter) check(value string) string {
+	var err error
+	switch p.Type {
+	case ParameterInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case ParameterFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case ParameterBool:
+		_, err = strconv.ParseBool(value)
+	case ParameterDuration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Sprintf("must be a valid %s", p.Type)
+	}
+
+	if p.Pattern != "" {
+		re, err := regexp.Compile(`^(?:` + p.Pattern + `)$`)
+		if err != nil {
+			return fmt.Sprintf("has an invalid pattern %q", p.Pattern)
+		}
+		if !re.MatchString(value) {
+			return fmt.Sprintf("must match %s", p.Pattern)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testSchema = Schema{
+	Parameters: []Parameter{
+		{Name: "to", Type: ParameterString, Required: true},
+		{Name: "count", Type: ParameterInt},
+		{Name: "ratio", Type: ParameterFloat},
+		{Name: "dry_run", Type: ParameterBool},
+		{Name: "timeout", Type: ParameterDuration},
+		{Name: "format", Type: ParameterString, Pattern: "csv|json"},
+		{Name: "header_Authorization", Type: ParameterString, Secret: true},
+	},
+}
+
+func TestSchemaValidate(t *testing.T) {
+	valid := map[string]string{"to": "ada", "count": "3", "ratio": "0.5", "dry_run": "true", "timeout": "1m30s", "format": "csv"}
+	if err := testSchema.Validate(valid); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	err := testSchema.Validate(map[string]string{
+		"to":      " ",
+		"count":   "three",
+		"ratio":   "half",
+		"dry_run": "maybe",
+		"timeout": "90",
+		"format":  "jsonl",
+		"extra":   "1",
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+	}
+	want := []FieldError{
+		{Parameter: "to", Message: "is required"},
+		{Parameter: "count", Message: "must be a valid int"},
+		{Parameter: "ratio", Message: "must be a valid float"},
+		{Parameter: "dry_run", Message: "must be a valid bool"},
+		{Parameter: "timeout", Message: "must be a valid duration"},
+		{Parameter: "format", Message: "must match csv|json"},
+		{Parameter: "extra", Message: "is not a parameter of this task type"},
+	}
+	if !reflect.DeepEqual(validationErr.Fields, want) {
+		t.Errorf("Validate() fields = %v, want %v", validationErr.Fields, want)
+	}
+}
+
+func TestSchemaValidateSecretRefs(t *testing.T) {
+	params := map[string]string{"to": "secret://recipient", "count": "secret://count", "format": "secret://format"}
+	if err := testSchema.Validate(params); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
+func TestSchemaValidateSecrets(t *testing.T) {
+	schema := testSchema
+	schema.AdditionalParameters = true
+	if err := schema.Validate(map[string]string{"to": "ada", "header_Authorization": "secret://api-token"}); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	err := schema.Validate(map[string]string{"to": "ada", "header_Authorization": "Bearer abc", "header_authorization": "Bearer abc"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+	}
+	want := []FieldError{
+		{Parameter: "header_Authorization", Message: "must refer to a secret, such as secret://name"},
+		{Parameter: "header_authorization", Message: "must be written header_Authorization, which only accepts secrets"},
+	}
+	if !reflect.DeepEqual(validationErr.Fields, want) {
+		t.Errorf("Validate() fields = %v, want %v", validationErr.Fields, want)
+	}
+}
+
+func TestSchemaAdditionalParameters(t *testing.T) {
+	schema := testSchema
+	schema.AdditionalParameters = true
+	if err := schema.Validate(map[string]string{"to": "ada", "header_Accept": "text/plain"}); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	Register("test_undescribed", func() Plugin { return &fakePlugin{} })
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "test_undescribed")
+		registryMu.Unlock()
+	})
+
+	schema, err := Describe("test_undescribed")
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if !schema.AdditionalParameters || len(schema.Parameters) != 0 {
+		t.Errorf("Describe() = %+v, want a schema that accepts any parameters", schema)
+	}
+
+	if _, err := Describe("UNKNOWN"); err == nil {
+		t.Errorf("Describe() error = nil, want an error for an unknown type")
+	}
+}
@@ -0,0 +1,195 @@
+package wasm
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// pageSize is the size of a page of WebAssembly linear memory.
+const pageSize = 64 * 1024
+
+// engine runs modules with the memory limit of a configuration and keeps the modules
+// it has compiled, so that a module is only compiled once however many tasks run it.
+// It keeps at most maxModules of them, closing the least recently used one beyond that.
+type engine struct {
+	runtime    wazero.Runtime
+	maxModules int
+
+	mu sync.Mutex
+	// compiled holds the elements of lru by the hex SHA-256 digest of their code.
+	compiled map[string]*list.Element
+	// lru holds the *compiledModule values from the most to the least recently used.
+	lru *list.List
+}
+
+// compiledModule is a module kept compiled by an engine.
+type compiledModule struct {
+	digest string
+	module wazero.CompiledModule
+	// users counts the runs of the module, which is only closed once they finished.
+	users   int
+	evicted bool
+}
+
+// engineKey identifies the settings a runtime is created with.
+type engineKey struct {
+	memoryPages uint32
+	cacheDir    string
+	maxModules  int
+}
+
+var (
+	enginesMu sync.Mutex
+	engines   = map[engineKey]*engine{}
+)
+
+// engineFor returns the engine of the configuration, creating it on first use.
+// Engines live as long as the worker.
+func engineFor(ctx context.Context, cfg *Config) (*engine, error) {
+	pages := cfg.MaxMemoryBytes / pageSize
+	if pages < 1 || pages > 65536 {
+		return nil, fmt.Errorf("WASM_MAX_MEMORY_BYTES must be between 64 KiB and 4 GiB")
+	}
+	if cfg.MaxCompiledModules < 1 {
+		return nil, fmt.Errorf("WASM_MAX_COMPILED_MODULES must be positive")
+	}
+	key := engineKey{memoryPages: uint32(pages), cacheDir: cfg.CacheDir, maxModules: cfg.MaxCompiledModules}
+
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	if e, ok := engines[key]; ok {
+		return e, nil
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(key.memoryPages)
+	if cfg.CacheDir != "" {
+		cache, err := wazero.NewCompilationCacheWithDir(cfg.CacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open WASM_CACHE_DIR: %w", err)
+		}
+		runtimeConfig = runtimeConfig.WithCompilationCache(cache)
+	}
+	// The runtime outlives the task that creates it
+	r := wazero.NewRuntimeWithConfig(context.WithoutCancel(ctx), runtimeConfig)
+	if _, err := wasi_snapshot_preview1.Instantiate(context.WithoutCancel(ctx), r); err != nil {
+		r.Close(context.Background())
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	e := &engine{runtime: r, maxModules: key.maxModules, compiled: map[string]*list.Element{}, lru: list.New()}
+	engines[key] = e
+	return e, nil
+}
+
+// compile returns the compiled module of the code with the given digest, along with
+// the function to call once the run of the module finished.
+func (e *engine) compile(ctx context.Context, digest string, code []byte) (wazero.CompiledModule, func(), error) {
+	// Modules are compiled one at a time, so that concurrent tasks do not compile the same module twice
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if elem, ok := e.compiled[digest]; ok {
+		e.lru.MoveToFront(elem)
+		compiled := elem.Value.(*compiledModule)
+		return compiled.module, e.use(compiled), nil
+	}
+
+	// The call listener is compiled in and meters the runs whose context carries a meter
+	module, err := e.runtime.CompileModule(experimental.WithFunctionListenerFactory(ctx, callListenerFactory), code)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compile module: %w", err)
+	}
+	compiled := &compiledModule{digest: digest, module: module}
+	e.compiled[digest] = e.lru.PushFront(compiled)
+	for e.lru.Len() > e.maxModules {
+		oldest := e.lru.Remove(e.lru.Back()).(*compiledModule)
+		delete(e.compiled, oldest.digest)
+		oldest.evicted = true
+		e.closeUnused(oldest)
+	}
+	return module, e.use(compiled), nil
+}
+
+// use counts a run of the module until the returned function is called. The caller holds e.mu.
+func (e *engine) use(compiled *compiledModule) func() {
+	compiled.users++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			compiled.users--
+			e.closeUnused(compiled)
+		})
+	}
+}
+
+// closeUnused closes the module once it was evicted and no run uses it. The caller holds e.mu.
+func (e *engine) closeUnused(compiled *compiledModule) {
+	if compiled.evicted && compiled.users == 0 {
+		compiled.module.Close(context.Background())
+	}
+}
+
+// meter counts the function calls of a run and stops the run once they exceed its limit.
+type meter struct {
+	limit int64
+	calls atomic.Int64
+	stop  context.CancelFunc
+	out   atomic.Bool
+}
+
+type meterKey struct{}
+
+func newMeter(limit int64, stop context.CancelFunc) *meter {
+	return &meter{limit: limit, stop: stop}
+}
+
+// withMeter returns a context that makes the module meter its calls with m.
+func withMeter(ctx context.Context, m *meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+func (m *meter) consume() {
+	if m.calls.Add(1) > m.limit && m.out.CompareAndSwap(false, true) {
+		m.stop()
+	}
+}
+
+// used returns the number of calls the run made, up to its limit.
+func (m *meter) used() int64 {
+	return min(m.calls.Load(), m.limit)
+}
+
+// exhausted reports whether the run was stopped because it exceeded its call limit.
+func (m *meter) exhausted() bool {
+	return m.out.Load()
+}
+
+// callListener counts each call of a function defined by the module. Calls of host functions
+// such as WASI are not metered, and neither are the instructions of a function, so a loop that
+// calls no function runs until the timeout of the run.
+type callListener struct{}
+
+func (callListener) Before(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	if m, ok := ctx.Value(meterKey{}).(*meter); ok {
+		m.consume()
+	}
+}
+
+func (callListener) After(context.Context, api.Module, api.FunctionDefinition, []uint64) {}
+
+func (callListener) Abort(context.Context, api.Module, api.FunctionDefinition, error) {}
+
+var callListenerFactory = experimental.FunctionListenerFactoryFunc(func(api.FunctionDefinition) experimental.FunctionListener {
+	return callListener{}
+})
@@ -0,0 +1,7 @@
+;; grow grows its memory by 2 MiB and traps when it cannot.
+(module
+  (import "wasi_snapshot_preview1" "proc_exit" (func $proc_exit (param i32)))
+  (memory (export "memory") 1)
+  (func (export "_start")
+    (if (i32.eq (memory.grow (i32.const 32)) (i32.const -1))
+      (then unreachable))))
@@ -0,0 +1,9 @@
+;; spin calls a function forever.
+(module
+  (import "wasi_snapshot_preview1" "proc_exit" (func $proc_exit (param i32)))
+  (memory (export "memory") 1)
+  (func $tick)
+  (func (export "_start")
+    (loop $spin
+      (call $tick)
+      (br $spin))))
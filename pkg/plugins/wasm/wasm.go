@@ -0,0 +1,310 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"task/pkg/plugins"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+var PLUGIN_NAME = "wasm"
+
+// Config holds the worker settings of the wasm plugin
+type Config struct {
+	// ModuleDir is the directory the modules of the tasks are loaded from.
+	ModuleDir string `envconfig:"WASM_MODULE_DIR"`
+	// CacheDir optionally persists the compiled modules across worker restarts.
+	CacheDir string `envconfig:"WASM_CACHE_DIR"`
+	// MaxCompiledModules bounds the modules the worker keeps compiled in memory.
+	MaxCompiledModules int `envconfig:"WASM_MAX_COMPILED_MODULES" default:"64"`
+	// MaxMemoryBytes bounds the linear memory of each module, rounded down to 64 KiB pages.
+	MaxMemoryBytes int64 `envconfig:"WASM_MAX_MEMORY_BYTES" default:"67108864"`
+	// MaxCalls bounds the number of function calls of each run; tasks can only lower it.
+	// Loops that call no function are only bounded by MaxTimeout.
+	MaxCalls int64 `envconfig:"WASM_MAX_CALLS" default:"100000000"`
+	// MaxTimeout bounds the wall-clock time of each run; tasks can only lower it.
+	MaxTimeout time.Duration `envconfig:"WASM_MAX_TIMEOUT" default:"5m"`
+	// MaxOutputBytes bounds the stdout captured as the output of a run, and the stderr logged.
+	MaxOutputBytes int `envconfig:"WASM_MAX_OUTPUT_BYTES" default:"1048576"`
+}
+
+// Wasm runs a WebAssembly module built for WASI preview 1 in a sandbox.
+// The module only gets the task args and env, and the payload parameters as a JSON
+// object on stdin and as PARAM_<NAME> environment variables; it has no access to the
+// file system or network. Its stdout becomes the stdout output of the task.
+// The task parameters are:
+//
+//   - module: the path of the .wasm file, relative to WASM_MODULE_DIR
+//   - sha256: an optional hex digest the module must match
+//   - max_calls: an optional number of function calls lower than WASM_MAX_CALLS
+//   - timeout: an optional wall-clock limit lower than WASM_MAX_TIMEOUT, such as 30s
+//
+// The other parameters are passed to the module. Only the calls of functions defined by the module
+// count towards max_calls, so a loop that calls no function is only stopped by the timeout.
+type Wasm struct {
+	// Config is loaded from the environment on the first run when nil.
+	Config *Config
+}
+
+func init() {
+	plugins.Register(PLUGIN_NAME, func() plugins.Plugin { return &Wasm{} })
+}
+
+// runtimeParameters are the parameters read by the plugin rather than passed to the module.
+var runtimeParameters = map[string]bool{"module": true, "sha256": true, "max_calls": true, "timeout": true}
+
+// Schema declares the parameters of wasm. Parameters that are not declared are passed to the module.
+func (w *Wasm) Schema() plugins.Schema {
+	return plugins.Schema{
+		Description: "Runs a sandboxed WebAssembly module and captures its stdout",
+		Parameters: []plugins.Parameter{
+			{Name: "module", Type: plugins.ParameterString, Required: true, Pattern: `[^/].*\.wasm`, Description: "Path of the module, relative to WASM_MODULE_DIR"},
+			{Name: "sha256", Type: plugins.ParameterString, Pattern: "[0-9a-f]{64}", Description: "Hex digest the module must match"},
+			{Name: "max_calls", Type: plugins.ParameterInt, Pattern: "[1-9][0-9]*", Description: "Limit of the calls of module functions, lowering WASM_MAX_CALLS; loops without calls are only bounded by the timeout"},
+			{Name: "timeout", Type: plugins.ParameterDuration, Description: "Wall-clock limit, lowering WASM_MAX_TIMEOUT"},
+		},
+		AdditionalParameters: true,
+	}
+}
+
+// limits are the resource limits a module is run with.
+type limits struct {
+	calls   int64
+	timeout time.Duration
+}
+
+func (w *Wasm) Run(ctx context.Context, task plugins.TaskContext) (plugins.Result, error) {
+	if w.Config == nil {
+		var cfg Config
+		if err := envconfig.Process("", &cfg); err != nil {
+			return plugins.Result{}, fmt.Errorf("failed to load wasm configuration: %w", err)
+		}
+		w.Config = &cfg
+	}
+	cfg := w.Config
+
+	lim, err := parseLimits(cfg, task.Parameters)
+	if err != nil {
+		return plugins.Result{}, err
+	}
+	code, digest, err := readModule(cfg.ModuleDir, task.Parameters["module"], task.Parameters["sha256"])
+	if err != nil {
+		return plugins.Result{}, err
+	}
+
+	eng, err := engineFor(ctx, cfg)
+	if err != nil {
+		return plugins.Result{}, err
+	}
+	compiled, release, err := eng.compile(ctx, digest, code)
+	if err != nil {
+		return plugins.Result{}, err
+	}
+	defer release()
+
+	stdin, err := json.Marshal(moduleParameters(task.Parameters))
+	if err != nil {
+		return plugins.Result{}, fmt.Errorf("failed to encode parameters: %w", err)
+	}
+	stdout := &limitedBuffer{max: cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{max: cfg.MaxOutputBytes}
+	modConfig := wazero.NewModuleConfig().
+		// Anonymous so that the same module can run for several tasks at once
+		WithName("").
+		WithArgs(append([]string{task.Parameters["module"]}, task.Args...)...).
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+	for _, kv := range environ(task) {
+		modConfig = modConfig.WithEnv(kv[0], kv[1])
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, lim.timeout)
+	defer cancel()
+	calls := newMeter(lim.calls, cancel)
+	runCtx = withMeter(runCtx, calls)
+
+	task.Logger.Info("Running module", "module", task.Parameters["module"], "sha256", digest, "max_calls", lim.calls, "timeout", lim.timeout)
+	start := time.Now()
+	mod, runErr := eng.runtime.InstantiateModule(runCtx, compiled, modConfig)
+	if mod != nil {
+		mod.Close(context.Background())
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n") {
+		if line != "" {
+			task.Logger.Info("Module output", "stream", "stderr", "line", line)
+		}
+	}
+	if stdout.truncated {
+		task.Logger.Warn("Module output was truncated", "max_bytes", cfg.MaxOutputBytes)
+	}
+
+	exitCode, err := exitError(ctx, runErr, calls, lim)
+	result := plugins.Result{
+		Outputs: map[string]string{"stdout": stdout.String()},
+		Metrics: map[string]float64{
+			"duration_seconds": time.Since(start).Seconds(),
+			"calls":            float64(calls.used()),
+		},
+	}
+	if exitCode >= 0 {
+		result.Outputs["exit_code"] = strconv.FormatInt(exitCode, 10)
+	}
+	return result, err
+}
+
+// exitError maps the way the module ended to the error of the task, which is nil when
+// the module returned or exited with 0. The exit code is -1 when the module was stopped.
+func exitError(ctx context.Context, runErr error, calls *meter, lim limits) (int64, error) {
+	if runErr == nil {
+		return 0, nil
+	}
+	var exitErr *sys.ExitError
+	if !errors.As(runErr, &exitErr) {
+		return -1, fmt.Errorf("module failed: %w", runErr)
+	}
+	switch code := exitErr.ExitCode(); {
+	case calls.exhausted():
+		return -1, fmt.Errorf("module exceeded its limit of %d function calls", lim.calls)
+	case ctx.Err() != nil:
+		return -1, fmt.Errorf("module was stopped: %w", ctx.Err())
+	case code == sys.ExitCodeDeadlineExceeded:
+		return -1, fmt.Errorf("module did not finish within %s", lim.timeout)
+	default:
+		return int64(code), fmt.Errorf("module exited with code %d", code)
+	}
+}
+
+// parseLimits returns the limits of the run, lowered by the task parameters.
+func parseLimits(cfg *Config, params map[string]string) (limits, error) {
+	lim := limits{calls: cfg.MaxCalls, timeout: cfg.MaxTimeout}
+	if value := params["max_calls"]; value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			return limits{}, fmt.Errorf("invalid max_calls %q", value)
+		}
+		lim.calls = min(lim.calls, n)
+	}
+	if value := params["timeout"]; value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return limits{}, fmt.Errorf("invalid timeout %q", value)
+		}
+		lim.timeout = min(lim.timeout, d)
+	}
+	if lim.calls <= 0 {
+		return limits{}, fmt.Errorf("WASM_MAX_CALLS must be positive")
+	}
+	if lim.timeout <= 0 {
+		return limits{}, fmt.Errorf("WASM_MAX_TIMEOUT must be positive")
+	}
+	return lim, nil
+}
+
+// readModule reads the module from dir and returns it with its hex SHA-256 digest,
+// which must match want when it is set.
+func readModule(dir, name, want string) ([]byte, string, error) {
+	if dir == "" {
+		return nil, "", fmt.Errorf("WASM_MODULE_DIR is not set")
+	}
+	if name == "" {
+		return nil, "", fmt.Errorf("the wasm task type requires a module")
+	}
+	if !filepath.IsLocal(name) {
+		return nil, "", fmt.Errorf("module %s is not a path inside WASM_MODULE_DIR", name)
+	}
+	code, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read module: %w", err)
+	}
+	sum := sha256.Sum256(code)
+	digest := hex.EncodeToString(sum[:])
+	if want != "" && !strings.EqualFold(want, digest) {
+		return nil, "", fmt.Errorf("module %s has digest %s instead of %s", name, digest, want)
+	}
+	return code, digest, nil
+}
+
+// moduleParameters returns the parameters that are passed to the module.
+func moduleParameters(params map[string]string) map[string]string {
+	result := make(map[string]string, len(params))
+	for name, value := range params {
+		if !runtimeParameters[name] {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// environ returns the sorted environment of the module: TASK_ID and TASK_ATTEMPT,
+// the parameters as PARAM_<NAME>, and the env of the task, which takes precedence.
+func environ(task plugins.TaskContext) [][2]string {
+	env := map[string]string{
+		"TASK_ID":      strconv.FormatInt(task.TaskID, 10),
+		"TASK_ATTEMPT": strconv.Itoa(task.Attempt),
+	}
+	for name, value := range moduleParameters(task.Parameters) {
+		env["PARAM_"+envName(name)] = value
+	}
+	for key, value := range task.Env {
+		env[key] = value
+	}
+
+	result := make([][2]string, 0, len(env))
+	for key, value := range env {
+		result = append(result, [2]string{key, value})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result
+}
+
+// envName upper-cases the parameter name and replaces the characters that are not
+// allowed in environment variable names with underscores.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// limitedBuffer keeps the first max bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		// Report the whole write so that the module does not retry
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,270 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"task/pkg/plugins"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+)
+
+// The modules in testdata are built from the .wat files next to them.
+
+func newTaskContext(module string, params map[string]string) plugins.TaskContext {
+	parameters := map[string]string{"module": module}
+	for name, value := range params {
+		parameters[name] = value
+	}
+	return plugins.TaskContext{TaskID: 7, Attempt: 1, Parameters: parameters, Logger: slog.Default()}
+}
+
+func newTestPlugin() *Wasm {
+	return &Wasm{Config: &Config{
+		ModuleDir:          "testdata",
+		MaxCompiledModules: 8,
+		MaxMemoryBytes:     1 << 20,
+		MaxCalls:           1_000_000,
+		MaxTimeout:         time.Minute,
+		MaxOutputBytes:     1024,
+	}}
+}
+
+func TestRun(t *testing.T) {
+	task := newTaskContext("echo.wasm", map[string]string{"greeting": "hello", "max-retries": "3", "max_calls": "1000"})
+	task.Env = map[string]string{"MODE": "fast", "TASK_ATTEMPT": "override"}
+
+	result, err := newTestPlugin().Run(context.Background(), task)
+	require.NoError(t, err)
+	assert.Equal(t, "0", result.Outputs["exit_code"])
+
+	// echo writes its environment, NUL-separated, followed by its stdin
+	stdout := result.Outputs["stdout"]
+	env, stdin, ok := strings.Cut(stdout, "{")
+	require.True(t, ok, stdout)
+	assert.Equal(t, []string{"MODE=fast", "PARAM_GREETING=hello", "PARAM_MAX_RETRIES=3", "TASK_ATTEMPT=override", "TASK_ID=7"},
+		strings.Split(strings.TrimSuffix(env, "\x00"), "\x00"))
+	assert.JSONEq(t, `{"greeting":"hello","max-retries":"3"}`, "{"+stdin)
+	assert.Greater(t, result.Metrics["calls"], float64(0))
+}
+
+func TestRunExitCode(t *testing.T) {
+	var buf bytes.Buffer
+	task := newTaskContext("exit.wasm", nil)
+	task.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	result, err := newTestPlugin().Run(context.Background(), task)
+	assert.EqualError(t, err, "module exited with code 3")
+	assert.Equal(t, "3", result.Outputs["exit_code"])
+	assert.Contains(t, buf.String(), "stream=stderr line=oops")
+}
+
+func TestRunLimits(t *testing.T) {
+	t.Run("Calls", func(t *testing.T) {
+		result, err := newTestPlugin().Run(context.Background(), newTaskContext("spin.wasm", map[string]string{"max_calls": "500"}))
+		assert.EqualError(t, err, "module exceeded its limit of 500 function calls")
+		assert.Equal(t, float64(500), result.Metrics["calls"])
+		assert.NotContains(t, result.Outputs, "exit_code")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		// loop does not call any function, so only the timeout stops it
+		start := time.Now()
+		_, err := newTestPlugin().Run(context.Background(), newTaskContext("loop.wasm", map[string]string{"timeout": "100ms"}))
+		assert.EqualError(t, err, "module did not finish within 100ms")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		// grow traps when it cannot grow its memory by 2 MiB
+		_, err := newTestPlugin().Run(context.Background(), newTaskContext("grow.wasm", nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unreachable")
+
+		p := newTestPlugin()
+		p.Config.MaxMemoryBytes = 4 << 20
+		_, err = p.Run(context.Background(), newTaskContext("grow.wasm", nil))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		_, err := newTestPlugin().Run(ctx, newTaskContext("loop.wasm", nil))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	echo, err := os.ReadFile(filepath.Join("testdata", "echo.wasm"))
+	require.NoError(t, err)
+	sum := sha256.Sum256(echo)
+
+	tests := []struct {
+		name    string
+		task    plugins.TaskContext
+		config  func(*Config)
+		wantErr string
+	}{
+		{
+			name:    "Missing module",
+			task:    newTaskContext("", nil),
+			wantErr: "requires a module",
+		},
+		{
+			name:    "Module outside the module directory",
+			task:    newTaskContext("../wasm.go", nil),
+			wantErr: "module ../wasm.go is not a path inside WASM_MODULE_DIR",
+		},
+		{
+			name:    "Unknown module",
+			task:    newTaskContext("missing.wasm", nil),
+			wantErr: "failed to read module",
+		},
+		{
+			name:    "Digest mismatch",
+			task:    newTaskContext("echo.wasm", map[string]string{"sha256": strings.Repeat("0", 64)}),
+			wantErr: "module echo.wasm has digest " + hex.EncodeToString(sum[:]),
+		},
+		{
+			name:    "Not a module",
+			task:    newTaskContext("echo.wat", nil),
+			wantErr: "failed to compile module",
+		},
+		{
+			name:    "No module directory",
+			task:    newTaskContext("echo.wasm", nil),
+			config:  func(cfg *Config) { cfg.ModuleDir = "" },
+			wantErr: "WASM_MODULE_DIR is not set",
+		},
+		{
+			name:    "Invalid max_calls",
+			task:    newTaskContext("echo.wasm", map[string]string{"max_calls": "lots"}),
+			wantErr: `invalid max_calls "lots"`,
+		},
+		{
+			name:    "Memory limit too low",
+			task:    newTaskContext("echo.wasm", nil),
+			config:  func(cfg *Config) { cfg.MaxMemoryBytes = 1024 },
+			wantErr: "WASM_MAX_MEMORY_BYTES must be between 64 KiB and 4 GiB",
+		},
+		{
+			name:    "No compiled modules",
+			task:    newTaskContext("echo.wasm", nil),
+			config:  func(cfg *Config) { cfg.MaxCompiledModules = 0 },
+			wantErr: "WASM_MAX_COMPILED_MODULES must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlugin()
+			if tt.config != nil {
+				tt.config(p.Config)
+			}
+			_, err := p.Run(context.Background(), tt.task)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCompileCache(t *testing.T) {
+	cfg := newTestPlugin().Config
+	cfg.MaxMemoryBytes = 3 << 20 // A runtime of its own
+	eng, err := engineFor(context.Background(), cfg)
+	require.NoError(t, err)
+
+	code, digest, err := readModule("testdata", "echo.wasm", "")
+	require.NoError(t, err)
+	first, release, err := eng.compile(context.Background(), digest, code)
+	require.NoError(t, err)
+	release()
+
+	// The same code under another name is not compiled again
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "copy.wasm"), code, 0o644))
+	code, copyDigest, err := readModule(dir, "copy.wasm", digest)
+	require.NoError(t, err)
+	assert.Equal(t, digest, copyDigest)
+	again, release, err := eng.compile(context.Background(), copyDigest, code)
+	require.NoError(t, err)
+	release()
+	assert.Same(t, first, again)
+	assert.Len(t, eng.compiled, 1)
+
+	same, err := engineFor(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Same(t, eng, same)
+}
+
+func TestCompileCacheEviction(t *testing.T) {
+	cfg := newTestPlugin().Config
+	cfg.MaxMemoryBytes = 5 << 20 // A runtime of its own
+	cfg.MaxCompiledModules = 1
+	eng, err := engineFor(context.Background(), cfg)
+	require.NoError(t, err)
+	compile := func(module string) (wazero.CompiledModule, func()) {
+		code, digest, err := readModule("testdata", module, "")
+		require.NoError(t, err)
+		compiled, release, err := eng.compile(context.Background(), digest, code)
+		require.NoError(t, err)
+		return compiled, release
+	}
+
+	// The module that is evicted while it runs is only closed once the run finished
+	echo, releaseEcho := compile("echo.wasm")
+	_, releaseExit := compile("exit.wasm")
+	releaseExit()
+	assert.Len(t, eng.compiled, 1)
+	mod, err := eng.runtime.InstantiateModule(context.Background(), echo, wazero.NewModuleConfig().WithName(""))
+	require.NoError(t, err)
+	mod.Close(context.Background())
+	releaseEcho()
+
+	// The evicted module is compiled again
+	again, releaseAgain := compile("echo.wasm")
+	defer releaseAgain()
+	assert.NotSame(t, echo, again)
+	assert.Len(t, eng.compiled, 1)
+}
+
+func TestRunConcurrently(t *testing.T) {
+	p := newTestPlugin()
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := p.Run(context.Background(), newTaskContext("echo.wasm", nil))
+			errs <- err
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		assert.NoError(t, <-errs)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 5}
+	n, err := b.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = b.Write([]byte("defg"))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, "abcde", b.String())
+	assert.True(t, b.truncated)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "MAX_RETRIES", envName("max-retries"))
+	assert.Equal(t, "USER_ID2", envName("user.id2"))
+}
@@ -0,0 +1,142 @@
+// Package progress reports the progress of task runs to the task service, which
+// shows it in GetTask and on followed log streams while the task runs.
+package progress
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+)
+
+// MaxMessageLength is the length of the longest message a progress report may have; longer messages are cut.
+const MaxMessageLength = 256
+
+const (
+	// DefaultInterval is how often the latest progress is reported.
+	DefaultInterval = time.Second
+	// reportTimeout bounds a single ReportProgress call.
+	reportTimeout = 10 * time.Second
+)
+
+// update is a progress report that has not been sent yet.
+type update struct {
+	percent int32
+	message string
+}
+
+// Reporter reports the progress of one attempt of a task in the background. Only the
+// latest progress is kept, and it is reported at most once per interval, so plugins can
+// report as often as they like. Close reports the last progress that was not reported yet.
+type Reporter struct {
+	client  cloudv1connect.TaskManagementServiceClient
+	taskID  int32
+	attempt int32
+	// errors logs the failures to report progress.
+	errors *slog.Logger
+
+	mu      sync.Mutex
+	pending *update
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	closed  sync.Once
+}
+
+// NewReporter starts a Reporter for an attempt of a task. Failures to report progress
+// are logged to errors, and a nil errors discards them.
+func NewReporter(client cloudv1connect.TaskManagementServiceClient, taskID int64, attempt int, interval time.Duration, errors *slog.Logger) *Reporter {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if errors == nil {
+		errors = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	r := &Reporter{
+		client:  client,
+		taskID:  int32(taskID),
+		attempt: int32(attempt),
+		errors:  errors,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go r.run(interval)
+	return r
+}
+
+// Report records the progress of the attempt: the percentage of the work done, clamped
+// to 0 to 100, and a short message describing what the task is doing.
+func (r *Reporter) Report(percent int, message string) {
+	r.mu.Lock()
+	r.pending = &update{percent: int32(min(max(percent, 0), 100)), message: truncate(message, MaxMessageLength)}
+	r.mu.Unlock()
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the Reporter and reports the progress that was not reported yet.
+func (r *Reporter) Close(ctx context.Context) error {
+	r.closed.Do(func() { close(r.done) })
+	<-r.stopped
+	return r.send(ctx)
+}
+
+// run reports the pending progress whenever Report is called, at most once per interval.
+func (r *Reporter) run(interval time.Duration) {
+	defer close(r.stopped)
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-r.wake:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+		if err := r.send(ctx); err != nil {
+			r.errors.Warn("Failed to report task progress", "task_id", r.taskID, "attempt", r.attempt, "error", err)
+		}
+		cancel()
+		select {
+		case <-r.done:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// send reports the pending progress, if any. Progress that cannot be reported is dropped,
+// as a later report replaces it anyway.
+func (r *Reporter) send(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	if pending == nil {
+		return nil
+	}
+	_, err := r.client.ReportProgress(ctx, connect.NewRequest(&cloudv1.ReportProgressRequest{
+		Id:      r.taskID,
+		Attempt: r.attempt,
+		Percent: pending.percent,
+		Message: pending.message,
+	}))
+	return err
+}
+
+// truncate cuts s to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,89 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient records the ReportProgress requests, and fails them while err is set.
+type fakeClient struct {
+	cloudv1connect.TaskManagementServiceClient
+
+	mu       sync.Mutex
+	requests []*cloudv1.ReportProgressRequest
+	err      error
+}
+
+func (c *fakeClient) ReportProgress(_ context.Context, req *connect.Request[cloudv1.ReportProgressRequest]) (*connect.Response[cloudv1.ReportProgressResponse], error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.requests = append(c.requests, req.Msg)
+	return connect.NewResponse(&cloudv1.ReportProgressResponse{Recorded: true}), nil
+}
+
+func (c *fakeClient) reports() []*cloudv1.ReportProgressRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*cloudv1.ReportProgressRequest(nil), c.requests...)
+}
+
+func TestReporter(t *testing.T) {
+	client := &fakeClient{}
+	reporter := NewReporter(client, 7, 2, time.Hour, nil)
+
+	// The first report is sent right away, while the later ones wait for the interval
+	reporter.Report(10, "Connecting")
+	require.Eventually(t, func() bool { return len(client.reports()) == 1 }, time.Second, time.Millisecond)
+	reporter.Report(40, "Exported 4000 rows")
+	reporter.Report(150, "Exported 9000 rows")
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, client.reports(), 1)
+
+	// Close sends the latest report only
+	require.NoError(t, reporter.Close(context.Background()))
+	reports := client.reports()
+	require.Len(t, reports, 2)
+	assert.Equal(t, int32(7), reports[0].Id)
+	assert.Equal(t, int32(2), reports[0].Attempt)
+	assert.Equal(t, int32(10), reports[0].Percent)
+	assert.Equal(t, "Connecting", reports[0].Message)
+	assert.Equal(t, int32(100), reports[1].Percent)
+	assert.Equal(t, "Exported 9000 rows", reports[1].Message)
+
+	// Nothing is left to send on a second Close
+	require.NoError(t, reporter.Close(context.Background()))
+	assert.Len(t, client.reports(), 2)
+}
+
+func TestReporterLimits(t *testing.T) {
+	client := &fakeClient{err: errors.New("unavailable")}
+	reporter := NewReporter(client, 7, 1, time.Hour, nil)
+	reporter.Report(-5, strings.Repeat("é", MaxMessageLength+10))
+	time.Sleep(20 * time.Millisecond)
+
+	// The failed report is dropped rather than retried
+	client.mu.Lock()
+	client.err = nil
+	client.mu.Unlock()
+	reporter.Report(-5, strings.Repeat("é", MaxMessageLength+10))
+	require.NoError(t, reporter.Close(context.Background()))
+
+	reports := client.reports()
+	require.Len(t, reports, 1)
+	assert.Equal(t, int32(0), reports[0].Percent)
+	assert.Equal(t, strings.Repeat("é", MaxMessageLength), reports[0].Message)
+}
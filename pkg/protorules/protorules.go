@@ -0,0 +1,245 @@
+// Package protorules checks messages against the protoc-gen-validate rules declared on their
+// fields with the (validate.rules) option, which protovalidate does not enforce.
+//
+// The rules used by the task service protos are supported: min_len, max_len and pattern of strings,
+// gt, gte, lt and lte of integers, defined_only of enums, required of messages, min_items, max_items
+// and items of repeated fields, and min_pairs, max_pairs, keys and values of maps. Other rules,
+// and rules that do not match the kind of their field, are ignored.
+package protorules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Violation is a field of a message that breaks one of its rules.
+type Violation struct {
+	// Field is the path of the field, such as input_artifacts[0].name or env.HOME.
+	Field string
+	// Description describes the rule the field breaks.
+	Description string
+}
+
+// Validate returns the violations of the rules of all fields of the message,
+// including the fields of the messages it holds.
+func Validate(msg proto.Message) []Violation {
+	var v validator
+	v.message("", msg.ProtoReflect())
+	return v.violations
+}
+
+// ValidateField returns the violations of the rules of the named field of the message.
+// It returns nil when the message has no such field.
+func ValidateField(msg proto.Message, name protoreflect.Name) []Violation {
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(name)
+	if fd == nil {
+		return nil
+	}
+	var v validator
+	v.field("", m, fd)
+	return v.violations
+}
+
+// validator collects the violations of the fields it checks.
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) violate(path, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Field: path, Description: fmt.Sprintf(format, args...)})
+}
+
+// message checks the fields of a message in the order they are declared.
+func (v *validator) message(prefix string, m protoreflect.Message) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		v.field(prefix, m, fields.Get(i))
+	}
+}
+
+// field checks a field of a message against its rules.
+func (v *validator) field(prefix string, m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	path := join(prefix, string(fd.Name()))
+	rules := fieldRules(fd)
+
+	switch {
+	case fd.IsMap():
+		entries := m.Get(fd).Map()
+		v.mapField(path, fd, entries, rules.GetMap())
+	case fd.IsList():
+		v.listField(path, fd, m.Get(fd).List(), rules.GetRepeated())
+	case fd.Message() != nil:
+		if !m.Has(fd) {
+			if rules.GetMessage().GetRequired() {
+				v.violate(path, "is required")
+			}
+			return
+		}
+		if !rules.GetMessage().GetSkip() {
+			v.message(path, m.Get(fd).Message())
+		}
+	default:
+		v.scalar(path, fd, m.Get(fd), rules)
+	}
+}
+
+// listField checks the number of items of a repeated field and each of its items.
+func (v *validator) listField(path string, fd protoreflect.FieldDescriptor, list protoreflect.List, rules *validate.RepeatedRules) {
+	if rules != nil && rules.MinItems != nil && uint64(list.Len()) < rules.GetMinItems() {
+		v.violate(path, "must have at least %d items", rules.GetMinItems())
+	}
+	if rules != nil && rules.MaxItems != nil && uint64(list.Len()) > rules.GetMaxItems() {
+		v.violate(path, "must have at most %d items", rules.GetMaxItems())
+	}
+	for i := 0; i < list.Len(); i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if fd.Message() != nil {
+			v.message(itemPath, list.Get(i).Message())
+			continue
+		}
+		v.scalar(itemPath, fd, list.Get(i), rules.GetItems())
+	}
+}
+
+// mapField checks the number of pairs of a map field and each of its keys and values, sorted by key.
+func (v *validator) mapField(path string, fd protoreflect.FieldDescriptor, entries protoreflect.Map, rules *validate.MapRules) {
+	if rules != nil && rules.MinPairs != nil && uint64(entries.Len()) < rules.GetMinPairs() {
+		v.violate(path, "must have at least %d pairs", rules.GetMinPairs())
+	}
+	if rules != nil && rules.MaxPairs != nil && uint64(entries.Len()) > rules.GetMaxPairs() {
+		v.violate(path, "must have at most %d pairs", rules.GetMaxPairs())
+	}
+
+	keys := make([]protoreflect.MapKey, 0, entries.Len())
+	entries.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, key := range keys {
+		entryPath := join(path, key.String())
+		before := len(v.violations)
+		v.scalar(entryPath, fd.MapKey(), key.Value(), rules.GetKeys())
+		for i := before; i < len(v.violations); i++ {
+			v.violations[i].Description = "key " + v.violations[i].Description
+		}
+		if fd.MapValue().Message() != nil {
+			v.message(entryPath, entries.Get(key).Message())
+			continue
+		}
+		v.scalar(entryPath, fd.MapValue(), entries.Get(key), rules.GetValues())
+	}
+}
+
+// scalar checks a string, integer or enum value against the rules matching its kind.
+func (v *validator) scalar(path string, fd protoreflect.FieldDescriptor, value protoreflect.Value, rules *validate.FieldRules) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		v.stringValue(path, value.String(), rules.GetString_())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if r := rules.GetInt32(); r != nil {
+			v.intValue(path, value.Int(), toInt64(r.Gt), toInt64(r.Gte), toInt64(r.Lt), toInt64(r.Lte))
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if r := rules.GetInt64(); r != nil {
+			v.intValue(path, value.Int(), r.Gt, r.Gte, r.Lt, r.Lte)
+		}
+	case protoreflect.EnumKind:
+		if rules.GetEnum().GetDefinedOnly() && fd.Enum().Values().ByNumber(value.Enum()) == nil {
+			v.violate(path, "must be one of the defined values of %s", fd.Enum().Name())
+		}
+	}
+}
+
+// stringValue checks the length of a string, in characters, and the pattern it must match.
+func (v *validator) stringValue(path, s string, rules *validate.StringRules) {
+	if rules == nil {
+		return
+	}
+	length := uint64(utf8.RuneCountInString(s))
+	if rules.MinLen != nil && length < rules.GetMinLen() {
+		v.violate(path, "must be at least %d characters long", rules.GetMinLen())
+	}
+	if rules.MaxLen != nil && length > rules.GetMaxLen() {
+		v.violate(path, "must be at most %d characters long", rules.GetMaxLen())
+	}
+	if rules.Pattern != nil {
+		re, err := compile(rules.GetPattern())
+		if err != nil {
+			v.violate(path, "has an invalid pattern rule %q: %v", rules.GetPattern(), err)
+		} else if !re.MatchString(s) {
+			v.violate(path, "must match the pattern %q", rules.GetPattern())
+		}
+	}
+}
+
+// intValue checks an integer against the bounds that are set.
+func (v *validator) intValue(path string, n int64, gt, gte, lt, lte *int64) {
+	switch {
+	case gt != nil && n <= *gt:
+		v.violate(path, "must be greater than %d", *gt)
+	case gte != nil && n < *gte:
+		v.violate(path, "must be greater than or equal to %d", *gte)
+	}
+	switch {
+	case lt != nil && n >= *lt:
+		v.violate(path, "must be less than %d", *lt)
+	case lte != nil && n > *lte:
+		v.violate(path, "must be less than or equal to %d", *lte)
+	}
+}
+
+// fieldRules returns the (validate.rules) option of a field, or nil when it has none.
+func fieldRules(fd protoreflect.FieldDescriptor) *validate.FieldRules {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, validate.E_Rules) {
+		return nil
+	}
+	rules, _ := proto.GetExtension(opts, validate.E_Rules).(*validate.FieldRules)
+	return rules
+}
+
+var (
+	patternsMu sync.Mutex
+	patterns   = map[string]*regexp.Regexp{}
+)
+
+// compile compiles a pattern rule, caching the patterns compiled before.
+func compile(pattern string) (*regexp.Regexp, error) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	if re, ok := patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns[pattern] = re
+	return re, nil
+}
+
+func toInt64(n *int32) *int64 {
+	if n == nil {
+		return nil
+	}
+	i := int64(*n)
+	return &i
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
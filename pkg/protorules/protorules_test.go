@@ -0,0 +1,108 @@
+package protorules
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validRequest() *cloudv1.CreateTaskRequest {
+	return &cloudv1.CreateTaskRequest{
+		Name:            "send_email",
+		Type:            "send_email",
+		Payload:         &cloudv1.Payload{Parameters: map[string]string{"to": "user@example.com"}},
+		Env:             map[string]string{"HOME": "/root"},
+		InputArtifacts:  []*cloudv1.ArtifactInput{{Name: "data.csv", TaskId: 1, Artifact: "report.csv"}},
+		OutputArtifacts: []string{"out.json"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*cloudv1.CreateTaskRequest)
+		want   []Violation
+	}{
+		{
+			name:   "valid",
+			modify: func(*cloudv1.CreateTaskRequest) {},
+		},
+		{
+			name:   "name pattern",
+			modify: func(req *cloudv1.CreateTaskRequest) { req.Name = "send email" },
+			want:   []Violation{{Field: "name", Description: `must match the pattern "^[a-zA-Z0-9_-]+$"`}},
+		},
+		{
+			name:   "empty type",
+			modify: func(req *cloudv1.CreateTaskRequest) { req.Type = "" },
+			want:   []Violation{{Field: "type", Description: "must be at least 1 characters long"}},
+		},
+		{
+			name:   "missing payload",
+			modify: func(req *cloudv1.CreateTaskRequest) { req.Payload = nil },
+			want:   []Violation{{Field: "payload", Description: "is required"}},
+		},
+		{
+			name:   "parameter value too long",
+			modify: func(req *cloudv1.CreateTaskRequest) { req.Payload.Parameters["to"] = strings.Repeat("a", 1025) },
+			want:   []Violation{{Field: "payload.parameters.to", Description: "must be at most 1024 characters long"}},
+		},
+		{
+			name:   "env key pattern",
+			modify: func(req *cloudv1.CreateTaskRequest) { req.Env["1HOME"] = "/root" },
+			want:   []Violation{{Field: "env.1HOME", Description: `key must match the pattern "^[a-zA-Z_][a-zA-Z0-9_]*$"`}},
+		},
+		{
+			name: "input artifact",
+			modify: func(req *cloudv1.CreateTaskRequest) {
+				req.InputArtifacts[0].TaskId = 0
+				req.InputArtifacts[0].Artifact = ".hidden"
+			},
+			want: []Violation{
+				{Field: "input_artifacts[0].task_id", Description: "must be greater than 0"},
+				{Field: "input_artifacts[0].artifact", Description: `must match the pattern "^[a-zA-Z0-9_][a-zA-Z0-9._-]*$"`},
+			},
+		},
+		{
+			name:   "too many output artifacts",
+			modify: func(req *cloudv1.CreateTaskRequest) { req.OutputArtifacts = make([]string, 33) },
+			want: append([]Violation{{Field: "output_artifacts", Description: "must have at most 32 items"}},
+				emptyArtifacts(33)...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(req)
+			assert.Equal(t, tt.want, Validate(req))
+		})
+	}
+}
+
+// emptyArtifacts returns the violations of n empty output artifact names.
+func emptyArtifacts(n int) []Violation {
+	var violations []Violation
+	for i := 0; i < n; i++ {
+		violations = append(violations, Violation{
+			Field:       fmt.Sprintf("output_artifacts[%d]", i),
+			Description: `must match the pattern "^[a-zA-Z0-9_][a-zA-Z0-9._-]*$"`,
+		})
+	}
+	return violations
+}
+
+func TestValidateField(t *testing.T) {
+	assert.Empty(t, ValidateField(&cloudv1.Task{Retries: 10}, "retries"))
+	assert.Equal(t, []Violation{{Field: "retries", Description: "must be less than or equal to 10"}},
+		ValidateField(&cloudv1.Task{Retries: 11}, "retries"))
+	assert.Equal(t, []Violation{{Field: "priority", Description: "must be greater than or equal to 0"}},
+		ValidateField(&cloudv1.Task{Priority: -1}, "priority"))
+	// Only the named field is checked, even though the payload of the task is missing
+	assert.Empty(t, ValidateField(&cloudv1.Task{}, "priority"))
+	assert.Nil(t, ValidateField(&cloudv1.Task{}, "unknown"))
+}
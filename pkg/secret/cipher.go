@@ -0,0 +1,67 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size of the keys secrets are encrypted with, which select AES-256.
+const KeySize = 32
+
+// Cipher encrypts the values of the secrets kept by the task service with AES-GCM.
+// The name of a secret is authenticated along with its value, so that the value of
+// one secret cannot be passed off as another.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a Cipher with a key of KeySize bytes.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("the secret key must be %d bytes long, not %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// ParseKey decodes a base64 encoded key, such as one generated with "openssl rand -base64 32".
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("the secret key must be base64 encoded: %w", err)
+	}
+	return key, nil
+}
+
+// Seal encrypts the value of the named secret. The random nonce is prepended to the result.
+func (c *Cipher) Seal(name string, value []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, value, []byte(name)), nil
+}
+
+// Open decrypts the value of the named secret sealed by Seal.
+func (c *Cipher) Open(name string, sealed []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errors.New("failed to decrypt secret: value is too short")
+	}
+	value, err := c.aead.Open(nil, sealed[:size], sealed[size:], []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+	return value, nil
+}
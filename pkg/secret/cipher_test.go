@@ -0,0 +1,73 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func newTestCipher(t *testing.T) *Cipher {
+	t.Helper()
+	c, err := NewCipher(bytes.Repeat([]byte{7}, KeySize))
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	return c
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c := newTestCipher(t)
+	sealed, err := c.Seal("smtp", []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if bytes.Contains(sealed, []byte("hunter2")) {
+		t.Error("Seal() result contains the plain value")
+	}
+	value, err := c.Open("smtp", sealed)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if string(value) != "hunter2" {
+		t.Errorf("Open() = %q, want hunter2", value)
+	}
+
+	// The nonce is random, so sealing the same value twice differs
+	again, _ := c.Seal("smtp", []byte("hunter2"))
+	if bytes.Equal(sealed, again) {
+		t.Error("Seal() returned the same result twice")
+	}
+}
+
+func TestCipherOpenErrors(t *testing.T) {
+	c := newTestCipher(t)
+	sealed, _ := c.Seal("smtp", []byte("hunter2"))
+
+	if _, err := c.Open("db", sealed); err == nil {
+		t.Error("Open() with another name succeeded")
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := c.Open("smtp", tampered); err == nil {
+		t.Error("Open() of a tampered value succeeded")
+	}
+	if _, err := c.Open("smtp", []byte{1}); err == nil {
+		t.Error("Open() of a short value succeeded")
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeySize)))
+	if err != nil {
+		t.Fatalf("ParseKey() error = %v", err)
+	}
+	if _, err := NewCipher(key); err != nil {
+		t.Errorf("NewCipher() error = %v", err)
+	}
+	if _, err := ParseKey("not base64!"); err == nil {
+		t.Error("ParseKey() of an invalid key succeeded")
+	}
+	if _, err := NewCipher([]byte("short")); err == nil {
+		t.Error("NewCipher() with a short key succeeded")
+	}
+}
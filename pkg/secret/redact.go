@@ -0,0 +1,122 @@
+package secret
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+const (
+	// Redacted replaces the secret values in redacted text.
+	Redacted = "[REDACTED]"
+
+	// minRedactLength is the length of the shortest value that is redacted, as shorter
+	// values would redact unrelated text such as every digit of a number.
+	minRedactLength = 4
+)
+
+// Redactor replaces secret values in text. The zero value and nil redact nothing.
+type Redactor struct {
+	replacer *strings.Replacer
+}
+
+// NewRedactor creates a Redactor for the values of secrets. Values shorter than 4
+// bytes are not redacted.
+func NewRedactor(secrets map[string]string) *Redactor {
+	var values []string
+	for _, value := range secrets {
+		if len(value) >= minRedactLength {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return &Redactor{}
+	}
+	// Longer values go first, so that a value containing another is redacted whole
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	oldnew := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		oldnew = append(oldnew, value, Redacted)
+	}
+	return &Redactor{replacer: strings.NewReplacer(oldnew...)}
+}
+
+// Redact returns s with the secret values replaced by [REDACTED].
+func (r *Redactor) Redact(s string) string {
+	if r == nil || r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// RedactMap returns a copy of m with the secret values in its values redacted.
+func (r *Redactor) RedactMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(m))
+	for key, value := range m {
+		redacted[key] = r.Redact(value)
+	}
+	return redacted
+}
+
+// Handler returns a slog.Handler that redacts the messages and string attributes
+// of records before passing them to next.
+func (r *Redactor) Handler(next slog.Handler) slog.Handler {
+	if r == nil || r.replacer == nil {
+		return next
+	}
+	return &redactHandler{redactor: r, next: next}
+}
+
+// redactHandler redacts records before they reach the next handler.
+type redactHandler struct {
+	redactor *Redactor
+	next     slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr)
+	}
+	return &redactHandler{redactor: h.redactor, next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{redactor: h.redactor, next: h.next.WithGroup(name)}
+}
+
+// redactAttr redacts the value of an attribute, which is formatted as a string
+// unless it is a number, bool, time or duration, as well as those of a group.
+func (h *redactHandler) redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = h.redactAttr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindBool, slog.KindTime, slog.KindDuration:
+		return slog.Attr{Key: attr.Key, Value: value}
+	default:
+		return slog.String(attr.Key, h.redactor.Redact(value.String()))
+	}
+}
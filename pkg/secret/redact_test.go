@@ -0,0 +1,46 @@
+package secret
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	r := NewRedactor(map[string]string{"smtp": "hunter2", "long": "hunter2-and-more", "pin": "12"})
+	got := r.Redact("password hunter2, token hunter2-and-more, pin 12")
+	want := "password [REDACTED], token [REDACTED], pin 12"
+	if got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+	if got := r.RedactMap(map[string]string{"out": "was hunter2"}); got["out"] != "was [REDACTED]" {
+		t.Errorf("RedactMap() = %v", got)
+	}
+
+	var nilRedactor *Redactor
+	if got := nilRedactor.Redact("hunter2"); got != "hunter2" {
+		t.Errorf("nil Redact() = %q, want hunter2", got)
+	}
+}
+
+func TestRedactHandler(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRedactor(map[string]string{"smtp": "hunter2"})
+	logger := slog.New(r.Handler(slog.NewTextHandler(&buf, nil))).With("password", "hunter2")
+	logger.Info("connecting with hunter2", "attempt", 1, slog.Group("smtp", "auth", "user:hunter2"), "err", errString("bad hunter2"))
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("log output contains the secret: %s", out)
+	}
+	for _, want := range []string{`msg="connecting with [REDACTED]"`, "password=[REDACTED]", "attempt=1", `smtp.auth=user:[REDACTED]`, `err="bad [REDACTED]"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log output %q does not contain %q", out, want)
+		}
+	}
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
@@ -0,0 +1,89 @@
+// Package secret resolves the secret references of task payloads and keeps the
+// resolved values out of logs, statuses and results.
+//
+// A payload parameter or env value of the form secret://<name> refers to a secret
+// instead of holding its value. Tasks are stored and returned with the references
+// only: workers resolve them right before a run, through a Resolver, and redact the
+// resolved values from everything the run reports.
+package secret
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// RefPrefix starts the values that refer to a secret.
+	RefPrefix = "secret://"
+
+	// MaxNameLength is the length of the longest secret name.
+	MaxNameLength = 253
+
+	// KubernetesKey is the key that holds the value of a secret in the Kubernetes Secret of the same name.
+	KubernetesKey = "value"
+)
+
+// namePattern matches valid secret names, which are also valid names of Kubernetes Secrets.
+var namePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+
+// Resolver looks up the values of secrets.
+type Resolver interface {
+	// Resolve returns the value of each named secret, or an error when one cannot be resolved.
+	Resolve(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// ParseRef returns the name of the secret value refers to, and whether it is a reference at all.
+// The name of a reference is not validated.
+func ParseRef(value string) (string, bool) {
+	return strings.CutPrefix(value, RefPrefix)
+}
+
+// Ref returns the reference to the named secret.
+func Ref(name string) string {
+	return RefPrefix + name
+}
+
+// ValidateName checks that name can be used as the name of a secret.
+func ValidateName(name string) error {
+	if len(name) > MaxNameLength || !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: must be 1 to %d lowercase letters, digits, dashes or dots, starting and ending with a letter or digit", name, MaxNameLength)
+	}
+	return nil
+}
+
+// Refs returns the sorted names of the secrets the values refer to.
+func Refs(values ...map[string]string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range values {
+		for _, value := range m {
+			if name, ok := ParseRef(value); ok && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Substitute returns a copy of values with the secret references replaced by the
+// resolved secrets. References to secrets missing from secrets are kept.
+func Substitute(values map[string]string, secrets map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	substituted := make(map[string]string, len(values))
+	for key, value := range values {
+		if name, ok := ParseRef(value); ok {
+			if resolved, ok := secrets[name]; ok {
+				value = resolved
+			}
+		}
+		substituted[key] = value
+	}
+	return substituted
+}
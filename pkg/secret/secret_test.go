@@ -0,0 +1,59 @@
+package secret
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	name, ok := ParseRef("secret://smtp-password")
+	if !ok || name != "smtp-password" {
+		t.Errorf("ParseRef() = %q, %v, want smtp-password, true", name, ok)
+	}
+	if _, ok := ParseRef("hunter2"); ok {
+		t.Error("ParseRef() of a plain value reported a reference")
+	}
+	if got := Ref("db-url"); got != "secret://db-url" {
+		t.Errorf("Ref() = %q, want secret://db-url", got)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"a", "smtp-password", "db.url", "k8s-1"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) error = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "-a", "a-", "Upper", "a_b", "a/b", string(make([]byte, MaxNameLength+1))} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) error = nil, want an error", name)
+		}
+	}
+}
+
+func TestRefs(t *testing.T) {
+	params := map[string]string{"to": "ada", "password": "secret://smtp", "token": "secret://api"}
+	env := map[string]string{"API_TOKEN": "secret://api", "DB_URL": "secret://db"}
+	want := []string{"api", "db", "smtp"}
+	if got := Refs(params, env); !reflect.DeepEqual(got, want) {
+		t.Errorf("Refs() = %v, want %v", got, want)
+	}
+	if got := Refs(nil, map[string]string{"MODE": "fast"}); got != nil {
+		t.Errorf("Refs() = %v, want nil", got)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	values := map[string]string{"to": "ada", "password": "secret://smtp", "token": "secret://missing"}
+	got := Substitute(values, map[string]string{"smtp": "hunter2"})
+	want := map[string]string{"to": "ada", "password": "hunter2", "token": "secret://missing"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Substitute() = %v, want %v", got, want)
+	}
+	if values["password"] != "secret://smtp" {
+		t.Error("Substitute() modified its argument")
+	}
+	if Substitute(nil, nil) != nil {
+		t.Error("Substitute(nil) != nil")
+	}
+}
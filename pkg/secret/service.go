@@ -0,0 +1,35 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+)
+
+// ServiceResolver resolves the secrets a task refers to through the task service,
+// which only hands out the secrets the task refers to while it runs.
+type ServiceResolver struct {
+	client cloudv1connect.TaskManagementServiceClient
+	taskID int32
+}
+
+// NewServiceResolver creates a Resolver for the secrets of a task kept by the task service.
+func NewServiceResolver(client cloudv1connect.TaskManagementServiceClient, taskID int32) *ServiceResolver {
+	return &ServiceResolver{client: client, taskID: taskID}
+}
+
+// Resolve returns the values of the named secrets.
+func (r *ServiceResolver) Resolve(ctx context.Context, names []string) (map[string]string, error) {
+	if len(names) == 0 {
+		return map[string]string{}, nil
+	}
+	resp, err := r.client.ResolveSecrets(ctx, connect.NewRequest(&cloudv1.ResolveSecretsRequest{Id: r.taskID, Names: names}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets of task %d: %w", r.taskID, err)
+	}
+	return resp.Msg.Values, nil
+}
@@ -0,0 +1,296 @@
+// Package tasklog ships the log lines of task runs to the task service, which stores
+// them so that they can be read with StreamTaskLogs after the worker is gone.
+package tasklog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Limits of the task service on appended log lines.
+const (
+	// MaxLinesPerRequest is the number of lines an AppendTaskLogs request may carry.
+	MaxLinesPerRequest = 1000
+	// MaxMessageBytes is the size of the longest message a line may have; longer messages are cut.
+	MaxMessageBytes = 8192
+	// MaxLevelLength is the length of the longest level name a line may have.
+	MaxLevelLength = 16
+)
+
+const (
+	// DefaultFlushInterval is how often buffered lines are shipped.
+	DefaultFlushInterval = time.Second
+	// batchLines is the number of buffered lines that are shipped without waiting for the next flush.
+	batchLines = 200
+	// maxBufferedLines is the number of lines kept while the task service cannot be reached.
+	// The oldest lines are dropped beyond it.
+	maxBufferedLines = 10 * MaxLinesPerRequest
+	// shipTimeout bounds a single AppendTaskLogs call.
+	shipTimeout = 10 * time.Second
+)
+
+// Shipper batches the log lines of one attempt of a task and ships them to the task
+// service in the background. Lines are shipped every flush interval, or as soon as a
+// batch is full, and Close ships the rest.
+type Shipper struct {
+	client  cloudv1connect.TaskManagementServiceClient
+	taskID  int32
+	attempt int32
+	// errors logs the failures to ship lines. It must not log through the shipper.
+	errors *slog.Logger
+
+	mu    sync.Mutex
+	lines []*cloudv1.LogLine
+	// first counts the lines removed from the front of lines, shipped or dropped.
+	first   int
+	dropped int
+	failing bool
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	closed  sync.Once
+}
+
+// NewShipper starts a Shipper for the lines of an attempt of a task. Failures to
+// ship lines are logged to errors, and a nil errors discards them.
+func NewShipper(client cloudv1connect.TaskManagementServiceClient, taskID int64, attempt int, interval time.Duration, errors *slog.Logger) *Shipper {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	if errors == nil {
+		errors = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	s := &Shipper{
+		client:  client,
+		taskID:  int32(taskID),
+		attempt: int32(attempt),
+		errors:  errors,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run(interval)
+	return s
+}
+
+// Handler returns a slog.Handler that hands every record to next and ships the records
+// at level Info and above. Records logged after Close are not shipped.
+func (s *Shipper) Handler(next slog.Handler) slog.Handler {
+	return &handler{shipper: s, next: next}
+}
+
+// Close stops shipping in the background and ships the buffered lines.
+// It returns an error when some of the lines could not be shipped before ctx is done.
+func (s *Shipper) Close(ctx context.Context) error {
+	s.closed.Do(func() { close(s.done) })
+	<-s.stopped
+	for s.pending() > 0 {
+		if err := s.flush(ctx); err != nil {
+			return fmt.Errorf("failed to ship %d task log lines: %w", s.pending(), err)
+		}
+	}
+	return nil
+}
+
+// run ships the buffered lines every interval, or when a batch fills up, until Close.
+func (s *Shipper) run(interval time.Duration) {
+	defer close(s.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), shipTimeout)
+		for s.pending() > 0 && s.flush(ctx) == nil {
+		}
+		cancel()
+	}
+}
+
+// add buffers a line, dropping the oldest line when the buffer is full.
+func (s *Shipper) add(line *cloudv1.LogLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if len(s.lines) >= maxBufferedLines {
+		s.lines = s.lines[1:]
+		s.first++
+		s.dropped++
+	}
+	s.lines = append(s.lines, line)
+	if len(s.lines) >= batchLines {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// pending returns the number of buffered lines.
+func (s *Shipper) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.lines)
+}
+
+// flush ships a batch of the buffered lines. Lines that cannot be shipped stay buffered,
+// so that they are shipped with the next batch.
+func (s *Shipper) flush(ctx context.Context) error {
+	s.mu.Lock()
+	first, dropped := s.first, s.dropped
+	batch := slices.Clone(s.lines[:min(len(s.lines), MaxLinesPerRequest)])
+	if dropped > 0 {
+		// Tell the reader about the gap, in place of the oldest line that is left
+		batch[0] = &cloudv1.LogLine{
+			Time:    batch[0].Time,
+			Level:   slog.LevelWarn.String(),
+			Message: fmt.Sprintf("%d log lines were dropped while the task service could not be reached", dropped+1),
+		}
+	}
+	s.mu.Unlock()
+
+	_, err := s.client.AppendTaskLogs(ctx, connect.NewRequest(&cloudv1.AppendTaskLogsRequest{
+		Id:      s.taskID,
+		Attempt: s.attempt,
+		Lines:   batch,
+	}))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if !s.failing {
+			s.errors.Error("Failed to ship task logs", "task_id", s.taskID, "attempt", s.attempt, "error", err)
+		}
+		s.failing = true
+		return err
+	}
+	s.failing = false
+	s.dropped -= dropped
+	// Lines may have been dropped from the front of the buffer while the batch was shipped
+	if shipped := first + len(batch) - s.first; shipped > 0 {
+		s.lines = s.lines[shipped:]
+		s.first += shipped
+	}
+	return nil
+}
+
+// handler is the slog.Handler returned by Shipper.Handler.
+type handler struct {
+	shipper *Shipper
+	next    slog.Handler
+	attrs   []slog.Attr
+	groups  []string
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo || h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) {
+		err = h.next.Handle(ctx, r)
+	}
+	if r.Level < slog.LevelInfo {
+		return err
+	}
+
+	attrs := make(map[string]string, len(h.attrs)+r.NumAttrs())
+	for _, a := range h.attrs {
+		addAttr(attrs, "", a)
+	}
+	prefix := groupPrefix(h.groups)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(attrs, prefix, a)
+		return true
+	})
+	logged := r.Time
+	if logged.IsZero() {
+		logged = time.Now()
+	}
+	h.shipper.add(&cloudv1.LogLine{
+		Time:       timestamppb.New(logged),
+		Level:      r.Level.String(),
+		Message:    truncate(r.Message, MaxMessageBytes),
+		Attributes: attrs,
+	})
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefix := groupPrefix(h.groups)
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		// Qualify the attributes with the current groups, which later groups do not apply to
+		a.Key = prefix + a.Key
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.groups = append(slices.Clone(h.groups), name)
+	return &h2
+}
+
+// groupPrefix returns the prefix that groups add to attribute keys.
+func groupPrefix(groups []string) string {
+	if len(groups) == 0 {
+		return ""
+	}
+	return strings.Join(groups, ".") + "."
+}
+
+// addAttr adds the attribute to attrs, flattening groups into dotted keys.
+func addAttr(attrs map[string]string, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(attrs, prefix, ga)
+		}
+		return
+	}
+	attrs[prefix+a.Key] = a.Value.String()
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
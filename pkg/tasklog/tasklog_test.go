@@ -0,0 +1,155 @@
+package tasklog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeClient records the AppendTaskLogs requests, and fails them while err is set.
+type fakeClient struct {
+	cloudv1connect.TaskManagementServiceClient
+
+	mu       sync.Mutex
+	requests []*cloudv1.AppendTaskLogsRequest
+	err      error
+}
+
+func (c *fakeClient) AppendTaskLogs(_ context.Context, req *connect.Request[cloudv1.AppendTaskLogsRequest]) (*connect.Response[emptypb.Empty], error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.requests = append(c.requests, req.Msg)
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (c *fakeClient) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// messages returns the messages of the shipped lines, in order.
+func (c *fakeClient) messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var messages []string
+	for _, req := range c.requests {
+		for _, line := range req.Lines {
+			messages = append(messages, line.Message)
+		}
+	}
+	return messages
+}
+
+func TestShipper(t *testing.T) {
+	client := &fakeClient{}
+	var local bytes.Buffer
+	shipper := NewShipper(client, 7, 2, time.Hour, nil)
+	logger := slog.New(shipper.Handler(slog.NewTextHandler(&local, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	logger.Debug("Connecting")
+	logger.With("task_id", 7).WithGroup("process").Info("Process output", "stream", "stdout", slog.Group("exit", "code", 0))
+	logger.Error("Process failed", "error", errors.New("exit status 1"))
+	require.NoError(t, shipper.Close(context.Background()))
+
+	// Everything is logged locally, while debug lines are not shipped
+	assert.Contains(t, local.String(), "Connecting")
+	assert.Contains(t, local.String(), "process.stream=stdout")
+
+	require.Len(t, client.requests, 1)
+	req := client.requests[0]
+	assert.Equal(t, int32(7), req.Id)
+	assert.Equal(t, int32(2), req.Attempt)
+	require.Len(t, req.Lines, 2)
+	assert.Equal(t, "INFO", req.Lines[0].Level)
+	assert.Equal(t, "Process output", req.Lines[0].Message)
+	assert.Equal(t, map[string]string{"task_id": "7", "process.stream": "stdout", "process.exit.code": "0"}, req.Lines[0].Attributes)
+	assert.NotNil(t, req.Lines[0].Time)
+	assert.Equal(t, "ERROR", req.Lines[1].Level)
+	assert.Equal(t, map[string]string{"error": "exit status 1"}, req.Lines[1].Attributes)
+
+	// Lines logged after Close are only logged locally
+	logger.Info("Late")
+	assert.Len(t, client.requests, 1)
+	assert.Contains(t, local.String(), "Late")
+}
+
+func TestShipperBatches(t *testing.T) {
+	client := &fakeClient{}
+	shipper := NewShipper(client, 7, 1, time.Hour, nil)
+	logger := slog.New(shipper.Handler(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+
+	// A full batch is shipped without waiting for the flush interval
+	for i := 0; i < batchLines; i++ {
+		logger.Info("line")
+	}
+	assert.Eventually(t, func() bool { return len(client.messages()) == batchLines }, 5*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < MaxLinesPerRequest+1; i++ {
+		logger.Info("line")
+	}
+	require.NoError(t, shipper.Close(context.Background()))
+	assert.Len(t, client.messages(), batchLines+MaxLinesPerRequest+1)
+	for _, req := range client.requests {
+		assert.LessOrEqual(t, len(req.Lines), MaxLinesPerRequest)
+	}
+}
+
+func TestShipperRetries(t *testing.T) {
+	client := &fakeClient{err: errors.New("unavailable")}
+	var errLog bytes.Buffer
+	shipper := NewShipper(client, 7, 1, 10*time.Millisecond, slog.New(slog.NewTextHandler(&errLog, nil)))
+	logger := slog.New(shipper.Handler(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+
+	logger.Info("first")
+	time.Sleep(50 * time.Millisecond)
+	logger.Info("second")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorContains(t, shipper.Close(ctx), "failed to ship 2 task log lines")
+	// The failure is only reported once while it lasts
+	assert.Equal(t, 1, strings.Count(errLog.String(), "Failed to ship task logs"))
+
+	client.setErr(nil)
+	require.NoError(t, shipper.Close(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, client.messages())
+}
+
+func TestShipperDropsOldestLines(t *testing.T) {
+	client := &fakeClient{err: errors.New("unavailable")}
+	shipper := NewShipper(client, 7, 1, time.Hour, nil)
+	logger := slog.New(shipper.Handler(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+
+	for i := 0; i < maxBufferedLines+5; i++ {
+		logger.Info("line")
+	}
+	client.setErr(nil)
+	require.NoError(t, shipper.Close(context.Background()))
+
+	messages := client.messages()
+	assert.Len(t, messages, maxBufferedLines)
+	assert.Equal(t, "6 log lines were dropped while the task service could not be reached", messages[0])
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "ab", truncate("abé", 3))
+	assert.Equal(t, "abé", truncate("abé", 4))
+}
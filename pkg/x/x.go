@@ -1,12 +1,15 @@
 package x
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"task/pkg/config"
@@ -120,9 +123,27 @@ func PrintTaskTable(table *tablewriter.Table, task *cloudv1.Task) {
 	table.Append([]string{"Type", task.Type})
 	table.Append([]string{"Status", task.Status.String()})
 	table.Append([]string{"Description", task.Description})
+	if task.Progress != nil {
+		table.Append([]string{"Progress", FormatProgress(task.Progress)})
+	}
 	table.Render()
 }
 
+// progressBarWidth is the number of cells of the progress bar FormatProgress draws
+const progressBarWidth = 20
+
+// FormatProgress formats the progress a task reported as a bar, its percentage and its message,
+// such as [########------------] 40% Exported 4000 rows
+func FormatProgress(progress *cloudv1.TaskProgress) string {
+	percent := min(max(int(progress.Percent), 0), 100)
+	filled := percent * progressBarWidth / 100
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+	if progress.Message == "" {
+		return fmt.Sprintf("[%s] %d%%", bar, percent)
+	}
+	return fmt.Sprintf("[%s] %d%% %s", bar, percent, progress.Message)
+}
+
 // printTaskListTable prints a list of tasks in a table format
 func PrintTaskListTable(table *tablewriter.Table, tasks *cloudv1.TaskList) {
 	table.SetHeader([]string{"ID", "Name", "Type", "Status", "Description"})
@@ -162,6 +183,12 @@ func PrintYAML(data interface{}) error {
 
 // printTaskHistoryTable prints task history in a table format
 func PrintTaskHistoryTable(table *tablewriter.Table, history *cloudv1.GetTaskHistoryResponse) {
+	table.SetHeader([]string{"ID", "Created At", "Message", "Status"})
+	PrintTaskHistoryRows(table, history)
+}
+
+// PrintTaskHistoryRows prints task history entries in the table format without a header
+func PrintTaskHistoryRows(table *tablewriter.Table, history *cloudv1.GetTaskHistoryResponse) {
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
@@ -174,7 +201,6 @@ func PrintTaskHistoryTable(table *tablewriter.Table, history *cloudv1.GetTaskHis
 	table.SetTablePadding("\t")
 	table.SetNoWhiteSpace(true)
 
-	table.SetHeader([]string{"ID", "Created At", "Message", "Status"})
 	for _, entry := range history.History {
 		createdAt, _ := time.Parse(time.RFC3339, entry.CreatedAt)
 		table.Append([]string{
@@ -187,6 +213,74 @@ func PrintTaskHistoryTable(table *tablewriter.Table, history *cloudv1.GetTaskHis
 	table.Render()
 }
 
+// PrintTaskResultTable prints the outputs of a task result in a table format, followed by its JSON data
+func PrintTaskResultTable(table *tablewriter.Table, result *cloudv1.GetTaskResultResponse) {
+	if result.Result == nil {
+		fmt.Printf("Task %d is %s and has not reported a result yet\n", result.Id, result.Status)
+		return
+	}
+	fmt.Printf("Task %d is %s, result recorded at %s\n\n", result.Id, result.Status, result.UpdatedAt.AsTime().Format(time.RFC3339))
+
+	names := make([]string, 0, len(result.Result.Outputs))
+	for name := range result.Result.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	table.SetHeader([]string{"Output", "Value"})
+	for _, name := range names {
+		table.Append([]string{name, result.Result.Outputs[name]})
+	}
+	table.Render()
+
+	if result.Result.Data != "" {
+		var data bytes.Buffer
+		if err := json.Indent(&data, []byte(result.Result.Data), "", "  "); err != nil {
+			data.Reset()
+			data.WriteString(result.Result.Data)
+		}
+		fmt.Printf("\nData:\n%s\n", data.String())
+	}
+}
+
+// PrintArtifactsTable prints the artifacts of a task in a table format: the outputs it stored,
+// the outputs it declared but did not store yet and the inputs it declared
+func PrintArtifactsTable(table *tablewriter.Table, artifacts *cloudv1.ListArtifactsResponse) {
+	table.SetHeader([]string{"Name", "Kind", "Size", "Content Type", "Status"})
+	stored := make(map[string]bool, len(artifacts.Artifacts))
+	for _, a := range artifacts.Artifacts {
+		stored[a.Name] = true
+		table.Append([]string{
+			a.Name,
+			"output",
+			fmt.Sprintf("%d", a.Size),
+			a.ContentType,
+			"stored " + a.UpdatedAt.AsTime().Format(time.RFC3339),
+		})
+	}
+	for _, name := range artifacts.OutputArtifacts {
+		if !stored[name] {
+			table.Append([]string{name, "output", "-", "-", "pending"})
+		}
+	}
+	for _, in := range artifacts.InputArtifacts {
+		table.Append([]string{in.Name, "input", "-", "-", fmt.Sprintf("from task %d/%s", in.TaskId, in.Artifact)})
+	}
+	table.Render()
+}
+
+// PrintSecretsTable prints the names of the secrets kept by the server in a table format
+func PrintSecretsTable(table *tablewriter.Table, secrets *cloudv1.ListSecretsResponse) {
+	table.SetHeader([]string{"Name", "Created At", "Updated At"})
+	for _, s := range secrets.Secrets {
+		table.Append([]string{
+			s.Name,
+			s.CreatedAt.AsTime().Format(time.RFC3339),
+			s.UpdatedAt.AsTime().Format(time.RFC3339),
+		})
+	}
+	table.Render()
+}
+
 // Helper function to truncate message to 30 characters
 func truncateMessage(message string) string {
 	if len(message) > 30 {
@@ -215,6 +309,12 @@ func PrintTable(data interface{}) {
 		PrintTaskTable(table, v)
 	case *cloudv1.TaskList:
 		PrintTaskListTable(table, v)
+	case *cloudv1.GetTaskResultResponse:
+		PrintTaskResultTable(table, v)
+	case *cloudv1.ListArtifactsResponse:
+		PrintArtifactsTable(table, v)
+	case *cloudv1.ListSecretsResponse:
+		PrintSecretsTable(table, v)
 	default:
 		log.Println("Unsupported data type for table format")
 		fmt.Println("Unsupported data type for table format")
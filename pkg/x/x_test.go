@@ -4,6 +4,8 @@ import (
 	"os"
 	"reflect"
 	"testing"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
 )
 
 func TestLoadEnv(t *testing.T) {
@@ -114,6 +116,28 @@ func TestGetStatusString(t *testing.T) {
 	}
 }
 
+func TestFormatProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress *cloudv1.TaskProgress
+		expected string
+	}{
+		{"Started", &cloudv1.TaskProgress{}, "[--------------------] 0%"},
+		{"Halfway", &cloudv1.TaskProgress{Percent: 40, Message: "Exported 4000 rows"}, "[########------------] 40% Exported 4000 rows"},
+		{"Done", &cloudv1.TaskProgress{Percent: 100, Message: "Done"}, "[####################] 100% Done"},
+		{"Out of range", &cloudv1.TaskProgress{Percent: 150}, "[####################] 100%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FormatProgress(tt.progress)
+			if result != tt.expected {
+				t.Errorf("FormatProgress(%v) = %q, want %q", tt.progress, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestCreateClient(t *testing.T) {
 	// Test with default server URL
 	t.Run("Default Server URL", func(t *testing.T) {
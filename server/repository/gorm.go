@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"fmt"
+	gormimpl "task/server/repository/gormimpl"
+	interfaces "task/server/repository/interface"
+
+	"gorm.io/gorm"
+)
+
+type GormRepo struct {
+	task      interfaces.TaskRepo
+	history   interfaces.TaskHistoryRepo
+	workflow  interfaces.WorkflowRepo
+	execution interfaces.ExecutionRepo
+	logs      interfaces.TaskLogRepo
+	secrets   interfaces.SecretRepo
+}
+
+func (r GormRepo) TaskRepo() interfaces.TaskRepo {
+	fmt.Println(r.task)
+	return r.task
+}
+
+func (r GormRepo) TaskHistoryRepo() interfaces.TaskHistoryRepo {
+	return r.history
+}
+
+func (r GormRepo) WorkflowRepo() interfaces.WorkflowRepo {
+	return r.workflow
+}
+
+func (r GormRepo) ExecutionRepo() interfaces.ExecutionRepo {
+	return r.execution
+}
+
+func (r GormRepo) TaskLogRepo() interfaces.TaskLogRepo {
+	return r.logs
+}
+
+func (r GormRepo) SecretRepo() interfaces.SecretRepo {
+	return r.secrets
+}
+
+func NewGormRepo(db *gorm.DB, reads *gormimpl.ReplicaRouter) interfaces.TaskManagmentInterface {
+	return &GormRepo{
+		task:      gormimpl.NewTaskRepo(db, reads),
+		history:   gormimpl.NewTaskHistoryRepo(db, reads),
+		workflow:  gormimpl.NewWorkflowRepo(db),
+		execution: gormimpl.NewExecutionRepo(db),
+		logs:      gormimpl.NewTaskLogRepo(db),
+		secrets:   gormimpl.NewSecretRepo(db),
+	}
+}
@@ -0,0 +1,138 @@
+package gormimpl
+
+import (
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	models "task/server/repository/model/task"
+)
+
+// Dialect names as reported by gorm.Dialector.Name for the supported databases.
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// index is a secondary index created by Migrate.
+type index struct {
+	table string
+	name  string
+	sql   string
+}
+
+// dialectIndexes lists the indexes Migrate creates for each dialect. Postgres and
+// MySQL get an index backing full-text search; SQLite searches with LIKE instead.
+var dialectIndexes = map[string][]index{
+	DialectPostgres: {
+		{"task_histories", "idx_task_id_created_at", "CREATE INDEX idx_task_id_created_at ON task_histories (task_id, created_at DESC)"},
+		{"task_histories", "idx_task_id_id", "CREATE INDEX idx_task_id_id ON task_histories (task_id, id)"},
+		{"task_log_chunks", "idx_task_log_chunks_task_id_id", "CREATE INDEX idx_task_log_chunks_task_id_id ON task_log_chunks (task_id, id)"},
+		{"tasks", "idx_type_status", "CREATE INDEX idx_type_status ON tasks (type, status)"},
+		{"tasks", "idx_created_at", "CREATE INDEX idx_created_at ON tasks (created_at)"},
+		{"tasks", "idx_status_created_at", "CREATE INDEX idx_status_created_at ON tasks (status, created_at)"},
+		{"tasks", "idx_updated_at", "CREATE INDEX idx_updated_at ON tasks (updated_at)"},
+		{"tasks", "idx_name_pattern", "CREATE INDEX idx_name_pattern ON tasks (name varchar_pattern_ops)"},
+		{"tasks", "idx_tasks_search", "CREATE INDEX idx_tasks_search ON tasks USING GIN (" + taskSearchVector + ")"},
+	},
+	DialectMySQL: {
+		{"task_histories", "idx_task_id_created_at", "CREATE INDEX idx_task_id_created_at ON task_histories (task_id, created_at DESC)"},
+		{"task_histories", "idx_task_id_id", "CREATE INDEX idx_task_id_id ON task_histories (task_id, id)"},
+		{"task_log_chunks", "idx_task_log_chunks_task_id_id", "CREATE INDEX idx_task_log_chunks_task_id_id ON task_log_chunks (task_id, id)"},
+		{"tasks", "idx_type_status", "CREATE INDEX idx_type_status ON tasks (type, status)"},
+		{"tasks", "idx_created_at", "CREATE INDEX idx_created_at ON tasks (created_at)"},
+		{"tasks", "idx_status_created_at", "CREATE INDEX idx_status_created_at ON tasks (status, created_at)"},
+		{"tasks", "idx_updated_at", "CREATE INDEX idx_updated_at ON tasks (updated_at)"},
+		{"tasks", "idx_name_pattern", "CREATE INDEX idx_name_pattern ON tasks (name)"},
+		{"tasks", "idx_tasks_search", "CREATE FULLTEXT INDEX idx_tasks_search ON tasks (name, description)"},
+	},
+	DialectSQLite: {
+		{"task_histories", "idx_task_id_created_at", "CREATE INDEX idx_task_id_created_at ON task_histories (task_id, created_at DESC)"},
+		{"task_histories", "idx_task_id_id", "CREATE INDEX idx_task_id_id ON task_histories (task_id, id)"},
+		{"task_log_chunks", "idx_task_log_chunks_task_id_id", "CREATE INDEX idx_task_log_chunks_task_id_id ON task_log_chunks (task_id, id)"},
+		{"tasks", "idx_type_status", "CREATE INDEX idx_type_status ON tasks (type, status)"},
+		{"tasks", "idx_created_at", "CREATE INDEX idx_created_at ON tasks (created_at)"},
+		{"tasks", "idx_status_created_at", "CREATE INDEX idx_status_created_at ON tasks (status, created_at)"},
+		{"tasks", "idx_updated_at", "CREATE INDEX idx_updated_at ON tasks (updated_at)"},
+		{"tasks", "idx_name_pattern", "CREATE INDEX idx_name_pattern ON tasks (name)"},
+	},
+}
+
+// Migrate creates or updates the task tables and their indexes for the dialect of db.
+func Migrate(db *gorm.DB) error {
+	indexes, ok := dialectIndexes[db.Dialector.Name()]
+	if !ok {
+		return fmt.Errorf("unsupported database dialect: %s", db.Dialector.Name())
+	}
+
+	// Status counters are backfilled from the existing tasks when they are first created
+	backfill := !db.Migrator().HasTable(&models.TaskStatusCount{})
+
+	// Perform database migrations
+	if err := db.AutoMigrate(&models.Task{}, &models.TaskHistory{}, &models.TaskStatusCount{}, &models.TaskResult{}, &models.TaskLogChunk{}, &models.Secret{}); err != nil {
+		return fmt.Errorf("failed to run auto migrations: %w", err)
+	}
+	if backfill {
+		if err := backfillStatusCounts(db); err != nil {
+			return err
+		}
+	}
+
+	// Create necessary indexes; not every dialect supports CREATE INDEX IF NOT EXISTS
+	for _, idx := range indexes {
+		if db.Migrator().HasIndex(idx.table, idx.name) {
+			continue
+		}
+		if err := db.Exec(idx.sql).Error; err != nil {
+			return fmt.Errorf("failed to create index %s: %w", idx.name, err)
+		}
+		slog.Info("Created index", "name", idx.name)
+	}
+	return nil
+}
+
+// whereNamePrefix restricts query to rows whose column starts with prefix.
+// SQLite has no default LIKE escape character, so it is declared explicitly.
+func whereNamePrefix(query *gorm.DB, column, prefix string) *gorm.DB {
+	pattern := escapeLike(prefix) + "%"
+	if query.Dialector.Name() == DialectSQLite {
+		return query.Where(column+` LIKE ? ESCAPE '\'`, pattern)
+	}
+	return query.Where(column+" LIKE ?", pattern)
+}
+
+// whereSearch restricts query to tasks whose name or description match search,
+// using the full-text search of the database where one is available.
+func whereSearch(query *gorm.DB, search string) *gorm.DB {
+	switch query.Dialector.Name() {
+	case DialectPostgres:
+		return query.Where(taskSearchVector+" @@ plainto_tsquery('english', ?)", search)
+	case DialectMySQL:
+		return query.Where("MATCH (name, description) AGAINST (? IN NATURAL LANGUAGE MODE)", search)
+	default:
+		pattern := "%" + escapeLike(search) + "%"
+		return query.Where(`(name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+}
+
+// lockForUpdate locks the rows read by query for update until the transaction ends.
+// SQLite has no row locks; its write transactions are serialized, so the query is returned unchanged.
+func lockForUpdate(query *gorm.DB) *gorm.DB {
+	if query.Dialector.Name() == DialectSQLite {
+		return query
+	}
+	return query.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// lockSkipLocked locks the rows read by query for update, skipping rows that are
+// already locked by another transaction. SQLite has no row locks; its write
+// transactions are serialized, so the query is returned unchanged.
+func lockSkipLocked(query *gorm.DB) *gorm.DB {
+	if query.Dialector.Name() == DialectSQLite {
+		return query
+	}
+	return query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+}
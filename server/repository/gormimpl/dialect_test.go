@@ -0,0 +1,251 @@
+package gormimpl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "task/pkg/plugins/builtin"
+	"task/server/repository/model/task"
+
+	"github.com/ncruces/go-sqlite3/gormlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// forEachDialect runs fn against a freshly migrated database of every supported dialect.
+// SQLite always runs in memory. Postgres and MySQL run when TEST_POSTGRES_DSN
+// (postgres://...) and TEST_MYSQL_DSN (user:pass@tcp(host:port)/db?parseTime=true)
+// point at disposable databases; their task tables are dropped before each run.
+func forEachDialect(t *testing.T, fn func(t *testing.T, db *gorm.DB)) {
+	dialectors := map[string]func() gorm.Dialector{
+		DialectSQLite: func() gorm.Dialector { return gormlite.Open(":memory:") },
+	}
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		dialectors[DialectPostgres] = func() gorm.Dialector { return postgres.Open(dsn) }
+	}
+	if dsn := os.Getenv("TEST_MYSQL_DSN"); dsn != "" {
+		dialectors[DialectMySQL] = func() gorm.Dialector { return mysql.Open(dsn) }
+	}
+
+	for _, name := range []string{DialectPostgres, DialectMySQL, DialectSQLite} {
+		open, ok := dialectors[name]
+		t.Run(name, func(t *testing.T) {
+			if !ok {
+				t.Skipf("set TEST_%s_DSN to run against %s", map[string]string{DialectPostgres: "POSTGRES", DialectMySQL: "MYSQL"}[name], name)
+			}
+			db, err := gorm.Open(open(), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			sqlDB, err := db.DB()
+			require.NoError(t, err)
+			t.Cleanup(func() { sqlDB.Close() })
+			if name == DialectSQLite {
+				// Every new connection would open a separate in-memory database
+				sqlDB.SetMaxOpenConns(1)
+			}
+
+			require.NoError(t, db.Migrator().DropTable(&task.Task{}, &task.TaskHistory{}, &task.TaskStatusCount{}))
+			require.NoError(t, Migrate(db))
+			fn(t, db)
+		})
+	}
+}
+
+func TestTaskRepoDialects(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		ctx := context.Background()
+		repo := NewTaskRepo(db, NewReplicaRouter(db, nil, 0))
+
+		seed := []task.Task{
+			{Name: "daily_report", Description: "Weekly report for finance", Type: "send_email", Status: 0, Priority: 1},
+			{Name: "dailyXreport", Description: "Refresh dashboards", Type: "run_query", Status: 1, Priority: 5},
+			{Name: "cleanup", Description: "Remove stale sessions", Type: "run_query", Status: 1, Priority: 3},
+		}
+		for i := range seed {
+			seed[i].Payload = `{"key":"value"}`
+			created, err := repo.CreateTask(ctx, seed[i])
+			require.NoError(t, err)
+			seed[i] = created
+		}
+
+		_, err := repo.CreateTask(ctx, task.Task{Name: "unknown", Type: "unknown_type", Payload: "{}"})
+		assert.ErrorContains(t, err, "invalid task type")
+
+		got, err := repo.GetTaskByID(ctx, seed[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "daily_report", got.Name)
+		assert.JSONEq(t, `{"key":"value"}`, string(got.Payload))
+
+		// The underscore in the prefix must not act as a wildcard
+		tasks, err := repo.ListTasks(ctx, task.TaskFilter{NamePrefix: "daily_"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"daily_report"}, taskNames(tasks))
+
+		tasks, err = repo.ListTasks(ctx, task.TaskFilter{Search: "finance"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"daily_report"}, taskNames(tasks))
+
+		tasks, err = repo.ListTasks(ctx, task.TaskFilter{Statuses: []int{1}, SortBy: task.SortByPriority, SortOrder: task.SortAsc})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cleanup", "dailyXreport"}, taskNames(tasks))
+
+		tasks, err = repo.ListTasks(ctx, task.TaskFilter{Limit: 1, Offset: 1, SortBy: task.SortByID, SortOrder: task.SortAsc})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dailyXreport"}, taskNames(tasks))
+
+		// The result is stored with the status, and not at all when the status is not updated
+		require.NoError(t, repo.UpdateTaskStatus(ctx, seed[0].ID, 3, &task.TaskResult{TaskID: seed[0].ID, Outputs: `{"rows":"2"}`}))
+		assert.Error(t, repo.UpdateTaskStatus(ctx, 9999, 3, &task.TaskResult{TaskID: 9999, Outputs: `{"rows":"2"}`}))
+		result, err := repo.GetTaskResult(ctx, seed[0].ID)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, task.JSON(`{"rows":"2"}`), result.Outputs)
+		result, err = repo.GetTaskResult(ctx, 9999)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+		counts, err := repo.GetTaskStatusCounts(ctx, task.StatusCountFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"/1": 2, "/3": 1}, countsByKey(counts))
+
+		counts, err = repo.GetTaskStatusCounts(ctx, task.StatusCountFilter{ByType: true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"run_query/1": 2, "send_email/3": 1}, countsByKey(counts))
+
+		counts, err = repo.GetTaskStatusCounts(ctx, task.StatusCountFilter{CreatedBefore: time.Now().Add(-2 * time.Hour)})
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+
+		// Only tasks that have been unknown for a while are reclaimed
+		require.NoError(t, repo.UpdateTaskStatus(ctx, seed[2].ID, 4, nil))
+		require.NoError(t, db.Model(&task.Task{}).Where("id = ?", seed[2].ID).
+			UpdateColumn("updated_at", time.Now().Add(-time.Minute)).Error)
+		stalled, err := repo.GetStalledTasks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cleanup"}, taskNames(stalled))
+
+		stalled, err = repo.GetStalledTasks(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, stalled)
+
+		// The counters are maintained with every transition and match a full recount
+		counts, err = repo.GetTaskStatusCounts(ctx, task.StatusCountFilter{ByType: true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"run_query/1": 1, "run_query/5": 1, "send_email/3": 1}, countsByKey(counts))
+
+		require.NoError(t, db.Migrator().DropTable(&task.TaskStatusCount{}))
+		require.NoError(t, Migrate(db))
+		backfilled, err := repo.GetTaskStatusCounts(ctx, task.StatusCountFilter{ByType: true})
+		require.NoError(t, err)
+		assert.Equal(t, countsByKey(counts), countsByKey(backfilled))
+	})
+}
+
+func TestTaskHistoryRepoDialects(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		ctx := context.Background()
+		repo := NewTaskHistoryRepo(db, NewReplicaRouter(db, nil, 0))
+
+		for _, status := range []int{0, 1, 2, 1, 3} {
+			_, err := repo.CreateTaskHistory(ctx, task.TaskHistory{TaskID: 1, Status: status, Details: "status changed"})
+			require.NoError(t, err)
+		}
+		_, err := repo.CreateTaskHistory(ctx, task.TaskHistory{TaskID: 2, Status: 0})
+		require.NoError(t, err)
+
+		all, err := repo.GetTaskHistory(ctx, 1)
+		require.NoError(t, err)
+		assert.Len(t, all, 5)
+
+		first, err := repo.ListTaskHistories(ctx, task.TaskHistoryFilter{TaskID: 1, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 1}, historyStatuses(first))
+
+		next, err := repo.ListTaskHistories(ctx, task.TaskHistoryFilter{TaskID: 1, Limit: 2, Cursor: first[1].ID})
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 1}, historyStatuses(next))
+
+		newest, err := repo.ListTaskHistories(ctx, task.TaskHistoryFilter{TaskID: 1, Limit: 2, SortOrder: task.SortDesc})
+		require.NoError(t, err)
+		assert.Equal(t, []int{3, 1}, historyStatuses(newest))
+
+		running, err := repo.ListTaskHistories(ctx, task.TaskHistoryFilter{TaskID: 1, Statuses: []int{1}})
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 1}, historyStatuses(running))
+	})
+}
+
+func TestDialectSQL(t *testing.T) {
+	mysqlDB, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(localhost:3306)/db", SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		db      *gorm.DB
+		wantSQL []string
+	}{
+		{
+			name: DialectPostgres,
+			db:   newDryRunDB(t),
+			wantSQL: []string{
+				"name LIKE $1",
+				"to_tsvector('english', name || ' ' || description) @@ plainto_tsquery('english', $2)",
+				"FOR UPDATE SKIP LOCKED",
+			},
+		},
+		{
+			name: DialectMySQL,
+			db:   mysqlDB,
+			wantSQL: []string{
+				"name LIKE ?",
+				"MATCH (name, description) AGAINST (? IN NATURAL LANGUAGE MODE)",
+				"FOR UPDATE SKIP LOCKED",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := lockSkipLocked(whereSearch(whereNamePrefix(tt.db.Model(&task.Task{}), "name", "daily_"), "report"))
+
+			var tasks []task.Task
+			stmt := query.Find(&tasks).Statement
+			for _, sql := range tt.wantSQL {
+				assert.Contains(t, stmt.SQL.String(), sql)
+			}
+			assert.Equal(t, []interface{}{`daily\_%`, "report"}, stmt.Vars)
+		})
+	}
+}
+
+func taskNames(tasks []task.Task) []string {
+	names := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func countsByKey(counts []task.StatusCount) map[string]int64 {
+	byKey := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		byKey[fmt.Sprintf("%s/%d", c.Type, c.Status)] = c.Count
+	}
+	return byKey
+}
+
+func historyStatuses(histories []task.TaskHistory) []int {
+	statuses := make([]int, 0, len(histories))
+	for _, h := range histories {
+		statuses = append(statuses, h.Status)
+	}
+	return statuses
+}
@@ -24,7 +24,8 @@ var (
 
 // TaskHistoryRepo handles database operations for task history entries.
 type TaskHistoryRepo struct {
-	db *gorm.DB
+	db    *gorm.DB
+	reads *ReplicaRouter
 }
 
 // CreateTaskHistory creates a new history entry for a task.
@@ -42,7 +43,7 @@ func (s *TaskHistoryRepo) CreateTaskHistory(ctx context.Context, history models.
 // It returns a slice of TaskHistory objects and any error encountered.
 func (s *TaskHistoryRepo) GetTaskHistory(ctx context.Context, taskID uint) ([]models.TaskHistory, error) {
 	var histories []models.TaskHistory
-	if err := s.db.Where("task_id = ?", taskID).Find(&histories).Error; err != nil {
+	if err := s.reads.Reader(ctx).Where("task_id = ?", taskID).Find(&histories).Error; err != nil {
 		taskHistoryOperations.WithLabelValues("get", "error").Inc()
 		return nil, fmt.Errorf("failed to retrieve task history by task ID: %w", err)
 	}
@@ -50,13 +51,20 @@ func (s *TaskHistoryRepo) GetTaskHistory(ctx context.Context, taskID uint) ([]mo
 	return histories, nil
 }
 
-// ListTaskHistories retrieves all history entries for a given task, sorted by ID in ascending order.
+// ListTaskHistories retrieves a page of history entries for a given task, sorted by ID.
+// Entries are filtered by status and creation time, and the page starts after filter.Cursor
+// in the requested order so that pagination stays stable while new entries are appended.
 // It returns a slice of TaskHistory objects and any error encountered.
-func (s *TaskHistoryRepo) ListTaskHistories(ctx context.Context, taskID uint) ([]models.TaskHistory, error) {
+func (s *TaskHistoryRepo) ListTaskHistories(ctx context.Context, filter models.TaskHistoryFilter) ([]models.TaskHistory, error) {
 	var histories []models.TaskHistory
 
-	// Added Order clause to sort by ID in descending order (latest first)
-	if err := s.db.Where("task_id = ?", taskID).Order("id ASC").Find(&histories).Error; err != nil {
+	query, err := applyTaskHistoryFilter(s.reads.Reader(ctx), filter)
+	if err != nil {
+		taskHistoryOperations.WithLabelValues("list", "error").Inc()
+		return nil, err
+	}
+
+	if err := query.Find(&histories).Error; err != nil {
 		taskHistoryOperations.WithLabelValues("list", "error").Inc()
 		return nil, fmt.Errorf("failed to retrieve task histories: %w", err)
 	}
@@ -65,10 +73,45 @@ func (s *TaskHistoryRepo) ListTaskHistories(ctx context.Context, taskID uint) ([
 	return histories, nil
 }
 
+// applyTaskHistoryFilter adds the conditions, keyset cursor, ordering and limit
+// described by filter to query.
+func applyTaskHistoryFilter(query *gorm.DB, filter models.TaskHistoryFilter) (*gorm.DB, error) {
+	order := filter.SortOrder
+	if order == "" {
+		order = models.SortAsc
+	}
+
+	query = query.Where("task_id = ?", filter.TaskID)
+	switch {
+	case order == models.SortAsc && filter.Cursor > 0:
+		query = query.Where("id > ?", filter.Cursor)
+	case order == models.SortDesc && filter.Cursor > 0:
+		query = query.Where("id < ?", filter.Cursor)
+	case order != models.SortAsc && order != models.SortDesc:
+		return nil, fmt.Errorf("unsupported sort order: %s", order)
+	}
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	return query.Order("id " + string(order)), nil
+}
+
 // NewTaskHistoryRepo creates and returns a new instance of TaskHistoryRepo.
-// It takes a GORM database connection and a River client as parameters.
-func NewTaskHistoryRepo(db *gorm.DB) interfaces.TaskHistoryRepo {
+// New entries are written through db, and history reads go through reads.
+func NewTaskHistoryRepo(db *gorm.DB, reads *ReplicaRouter) interfaces.TaskHistoryRepo {
 	return &TaskHistoryRepo{
-		db: db,
+		db:    db,
+		reads: reads,
 	}
 }
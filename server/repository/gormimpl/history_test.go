@@ -3,6 +3,7 @@ package gormimpl
 import (
 	"context"
 	"testing"
+	"time"
 
 	"task/server/repository/mocks"
 	"task/server/repository/model/task"
@@ -37,3 +38,86 @@ func TestGetTaskHistory(t *testing.T) {
 	assert.Len(t, result, len(histories))
 	mockRepo.AssertExpectations(t)
 }
+
+func TestListTaskHistories(t *testing.T) {
+	mockRepo := mocks.NewTaskHistoryRepo(t)
+	filter := task.TaskHistoryFilter{TaskID: 1, Limit: 50, Cursor: 10}
+	histories := []task.TaskHistory{{TaskID: 1, Status: 1}}
+
+	mockRepo.EXPECT().ListTaskHistories(mock.Anything, filter).Return(histories, nil)
+
+	result, err := mockRepo.ListTaskHistories(context.Background(), filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, histories, result)
+}
+
+func TestApplyTaskHistoryFilter(t *testing.T) {
+	db := newDryRunDB(t)
+	since := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		filter   task.TaskHistoryFilter
+		wantSQL  []string
+		wantVars []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "Default order",
+			filter:   task.TaskHistoryFilter{TaskID: 1},
+			wantSQL:  []string{"task_id = $1", "ORDER BY id ASC"},
+			wantVars: []interface{}{uint(1)},
+		},
+		{
+			name: "Ascending cursor and filters",
+			filter: task.TaskHistoryFilter{
+				TaskID:   1,
+				Limit:    101,
+				Cursor:   42,
+				Statuses: []int{2, 3},
+				Since:    since,
+				Until:    until,
+			},
+			wantSQL: []string{
+				"task_id = $1",
+				"id > $2",
+				"status IN ($3,$4)",
+				"created_at >= $5",
+				"created_at < $6",
+				"ORDER BY id ASC LIMIT $7",
+			},
+			wantVars: []interface{}{uint(1), uint(42), 2, 3, since, until, 101},
+		},
+		{
+			name:     "Descending cursor",
+			filter:   task.TaskHistoryFilter{TaskID: 1, Cursor: 42, SortOrder: task.SortDesc},
+			wantSQL:  []string{"id < $2", "ORDER BY id DESC"},
+			wantVars: []interface{}{uint(1), uint(42)},
+		},
+		{
+			name:    "Unknown sort order",
+			filter:  task.TaskHistoryFilter{TaskID: 1, SortOrder: "SIDEWAYS"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := applyTaskHistoryFilter(db, tt.filter)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var histories []task.TaskHistory
+			stmt := query.Find(&histories).Statement
+			for _, sql := range tt.wantSQL {
+				assert.Contains(t, stmt.SQL.String(), sql)
+			}
+			assert.Equal(t, tt.wantVars, stmt.Vars)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package gormimpl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+
+	interfaces "task/server/repository/interface"
+	models "task/server/repository/model/task"
+)
+
+var (
+	taskLogOperations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "task_log_repository_operations_total",
+			Help: "The total number of task log repository operations",
+		},
+		[]string{"operation", "status"},
+	)
+)
+
+// TaskLogRepo handles database operations for task log chunks.
+// Logs are read from the primary, as followers poll for chunks appended moments ago.
+type TaskLogRepo struct {
+	db *gorm.DB
+}
+
+// AppendTaskLogs stores a chunk of log lines of a task.
+// It returns the stored chunk and any error encountered.
+func (s *TaskLogRepo) AppendTaskLogs(ctx context.Context, chunk models.TaskLogChunk) (models.TaskLogChunk, error) {
+	if err := s.db.WithContext(ctx).Create(&chunk).Error; err != nil {
+		taskLogOperations.WithLabelValues("append", "error").Inc()
+		return models.TaskLogChunk{}, fmt.Errorf("failed to append task logs: %w", err)
+	}
+	taskLogOperations.WithLabelValues("append", "success").Inc()
+	return chunk, nil
+}
+
+// ListTaskLogChunks retrieves a page of log chunks for a given task, sorted by ID.
+// The page starts after filter.Cursor, so that new chunks can be polled for.
+// It returns a slice of TaskLogChunk objects and any error encountered.
+func (s *TaskLogRepo) ListTaskLogChunks(ctx context.Context, filter models.TaskLogFilter) ([]models.TaskLogChunk, error) {
+	var chunks []models.TaskLogChunk
+	if err := applyTaskLogFilter(s.db.WithContext(ctx), filter).Find(&chunks).Error; err != nil {
+		taskLogOperations.WithLabelValues("list", "error").Inc()
+		return nil, fmt.Errorf("failed to retrieve task logs: %w", err)
+	}
+	taskLogOperations.WithLabelValues("list", "success").Inc()
+	return chunks, nil
+}
+
+// applyTaskLogFilter adds the conditions, cursor, ordering and limit described by filter to query.
+func applyTaskLogFilter(query *gorm.DB, filter models.TaskLogFilter) *gorm.DB {
+	query = query.Where("task_id = ?", filter.TaskID)
+	if filter.Cursor > 0 {
+		query = query.Where("id > ?", filter.Cursor)
+	}
+	if filter.Attempt > 0 {
+		query = query.Where("attempt = ?", filter.Attempt)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	return query.Order("id ASC")
+}
+
+// NewTaskLogRepo creates and returns a new instance of TaskLogRepo.
+func NewTaskLogRepo(db *gorm.DB) interfaces.TaskLogRepo {
+	return &TaskLogRepo{db: db}
+}
@@ -0,0 +1,64 @@
+package gormimpl
+
+import (
+	"testing"
+	"time"
+
+	"task/server/repository/model/task"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTaskLogFilter(t *testing.T) {
+	db := newDryRunDB(t)
+
+	tests := []struct {
+		name     string
+		filter   task.TaskLogFilter
+		wantSQL  []string
+		wantVars []interface{}
+	}{
+		{
+			name:     "All chunks",
+			filter:   task.TaskLogFilter{TaskID: 1},
+			wantSQL:  []string{"task_id = $1", "ORDER BY id ASC"},
+			wantVars: []interface{}{uint(1)},
+		},
+		{
+			name:   "Cursor and filters",
+			filter: task.TaskLogFilter{TaskID: 1, Limit: 100, Cursor: 42, Attempt: 2},
+			wantSQL: []string{
+				"task_id = $1",
+				"id > $2",
+				"attempt = $3",
+				"ORDER BY id ASC LIMIT $4",
+			},
+			wantVars: []interface{}{uint(1), uint(42), 2, 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chunks []task.TaskLogChunk
+			stmt := applyTaskLogFilter(db, tt.filter).Find(&chunks).Statement
+			for _, sql := range tt.wantSQL {
+				assert.Contains(t, stmt.SQL.String(), sql)
+			}
+			assert.Equal(t, tt.wantVars, stmt.Vars)
+		})
+	}
+}
+
+func TestTaskLogLines(t *testing.T) {
+	logged := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+	lines := []task.LogLine{
+		{Time: logged, Level: "INFO", Message: "Process output", Attributes: map[string]string{"stream": "stdout"}},
+		{Time: logged, Level: "ERROR", Message: "Process failed"},
+	}
+
+	var chunk task.TaskLogChunk
+	assert.NoError(t, chunk.SetLogLines(lines))
+	got, err := chunk.LogLines()
+	assert.NoError(t, err)
+	assert.Equal(t, lines, got)
+}
@@ -0,0 +1,144 @@
+package gormimpl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+// replicaLagCheckInterval is how long a replica lag measurement is reused before
+// the replica is asked again, so that busy read paths do not probe on every query.
+const replicaLagCheckInterval = time.Second
+
+// replicaLagQuery returns how far, in seconds, a Postgres standby is behind its primary.
+// MySQL replicas report their lag through SHOW REPLICA STATUS instead.
+// A standby that has replayed everything it received is considered current even if the
+// last replayed transaction is old, which happens when the primary is idle.
+const replicaLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+var (
+	replicaReads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "task_repository_replica_reads_total",
+			Help: "The total number of replica eligible reads by the connection that served them",
+		},
+		[]string{"target"},
+	)
+	replicaLag = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "task_repository_replica_lag_seconds",
+			Help: "The last measured replication lag of the read replica in seconds",
+		},
+	)
+)
+
+// ReplicaRouter picks the connection for reads that can tolerate slightly stale data.
+// Such reads go to the read replica while its replication lag is within maxStaleness
+// and fall back to the primary otherwise. Writes and locking reads must always use the primary.
+type ReplicaRouter struct {
+	primary      *gorm.DB
+	replica      *gorm.DB
+	maxStaleness time.Duration
+	lag          func(ctx context.Context) (time.Duration, error)
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	current   bool
+}
+
+// NewReplicaRouter creates a ReplicaRouter for the given primary and replica connections.
+// A nil replica routes every read to the primary, and a maxStaleness of zero
+// disables the lag check so that every read goes to the replica.
+func NewReplicaRouter(primary, replica *gorm.DB, maxStaleness time.Duration) *ReplicaRouter {
+	r := &ReplicaRouter{
+		primary:      primary,
+		replica:      replica,
+		maxStaleness: maxStaleness,
+	}
+	r.lag = r.measureLag
+	return r
+}
+
+// Reader returns the connection that should serve a replica eligible read.
+func (r *ReplicaRouter) Reader(ctx context.Context) *gorm.DB {
+	if r.replica == nil {
+		return r.primary
+	}
+	if r.maxStaleness <= 0 || r.replicaCurrent(ctx) {
+		replicaReads.WithLabelValues("replica").Inc()
+		return r.replica
+	}
+	replicaReads.WithLabelValues("primary").Inc()
+	return r.primary
+}
+
+// replicaCurrent reports whether the replica lag was within maxStaleness at the last check,
+// measuring it again when the previous measurement is older than replicaLagCheckInterval.
+func (r *ReplicaRouter) replicaCurrent(ctx context.Context) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < replicaLagCheckInterval {
+		return r.current
+	}
+	r.checkedAt = time.Now()
+
+	lag, err := r.lag(ctx)
+	if err != nil {
+		slog.Warn("Failed to measure replica lag, reading from the primary", "error", err)
+		r.current = false
+		return false
+	}
+	replicaLag.Set(lag.Seconds())
+
+	current := lag <= r.maxStaleness
+	if current != r.current {
+		slog.Info("Replica routing changed", "useReplica", current, "lag", lag, "maxStaleness", r.maxStaleness)
+	}
+	r.current = current
+	return current
+}
+
+// measureLag queries the replica for its replication lag.
+func (r *ReplicaRouter) measureLag(ctx context.Context) (time.Duration, error) {
+	db := r.replica.WithContext(ctx)
+	switch db.Dialector.Name() {
+	case DialectPostgres:
+		var seconds float64
+		if err := db.Raw(replicaLagQuery).Scan(&seconds).Error; err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	case DialectMySQL:
+		status := map[string]interface{}{}
+		if err := db.Raw("SHOW REPLICA STATUS").Scan(&status).Error; err != nil {
+			return 0, err
+		}
+		if len(status) == 0 {
+			// Not a replica, so it cannot lag behind
+			return 0, nil
+		}
+		// The driver returns text protocol values as bytes; NULL means replication is stopped
+		value := status["Seconds_Behind_Source"]
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		seconds, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return 0, fmt.Errorf("replication is not running")
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("replica lag cannot be measured for %s", db.Dialector.Name())
+	}
+}
@@ -0,0 +1,74 @@
+package gormimpl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicaRouterReader(t *testing.T) {
+	primary, replica := newDryRunDB(t), newDryRunDB(t)
+
+	t.Run("No replica", func(t *testing.T) {
+		router := NewReplicaRouter(primary, nil, time.Second)
+
+		assert.Same(t, primary, router.Reader(context.Background()))
+	})
+
+	t.Run("Staleness check disabled", func(t *testing.T) {
+		router := NewReplicaRouter(primary, replica, 0)
+		router.lag = func(ctx context.Context) (time.Duration, error) {
+			t.Fatal("lag should not be measured")
+			return 0, nil
+		}
+
+		assert.Same(t, replica, router.Reader(context.Background()))
+	})
+
+	tests := []struct {
+		name string
+		lag  time.Duration
+		err  error
+		want bool
+	}{
+		{name: "Replica within tolerance", lag: 2 * time.Second, want: true},
+		{name: "Replica too stale", lag: 10 * time.Second, want: false},
+		{name: "Lag check fails", err: errors.New("connection refused"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewReplicaRouter(primary, replica, 5*time.Second)
+			router.lag = func(ctx context.Context) (time.Duration, error) {
+				return tt.lag, tt.err
+			}
+
+			if tt.want {
+				assert.Same(t, replica, router.Reader(context.Background()))
+			} else {
+				assert.Same(t, primary, router.Reader(context.Background()))
+			}
+		})
+	}
+
+	t.Run("Lag measurement is reused", func(t *testing.T) {
+		calls := 0
+		router := NewReplicaRouter(primary, replica, 5*time.Second)
+		router.lag = func(ctx context.Context) (time.Duration, error) {
+			calls++
+			return 0, nil
+		}
+
+		for i := 0; i < 3; i++ {
+			assert.Same(t, replica, router.Reader(context.Background()))
+		}
+		assert.Equal(t, 1, calls)
+
+		router.checkedAt = time.Now().Add(-replicaLagCheckInterval)
+		router.Reader(context.Background())
+		assert.Equal(t, 2, calls)
+	})
+}
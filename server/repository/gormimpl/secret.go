@@ -0,0 +1,107 @@
+package gormimpl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	interfaces "task/server/repository/interface"
+	models "task/server/repository/model/task"
+)
+
+var (
+	secretOperations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "secret_repository_operations_total",
+			Help: "The total number of secret repository operations",
+		},
+		[]string{"operation", "status"},
+	)
+)
+
+// SecretRepo handles database operations for secrets.
+// Secrets are read from the primary, so that a secret is resolved with the value it was just set to.
+type SecretRepo struct {
+	db *gorm.DB
+}
+
+// PutSecret stores a secret, replacing the value of an existing secret with the same name.
+// It returns the stored secret and any error encountered.
+func (s *SecretRepo) PutSecret(ctx context.Context, secret models.Secret) (models.Secret, error) {
+	var stored models.Secret
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := upsertSecret(tx, &secret).Error; err != nil {
+			return err
+		}
+		// The created_at of an existing secret is kept by the upsert
+		return tx.Where("name = ?", secret.Name).First(&stored).Error
+	})
+	if err != nil {
+		secretOperations.WithLabelValues("put", "error").Inc()
+		return models.Secret{}, fmt.Errorf("failed to put secret: %w", err)
+	}
+	secretOperations.WithLabelValues("put", "success").Inc()
+	return stored, nil
+}
+
+// GetSecrets retrieves the secrets with the given names.
+// It returns a slice of Secret objects and any error encountered.
+func (s *SecretRepo) GetSecrets(ctx context.Context, names []string) ([]models.Secret, error) {
+	var secrets []models.Secret
+	if err := s.db.WithContext(ctx).Where("name IN ?", names).Find(&secrets).Error; err != nil {
+		secretOperations.WithLabelValues("get", "error").Inc()
+		return nil, fmt.Errorf("failed to retrieve secrets: %w", err)
+	}
+	secretOperations.WithLabelValues("get", "success").Inc()
+	return secrets, nil
+}
+
+// ListSecrets retrieves all secrets sorted by name, leaving out their values.
+// It returns a slice of Secret objects and any error encountered.
+func (s *SecretRepo) ListSecrets(ctx context.Context) ([]models.Secret, error) {
+	var secrets []models.Secret
+	if err := listSecrets(s.db.WithContext(ctx)).Find(&secrets).Error; err != nil {
+		secretOperations.WithLabelValues("list", "error").Inc()
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	secretOperations.WithLabelValues("list", "success").Inc()
+	return secrets, nil
+}
+
+// DeleteSecret deletes the secret with the given name.
+// It returns gorm.ErrRecordNotFound when there is no such secret.
+func (s *SecretRepo) DeleteSecret(ctx context.Context, name string) error {
+	result := s.db.WithContext(ctx).Where("name = ?", name).Delete(&models.Secret{})
+	if result.Error != nil {
+		secretOperations.WithLabelValues("delete", "error").Inc()
+		return fmt.Errorf("failed to delete secret: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		secretOperations.WithLabelValues("delete", "error").Inc()
+		return gorm.ErrRecordNotFound
+	}
+	secretOperations.WithLabelValues("delete", "success").Inc()
+	return nil
+}
+
+// upsertSecret inserts secret, or replaces the value of the existing secret with the same name.
+func upsertSecret(db *gorm.DB, secret *models.Secret) *gorm.DB {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(secret)
+}
+
+// listSecrets selects the secrets without their values, sorted by name.
+func listSecrets(query *gorm.DB) *gorm.DB {
+	return query.Select("id", "name", "created_at", "updated_at").Order("name ASC")
+}
+
+// NewSecretRepo creates and returns a new instance of SecretRepo.
+func NewSecretRepo(db *gorm.DB) interfaces.SecretRepo {
+	return &SecretRepo{db: db}
+}
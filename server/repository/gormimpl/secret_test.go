@@ -0,0 +1,29 @@
+package gormimpl
+
+import (
+	"testing"
+
+	"task/server/repository/model/task"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestUpsertSecret(t *testing.T) {
+	// The default transaction of Create would need a connection
+	db := newDryRunDB(t).Session(&gorm.Session{SkipDefaultTransaction: true})
+	secret := task.Secret{Name: "smtp-password", Value: []byte("sealed")}
+
+	stmt := upsertSecret(db, &secret).Statement
+
+	assert.Contains(t, stmt.SQL.String(), `INSERT INTO "secrets" ("name","value","created_at","updated_at")`)
+	assert.Contains(t, stmt.SQL.String(), `ON CONFLICT ("name") DO UPDATE SET "value"="excluded"."value","updated_at"="excluded"."updated_at"`)
+}
+
+func TestListSecrets(t *testing.T) {
+	var secrets []task.Secret
+	stmt := listSecrets(newDryRunDB(t)).Find(&secrets).Statement
+
+	// Values are never read when listing
+	assert.Equal(t, `SELECT "id","name","created_at","updated_at" FROM "secrets" ORDER BY name ASC`, stmt.SQL.String())
+}
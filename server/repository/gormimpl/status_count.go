@@ -0,0 +1,117 @@
+package gormimpl
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	models "task/server/repository/model/task"
+)
+
+// statusCountKey identifies one row of the task_status_counts table.
+type statusCountKey struct {
+	taskType    string
+	status      int
+	createdHour time.Time
+}
+
+// statusCountDeltas accumulates the changes to task_status_counts made by a transaction.
+type statusCountDeltas map[statusCountKey]int64
+
+// createdHour returns the counter bucket of a task created at createdAt.
+func createdHour(createdAt time.Time) time.Time {
+	return createdAt.UTC().Truncate(time.Hour)
+}
+
+// add counts task as being in status.
+func (d statusCountDeltas) add(task models.Task, status int) {
+	d[statusCountKey{task.Type, status, createdHour(task.CreatedAt)}]++
+}
+
+// move records a transition of task from one status to another.
+func (d statusCountDeltas) move(task models.Task, from, to int) {
+	if from == to {
+		return
+	}
+	d[statusCountKey{task.Type, from, createdHour(task.CreatedAt)}]--
+	d[statusCountKey{task.Type, to, createdHour(task.CreatedAt)}]++
+}
+
+// apply upserts the accumulated deltas into task_status_counts using tx.
+// Rows are written in a fixed order so that concurrent transactions cannot deadlock.
+func (d statusCountDeltas) apply(tx *gorm.DB) error {
+	keys := make([]statusCountKey, 0, len(d))
+	for key, delta := range d {
+		if delta != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.taskType != b.taskType {
+			return a.taskType < b.taskType
+		}
+		if !a.createdHour.Equal(b.createdHour) {
+			return a.createdHour.Before(b.createdHour)
+		}
+		return a.status < b.status
+	})
+
+	for _, key := range keys {
+		delta := d[key]
+		row := models.TaskStatusCount{Type: key.taskType, Status: key.status, CreatedHour: key.createdHour, TaskCount: delta}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "type"}, {Name: "status"}, {Name: "created_hour"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"task_count": gorm.Expr("task_status_counts.task_count + ?", delta)}),
+		}).Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to update task status counts: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillStatusCounts fills task_status_counts from the existing tasks. It runs once,
+// when Migrate creates the table for a database that already holds tasks.
+func backfillStatusCounts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		deltas := statusCountDeltas{}
+		var batch []models.Task
+		err := tx.Model(&models.Task{}).Select("id", "type", "status", "created_at").
+			FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+				for _, task := range batch {
+					deltas.add(task, task.Status)
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to count existing tasks: %w", err)
+		}
+		return deltas.apply(tx)
+	})
+}
+
+// applyStatusCountFilter selects the status counts described by filter from db.
+func applyStatusCountFilter(db *gorm.DB, filter models.StatusCountFilter) *gorm.DB {
+	query := db.Model(&models.TaskStatusCount{})
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_hour >= ?", createdHour(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		// Include the partial hour the window ends in
+		before := createdHour(filter.CreatedBefore)
+		if before.Before(filter.CreatedBefore) {
+			before = before.Add(time.Hour)
+		}
+		query = query.Where("created_hour < ?", before)
+	}
+
+	if filter.ByType {
+		query = query.Select("type, status, SUM(task_count) AS count").Group("type, status")
+	} else {
+		query = query.Select("status, SUM(task_count) AS count").Group("status")
+	}
+	return query.Having("SUM(task_count) > 0")
+}
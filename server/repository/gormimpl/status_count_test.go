@@ -0,0 +1,44 @@
+package gormimpl
+
+import (
+	"testing"
+	"time"
+
+	"task/server/repository/model/task"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyStatusCountFilter(t *testing.T) {
+	db := newDryRunDB(t)
+	after := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+	before := time.Date(2024, 10, 1, 17, 15, 0, 0, time.UTC)
+
+	var counts []task.StatusCount
+	stmt := applyStatusCountFilter(db, task.StatusCountFilter{CreatedAfter: after, CreatedBefore: before, ByType: true}).
+		Scan(&counts).Statement
+
+	assert.Contains(t, stmt.SQL.String(), "SELECT type, status, SUM(task_count) AS count FROM \"task_status_counts\"")
+	assert.Contains(t, stmt.SQL.String(), "GROUP BY type, status HAVING SUM(task_count) > 0")
+	// The window is widened to the whole hours it touches
+	assert.Equal(t, []interface{}{
+		time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 10, 1, 18, 0, 0, 0, time.UTC),
+	}, stmt.Vars)
+}
+
+func TestStatusCountDeltas(t *testing.T) {
+	created := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+	email := task.Task{Type: "send_email", CreatedAt: created}
+
+	deltas := statusCountDeltas{}
+	deltas.add(email, 0)
+	deltas.move(email, 0, 1)
+	deltas.move(email, 1, 1)
+
+	hour := time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, statusCountDeltas{
+		{"send_email", 0, hour}: 0,
+		{"send_email", 1, hour}: 1,
+	}, deltas)
+}
@@ -3,11 +3,13 @@ package gormimpl
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	interfaces "task/server/repository/interface"
 	models "task/server/repository/model/task"
@@ -34,19 +36,28 @@ var (
 // TaskRepo implements the TaskRepo interface using GORM for database operations
 // and River for task queue management.
 type TaskRepo struct {
-	db *gorm.DB
+	db    *gorm.DB
+	reads *ReplicaRouter
 }
 
 // CreateTask creates a new task in the database and enqueues it for processing.
+// The task is counted in the status counters within the same transaction.
 // It returns the created task with its assigned ID or an error if the operation fails.
 func (s *TaskRepo) CreateTask(ctx context.Context, task models.Task) (models.Task, error) {
 	timer := prometheus.NewTimer(taskLatency.WithLabelValues("create"))
 	defer timer.ObserveDuration()
 
-	result := s.db.Create(&task)
-	if result.Error != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		deltas := statusCountDeltas{}
+		deltas.add(task, task.Status)
+		return deltas.apply(tx)
+	})
+	if err != nil {
 		taskOperations.WithLabelValues("create", "error").Inc()
-		return models.Task{}, fmt.Errorf("failed to create task: %w", result.Error)
+		return models.Task{}, fmt.Errorf("failed to create task: %w", err)
 	}
 
 	if task.ID == 0 {
@@ -74,12 +85,33 @@ func (s *TaskRepo) GetTaskByID(ctx context.Context, taskID uint) (*models.Task,
 }
 
 // UpdateTaskStatus updates the status of a task identified by its ID.
-// It returns an error if the update operation fails.
-func (s *TaskRepo) UpdateTaskStatus(ctx context.Context, taskID uint, status int) error {
+// The status counters are moved to the new status, and the result, if not nil, replaces
+// any earlier result of the task within the same transaction.
+// It returns an error if the task does not exist or the update operation fails.
+func (s *TaskRepo) UpdateTaskStatus(ctx context.Context, taskID uint, status int, result *models.TaskResult) error {
 	timer := prometheus.NewTimer(taskLatency.WithLabelValues("update_status"))
 	defer timer.ObserveDuration()
 
-	if err := s.db.Model(&models.Task{}).Where("id = ?", taskID).Update("status", status).Error; err != nil {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the task so that concurrent transitions count from the right status
+		var task models.Task
+		if err := lockForUpdate(tx).Select("id", "type", "status", "created_at").First(&task, taskID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Task{}).Where("id = ?", taskID).Update("status", status).Error; err != nil {
+			return err
+		}
+		deltas := statusCountDeltas{}
+		deltas.move(task, task.Status, status)
+		if err := deltas.apply(tx); err != nil {
+			return err
+		}
+		if result == nil {
+			return nil
+		}
+		return upsertTaskResult(tx, result).Error
+	})
+	if err != nil {
 		taskOperations.WithLabelValues("update_status", "error").Inc()
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
@@ -88,27 +120,31 @@ func (s *TaskRepo) UpdateTaskStatus(ctx context.Context, taskID uint, status int
 	return nil
 }
 
-// ListTasks retrieves a paginated list of tasks from the database, filtered by status and type.
-// The 'limit' parameter specifies the maximum number of tasks to return,
-// 'offset' determines the starting point for pagination,
-// 'status' allows filtering by task status, and 'taskType' allows filtering by task type.
+// ListTasks retrieves a paginated list of tasks from the database.
+// The filter restricts the tasks by status, type, name prefix, priority range,
+// creation and update time, and free-text search over the name and description,
+// and determines the sort field and direction of the results.
 // It returns a slice of tasks and an error if the operation fails.
-func (s *TaskRepo) ListTasks(ctx context.Context, limit, offset int, status int, taskType string) ([]models.Task, error) {
+func (s *TaskRepo) ListTasks(ctx context.Context, filter models.TaskFilter) ([]models.Task, error) {
 	timer := prometheus.NewTimer(taskLatency.WithLabelValues("list"))
 	defer timer.ObserveDuration()
 
-	var tasks []models.Task
-	query := s.db.Limit(limit).Offset(offset)
+	query, err := applyTaskFilter(s.reads.Reader(ctx), filter)
+	if err != nil {
+		taskOperations.WithLabelValues("list", "error").Inc()
+		return nil, err
+	}
 
-	// Apply filters if they are provided
-	if status != 5 {
-		query = query.Where("status = ?", status)
+	// A zero limit lists every task
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
 	}
-	if taskType != "" {
-		query = query.Where("type = ?", taskType)
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
 	}
 
 	// Execute the query
+	var tasks []models.Task
 	if err := query.Find(&tasks).Error; err != nil {
 		taskOperations.WithLabelValues("list", "error").Inc()
 		return nil, fmt.Errorf("failed to retrieve tasks: %w", err)
@@ -118,31 +154,19 @@ func (s *TaskRepo) ListTasks(ctx context.Context, limit, offset int, status int,
 	return tasks, nil
 }
 
-// GetTaskStatusCounts retrieves the count of tasks for each status.
-// It returns a map where the key is the status code and the value is the count of tasks with that status.
-// An error is returned if the operation fails.
-func (s *TaskRepo) GetTaskStatusCounts(ctx context.Context) (map[int]int64, error) {
+// GetTaskStatusCounts retrieves the number of tasks in each status from the materialized
+// status counters, optionally restricted to a creation time window and broken down by type.
+// It returns one StatusCount per status, or per type and status, and an error if the operation fails.
+func (s *TaskRepo) GetTaskStatusCounts(ctx context.Context, filter models.StatusCountFilter) ([]models.StatusCount, error) {
 	timer := prometheus.NewTimer(taskLatency.WithLabelValues("status_counts"))
 	defer timer.ObserveDuration()
 
-	var results []struct {
-		Status int
-		Count  int64
-	}
-
-	if err := s.db.Model(&models.Task{}).
-		Select("status, count(*) as count").
-		Group("status").
-		Find(&results).Error; err != nil {
+	var counts []models.StatusCount
+	if err := applyStatusCountFilter(s.reads.Reader(ctx), filter).Scan(&counts).Error; err != nil {
 		taskOperations.WithLabelValues("status_counts", "error").Inc()
 		return nil, fmt.Errorf("failed to retrieve task status counts: %w", err)
 	}
 
-	counts := make(map[int]int64)
-	for _, result := range results {
-		counts[result.Status] = result.Count
-	}
-
 	taskOperations.WithLabelValues("status_counts", "success").Inc()
 	return counts, nil
 }
@@ -159,7 +183,7 @@ func (s *TaskRepo) GetStalledTasks(ctx context.Context) ([]models.Task, error) {
 	// Start a transaction
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// Find stalled tasks and lock them for update
-		if err := tx.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		if err := lockSkipLocked(tx).
 			Where("(status = ?) AND updated_at < ?", 4, thirtySecondsAgo).
 			Find(&tasks).Error; err != nil {
 			return err
@@ -168,14 +192,17 @@ func (s *TaskRepo) GetStalledTasks(ctx context.Context) ([]models.Task, error) {
 		// Update the status of found tasks to a temporary "processing" state
 		if len(tasks) > 0 {
 			taskIDs := make([]uint, len(tasks))
+			deltas := statusCountDeltas{}
 			for i, task := range tasks {
 				taskIDs[i] = task.ID
+				deltas.move(task, task.Status, 5)
 			}
 			if err := tx.Model(&models.Task{}).
 				Where("id IN ?", taskIDs).
 				Update("status", 5).Error; err != nil { // Assuming 5 is a temporary "processing" status
 				return err
 			}
+			return deltas.apply(tx)
 		}
 
 		return nil
@@ -190,10 +217,157 @@ func (s *TaskRepo) GetStalledTasks(ctx context.Context) ([]models.Task, error) {
 	return tasks, nil
 }
 
+// GetTaskResult retrieves the result of a task by its ID.
+// It returns nil without an error when the task has not reported a result.
+func (s *TaskRepo) GetTaskResult(ctx context.Context, taskID uint) (*models.TaskResult, error) {
+	timer := prometheus.NewTimer(taskLatency.WithLabelValues("get_result"))
+	defer timer.ObserveDuration()
+
+	var results []models.TaskResult
+	if err := s.db.Where("task_id = ?", taskID).Limit(1).Find(&results).Error; err != nil {
+		taskOperations.WithLabelValues("get_result", "error").Inc()
+		return nil, fmt.Errorf("failed to retrieve task result: %w", err)
+	}
+
+	taskOperations.WithLabelValues("get_result", "success").Inc()
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// upsertTaskResult inserts result, or replaces the outputs and data of the existing result of the task.
+func upsertTaskResult(db *gorm.DB, result *models.TaskResult) *gorm.DB {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"outputs", "data", "updated_at"}),
+	}).Create(result)
+}
+
+// UpdateTaskProgress records the progress a task reported. Reports of the same attempt
+// are throttled to one per minInterval in the database, so that the throttling holds
+// across servers, and reports of completion or of a new attempt are always recorded.
+// It reports whether the progress was recorded.
+func (s *TaskRepo) UpdateTaskProgress(ctx context.Context, taskID uint, progress models.Progress, minInterval time.Duration) (bool, error) {
+	timer := prometheus.NewTimer(taskLatency.WithLabelValues("update_progress"))
+	defer timer.ObserveDuration()
+
+	result := updateTaskProgress(s.db.WithContext(ctx), taskID, progress, minInterval)
+	if result.Error != nil {
+		taskOperations.WithLabelValues("update_progress", "error").Inc()
+		return false, fmt.Errorf("failed to update task progress: %w", result.Error)
+	}
+
+	taskOperations.WithLabelValues("update_progress", "success").Inc()
+	return result.RowsAffected > 0, nil
+}
+
+// updateTaskProgress builds the conditional update of UpdateTaskProgress. The progress
+// columns are updated on their own, leaving updated_at to changes of the task itself.
+func updateTaskProgress(db *gorm.DB, taskID uint, progress models.Progress, minInterval time.Duration) *gorm.DB {
+	reportedAt := time.Now()
+	if progress.ReportedAt != nil {
+		reportedAt = *progress.ReportedAt
+	}
+	query := db.Model(&models.Task{}).Where("id = ?", taskID)
+	if progress.Percent < 100 && minInterval > 0 {
+		query = query.Where("progress_reported_at IS NULL OR progress_attempt <> ? OR progress_reported_at <= ?",
+			progress.Attempt, reportedAt.Add(-minInterval))
+	}
+	return query.UpdateColumns(map[string]any{
+		"progress_percent":     progress.Percent,
+		"progress_message":     progress.Message,
+		"progress_attempt":     progress.Attempt,
+		"progress_reported_at": reportedAt,
+	})
+}
+
 // NewTaskRepo creates and returns a new instance of TaskRepo.
-// It requires a GORM database connection and a River client for task queue management.
-func NewTaskRepo(db *gorm.DB) interfaces.TaskRepo {
+// Writes and locking reads use db, while ListTasks and GetTaskStatusCounts read through reads.
+func NewTaskRepo(db *gorm.DB, reads *ReplicaRouter) interfaces.TaskRepo {
 	return &TaskRepo{
-		db: db,
+		db:    db,
+		reads: reads,
+	}
+}
+
+// taskSortColumns whitelists the columns ListTasks can order by, so that the
+// ORDER BY clause is never built from caller-supplied strings.
+var taskSortColumns = map[models.SortField]string{
+	models.SortByCreatedAt: "created_at",
+	models.SortByUpdatedAt: "updated_at",
+	models.SortByPriority:  "priority",
+	models.SortByName:      "name",
+	models.SortByID:        "id",
+}
+
+// taskSearchVector is the document matched by Postgres full-text search. It must
+// stay in sync with the expression indexed by idx_tasks_search.
+const taskSearchVector = "to_tsvector('english', name || ' ' || description)"
+
+// applyTaskFilter adds the WHERE and ORDER BY clauses described by filter to db.
+func applyTaskFilter(db *gorm.DB, filter models.TaskFilter) (*gorm.DB, error) {
+	query := db.Model(&models.Task{})
+
+	// Apply filters if they are provided
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.NamePrefix != "" {
+		query = whereNamePrefix(query, "name", filter.NamePrefix)
 	}
+	if filter.MinPriority != nil {
+		query = query.Where("priority >= ?", *filter.MinPriority)
+	}
+	if filter.MaxPriority != nil {
+		query = query.Where("priority <= ?", *filter.MaxPriority)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		query = query.Where("updated_at >= ?", filter.UpdatedAfter)
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		query = query.Where("updated_at < ?", filter.UpdatedBefore)
+	}
+	if filter.Search != "" {
+		query = whereSearch(query, filter.Search)
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = models.SortByCreatedAt
+	}
+	column, ok := taskSortColumns[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field: %s", sortBy)
+	}
+
+	order := filter.SortOrder
+	if order == "" {
+		order = models.SortDesc
+	}
+	if order != models.SortAsc && order != models.SortDesc {
+		return nil, fmt.Errorf("unsupported sort order: %s", order)
+	}
+
+	// Break ties on the primary key so that offset pagination is stable
+	query = query.Order(column + " " + string(order))
+	if column != "id" {
+		query = query.Order("id " + string(order))
+	}
+
+	return query, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so that it is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
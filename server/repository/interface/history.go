@@ -19,7 +19,8 @@ type TaskHistoryRepo interface {
 	// Returns a slice of task history entries, or an error if none found.
 	GetTaskHistory(ctx context.Context, taskID uint) ([]model.TaskHistory, error)
 
-	// ListTaskHistories lists all history entries for a given task, with pagination support.
-	// Returns a slice of task history entries, along with a pagination token (if any) for subsequent queries.
-	ListTaskHistories(ctx context.Context, taskID uint) ([]model.TaskHistory, error)
+	// ListTaskHistories lists the history entries for a given task, with pagination support.
+	// The filter selects the task, page cursor and size, statuses, time range and sort order.
+	// Returns a slice of at most filter.Limit task history entries.
+	ListTaskHistories(ctx context.Context, filter model.TaskHistoryFilter) ([]model.TaskHistory, error)
 }
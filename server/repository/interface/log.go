@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	model "task/server/repository/model/task"
+)
+
+// TaskLogRepo defines the interface for the task log repository.
+// It handles the log lines workers ship while running tasks.
+//
+//go:generate mockery --output=../mocks --case=underscore --all --with-expecter
+type TaskLogRepo interface {
+	// AppendTaskLogs stores a chunk of log lines of a task.
+	// It returns the stored chunk with its ID set.
+	AppendTaskLogs(ctx context.Context, chunk model.TaskLogChunk) (model.TaskLogChunk, error)
+
+	// ListTaskLogChunks lists the log chunks of a task in the order they were appended.
+	// The filter selects the task, attempt, cursor, page size and start time.
+	// Returns a slice of at most filter.Limit chunks.
+	ListTaskLogChunks(ctx context.Context, filter model.TaskLogFilter) ([]model.TaskLogChunk, error)
+}
@@ -6,4 +6,6 @@ type TaskManagmentInterface interface {
 	TaskHistoryRepo() TaskHistoryRepo
 	WorkflowRepo() WorkflowRepo
 	ExecutionRepo() ExecutionRepo
+	TaskLogRepo() TaskLogRepo
+	SecretRepo() SecretRepo
 }
@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+
+	model "task/server/repository/model/task"
+)
+
+// SecretRepo defines the interface for the secret repository.
+// It handles the encrypted secrets tasks refer to.
+//
+//go:generate mockery --output=../mocks --case=underscore --all --with-expecter
+type SecretRepo interface {
+	// PutSecret stores a secret, replacing the value of an existing secret with the same name.
+	// It returns the stored secret.
+	PutSecret(ctx context.Context, secret model.Secret) (model.Secret, error)
+
+	// GetSecrets retrieves the secrets with the given names.
+	// Names without a secret are left out of the returned slice.
+	GetSecrets(ctx context.Context, names []string) ([]model.Secret, error)
+
+	// ListSecrets lists the secrets sorted by name, without their values.
+	ListSecrets(ctx context.Context) ([]model.Secret, error)
+
+	// DeleteSecret deletes the secret with the given name.
+	// It returns gorm.ErrRecordNotFound when there is no such secret.
+	DeleteSecret(ctx context.Context, name string) error
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	model "task/server/repository/model/task"
 )
@@ -20,22 +21,31 @@ type TaskRepo interface {
 	GetTaskByID(ctx context.Context, taskID uint) (*model.Task, error)
 
 	// UpdateTaskStatus updates the status of a task.
-	// It requires the task ID and the new status to be set.
-	UpdateTaskStatus(ctx context.Context, taskID uint, status int) error
+	// It requires the task ID and the new status to be set. The result, if not nil,
+	// replaces any earlier result of the task in the same transaction as the status.
+	UpdateTaskStatus(ctx context.Context, taskID uint, status int, result *model.TaskResult) error
 
 	// ListTasks retrieves a list of tasks based on the provided criteria.
 	// It takes a context.Context parameter for handling request-scoped values and deadlines.
-	// The limit and offset parameters are used for pagination.
-	// The status parameter filters tasks by their status (use -1 for all statuses).
-	// The taskType parameter filters tasks by their type (use an empty string for all types).
+	// The filter carries pagination, the status, type, time range, priority, name prefix
+	// and full-text search filters, and the sort field and direction.
 	// It returns a slice of tasks and an error if any occurs during the operation.
-	ListTasks(ctx context.Context, limit, offset int, status int, taskType string) ([]model.Task, error)
+	ListTasks(ctx context.Context, filter model.TaskFilter) ([]model.Task, error)
 
 	// GetTaskStatusCounts retrieves the count of tasks for each status.
 	// It takes a context.Context parameter for handling request-scoped values and deadlines.
-	// It returns a map where the key is the status code and the value is the count of tasks with that status.
-	// An error is returned if any occurs during the operation.
-	GetTaskStatusCounts(ctx context.Context) (map[int]int64, error)
+	// The filter restricts the counts to a creation time window and can break them down by task type.
+	// It returns one entry per status, or per type and status, and an error if any occurs during the operation.
+	GetTaskStatusCounts(ctx context.Context, filter model.StatusCountFilter) ([]model.StatusCount, error)
 
 	GetStalledTasks(ctx context.Context) ([]model.Task, error)
+
+	// GetTaskResult retrieves the result of a task by its ID.
+	// It returns nil without an error when the task has not reported a result.
+	GetTaskResult(ctx context.Context, taskID uint) (*model.TaskResult, error)
+
+	// UpdateTaskProgress records the progress a task reported, unless the last recorded
+	// progress of the same attempt was reported less than minInterval before it.
+	// Progress of 100 percent is always recorded. It reports whether the progress was recorded.
+	UpdateTaskProgress(ctx context.Context, taskID uint, progress model.Progress, minInterval time.Duration) (bool, error)
 }
@@ -139,9 +139,9 @@ func (_c *TaskHistoryRepo_GetTaskHistory_Call) RunAndReturn(run func(context.Con
 	return _c
 }
 
-// ListTaskHistories provides a mock function with given fields: ctx, taskID
-func (_m *TaskHistoryRepo) ListTaskHistories(ctx context.Context, taskID uint) ([]task.TaskHistory, error) {
-	ret := _m.Called(ctx, taskID)
+// ListTaskHistories provides a mock function with given fields: ctx, filter
+func (_m *TaskHistoryRepo) ListTaskHistories(ctx context.Context, filter task.TaskHistoryFilter) ([]task.TaskHistory, error) {
+	ret := _m.Called(ctx, filter)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListTaskHistories")
@@ -149,19 +149,19 @@ func (_m *TaskHistoryRepo) ListTaskHistories(ctx context.Context, taskID uint) (
 
 	var r0 []task.TaskHistory
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]task.TaskHistory, error)); ok {
-		return rf(ctx, taskID)
+	if rf, ok := ret.Get(0).(func(context.Context, task.TaskHistoryFilter) ([]task.TaskHistory, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint) []task.TaskHistory); ok {
-		r0 = rf(ctx, taskID)
+	if rf, ok := ret.Get(0).(func(context.Context, task.TaskHistoryFilter) []task.TaskHistory); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]task.TaskHistory)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
-		r1 = rf(ctx, taskID)
+	if rf, ok := ret.Get(1).(func(context.Context, task.TaskHistoryFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -176,14 +176,14 @@ type TaskHistoryRepo_ListTaskHistories_Call struct {
 
 // ListTaskHistories is a helper method to define mock.On call
 //   - ctx context.Context
-//   - taskID uint
-func (_e *TaskHistoryRepo_Expecter) ListTaskHistories(ctx interface{}, taskID interface{}) *TaskHistoryRepo_ListTaskHistories_Call {
-	return &TaskHistoryRepo_ListTaskHistories_Call{Call: _e.mock.On("ListTaskHistories", ctx, taskID)}
+//   - filter task.TaskHistoryFilter
+func (_e *TaskHistoryRepo_Expecter) ListTaskHistories(ctx interface{}, filter interface{}) *TaskHistoryRepo_ListTaskHistories_Call {
+	return &TaskHistoryRepo_ListTaskHistories_Call{Call: _e.mock.On("ListTaskHistories", ctx, filter)}
 }
 
-func (_c *TaskHistoryRepo_ListTaskHistories_Call) Run(run func(ctx context.Context, taskID uint)) *TaskHistoryRepo_ListTaskHistories_Call {
+func (_c *TaskHistoryRepo_ListTaskHistories_Call) Run(run func(ctx context.Context, filter task.TaskHistoryFilter)) *TaskHistoryRepo_ListTaskHistories_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uint))
+		run(args[0].(context.Context), args[1].(task.TaskHistoryFilter))
 	})
 	return _c
 }
@@ -193,7 +193,7 @@ func (_c *TaskHistoryRepo_ListTaskHistories_Call) Return(_a0 []task.TaskHistory,
 	return _c
 }
 
-func (_c *TaskHistoryRepo_ListTaskHistories_Call) RunAndReturn(run func(context.Context, uint) ([]task.TaskHistory, error)) *TaskHistoryRepo_ListTaskHistories_Call {
+func (_c *TaskHistoryRepo_ListTaskHistories_Call) RunAndReturn(run func(context.Context, task.TaskHistoryFilter) ([]task.TaskHistory, error)) *TaskHistoryRepo_ListTaskHistories_Call {
 	_c.Call.Return(run)
 	return _c
 }
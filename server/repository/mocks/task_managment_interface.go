@@ -21,6 +21,100 @@ func (_m *TaskManagmentInterface) EXPECT() *TaskManagmentInterface_Expecter {
 	return &TaskManagmentInterface_Expecter{mock: &_m.Mock}
 }
 
+// ExecutionRepo provides a mock function with given fields:
+func (_m *TaskManagmentInterface) ExecutionRepo() interfaces.ExecutionRepo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExecutionRepo")
+	}
+
+	var r0 interfaces.ExecutionRepo
+	if rf, ok := ret.Get(0).(func() interfaces.ExecutionRepo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.ExecutionRepo)
+		}
+	}
+
+	return r0
+}
+
+// TaskManagmentInterface_ExecutionRepo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExecutionRepo'
+type TaskManagmentInterface_ExecutionRepo_Call struct {
+	*mock.Call
+}
+
+// ExecutionRepo is a helper method to define mock.On call
+func (_e *TaskManagmentInterface_Expecter) ExecutionRepo() *TaskManagmentInterface_ExecutionRepo_Call {
+	return &TaskManagmentInterface_ExecutionRepo_Call{Call: _e.mock.On("ExecutionRepo")}
+}
+
+func (_c *TaskManagmentInterface_ExecutionRepo_Call) Run(run func()) *TaskManagmentInterface_ExecutionRepo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *TaskManagmentInterface_ExecutionRepo_Call) Return(_a0 interfaces.ExecutionRepo) *TaskManagmentInterface_ExecutionRepo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TaskManagmentInterface_ExecutionRepo_Call) RunAndReturn(run func() interfaces.ExecutionRepo) *TaskManagmentInterface_ExecutionRepo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SecretRepo provides a mock function with given fields:
+func (_m *TaskManagmentInterface) SecretRepo() interfaces.SecretRepo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for SecretRepo")
+	}
+
+	var r0 interfaces.SecretRepo
+	if rf, ok := ret.Get(0).(func() interfaces.SecretRepo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.SecretRepo)
+		}
+	}
+
+	return r0
+}
+
+// TaskManagmentInterface_SecretRepo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SecretRepo'
+type TaskManagmentInterface_SecretRepo_Call struct {
+	*mock.Call
+}
+
+// SecretRepo is a helper method to define mock.On call
+func (_e *TaskManagmentInterface_Expecter) SecretRepo() *TaskManagmentInterface_SecretRepo_Call {
+	return &TaskManagmentInterface_SecretRepo_Call{Call: _e.mock.On("SecretRepo")}
+}
+
+func (_c *TaskManagmentInterface_SecretRepo_Call) Run(run func()) *TaskManagmentInterface_SecretRepo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *TaskManagmentInterface_SecretRepo_Call) Return(_a0 interfaces.SecretRepo) *TaskManagmentInterface_SecretRepo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TaskManagmentInterface_SecretRepo_Call) RunAndReturn(run func() interfaces.SecretRepo) *TaskManagmentInterface_SecretRepo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // TaskHistoryRepo provides a mock function with given fields:
 func (_m *TaskManagmentInterface) TaskHistoryRepo() interfaces.TaskHistoryRepo {
 	ret := _m.Called()
@@ -68,6 +162,53 @@ func (_c *TaskManagmentInterface_TaskHistoryRepo_Call) RunAndReturn(run func() i
 	return _c
 }
 
+// TaskLogRepo provides a mock function with given fields:
+func (_m *TaskManagmentInterface) TaskLogRepo() interfaces.TaskLogRepo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for TaskLogRepo")
+	}
+
+	var r0 interfaces.TaskLogRepo
+	if rf, ok := ret.Get(0).(func() interfaces.TaskLogRepo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.TaskLogRepo)
+		}
+	}
+
+	return r0
+}
+
+// TaskManagmentInterface_TaskLogRepo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TaskLogRepo'
+type TaskManagmentInterface_TaskLogRepo_Call struct {
+	*mock.Call
+}
+
+// TaskLogRepo is a helper method to define mock.On call
+func (_e *TaskManagmentInterface_Expecter) TaskLogRepo() *TaskManagmentInterface_TaskLogRepo_Call {
+	return &TaskManagmentInterface_TaskLogRepo_Call{Call: _e.mock.On("TaskLogRepo")}
+}
+
+func (_c *TaskManagmentInterface_TaskLogRepo_Call) Run(run func()) *TaskManagmentInterface_TaskLogRepo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *TaskManagmentInterface_TaskLogRepo_Call) Return(_a0 interfaces.TaskLogRepo) *TaskManagmentInterface_TaskLogRepo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TaskManagmentInterface_TaskLogRepo_Call) RunAndReturn(run func() interfaces.TaskLogRepo) *TaskManagmentInterface_TaskLogRepo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // TaskRepo provides a mock function with given fields:
 func (_m *TaskManagmentInterface) TaskRepo() interfaces.TaskRepo {
 	ret := _m.Called()
@@ -115,6 +256,53 @@ func (_c *TaskManagmentInterface_TaskRepo_Call) RunAndReturn(run func() interfac
 	return _c
 }
 
+// WorkflowRepo provides a mock function with given fields:
+func (_m *TaskManagmentInterface) WorkflowRepo() interfaces.WorkflowRepo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for WorkflowRepo")
+	}
+
+	var r0 interfaces.WorkflowRepo
+	if rf, ok := ret.Get(0).(func() interfaces.WorkflowRepo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.WorkflowRepo)
+		}
+	}
+
+	return r0
+}
+
+// TaskManagmentInterface_WorkflowRepo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WorkflowRepo'
+type TaskManagmentInterface_WorkflowRepo_Call struct {
+	*mock.Call
+}
+
+// WorkflowRepo is a helper method to define mock.On call
+func (_e *TaskManagmentInterface_Expecter) WorkflowRepo() *TaskManagmentInterface_WorkflowRepo_Call {
+	return &TaskManagmentInterface_WorkflowRepo_Call{Call: _e.mock.On("WorkflowRepo")}
+}
+
+func (_c *TaskManagmentInterface_WorkflowRepo_Call) Run(run func()) *TaskManagmentInterface_WorkflowRepo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *TaskManagmentInterface_WorkflowRepo_Call) Return(_a0 interfaces.WorkflowRepo) *TaskManagmentInterface_WorkflowRepo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TaskManagmentInterface_WorkflowRepo_Call) RunAndReturn(run func() interfaces.WorkflowRepo) *TaskManagmentInterface_WorkflowRepo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewTaskManagmentInterface creates a new instance of TaskManagmentInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewTaskManagmentInterface(t interface {
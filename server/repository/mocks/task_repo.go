@@ -8,6 +8,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	task "task/server/repository/model/task"
+
+	time "time"
 )
 
 // TaskRepo is an autogenerated mock type for the TaskRepo type
@@ -80,6 +82,64 @@ func (_c *TaskRepo_CreateTask_Call) RunAndReturn(run func(context.Context, task.
 	return _c
 }
 
+// GetStalledTasks provides a mock function with given fields: ctx
+func (_m *TaskRepo) GetStalledTasks(ctx context.Context) ([]task.Task, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStalledTasks")
+	}
+
+	var r0 []task.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]task.Task, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []task.Task); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]task.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TaskRepo_GetStalledTasks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStalledTasks'
+type TaskRepo_GetStalledTasks_Call struct {
+	*mock.Call
+}
+
+// GetStalledTasks is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *TaskRepo_Expecter) GetStalledTasks(ctx interface{}) *TaskRepo_GetStalledTasks_Call {
+	return &TaskRepo_GetStalledTasks_Call{Call: _e.mock.On("GetStalledTasks", ctx)}
+}
+
+func (_c *TaskRepo_GetStalledTasks_Call) Run(run func(ctx context.Context)) *TaskRepo_GetStalledTasks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *TaskRepo_GetStalledTasks_Call) Return(_a0 []task.Task, _a1 error) *TaskRepo_GetStalledTasks_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TaskRepo_GetStalledTasks_Call) RunAndReturn(run func(context.Context) ([]task.Task, error)) *TaskRepo_GetStalledTasks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetTaskByID provides a mock function with given fields: ctx, taskID
 func (_m *TaskRepo) GetTaskByID(ctx context.Context, taskID uint) (*task.Task, error) {
 	ret := _m.Called(ctx, taskID)
@@ -139,29 +199,88 @@ func (_c *TaskRepo_GetTaskByID_Call) RunAndReturn(run func(context.Context, uint
 	return _c
 }
 
-// GetTaskStatusCounts provides a mock function with given fields: ctx
-func (_m *TaskRepo) GetTaskStatusCounts(ctx context.Context) (map[int]int64, error) {
-	ret := _m.Called(ctx)
+// GetTaskResult provides a mock function with given fields: ctx, taskID
+func (_m *TaskRepo) GetTaskResult(ctx context.Context, taskID uint) (*task.TaskResult, error) {
+	ret := _m.Called(ctx, taskID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTaskResult")
+	}
+
+	var r0 *task.TaskResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*task.TaskResult, error)); ok {
+		return rf(ctx, taskID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *task.TaskResult); ok {
+		r0 = rf(ctx, taskID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*task.TaskResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, taskID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TaskRepo_GetTaskResult_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTaskResult'
+type TaskRepo_GetTaskResult_Call struct {
+	*mock.Call
+}
+
+// GetTaskResult is a helper method to define mock.On call
+//   - ctx context.Context
+//   - taskID uint
+func (_e *TaskRepo_Expecter) GetTaskResult(ctx interface{}, taskID interface{}) *TaskRepo_GetTaskResult_Call {
+	return &TaskRepo_GetTaskResult_Call{Call: _e.mock.On("GetTaskResult", ctx, taskID)}
+}
+
+func (_c *TaskRepo_GetTaskResult_Call) Run(run func(ctx context.Context, taskID uint)) *TaskRepo_GetTaskResult_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint))
+	})
+	return _c
+}
+
+func (_c *TaskRepo_GetTaskResult_Call) Return(_a0 *task.TaskResult, _a1 error) *TaskRepo_GetTaskResult_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TaskRepo_GetTaskResult_Call) RunAndReturn(run func(context.Context, uint) (*task.TaskResult, error)) *TaskRepo_GetTaskResult_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTaskStatusCounts provides a mock function with given fields: ctx, filter
+func (_m *TaskRepo) GetTaskStatusCounts(ctx context.Context, filter task.StatusCountFilter) ([]task.StatusCount, error) {
+	ret := _m.Called(ctx, filter)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTaskStatusCounts")
 	}
 
-	var r0 map[int]int64
+	var r0 []task.StatusCount
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (map[int]int64, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, task.StatusCountFilter) ([]task.StatusCount, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) map[int]int64); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, task.StatusCountFilter) []task.StatusCount); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[int]int64)
+			r0 = ret.Get(0).([]task.StatusCount)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, task.StatusCountFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -176,30 +295,31 @@ type TaskRepo_GetTaskStatusCounts_Call struct {
 
 // GetTaskStatusCounts is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *TaskRepo_Expecter) GetTaskStatusCounts(ctx interface{}) *TaskRepo_GetTaskStatusCounts_Call {
-	return &TaskRepo_GetTaskStatusCounts_Call{Call: _e.mock.On("GetTaskStatusCounts", ctx)}
+//   - filter task.StatusCountFilter
+func (_e *TaskRepo_Expecter) GetTaskStatusCounts(ctx interface{}, filter interface{}) *TaskRepo_GetTaskStatusCounts_Call {
+	return &TaskRepo_GetTaskStatusCounts_Call{Call: _e.mock.On("GetTaskStatusCounts", ctx, filter)}
 }
 
-func (_c *TaskRepo_GetTaskStatusCounts_Call) Run(run func(ctx context.Context)) *TaskRepo_GetTaskStatusCounts_Call {
+func (_c *TaskRepo_GetTaskStatusCounts_Call) Run(run func(ctx context.Context, filter task.StatusCountFilter)) *TaskRepo_GetTaskStatusCounts_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(task.StatusCountFilter))
 	})
 	return _c
 }
 
-func (_c *TaskRepo_GetTaskStatusCounts_Call) Return(_a0 map[int]int64, _a1 error) *TaskRepo_GetTaskStatusCounts_Call {
+func (_c *TaskRepo_GetTaskStatusCounts_Call) Return(_a0 []task.StatusCount, _a1 error) *TaskRepo_GetTaskStatusCounts_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *TaskRepo_GetTaskStatusCounts_Call) RunAndReturn(run func(context.Context) (map[int]int64, error)) *TaskRepo_GetTaskStatusCounts_Call {
+func (_c *TaskRepo_GetTaskStatusCounts_Call) RunAndReturn(run func(context.Context, task.StatusCountFilter) ([]task.StatusCount, error)) *TaskRepo_GetTaskStatusCounts_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListTasks provides a mock function with given fields: ctx, limit, offset, status, taskType
-func (_m *TaskRepo) ListTasks(ctx context.Context, limit int, offset int, status int, taskType string) ([]task.Task, error) {
-	ret := _m.Called(ctx, limit, offset, status, taskType)
+// ListTasks provides a mock function with given fields: ctx, filter
+func (_m *TaskRepo) ListTasks(ctx context.Context, filter task.TaskFilter) ([]task.Task, error) {
+	ret := _m.Called(ctx, filter)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListTasks")
@@ -207,19 +327,19 @@ func (_m *TaskRepo) ListTasks(ctx context.Context, limit int, offset int, status
 
 	var r0 []task.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) ([]task.Task, error)); ok {
-		return rf(ctx, limit, offset, status, taskType)
+	if rf, ok := ret.Get(0).(func(context.Context, task.TaskFilter) ([]task.Task, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) []task.Task); ok {
-		r0 = rf(ctx, limit, offset, status, taskType)
+	if rf, ok := ret.Get(0).(func(context.Context, task.TaskFilter) []task.Task); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]task.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, string) error); ok {
-		r1 = rf(ctx, limit, offset, status, taskType)
+	if rf, ok := ret.Get(1).(func(context.Context, task.TaskFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -234,17 +354,14 @@ type TaskRepo_ListTasks_Call struct {
 
 // ListTasks is a helper method to define mock.On call
 //   - ctx context.Context
-//   - limit int
-//   - offset int
-//   - status int
-//   - taskType string
-func (_e *TaskRepo_Expecter) ListTasks(ctx interface{}, limit interface{}, offset interface{}, status interface{}, taskType interface{}) *TaskRepo_ListTasks_Call {
-	return &TaskRepo_ListTasks_Call{Call: _e.mock.On("ListTasks", ctx, limit, offset, status, taskType)}
+//   - filter task.TaskFilter
+func (_e *TaskRepo_Expecter) ListTasks(ctx interface{}, filter interface{}) *TaskRepo_ListTasks_Call {
+	return &TaskRepo_ListTasks_Call{Call: _e.mock.On("ListTasks", ctx, filter)}
 }
 
-func (_c *TaskRepo_ListTasks_Call) Run(run func(ctx context.Context, limit int, offset int, status int, taskType string)) *TaskRepo_ListTasks_Call {
+func (_c *TaskRepo_ListTasks_Call) Run(run func(ctx context.Context, filter task.TaskFilter)) *TaskRepo_ListTasks_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int), args[2].(int), args[3].(int), args[4].(string))
+		run(args[0].(context.Context), args[1].(task.TaskFilter))
 	})
 	return _c
 }
@@ -254,22 +371,81 @@ func (_c *TaskRepo_ListTasks_Call) Return(_a0 []task.Task, _a1 error) *TaskRepo_
 	return _c
 }
 
-func (_c *TaskRepo_ListTasks_Call) RunAndReturn(run func(context.Context, int, int, int, string) ([]task.Task, error)) *TaskRepo_ListTasks_Call {
+func (_c *TaskRepo_ListTasks_Call) RunAndReturn(run func(context.Context, task.TaskFilter) ([]task.Task, error)) *TaskRepo_ListTasks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateTaskProgress provides a mock function with given fields: ctx, taskID, progress, minInterval
+func (_m *TaskRepo) UpdateTaskProgress(ctx context.Context, taskID uint, progress task.Progress, minInterval time.Duration) (bool, error) {
+	ret := _m.Called(ctx, taskID, progress, minInterval)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTaskProgress")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, task.Progress, time.Duration) (bool, error)); ok {
+		return rf(ctx, taskID, progress, minInterval)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, task.Progress, time.Duration) bool); ok {
+		r0 = rf(ctx, taskID, progress, minInterval)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, task.Progress, time.Duration) error); ok {
+		r1 = rf(ctx, taskID, progress, minInterval)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TaskRepo_UpdateTaskProgress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateTaskProgress'
+type TaskRepo_UpdateTaskProgress_Call struct {
+	*mock.Call
+}
+
+// UpdateTaskProgress is a helper method to define mock.On call
+//   - ctx context.Context
+//   - taskID uint
+//   - progress task.Progress
+//   - minInterval time.Duration
+func (_e *TaskRepo_Expecter) UpdateTaskProgress(ctx interface{}, taskID interface{}, progress interface{}, minInterval interface{}) *TaskRepo_UpdateTaskProgress_Call {
+	return &TaskRepo_UpdateTaskProgress_Call{Call: _e.mock.On("UpdateTaskProgress", ctx, taskID, progress, minInterval)}
+}
+
+func (_c *TaskRepo_UpdateTaskProgress_Call) Run(run func(ctx context.Context, taskID uint, progress task.Progress, minInterval time.Duration)) *TaskRepo_UpdateTaskProgress_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint), args[2].(task.Progress), args[3].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *TaskRepo_UpdateTaskProgress_Call) Return(_a0 bool, _a1 error) *TaskRepo_UpdateTaskProgress_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TaskRepo_UpdateTaskProgress_Call) RunAndReturn(run func(context.Context, uint, task.Progress, time.Duration) (bool, error)) *TaskRepo_UpdateTaskProgress_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateTaskStatus provides a mock function with given fields: ctx, taskID, status
-func (_m *TaskRepo) UpdateTaskStatus(ctx context.Context, taskID uint, status int) error {
-	ret := _m.Called(ctx, taskID, status)
+// UpdateTaskStatus provides a mock function with given fields: ctx, taskID, status, result
+func (_m *TaskRepo) UpdateTaskStatus(ctx context.Context, taskID uint, status int, result *task.TaskResult) error {
+	ret := _m.Called(ctx, taskID, status, result)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateTaskStatus")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint, int) error); ok {
-		r0 = rf(ctx, taskID, status)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, int, *task.TaskResult) error); ok {
+		r0 = rf(ctx, taskID, status, result)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -286,13 +462,14 @@ type TaskRepo_UpdateTaskStatus_Call struct {
 //   - ctx context.Context
 //   - taskID uint
 //   - status int
-func (_e *TaskRepo_Expecter) UpdateTaskStatus(ctx interface{}, taskID interface{}, status interface{}) *TaskRepo_UpdateTaskStatus_Call {
-	return &TaskRepo_UpdateTaskStatus_Call{Call: _e.mock.On("UpdateTaskStatus", ctx, taskID, status)}
+//   - result *task.TaskResult
+func (_e *TaskRepo_Expecter) UpdateTaskStatus(ctx interface{}, taskID interface{}, status interface{}, result interface{}) *TaskRepo_UpdateTaskStatus_Call {
+	return &TaskRepo_UpdateTaskStatus_Call{Call: _e.mock.On("UpdateTaskStatus", ctx, taskID, status, result)}
 }
 
-func (_c *TaskRepo_UpdateTaskStatus_Call) Run(run func(ctx context.Context, taskID uint, status int)) *TaskRepo_UpdateTaskStatus_Call {
+func (_c *TaskRepo_UpdateTaskStatus_Call) Run(run func(ctx context.Context, taskID uint, status int, result *task.TaskResult)) *TaskRepo_UpdateTaskStatus_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uint), args[2].(int))
+		run(args[0].(context.Context), args[1].(uint), args[2].(int), args[3].(*task.TaskResult))
 	})
 	return _c
 }
@@ -302,7 +479,7 @@ func (_c *TaskRepo_UpdateTaskStatus_Call) Return(_a0 error) *TaskRepo_UpdateTask
 	return _c
 }
 
-func (_c *TaskRepo_UpdateTaskStatus_Call) RunAndReturn(run func(context.Context, uint, int) error) *TaskRepo_UpdateTaskStatus_Call {
+func (_c *TaskRepo_UpdateTaskStatus_Call) RunAndReturn(run func(context.Context, uint, int, *task.TaskResult) error) *TaskRepo_UpdateTaskStatus_Call {
 	_c.Call.Return(run)
 	return _c
 }
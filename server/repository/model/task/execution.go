@@ -10,9 +10,9 @@ import (
 // Execution represents a task with its attributes.
 type Execution struct {
 	gorm.Model
-	TaskID    uint      `json:"task_id" gorm:"not null"`            // Foreign key for Task
-	Status    int       `json:"status" gorm:"type:int;not null"`    // Refers to the custom PostgreSQL enum
-	Payload   string    `json:"payload" gorm:"type:jsonb;not null"` // Storing JSON as a string in PostgreSQL
+	TaskID    uint      `json:"task_id" gorm:"not null"`         // Foreign key for Task
+	Status    int       `json:"status" gorm:"type:int;not null"` // Refers to the custom PostgreSQL enum
+	Payload   JSON      `json:"payload" gorm:"not null"`         // Stored as jsonb, json or text depending on the database
 	Retries   int       `json:"retries" gorm:"default:0;check:retries >= 0 AND retries <= 10"`
 	Priority  int       `json:"priority" gorm:"default:0;check:priority >= 0"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime; not null"`
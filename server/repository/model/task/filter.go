@@ -0,0 +1,100 @@
+package task
+
+import "time"
+
+// SortField names a column that task listings can be ordered by.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+	SortByPriority  SortField = "priority"
+	SortByName      SortField = "name"
+	SortByID        SortField = "id"
+)
+
+// SortOrder is the direction of a sort.
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "ASC"
+	SortDesc SortOrder = "DESC"
+)
+
+// TaskFilter describes which tasks ListTasks returns and in what order.
+// Zero values mean "no filter" for every field, so an empty TaskFilter
+// matches all tasks sorted by creation time, newest first.
+type TaskFilter struct {
+	Limit  int
+	Offset int
+
+	// Statuses restricts results to tasks in any of the given statuses.
+	Statuses []int
+	// Type restricts results to a single task type.
+	Type string
+	// NamePrefix restricts results to tasks whose name starts with the prefix.
+	NamePrefix string
+	// MinPriority and MaxPriority bound the task priority, inclusive.
+	MinPriority *int
+	MaxPriority *int
+
+	// CreatedAfter and UpdatedAfter are inclusive; CreatedBefore and UpdatedBefore are exclusive.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	// Search is matched against the task name and description using full-text search.
+	Search string
+
+	SortBy    SortField
+	SortOrder SortOrder
+}
+
+// TaskHistoryFilter describes which history entries ListTaskHistories returns.
+// Pagination is keyset based: Cursor is the ID of the last entry of the previous
+// page, and zero starts from the first entry in the requested order.
+type TaskHistoryFilter struct {
+	TaskID uint
+	Limit  int
+	Cursor uint
+
+	// Statuses restricts results to entries recorded with any of the given statuses.
+	Statuses []int
+	// Since is inclusive and Until is exclusive; zero values leave the range open.
+	Since time.Time
+	Until time.Time
+
+	// SortOrder orders entries by ID, which follows creation order. Defaults to ascending.
+	SortOrder SortOrder
+}
+
+// StatusCountFilter describes which tasks GetTaskStatusCounts counts.
+type StatusCountFilter struct {
+	// CreatedAfter is inclusive and CreatedBefore is exclusive. Counts are kept per hour,
+	// so the window is widened to whole hours; zero values leave the range open.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// ByType breaks the counts down by task type.
+	ByType bool
+}
+
+// StatusCount is the number of tasks in one status, and of one type when counts are broken down by type.
+type StatusCount struct {
+	Type   string
+	Status int
+	Count  int64
+}
+
+// TaskLogFilter describes which log chunks ListTaskLogChunks returns. Chunks are
+// returned in the order they were appended, and Cursor is the ID of the last chunk
+// already read, so that a follower can poll for the chunks appended since.
+type TaskLogFilter struct {
+	TaskID uint
+	Limit  int
+	Cursor uint
+
+	// Attempt restricts results to the chunks of a single attempt; zero matches every attempt.
+	Attempt int
+}
@@ -0,0 +1,52 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TaskLogChunk holds a batch of log lines that a worker shipped while running one
+// attempt of a task. Chunks are appended as they arrive and read back in ID order.
+type TaskLogChunk struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TaskID    uint      `json:"task_id" gorm:"not null"` // Foreign key for Task
+	Attempt   int       `json:"attempt" gorm:"not null"` // Attempt of the task that logged the lines
+	Lines     JSON      `json:"lines" gorm:"not null"`   // Log lines, stored as a JSON array
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime; not null"`
+}
+
+// LogLine is a single line of task log output.
+type LogLine struct {
+	Time       time.Time         `json:"time"`
+	Level      string            `json:"level"`
+	Message    string            `json:"message"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// TableName returns the custom table name for the TaskLogChunk model.
+func (*TaskLogChunk) TableName() string {
+	return "task_log_chunks"
+}
+
+// LogLines decodes the lines of the chunk.
+func (c *TaskLogChunk) LogLines() ([]LogLine, error) {
+	var lines []LogLine
+	if err := json.Unmarshal([]byte(c.Lines), &lines); err != nil {
+		return nil, fmt.Errorf("invalid task log lines: %w", err)
+	}
+	return lines, nil
+}
+
+// SetLogLines encodes lines into the Lines of the chunk.
+func (c *TaskLogChunk) SetLogLines(lines []LogLine) error {
+	if lines == nil {
+		lines = []LogLine{}
+	}
+	data, err := json.Marshal(lines)
+	if err != nil {
+		return fmt.Errorf("failed to encode task log lines: %w", err)
+	}
+	c.Lines = JSON(data)
+	return nil
+}
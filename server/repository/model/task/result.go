@@ -0,0 +1,44 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TaskResult holds the result a task reported when it finished. A task has at most
+// one result; the result of a later run replaces it.
+type TaskResult struct {
+	TaskID    uint      `json:"task_id" gorm:"primaryKey;autoIncrement:false"` // Foreign key for Task
+	Outputs   JSON      `json:"outputs" gorm:"not null"`                       // Output names mapped to values, stored as a JSON object
+	Data      *JSON     `json:"data"`                                          // Optional JSON document, NULL when the task reported none
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime; not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime; not null"`
+}
+
+// TableName returns the custom table name for the TaskResult model.
+func (*TaskResult) TableName() string {
+	return "task_results"
+}
+
+// OutputMap decodes the outputs of the result.
+func (r *TaskResult) OutputMap() (map[string]string, error) {
+	var outputs map[string]string
+	if err := json.Unmarshal([]byte(r.Outputs), &outputs); err != nil {
+		return nil, fmt.Errorf("invalid task outputs: %w", err)
+	}
+	return outputs, nil
+}
+
+// SetOutputs encodes outputs into the Outputs of the result.
+func (r *TaskResult) SetOutputs(outputs map[string]string) error {
+	if outputs == nil {
+		outputs = map[string]string{}
+	}
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to encode task outputs: %w", err)
+	}
+	r.Outputs = JSON(data)
+	return nil
+}
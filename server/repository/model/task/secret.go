@@ -0,0 +1,18 @@
+package task
+
+import "time"
+
+// Secret is a secret kept by the task service, which tasks refer to as secret://<name>.
+// The value is encrypted by the server before it is stored.
+type Secret struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_secrets_name; not null"`
+	Value     []byte    `json:"-" gorm:"not null"` // Encrypted value, prefixed with its nonce
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime; not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime; not null"`
+}
+
+// TableName returns the custom table name for the Secret model.
+func (*Secret) TableName() string {
+	return "secrets"
+}
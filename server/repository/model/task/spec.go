@@ -0,0 +1,48 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ExecutionSpec describes how a task is executed. It is stored as JSON in Task.Spec.
+type ExecutionSpec struct {
+	BaseImage  string            `json:"base_image,omitempty"`
+	Entrypoint string            `json:"entrypoint,omitempty"`
+	Args       []string          `json:"args,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	// InputArtifacts are output artifacts of earlier tasks the task takes as input.
+	InputArtifacts []ArtifactInput `json:"input_artifacts,omitempty"`
+	// OutputArtifacts are the names of the artifacts the task produces.
+	OutputArtifacts []string `json:"output_artifacts,omitempty"`
+}
+
+// ArtifactInput makes the output artifact of an earlier task available to a task under Name.
+type ArtifactInput struct {
+	Name     string `json:"name"`
+	TaskID   uint   `json:"task_id"`
+	Artifact string `json:"artifact"`
+}
+
+// ExecutionSpec decodes the execution spec of the task. Tasks created without one
+// have an empty Spec and return the zero value.
+func (t *Task) ExecutionSpec() (ExecutionSpec, error) {
+	var spec ExecutionSpec
+	if len(t.Spec) == 0 {
+		return spec, nil
+	}
+	if err := json.Unmarshal(t.Spec, &spec); err != nil {
+		return ExecutionSpec{}, fmt.Errorf("invalid execution spec: %w", err)
+	}
+	return spec, nil
+}
+
+// SetExecutionSpec encodes spec into the Spec of the task.
+func (t *Task) SetExecutionSpec(spec ExecutionSpec) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode execution spec: %w", err)
+	}
+	t.Spec = data
+	return nil
+}
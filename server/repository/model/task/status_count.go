@@ -0,0 +1,18 @@
+package task
+
+import "time"
+
+// TaskStatusCount is the materialized number of tasks of one type that were created
+// within one hour and are currently in one status. Rows are adjusted in the same
+// transaction as every task status transition, so reading them replaces counting tasks.
+type TaskStatusCount struct {
+	Type        string    `json:"type" gorm:"primaryKey;type:varchar(255)"`
+	Status      int       `json:"status" gorm:"primaryKey;autoIncrement:false"`
+	CreatedHour time.Time `json:"created_hour" gorm:"primaryKey"` // Creation time of the tasks truncated to the hour, in UTC
+	TaskCount   int64     `json:"task_count" gorm:"not null;default:0"`
+}
+
+// TableName returns the custom table name for the TaskStatusCount model.
+func (*TaskStatusCount) TableName() string {
+	return "task_status_counts"
+}
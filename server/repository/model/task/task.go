@@ -2,8 +2,11 @@ package task
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
+	"task/pkg/plugins"
+
 	"gorm.io/gorm"
 )
 
@@ -14,12 +17,21 @@ type Task struct {
 	Description string    `json:"description" gorm:"type:text;not null"`
 	Type        string    `json:"type" gorm:"type:varchar(255);not null"` // Refers to the custom PostgreSQL enum
 	Status      int       `json:"status" gorm:"type:int;not null"`        // Refers to the custom PostgreSQL enum
-	Payload     string    `json:"payload" gorm:"type:jsonb;not null"`     // Storing JSON as a string in PostgreSQL
-	Spec        []byte    `json:"spec" gorm:"type:bytea;not null"`        // Storing binary data in PostgreSQL
+	Payload     JSON      `json:"payload" gorm:"not null"`                // Stored as jsonb, json or text depending on the database
+	Spec        []byte    `json:"spec" gorm:"type:bytes;not null"`        // Stored as bytea, longblob or blob depending on the database
 	Retries     int       `json:"retries" gorm:"default:0;check:retries >= 0 AND retries <= 10"`
 	Priority    int       `json:"priority" gorm:"default:0;check:priority >= 0"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime; not null"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime; not null"`
+	Progress    Progress  `json:"progress" gorm:"embedded;embeddedPrefix:progress_"`
+}
+
+// Progress is the progress a task last reported while running.
+type Progress struct {
+	Percent    int        `json:"percent" gorm:"default:0;check:progress_percent >= 0 AND progress_percent <= 100"`
+	Message    string     `json:"message" gorm:"type:varchar(256);default:''"`
+	Attempt    int        `json:"attempt" gorm:"default:0"`
+	ReportedAt *time.Time `json:"reported_at"` // NULL until the task reports progress
 }
 
 // TableName returns the custom table name for the Task model.
@@ -33,8 +45,8 @@ func (t *Task) BeforeCreate(tx *gorm.DB) (err error) {
 	t.CreatedAt = time.Now()
 
 	// Ensure task type is valid
-	if t.Type != "send_email" && t.Type != "run_query" {
-		return errors.New("invalid task type")
+	if !plugins.IsRegistered(t.Type) {
+		return fmt.Errorf("invalid task type: %s", t.Type)
 	}
 
 	// Ensure task status is valid
@@ -42,5 +54,10 @@ func (t *Task) BeforeCreate(tx *gorm.DB) (err error) {
 		return errors.New("invalid task status")
 	}
 
+	// Spec is not nullable, and drivers store a nil slice as NULL
+	if t.Spec == nil {
+		t.Spec = []byte{}
+	}
+
 	return nil
 }
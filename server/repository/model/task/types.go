@@ -0,0 +1,22 @@
+package task
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// JSON is a JSON document stored as a string. It maps to the native JSON column
+// type of the database in use: jsonb on PostgreSQL, json on MySQL and text on SQLite.
+type JSON string
+
+// GormDBDataType returns the column type for a JSON field in the dialect of db.
+func (JSON) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "jsonb"
+	case "mysql":
+		return "json"
+	default:
+		return "text"
+	}
+}
@@ -11,8 +11,8 @@ type Workflow struct {
 	gorm.Model
 	Name        string    `json:"name" gorm:"type:varchar(255);not null"`
 	Description string    `json:"description" gorm:"type:text;not null"`
-	Payload     string    `json:"payload" gorm:"type:jsonb;not null"` // Storing JSON as a string in PostgreSQL
-	Spec        []byte    `json:"spec" gorm:"type:bytea;not null"`    // Storing binary data in PostgreSQL
+	Payload     JSON      `json:"payload" gorm:"not null"`         // Stored as jsonb, json or text depending on the database
+	Spec        []byte    `json:"spec" gorm:"type:bytes;not null"` // Stored as bytea, longblob or blob depending on the database
 	Retries     int       `json:"retries" gorm:"default:0;check:retries >= 0 AND retries <= 10"`
 	Priority    int       `json:"priority" gorm:"default:0;check:priority >= 0"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime; not null"`
@@ -12,7 +12,11 @@ import (
 	"syscall"
 	"time"
 
+	"task/pkg/artifact"
 	cloudv1connect "task/pkg/gen/cloud/v1/cloudv1connect"
+	_ "task/pkg/plugins/builtin"                  // Register the built-in task types
+	"task/pkg/plugins/external"                   // Register the task types of out-of-process plugins
+	"task/pkg/secret"                             // Encrypt the secrets kept by the server
 	"task/pkg/x"                                  // Import the x package for env and config
 	repository "task/server/repository"           // Import repository package
 	interfaces "task/server/repository/interface" // Import repository package
@@ -110,6 +114,13 @@ func run() error {
 	}
 	slog.Info("Application started", "config", env)
 
+	// The plugins in PLUGIN_DIR are started so that their task types are validated
+	pluginHost, err := external.Load(context.Background(), slog.Default())
+	if err != nil {
+		return fmt.Errorf("failed to load plugins: %w", err)
+	}
+	defer pluginHost.Close()
+
 	// Set up a channel to handle exit signals
 	exitChan := make(chan os.Signal, 1)
 	signal.Notify(exitChan, syscall.SIGINT, syscall.SIGTERM)
@@ -127,12 +138,37 @@ func run() error {
 	}
 
 	// Create the repository with DB configuration
-	repo, err := repository.GetRepository(env.Database.ToDbConnectionUri(), env.WorkerCount, env.Database.PoolMaxConns)
+	repo, err := repository.GetRepository(env.Database.ToDbConnectionUri(), env.Database.PoolMaxConns, repository.ReplicaConfig{
+		URL:          env.Database.ReplicaDSN,
+		MaxStaleness: env.Database.ReplicaMaxStaleness,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize database repository: %w", err)
 	}
 
-	slog.Info("Database repository initialized", "workerCount", env.WorkerCount)
+	slog.Info("Database repository initialized")
+
+	artifactStore, err := artifact.NewStore(env.Artifacts)
+	if err != nil {
+		return fmt.Errorf("failed to initialize artifact store: %w", err)
+	}
+	slog.Info("Artifact store initialized", "store", env.Artifacts.Store)
+	if env.WorkerToken == "" {
+		slog.Warn("WORKER_TOKEN is not set, so workers cannot upload artifacts or resolve the values of secrets")
+	}
+
+	// The secret store stays disabled until a key is configured
+	var secretCipher *secret.Cipher
+	if env.SecretKey != "" {
+		key, err := secret.ParseKey(env.SecretKey)
+		if err != nil {
+			return err
+		}
+		if secretCipher, err = secret.NewCipher(key); err != nil {
+			return err
+		}
+		slog.Info("Secret store initialized")
+	}
 
 	// Set up gRPC middleware
 	middleware := connectauth.NewMiddleware(func(ctx context.Context, req *connectauth.Request) (any, error) {
@@ -144,7 +180,7 @@ func run() error {
 
 	// Set up HTTP server
 	mux := http.NewServeMux()
-	if err := setupHandlers(mux, repo, middleware); err != nil {
+	if err := setupHandlers(mux, repo, artifactStore, env.Artifacts.URLExpiry, secretCipher, env.WorkerToken, middleware); err != nil {
 		return fmt.Errorf("failed to set up handlers: %w", err)
 	}
 
@@ -195,21 +231,27 @@ func run() error {
 
 // setupHandlers configures the HTTP handlers for the server
 // It sets up the gRPC service, health check, and reflection handlers
-func setupHandlers(mux *http.ServeMux, repo interfaces.TaskManagmentInterface, middleware *connectauth.Middleware) error {
+func setupHandlers(mux *http.ServeMux, repo interfaces.TaskManagmentInterface, store artifact.Store, artifactURLExpiry time.Duration, secrets *secret.Cipher, workerToken string, middleware *connectauth.Middleware) error {
 	otelInterceptor, err := otelconnect.NewInterceptor()
 	if err != nil {
 		return fmt.Errorf("failed to create interceptor: %w", err)
 	}
 
 	pattern, handler := cloudv1connect.NewTaskManagementServiceHandler(
-		route.NewTaskServer(repo),
-		connect.WithInterceptors(otelInterceptor),
+		route.NewTaskServer(repo, store, artifactURLExpiry, secrets),
+		// Only workers presenting the worker token get the values of secrets
+		connect.WithInterceptors(otelInterceptor, route.NewWorkerAuthInterceptor(workerToken)),
 		connect.WithCompressMinBytes(CompressMinByte),
 		connect.WithSendMaxBytes(math.MaxInt32),
 		connect.WithReadMaxBytes(math.MaxInt32),
 	)
 	mux.Handle(pattern, handler)
 
+	// The local artifact store serves the signed URLs it hands out itself
+	if local, ok := store.(*artifact.LocalStore); ok {
+		mux.Handle(artifact.LocalPath, local)
+	}
+
 	// Health check and reflection handlers
 	mux.Handle(grpchealth.NewHandler(
 		grpchealth.NewStaticChecker(cloudv1connect.TaskManagementServiceName),
@@ -0,0 +1,233 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"time"
+
+	"task/pkg/artifact"
+	v1 "task/pkg/gen/cloud/v1"
+	"task/server/repository/model/task"
+
+	connect "connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// maxArtifacts is the largest number of input, and of output, artifacts a task can declare.
+	maxArtifacts = 32
+	// defaultArtifactURLExpiry is how long the artifact URLs handed out by the server stay valid.
+	defaultArtifactURLExpiry = 15 * time.Minute
+)
+
+// ListArtifacts lists the output artifacts a task stored, along with the artifacts it declared.
+func (s *TaskServer) ListArtifacts(ctx context.Context, req *connect.Request[v1.ListArtifactsRequest]) (*connect.Response[v1.ListArtifactsResponse], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("list_artifacts"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Listing artifacts: id=%d", req.Msg.Id)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+
+	_, spec, err := s.taskExecutionSpec(ctx, req.Msg.Id, "list_artifacts")
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := s.artifactStore.List(ctx, artifact.Prefix(int64(req.Msg.Id)))
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("list_artifacts").Inc()
+		return nil, s.logError(err, "Failed to list artifacts: id=%d", req.Msg.Id)
+	}
+
+	artifacts := make([]*v1.Artifact, len(infos))
+	for i, info := range infos {
+		artifacts[i] = &v1.Artifact{
+			Name:        path.Base(info.Key),
+			Size:        info.Size,
+			ContentType: info.ContentType,
+			UpdatedAt:   timestamppb.New(info.ModTime),
+		}
+	}
+	return connect.NewResponse(&v1.ListArtifactsResponse{
+		Artifacts:       artifacts,
+		InputArtifacts:  convertArtifactInputsToProto(spec.InputArtifacts),
+		OutputArtifacts: spec.OutputArtifacts,
+	}), nil
+}
+
+// GetArtifactUploadURL hands out a URL that uploads an output artifact the task declared,
+// until the task has finished. Only workers may call it, see NewWorkerAuthInterceptor.
+func (s *TaskServer) GetArtifactUploadURL(ctx context.Context, req *connect.Request[v1.GetArtifactUploadURLRequest]) (*connect.Response[v1.ArtifactURL], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("get_artifact_upload_url"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Signing artifact upload URL: id=%d, name=%s", req.Msg.Id, req.Msg.Name)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+
+	taskModel, spec, err := s.taskExecutionSpec(ctx, req.Msg.Id, "get_artifact_upload_url")
+	if err != nil {
+		return nil, err
+	}
+	if isFinalStatus(v1.TaskStatusEnum(taskModel.Status)) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("task %d has finished", req.Msg.Id))
+	}
+	if !slices.Contains(spec.OutputArtifacts, req.Msg.Name) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("task %d does not declare the output artifact %s", req.Msg.Id, req.Msg.Name))
+	}
+
+	return s.signArtifactURL(ctx, http.MethodPut, req.Msg.Id, req.Msg.Name, "get_artifact_upload_url")
+}
+
+// GetArtifactDownloadURL hands out a URL that downloads an output artifact the task stored.
+func (s *TaskServer) GetArtifactDownloadURL(ctx context.Context, req *connect.Request[v1.GetArtifactDownloadURLRequest]) (*connect.Response[v1.ArtifactURL], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("get_artifact_download_url"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Signing artifact download URL: id=%d, name=%s", req.Msg.Id, req.Msg.Name)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+
+	_, spec, err := s.taskExecutionSpec(ctx, req.Msg.Id, "get_artifact_download_url")
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(spec.OutputArtifacts, req.Msg.Name) {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("task %d does not declare the output artifact %s", req.Msg.Id, req.Msg.Name))
+	}
+	if _, err := s.artifactStore.Stat(ctx, artifact.Key(int64(req.Msg.Id), req.Msg.Name)); err != nil {
+		if errors.Is(err, artifact.ErrNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("task %d has not stored the artifact %s yet", req.Msg.Id, req.Msg.Name))
+		}
+		s.metrics.errorCounter.WithLabelValues("get_artifact_download_url").Inc()
+		return nil, s.logError(err, "Failed to stat artifact: id=%d, name=%s", req.Msg.Id, req.Msg.Name)
+	}
+
+	return s.signArtifactURL(ctx, http.MethodGet, req.Msg.Id, req.Msg.Name, "get_artifact_download_url")
+}
+
+// taskExecutionSpec loads a task along with its execution spec, which holds its artifact declarations.
+func (s *TaskServer) taskExecutionSpec(ctx context.Context, id int32, op string) (*task.Task, task.ExecutionSpec, error) {
+	taskModel, err := s.taskRepo.GetTaskByID(ctx, uint(id))
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues(op).Inc()
+		return nil, task.ExecutionSpec{}, connect.NewError(connect.CodeNotFound, fmt.Errorf("task not found: %w", err))
+	}
+	spec, err := taskModel.ExecutionSpec()
+	if err != nil {
+		return nil, task.ExecutionSpec{}, s.logError(err, "Failed to decode task spec: id=%d", id)
+	}
+	return taskModel, spec, nil
+}
+
+// signArtifactURL signs a URL allowing method on an artifact of a task.
+func (s *TaskServer) signArtifactURL(ctx context.Context, method string, id int32, name, op string) (*connect.Response[v1.ArtifactURL], error) {
+	expiresAt := time.Now().Add(s.artifactURLExpiry)
+	u, err := s.artifactStore.SignURL(ctx, method, artifact.Key(int64(id), name), s.artifactURLExpiry)
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues(op).Inc()
+		return nil, s.logError(err, "Failed to sign artifact URL: id=%d, name=%s", id, name)
+	}
+	return connect.NewResponse(&v1.ArtifactURL{
+		Url:       u,
+		Method:    method,
+		ExpiresAt: timestamppb.New(expiresAt),
+	}), nil
+}
+
+// validateArtifacts checks the artifacts a new task declares. Inputs must name output
+// artifacts that existing tasks declare, and tasks run from a base image cannot have
+// artifacts as they are not run in a workspace.
+func (s *TaskServer) validateArtifacts(ctx context.Context, req *v1.CreateTaskRequest) error {
+	var violations fieldViolations
+
+	if req.BaseImage != "" && (len(req.InputArtifacts) > 0 || len(req.OutputArtifacts) > 0) {
+		violations.add("base_image", "tasks run from a base image cannot declare artifacts")
+	}
+	if len(req.InputArtifacts) > maxArtifacts {
+		violations.add("input_artifacts", fmt.Sprintf("must have at most %d artifacts", maxArtifacts))
+	}
+	if len(req.OutputArtifacts) > maxArtifacts {
+		violations.add("output_artifacts", fmt.Sprintf("must have at most %d artifacts", maxArtifacts))
+	}
+
+	inputs := make(map[string]bool, len(req.InputArtifacts))
+	for i, input := range req.InputArtifacts {
+		field := fmt.Sprintf("input_artifacts[%d]", i)
+		if err := artifact.ValidateName(input.Name); err != nil {
+			violations.add(field+".name", err.Error())
+		} else if inputs[input.Name] {
+			violations.add(field+".name", fmt.Sprintf("duplicate input artifact %s", input.Name))
+		}
+		inputs[input.Name] = true
+
+		if input.TaskId <= 0 {
+			violations.add(field+".task_id", "must be greater than 0")
+			continue
+		}
+		producer, err := s.taskRepo.GetTaskByID(ctx, uint(input.TaskId))
+		if err != nil {
+			violations.add(field+".task_id", fmt.Sprintf("task %d not found", input.TaskId))
+			continue
+		}
+		spec, err := producer.ExecutionSpec()
+		if err != nil || !slices.Contains(spec.OutputArtifacts, input.Artifact) {
+			violations.add(field+".artifact", fmt.Sprintf("task %d does not declare the output artifact %s", input.TaskId, input.Artifact))
+		}
+	}
+
+	outputs := make(map[string]bool, len(req.OutputArtifacts))
+	for i, name := range req.OutputArtifacts {
+		field := fmt.Sprintf("output_artifacts[%d]", i)
+		if err := artifact.ValidateName(name); err != nil {
+			violations.add(field, err.Error())
+		} else if outputs[name] {
+			violations.add(field, fmt.Sprintf("duplicate output artifact %s", name))
+		}
+		outputs[name] = true
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return badRequest("invalid task artifacts", violations...)
+}
+
+// convertArtifactInputsFromProto converts the declared input artifacts to the model.
+func convertArtifactInputsFromProto(inputs []*v1.ArtifactInput) []task.ArtifactInput {
+	if len(inputs) == 0 {
+		return nil
+	}
+	converted := make([]task.ArtifactInput, len(inputs))
+	for i, input := range inputs {
+		converted[i] = task.ArtifactInput{Name: input.Name, TaskID: uint(input.TaskId), Artifact: input.Artifact}
+	}
+	return converted
+}
+
+// convertArtifactInputsToProto converts the declared input artifacts to protobuf messages.
+func convertArtifactInputsToProto(inputs []task.ArtifactInput) []*v1.ArtifactInput {
+	if len(inputs) == 0 {
+		return nil
+	}
+	converted := make([]*v1.ArtifactInput, len(inputs))
+	for i, input := range inputs {
+		converted[i] = &v1.ArtifactInput{Name: input.Name, TaskId: int32(input.TaskID), Artifact: input.Artifact}
+	}
+	return converted
+}
@@ -0,0 +1,214 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"task/pkg/artifact"
+	cloudv1 "task/pkg/gen/cloud/v1"
+	repomocks "task/server/repository/mocks"
+	"task/server/repository/model/task"
+)
+
+// newTestArtifactServer returns a TaskServer with a local artifact store, and the directory of the store.
+func newTestArtifactServer(t *testing.T) (*TaskServer, *repomocks.TaskRepo, string) {
+	t.Helper()
+	server, taskRepo, _ := newTestServer(t)
+	dir := t.TempDir()
+	store, err := artifact.NewLocalStore(dir, "http://localhost:8080", []byte("secret"), 1<<20)
+	require.NoError(t, err)
+	server.artifactStore = store
+	server.artifactURLExpiry = time.Minute
+	return server, taskRepo, dir
+}
+
+// taskWithArtifacts returns a task that declares the artifacts.
+func taskWithArtifacts(t *testing.T, inputs []task.ArtifactInput, outputs ...string) *task.Task {
+	t.Helper()
+	var taskModel task.Task
+	require.NoError(t, taskModel.SetExecutionSpec(task.ExecutionSpec{InputArtifacts: inputs, OutputArtifacts: outputs}))
+	return &taskModel
+}
+
+// storeArtifact writes an artifact into the directory of a local store.
+func storeArtifact(t *testing.T, dir string, taskID int64, name, data string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(artifact.Key(taskID, name)))
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+	require.NoError(t, os.WriteFile(p, []byte(data), 0o644))
+}
+
+func TestListArtifacts(t *testing.T) {
+	server, taskRepo, dir := newTestArtifactServer(t)
+	inputs := []task.ArtifactInput{{Name: "input.csv", TaskID: 3, Artifact: "report.csv"}}
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithArtifacts(t, inputs, "scores.csv", "model.pt"), nil)
+	storeArtifact(t, dir, 7, "scores.csv", "a,b\n")
+
+	resp, err := server.ListArtifacts(context.Background(), connect.NewRequest(&cloudv1.ListArtifactsRequest{Id: 7}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Artifacts, 1)
+	assert.Equal(t, "scores.csv", resp.Msg.Artifacts[0].Name)
+	assert.Equal(t, int64(4), resp.Msg.Artifacts[0].Size)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Msg.Artifacts[0].ContentType)
+	assert.Equal(t, []string{"scores.csv", "model.pt"}, resp.Msg.OutputArtifacts)
+	require.Len(t, resp.Msg.InputArtifacts, 1)
+	assert.Equal(t, "input.csv", resp.Msg.InputArtifacts[0].Name)
+	assert.Equal(t, int32(3), resp.Msg.InputArtifacts[0].TaskId)
+	assert.Equal(t, "report.csv", resp.Msg.InputArtifacts[0].Artifact)
+}
+
+func TestGetArtifactUploadURL(t *testing.T) {
+	t.Run("Declared output", func(t *testing.T) {
+		server, taskRepo, _ := newTestArtifactServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithArtifacts(t, nil, "scores.csv"), nil)
+
+		resp, err := server.GetArtifactUploadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactUploadURLRequest{Id: 7, Name: "scores.csv"}))
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPut, resp.Msg.Method)
+		assert.Contains(t, resp.Msg.Url, "http://localhost:8080/artifacts/tasks/7/scores.csv?")
+		assert.WithinDuration(t, time.Now().Add(time.Minute), resp.Msg.ExpiresAt.AsTime(), 5*time.Second)
+	})
+
+	t.Run("Undeclared output", func(t *testing.T) {
+		server, taskRepo, _ := newTestArtifactServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithArtifacts(t, nil, "scores.csv"), nil)
+
+		_, err := server.GetArtifactUploadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactUploadURLRequest{Id: 7, Name: "other.csv"}))
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+
+	t.Run("Finished task", func(t *testing.T) {
+		server, taskRepo, _ := newTestArtifactServer(t)
+		finished := taskWithArtifacts(t, nil, "scores.csv")
+		finished.Status = int(cloudv1.TaskStatusEnum_SUCCEEDED)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(finished, nil)
+
+		_, err := server.GetArtifactUploadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactUploadURLRequest{Id: 7, Name: "scores.csv"}))
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+
+	t.Run("Unknown task", func(t *testing.T) {
+		server, taskRepo, _ := newTestArtifactServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(nil, errors.New("record not found"))
+
+		_, err := server.GetArtifactUploadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactUploadURLRequest{Id: 7, Name: "scores.csv"}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
+
+func TestGetArtifactDownloadURL(t *testing.T) {
+	t.Run("Stored output", func(t *testing.T) {
+		server, taskRepo, dir := newTestArtifactServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithArtifacts(t, nil, "scores.csv"), nil)
+		storeArtifact(t, dir, 7, "scores.csv", "a,b\n")
+
+		resp, err := server.GetArtifactDownloadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactDownloadURLRequest{Id: 7, Name: "scores.csv"}))
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodGet, resp.Msg.Method)
+		assert.Contains(t, resp.Msg.Url, "/artifacts/tasks/7/scores.csv?")
+	})
+
+	t.Run("Output not stored yet", func(t *testing.T) {
+		server, taskRepo, _ := newTestArtifactServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithArtifacts(t, nil, "scores.csv"), nil)
+
+		_, err := server.GetArtifactDownloadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactDownloadURLRequest{Id: 7, Name: "scores.csv"}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+		assert.ErrorContains(t, err, "has not stored the artifact scores.csv yet")
+	})
+
+	t.Run("Undeclared output", func(t *testing.T) {
+		server, taskRepo, dir := newTestArtifactServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithArtifacts(t, nil), nil)
+		storeArtifact(t, dir, 7, "scores.csv", "a,b\n")
+
+		_, err := server.GetArtifactDownloadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactDownloadURLRequest{Id: 7, Name: "scores.csv"}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
+
+func TestValidateArtifacts(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *cloudv1.CreateTaskRequest
+		want []string
+	}{
+		{
+			name: "Valid artifacts",
+			req: &cloudv1.CreateTaskRequest{
+				InputArtifacts:  []*cloudv1.ArtifactInput{{Name: "input.csv", TaskId: 3, Artifact: "report.csv"}},
+				OutputArtifacts: []string{"scores.csv"},
+			},
+		},
+		{
+			name: "Invalid and duplicate names",
+			req: &cloudv1.CreateTaskRequest{
+				InputArtifacts:  []*cloudv1.ArtifactInput{{Name: "../input.csv", TaskId: 3, Artifact: "report.csv"}},
+				OutputArtifacts: []string{"scores.csv", "scores.csv"},
+			},
+			want: []string{
+				`input_artifacts[0].name: invalid artifact name "../input.csv": must be 1 to 255 letters, digits, dots, underscores or dashes, not starting with a dot or dash`,
+				"output_artifacts[1]: duplicate output artifact scores.csv",
+			},
+		},
+		{
+			name: "Inputs the producing task does not declare",
+			req: &cloudv1.CreateTaskRequest{
+				InputArtifacts: []*cloudv1.ArtifactInput{
+					{Name: "a.csv", TaskId: 3, Artifact: "other.csv"},
+					{Name: "b.csv", TaskId: 4, Artifact: "report.csv"},
+					{Name: "c.csv", Artifact: "report.csv"},
+				},
+			},
+			want: []string{
+				"input_artifacts[0].artifact: task 3 does not declare the output artifact other.csv",
+				"input_artifacts[1].task_id: task 4 not found",
+				"input_artifacts[2].task_id: must be greater than 0",
+			},
+		},
+		{
+			name: "Base image",
+			req: &cloudv1.CreateTaskRequest{
+				BaseImage:       "python:3.12",
+				OutputArtifacts: []string{"scores.csv"},
+			},
+			want: []string{"base_image: tasks run from a base image cannot declare artifacts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, taskRepo, _ := newTestArtifactServer(t)
+			taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(3)).Return(taskWithArtifacts(t, nil, "report.csv"), nil).Maybe()
+			taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(4)).Return(nil, errors.New("record not found")).Maybe()
+
+			err := server.validateArtifacts(context.Background(), tt.req)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var connectErr *connect.Error
+			require.ErrorAs(t, err, &connectErr)
+			assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+			require.Len(t, connectErr.Details(), 1)
+			detail, err := connectErr.Details()[0].Value()
+			require.NoError(t, err)
+			var violations []string
+			for _, v := range detail.(*errdetails.BadRequest).FieldViolations {
+				violations = append(violations, v.Field+": "+v.Description)
+			}
+			assert.Equal(t, tt.want, violations)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "task/pkg/gen/cloud/v1"
+	"task/pkg/tasklog"
+	"task/server/repository/model/task"
+
+	connect "connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// logChunkPageSize is the number of log chunks read from the repository at a time.
+	logChunkPageSize = 100
+	// defaultLogPollInterval is how often a followed log stream polls for new chunks.
+	defaultLogPollInterval = time.Second
+	// defaultLogFollowGrace is how long a followed log stream stays open once the task
+	// has finished, as a failed task may be retried and workers ship their last lines late.
+	defaultLogFollowGrace = 10 * time.Second
+	// defaultLogCommitWindow is how long after a poll a followed log stream still rereads
+	// the chunks it read, as a chunk may commit after chunks with higher IDs.
+	defaultLogCommitWindow = 5 * time.Second
+)
+
+// logCursorMark is the ID of the last chunk a log stream had read at a time.
+type logCursorMark struct {
+	at time.Time
+	id uint
+}
+
+// AppendTaskLogs stores a batch of log lines that a worker shipped while running a task.
+func (s *TaskServer) AppendTaskLogs(ctx context.Context, req *connect.Request[v1.AppendTaskLogsRequest]) (*connect.Response[emptypb.Empty], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("append_task_logs"))
+	defer timer.ObserveDuration()
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+	if err := validateLogLines(req.Msg); err != nil {
+		s.logger.Printf("AppendTaskLogs validation failed: %v", err)
+		return nil, err
+	}
+
+	if _, err := s.taskRepo.GetTaskByID(ctx, uint(req.Msg.Id)); err != nil {
+		s.metrics.errorCounter.WithLabelValues("append_task_logs").Inc()
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("task not found: %w", err))
+	}
+
+	chunk := task.TaskLogChunk{TaskID: uint(req.Msg.Id), Attempt: int(req.Msg.Attempt)}
+	if err := chunk.SetLogLines(convertLogLinesFromProto(req.Msg.Lines)); err != nil {
+		return nil, s.logError(err, "Failed to encode task logs: id=%d", req.Msg.Id)
+	}
+	if _, err := s.logRepo.AppendTaskLogs(ctx, chunk); err != nil {
+		s.metrics.errorCounter.WithLabelValues("append_task_logs").Inc()
+		return nil, s.logError(err, "Failed to append task logs: id=%d", req.Msg.Id)
+	}
+
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+// StreamTaskLogs streams the log lines of a task, oldest first, one message per stored chunk.
+// With follow set, it keeps polling for new chunks until the task has been finished for
+// the follow grace period without logging anything, or the client goes away, and sends
+// the progress of the task whenever it reports new progress.
+//
+// Chunk IDs are assigned when a chunk is inserted, but concurrent appends may commit
+// out of ID order, so a followed stream only moves its cursor past the chunks it read
+// once the commit window has passed, and skips the chunks it already sent. A chunk that
+// commits more than the commit window after a chunk with a higher ID is not streamed.
+func (s *TaskServer) StreamTaskLogs(ctx context.Context, req *connect.Request[v1.StreamTaskLogsRequest], stream *connect.ServerStream[v1.StreamTaskLogsResponse]) error {
+	s.logger.Printf("Streaming task logs: id=%d, attempt=%d, follow=%t", req.Msg.Id, req.Msg.Attempt, req.Msg.Follow)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return err
+	}
+
+	filter := task.TaskLogFilter{
+		TaskID:  uint(req.Msg.Id),
+		Attempt: int(req.Msg.Attempt),
+		Limit:   logChunkPageSize,
+	}
+	var since time.Time
+	if req.Msg.Since != nil {
+		since = req.Msg.Since.AsTime()
+	}
+
+	lastActive := time.Now()
+	var lastProgress *time.Time
+	sentChunks := map[uint]bool{}
+	var marks []logCursorMark
+	for {
+		// The status is read before the chunks, so that the lines a worker shipped
+		// before finishing the task are always streamed
+		taskModel, err := s.taskRepo.GetTaskByID(ctx, uint(req.Msg.Id))
+		if err != nil {
+			s.metrics.errorCounter.WithLabelValues("stream_task_logs").Inc()
+			return connect.NewError(connect.CodeNotFound, fmt.Errorf("task not found: %w", err))
+		}
+		finished := isFinalStatus(v1.TaskStatusEnum(taskModel.Status))
+
+		last, sent, err := s.sendTaskLogs(ctx, filter, since, sentChunks, stream)
+		if err != nil {
+			return err
+		}
+		if !req.Msg.Follow {
+			return nil
+		}
+
+		// Advance the cursor to the chunks read a commit window ago
+		marks = append(marks, logCursorMark{at: time.Now(), id: last})
+		for len(marks) > 0 && time.Since(marks[0].at) >= s.logCommitWindow {
+			filter.Cursor = marks[0].id
+			marks = marks[1:]
+		}
+		for id := range sentChunks {
+			if id <= filter.Cursor {
+				delete(sentChunks, id)
+			}
+		}
+
+		if reportedAt := taskModel.Progress.ReportedAt; reportedAt != nil && (lastProgress == nil || !reportedAt.Equal(*lastProgress)) {
+			lastProgress = reportedAt
+			if err := stream.Send(&v1.StreamTaskLogsResponse{Progress: convertProgressToProto(taskModel.Progress)}); err != nil {
+				return err
+			}
+		}
+		if sent > 0 || !finished {
+			lastActive = time.Now()
+		} else if time.Since(lastActive) >= s.logFollowGrace {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.logPollInterval):
+		}
+	}
+}
+
+// sendTaskLogs sends the chunks after filter.Cursor that are not in sentChunks, adding them to it.
+// Lines logged before since are left out. It returns the ID of the last chunk read, or the
+// cursor when there are none, and the number of lines sent.
+func (s *TaskServer) sendTaskLogs(ctx context.Context, filter task.TaskLogFilter, since time.Time, sentChunks map[uint]bool, stream *connect.ServerStream[v1.StreamTaskLogsResponse]) (uint, int, error) {
+	sent := 0
+	for {
+		chunks, err := s.logRepo.ListTaskLogChunks(ctx, filter)
+		if err != nil {
+			s.metrics.errorCounter.WithLabelValues("stream_task_logs").Inc()
+			return filter.Cursor, sent, s.logError(err, "Failed to retrieve task logs: id=%d", filter.TaskID)
+		}
+
+		for _, chunk := range chunks {
+			filter.Cursor = chunk.ID
+			if sentChunks[chunk.ID] {
+				continue
+			}
+			sentChunks[chunk.ID] = true
+			lines, err := chunk.LogLines()
+			if err != nil {
+				return filter.Cursor, sent, s.logError(err, "Failed to decode task logs: id=%d, chunk=%d", filter.TaskID, chunk.ID)
+			}
+			response := &v1.StreamTaskLogsResponse{}
+			for _, line := range lines {
+				if line.Time.Before(since) {
+					continue
+				}
+				response.Lines = append(response.Lines, convertLogLineToProto(line, chunk.Attempt))
+			}
+			if len(response.Lines) == 0 {
+				continue
+			}
+			if err := stream.Send(response); err != nil {
+				return filter.Cursor, sent, err
+			}
+			sent += len(response.Lines)
+		}
+
+		if len(chunks) < filter.Limit {
+			return filter.Cursor, sent, nil
+		}
+	}
+}
+
+// isFinalStatus reports whether a task in the status has finished running.
+func isFinalStatus(status v1.TaskStatusEnum) bool {
+	return status == v1.TaskStatusEnum_SUCCEEDED || status == v1.TaskStatusEnum_FAILED
+}
+
+// validateLogLines checks the appended log lines against the limits of the service.
+func validateLogLines(req *v1.AppendTaskLogsRequest) error {
+	var violations fieldViolations
+
+	if req.Attempt < 1 {
+		violations.add("attempt", "must be at least 1")
+	}
+	if len(req.Lines) == 0 || len(req.Lines) > tasklog.MaxLinesPerRequest {
+		violations.add("lines", fmt.Sprintf("must have 1 to %d lines", tasklog.MaxLinesPerRequest))
+	}
+	for i, line := range req.Lines {
+		if len(line.Level) > tasklog.MaxLevelLength {
+			violations.add(fmt.Sprintf("lines[%d].level", i), fmt.Sprintf("must be at most %d characters long", tasklog.MaxLevelLength))
+		}
+		if len(line.Message) > tasklog.MaxMessageBytes {
+			violations.add(fmt.Sprintf("lines[%d].message", i), fmt.Sprintf("must be at most %d bytes long", tasklog.MaxMessageBytes))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return badRequest("invalid task log lines", violations...)
+}
+
+// convertLogLinesFromProto converts appended log lines to the log line model.
+// Lines without a time are stamped with the time they arrived.
+func convertLogLinesFromProto(lines []*v1.LogLine) []task.LogLine {
+	now := time.Now()
+	converted := make([]task.LogLine, len(lines))
+	for i, line := range lines {
+		converted[i] = task.LogLine{
+			Time:       now,
+			Level:      line.Level,
+			Message:    line.Message,
+			Attributes: line.Attributes,
+		}
+		if line.Time != nil {
+			converted[i].Time = line.Time.AsTime()
+		}
+		if converted[i].Level == "" {
+			converted[i].Level = "INFO"
+		}
+	}
+	return converted
+}
+
+// convertLogLineToProto converts a stored log line of an attempt to a protobuf LogLine message.
+func convertLogLineToProto(line task.LogLine, attempt int) *v1.LogLine {
+	return &v1.LogLine{
+		Time:       timestamppb.New(line.Time),
+		Level:      line.Level,
+		Message:    line.Message,
+		Attributes: line.Attributes,
+		Attempt:    int32(attempt),
+	}
+}
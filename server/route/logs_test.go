@@ -0,0 +1,282 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+	"task/pkg/tasklog"
+	repomocks "task/server/repository/mocks"
+	"task/server/repository/model/task"
+)
+
+// newTestLogServer returns a TaskServer with a mocked log repository, and a client
+// that calls it over HTTP so that log streams can be received.
+func newTestLogServer(t *testing.T) (cloudv1connect.TaskManagementServiceClient, *repomocks.TaskRepo, *repomocks.TaskLogRepo) {
+	t.Helper()
+	return newTestLogServerWithWindow(t, 0)
+}
+
+// newTestLogServerWithWindow is newTestLogServer with a commit window for followed log streams.
+func newTestLogServerWithWindow(t *testing.T, commitWindow time.Duration) (cloudv1connect.TaskManagementServiceClient, *repomocks.TaskRepo, *repomocks.TaskLogRepo) {
+	t.Helper()
+	server, taskRepo, _ := newTestServer(t)
+	logRepo := repomocks.NewTaskLogRepo(t)
+	server.logRepo = logRepo
+	server.logPollInterval = 10 * time.Millisecond
+	server.logFollowGrace = 50 * time.Millisecond
+	server.logCommitWindow = commitWindow
+
+	mux := http.NewServeMux()
+	mux.Handle(cloudv1connect.NewTaskManagementServiceHandler(server))
+	httpServer := httptest.NewServer(mux)
+	t.Cleanup(httpServer.Close)
+	return cloudv1connect.NewTaskManagementServiceClient(httpServer.Client(), httpServer.URL), taskRepo, logRepo
+}
+
+// newLogChunk returns a stored chunk with a line for each message.
+func newLogChunk(t *testing.T, id uint, attempt int, logged time.Time, messages ...string) task.TaskLogChunk {
+	t.Helper()
+	chunk := task.TaskLogChunk{ID: id, TaskID: 7, Attempt: attempt}
+	lines := make([]task.LogLine, len(messages))
+	for i, message := range messages {
+		lines[i] = task.LogLine{Time: logged, Level: "INFO", Message: message}
+	}
+	require.NoError(t, chunk.SetLogLines(lines))
+	return chunk
+}
+
+// receiveLines returns the messages of the streamed lines, prefixed with their attempt.
+func receiveLines(t *testing.T, stream *connect.ServerStreamForClient[cloudv1.StreamTaskLogsResponse]) []string {
+	t.Helper()
+	var lines []string
+	for stream.Receive() {
+		for _, line := range stream.Msg().Lines {
+			lines = append(lines, strings.Repeat("#", int(line.Attempt))+" "+line.Message)
+		}
+	}
+	require.NoError(t, stream.Err())
+	return lines
+}
+
+func TestAppendTaskLogs(t *testing.T) {
+	server, taskRepo, _ := newTestServer(t)
+	logRepo := repomocks.NewTaskLogRepo(t)
+	server.logRepo = logRepo
+	logged := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{}, nil)
+	logRepo.EXPECT().AppendTaskLogs(mock.Anything, mock.MatchedBy(func(chunk task.TaskLogChunk) bool {
+		lines, err := chunk.LogLines()
+		return err == nil && chunk.TaskID == 7 && chunk.Attempt == 2 && len(lines) == 2 &&
+			lines[0].Time.Equal(logged) && lines[0].Level == "ERROR" && lines[0].Message == "Process failed" &&
+			lines[1].Level == "INFO" && !lines[1].Time.IsZero()
+	})).Return(task.TaskLogChunk{ID: 1}, nil)
+
+	_, err := server.AppendTaskLogs(context.Background(), connect.NewRequest(&cloudv1.AppendTaskLogsRequest{
+		Id:      7,
+		Attempt: 2,
+		Lines: []*cloudv1.LogLine{
+			{Time: timestamppb.New(logged), Level: "ERROR", Message: "Process failed"},
+			{Message: "Without time and level"},
+		},
+	}))
+	assert.NoError(t, err)
+}
+
+func TestAppendTaskLogsUnknownTask(t *testing.T) {
+	server, taskRepo, _ := newTestServer(t)
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(nil, errors.New("record not found"))
+
+	_, err := server.AppendTaskLogs(context.Background(), connect.NewRequest(&cloudv1.AppendTaskLogsRequest{
+		Id:      7,
+		Attempt: 1,
+		Lines:   []*cloudv1.LogLine{{Message: "hello"}},
+	}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
+
+func TestAppendTaskLogsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *cloudv1.AppendTaskLogsRequest
+		want []string
+	}{
+		{
+			name: "No lines",
+			req:  &cloudv1.AppendTaskLogsRequest{Id: 7, Attempt: 1},
+			want: []string{"lines: must have 1 to 1000 lines"},
+		},
+		{
+			name: "No attempt",
+			req:  &cloudv1.AppendTaskLogsRequest{Id: 7, Lines: []*cloudv1.LogLine{{Message: "hello"}}},
+			want: []string{"attempt: must be at least 1"},
+		},
+		{
+			name: "Large lines",
+			req: &cloudv1.AppendTaskLogsRequest{Id: 7, Attempt: 1, Lines: []*cloudv1.LogLine{
+				{Message: "hello"},
+				{Level: strings.Repeat("L", tasklog.MaxLevelLength+1), Message: strings.Repeat("x", tasklog.MaxMessageBytes+1)},
+			}},
+			want: []string{"lines[1].level: must be at most 16 characters long", "lines[1].message: must be at most 8192 bytes long"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _, _ := newTestServer(t)
+			_, err := server.AppendTaskLogs(context.Background(), connect.NewRequest(tt.req))
+			require.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+			var connectErr *connect.Error
+			require.True(t, errors.As(err, &connectErr))
+			require.Len(t, connectErr.Details(), 1)
+			detail, err := connectErr.Details()[0].Value()
+			require.NoError(t, err)
+			var got []string
+			for _, v := range detail.(*errdetails.BadRequest).FieldViolations {
+				got = append(got, v.Field+": "+v.Description)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStreamTaskLogs(t *testing.T) {
+	client, taskRepo, logRepo := newTestLogServer(t)
+	logged := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_RUNNING)}, nil)
+	// A full page is followed by the next one
+	page := make([]task.TaskLogChunk, logChunkPageSize)
+	for i := range page {
+		page[i] = newLogChunk(t, uint(i+1), 1, logged, "line")
+	}
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize}).Return(page, nil)
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize, Cursor: logChunkPageSize}).
+		Return([]task.TaskLogChunk{newLogChunk(t, 200, 2, logged, "retry", "done")}, nil)
+
+	stream, err := client.StreamTaskLogs(context.Background(), connect.NewRequest(&cloudv1.StreamTaskLogsRequest{Id: 7}))
+	require.NoError(t, err)
+	lines := receiveLines(t, stream)
+	assert.Len(t, lines, logChunkPageSize+2)
+	assert.Equal(t, []string{"# line", "## retry", "## done"}, lines[logChunkPageSize-1:])
+}
+
+func TestStreamTaskLogsSince(t *testing.T) {
+	client, taskRepo, logRepo := newTestLogServer(t)
+	since := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	// Lines are only filtered by the time they were logged, as the clock of the worker may
+	// be behind the server that stored the chunk
+
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{}, nil)
+	chunk := newLogChunk(t, 1, 1, since, "at since")
+	lines, _ := chunk.LogLines()
+	lines = append([]task.LogLine{{Time: since.Add(-time.Second), Message: "before since"}}, lines...)
+	require.NoError(t, chunk.SetLogLines(lines))
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Attempt: 1, Limit: logChunkPageSize}).
+		Return([]task.TaskLogChunk{chunk}, nil)
+
+	stream, err := client.StreamTaskLogs(context.Background(), connect.NewRequest(&cloudv1.StreamTaskLogsRequest{
+		Id:      7,
+		Attempt: 1,
+		Since:   timestamppb.New(since),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"# at since"}, receiveLines(t, stream))
+}
+
+func TestStreamTaskLogsFollow(t *testing.T) {
+	client, taskRepo, logRepo := newTestLogServer(t)
+	logged := time.Now()
+
+	// The task runs for two polls and then finishes, and the stream ends after the grace period
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_RUNNING)}, nil).Times(2)
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_SUCCEEDED)}, nil)
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize}).
+		Return([]task.TaskLogChunk{newLogChunk(t, 1, 1, logged, "first")}, nil).Once()
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize, Cursor: 1}).
+		Return(nil, nil).Once()
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize, Cursor: 1}).
+		Return([]task.TaskLogChunk{newLogChunk(t, 2, 1, logged, "last")}, nil).Once()
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize, Cursor: 2}).
+		Return(nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamTaskLogs(ctx, connect.NewRequest(&cloudv1.StreamTaskLogsRequest{Id: 7, Follow: true}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"# first", "# last"}, receiveLines(t, stream))
+	assert.NoError(t, ctx.Err())
+}
+
+func TestStreamTaskLogsFollowLateCommit(t *testing.T) {
+	client, taskRepo, logRepo := newTestLogServerWithWindow(t, time.Hour)
+	logged := time.Now()
+
+	// Chunk 1 commits after chunk 2, and is streamed as the cursor stays behind within the commit window
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_RUNNING)}, nil).Times(2)
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_SUCCEEDED)}, nil)
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize}).
+		Return([]task.TaskLogChunk{newLogChunk(t, 2, 1, logged, "second")}, nil).Once()
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, task.TaskLogFilter{TaskID: 7, Limit: logChunkPageSize}).
+		Return([]task.TaskLogChunk{newLogChunk(t, 1, 1, logged, "first"), newLogChunk(t, 2, 1, logged, "second")}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamTaskLogs(ctx, connect.NewRequest(&cloudv1.StreamTaskLogsRequest{Id: 7, Follow: true}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"# second", "# first"}, receiveLines(t, stream))
+}
+
+func TestStreamTaskLogsFollowProgress(t *testing.T) {
+	client, taskRepo, logRepo := newTestLogServer(t)
+	first, second := time.Now(), time.Now().Add(time.Second)
+	running := func(percent int, reportedAt *time.Time) *task.Task {
+		return &task.Task{Status: int(cloudv1.TaskStatusEnum_RUNNING), Progress: task.Progress{Percent: percent, Attempt: 1, ReportedAt: reportedAt}}
+	}
+
+	// The progress is sent once per report, and not at all before the first one
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(running(0, nil), nil).Once()
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(running(40, &first), nil).Times(2)
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(running(80, &second), nil).Once()
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_SUCCEEDED)}, nil)
+	logRepo.EXPECT().ListTaskLogChunks(mock.Anything, mock.Anything).Return(nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamTaskLogs(ctx, connect.NewRequest(&cloudv1.StreamTaskLogsRequest{Id: 7, Follow: true}))
+	require.NoError(t, err)
+	var percents []int32
+	for stream.Receive() {
+		if progress := stream.Msg().Progress; progress != nil {
+			percents = append(percents, progress.Percent)
+		}
+	}
+	require.NoError(t, stream.Err())
+	assert.Equal(t, []int32{40, 80}, percents)
+}
+
+func TestStreamTaskLogsUnknownTask(t *testing.T) {
+	client, taskRepo, _ := newTestLogServer(t)
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(nil, errors.New("record not found"))
+
+	stream, err := client.StreamTaskLogs(context.Background(), connect.NewRequest(&cloudv1.StreamTaskLogsRequest{Id: 7}))
+	require.NoError(t, err)
+	assert.False(t, stream.Receive())
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(stream.Err()))
+}
@@ -0,0 +1,93 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	v1 "task/pkg/gen/cloud/v1"
+	"task/pkg/progress"
+	"task/server/repository/model/task"
+
+	connect "connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultProgressInterval is the shortest time between two recorded progress reports of an attempt.
+const defaultProgressInterval = time.Second
+
+// ReportProgress records the progress a worker reported for a running task. Reports
+// are throttled per attempt to one per progress interval, and the dropped ones are
+// answered with recorded unset rather than an error, as the next report replaces them.
+func (s *TaskServer) ReportProgress(ctx context.Context, req *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("report_progress"))
+	defer timer.ObserveDuration()
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+	if err := validateProgress(req.Msg); err != nil {
+		s.logger.Printf("ReportProgress validation failed: %v", err)
+		return nil, err
+	}
+
+	taskModel, err := s.taskRepo.GetTaskByID(ctx, uint(req.Msg.Id))
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("report_progress").Inc()
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("task not found: %w", err))
+	}
+	if isFinalStatus(v1.TaskStatusEnum(taskModel.Status)) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("task %d has finished", req.Msg.Id))
+	}
+
+	now := time.Now()
+	recorded, err := s.taskRepo.UpdateTaskProgress(ctx, uint(req.Msg.Id), task.Progress{
+		Percent:    int(req.Msg.Percent),
+		Message:    req.Msg.Message,
+		Attempt:    int(req.Msg.Attempt),
+		ReportedAt: &now,
+	}, s.progressInterval)
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("report_progress").Inc()
+		return nil, s.logError(err, "Failed to update task progress: id=%d", req.Msg.Id)
+	}
+
+	return connect.NewResponse(&v1.ReportProgressResponse{Recorded: recorded}), nil
+}
+
+// validateProgress checks a progress report against the limits of the service.
+func validateProgress(req *v1.ReportProgressRequest) error {
+	var violations fieldViolations
+
+	if req.Attempt < 1 {
+		violations.add("attempt", "must be at least 1")
+	}
+	if req.Percent < 0 || req.Percent > 100 {
+		violations.add("percent", "must be between 0 and 100")
+	}
+	if utf8.RuneCountInString(req.Message) > progress.MaxMessageLength {
+		violations.add("message", fmt.Sprintf("must be at most %d characters long", progress.MaxMessageLength))
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return badRequest("invalid task progress", violations...)
+}
+
+// convertProgressToProto converts the progress of a task model to a protobuf TaskProgress
+// message. It returns nil when the task has not reported progress.
+func convertProgressToProto(p task.Progress) *v1.TaskProgress {
+	if p.ReportedAt == nil {
+		return nil
+	}
+	return &v1.TaskProgress{
+		Percent:    int32(p.Percent),
+		Message:    p.Message,
+		Attempt:    int32(p.Attempt),
+		ReportedAt: timestamppb.New(*p.ReportedAt),
+	}
+}
@@ -0,0 +1,91 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/server/repository/model/task"
+)
+
+func TestReportProgress(t *testing.T) {
+	server, taskRepo, _ := newTestServer(t)
+	server.progressInterval = time.Second
+	taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_RUNNING)}, nil)
+	taskRepo.EXPECT().UpdateTaskProgress(mock.Anything, uint(7), mock.MatchedBy(func(p task.Progress) bool {
+		return p.Percent == 40 && p.Message == "Exported 4000 rows" && p.Attempt == 2 && p.ReportedAt != nil
+	}), time.Second).Return(true, nil).Once()
+	taskRepo.EXPECT().UpdateTaskProgress(mock.Anything, uint(7), mock.Anything, time.Second).Return(false, nil).Once()
+
+	req := &cloudv1.ReportProgressRequest{Id: 7, Attempt: 2, Percent: 40, Message: "Exported 4000 rows"}
+	resp, err := server.ReportProgress(context.Background(), connect.NewRequest(req))
+	require.NoError(t, err)
+	assert.True(t, resp.Msg.Recorded)
+
+	// A throttled report is not an error
+	resp, err = server.ReportProgress(context.Background(), connect.NewRequest(req))
+	require.NoError(t, err)
+	assert.False(t, resp.Msg.Recorded)
+}
+
+func TestReportProgressErrors(t *testing.T) {
+	t.Run("Unknown task", func(t *testing.T) {
+		server, taskRepo, _ := newTestServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(nil, errors.New("record not found"))
+
+		_, err := server.ReportProgress(context.Background(), connect.NewRequest(&cloudv1.ReportProgressRequest{Id: 7, Attempt: 1}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+
+	t.Run("Finished task", func(t *testing.T) {
+		server, taskRepo, _ := newTestServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Status: int(cloudv1.TaskStatusEnum_FAILED)}, nil)
+
+		_, err := server.ReportProgress(context.Background(), connect.NewRequest(&cloudv1.ReportProgressRequest{Id: 7, Attempt: 1}))
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+
+	t.Run("Invalid report", func(t *testing.T) {
+		server, _, _ := newTestServer(t)
+
+		_, err := server.ReportProgress(context.Background(), connect.NewRequest(&cloudv1.ReportProgressRequest{
+			Id: 7, Percent: 101, Message: strings.Repeat("a", 257),
+		}))
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+		require.Len(t, connectErr.Details(), 1)
+		detail, err := connectErr.Details()[0].Value()
+		require.NoError(t, err)
+		var fields []string
+		for _, v := range detail.(*errdetails.BadRequest).FieldViolations {
+			fields = append(fields, v.Field)
+		}
+		assert.Equal(t, []string{"attempt", "percent", "message"}, fields)
+	})
+}
+
+func TestConvertTaskProgressToProto(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	assert.Nil(t, server.convertTaskToProto(&task.Task{Payload: task.JSON(`{}`)}).Progress)
+
+	reportedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	progress := server.convertTaskToProto(&task.Task{
+		Payload:  task.JSON(`{}`),
+		Progress: task.Progress{Percent: 40, Message: "Exported 4000 rows", Attempt: 2, ReportedAt: &reportedAt},
+	}).Progress
+	require.NotNil(t, progress)
+	assert.Equal(t, int32(40), progress.Percent)
+	assert.Equal(t, "Exported 4000 rows", progress.Message)
+	assert.Equal(t, int32(2), progress.Attempt)
+	assert.Equal(t, reportedAt, progress.ReportedAt.AsTime())
+}
@@ -0,0 +1,152 @@
+package route
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	protovalidate "github.com/bufbuild/protovalidate-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"gorm.io/gorm"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/plugins"
+	repomocks "task/server/repository/mocks"
+	"task/server/repository/model/task"
+)
+
+// testMetrics are shared by the test servers, as the metrics can only be registered once.
+var testMetrics = sync.OnceValue(newTaskMetrics)
+
+// newTestServer returns a TaskServer backed by mocked repositories.
+func newTestServer(t *testing.T) (*TaskServer, *repomocks.TaskRepo, *repomocks.TaskHistoryRepo) {
+	t.Helper()
+	validator, err := protovalidate.New()
+	require.NoError(t, err)
+	taskRepo := repomocks.NewTaskRepo(t)
+	historyRepo := repomocks.NewTaskHistoryRepo(t)
+	return &TaskServer{
+		taskRepo:    taskRepo,
+		historyRepo: historyRepo,
+		logger:      log.New(io.Discard, "", 0),
+		validator:   validator,
+		metrics:     testMetrics(),
+	}, taskRepo, historyRepo
+}
+
+func TestUpdateTaskStatusWithResult(t *testing.T) {
+	server, taskRepo, historyRepo := newTestServer(t)
+	data := task.JSON(`{"rows":[1,2]}`)
+	// The result is stored along with the status
+	taskRepo.EXPECT().UpdateTaskStatus(mock.Anything, uint(7), int(cloudv1.TaskStatusEnum_SUCCEEDED),
+		&task.TaskResult{TaskID: 7, Outputs: `{"row_count":"2"}`, Data: &data}).Return(nil)
+	historyRepo.EXPECT().CreateTaskHistory(mock.Anything, mock.Anything).Return(task.TaskHistory{}, nil)
+
+	_, err := server.UpdateTaskStatus(context.Background(), connect.NewRequest(&cloudv1.UpdateTaskStatusRequest{
+		Id:     7,
+		Status: cloudv1.TaskStatusEnum_SUCCEEDED,
+		Result: &cloudv1.TaskResult{Outputs: map[string]string{"row_count": "2"}, Data: string(data)},
+	}))
+	assert.NoError(t, err)
+}
+
+func TestUpdateTaskStatusInvalidResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *cloudv1.TaskResult
+		want   []string
+	}{
+		{
+			name:   "Invalid data",
+			result: &cloudv1.TaskResult{Data: `{"rows":`},
+			want:   []string{"result.data: must be a JSON document"},
+		},
+		{
+			name:   "Large data",
+			result: &cloudv1.TaskResult{Data: `"` + strings.Repeat("x", plugins.MaxDataBytes) + `"`},
+			want:   []string{"result.data: must be at most 65536 bytes long"},
+		},
+		{
+			name: "Large outputs",
+			result: &cloudv1.TaskResult{Outputs: map[string]string{
+				"body":                   strings.Repeat("x", plugins.MaxOutputValueLength+1),
+				strings.Repeat("n", 129): "x",
+				"status_code":            "200",
+			}},
+			want: []string{
+				"result.outputs.body: must be at most 4096 bytes long",
+				"result.outputs: output names must be 1 to 128 bytes long",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The status is not updated when the result is rejected
+			server, _, _ := newTestServer(t)
+			_, err := server.UpdateTaskStatus(context.Background(), connect.NewRequest(&cloudv1.UpdateTaskStatusRequest{
+				Id:     7,
+				Status: cloudv1.TaskStatusEnum_SUCCEEDED,
+				Result: tt.result,
+			}))
+
+			var connectErr *connect.Error
+			require.ErrorAs(t, err, &connectErr)
+			assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+			require.Len(t, connectErr.Details(), 1)
+			detail, err := connectErr.Details()[0].Value()
+			require.NoError(t, err)
+			var violations []string
+			for _, v := range detail.(*errdetails.BadRequest).FieldViolations {
+				violations = append(violations, v.Field+": "+v.Description)
+			}
+			assert.Equal(t, tt.want, violations)
+		})
+	}
+}
+
+func TestGetTaskResult(t *testing.T) {
+	t.Run("With result", func(t *testing.T) {
+		server, taskRepo, _ := newTestServer(t)
+		updated := time.Date(2024, 10, 1, 9, 30, 0, 0, time.UTC)
+		data := task.JSON(`{"ok":true}`)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Model: gorm.Model{ID: 7}, Status: int(cloudv1.TaskStatusEnum_SUCCEEDED)}, nil)
+		taskRepo.EXPECT().GetTaskResult(mock.Anything, uint(7)).Return(&task.TaskResult{TaskID: 7, Outputs: `{"status_code":"200"}`, Data: &data, UpdatedAt: updated}, nil)
+
+		resp, err := server.GetTaskResult(context.Background(), connect.NewRequest(&cloudv1.GetTaskResultRequest{Id: 7}))
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), resp.Msg.Id)
+		assert.Equal(t, cloudv1.TaskStatusEnum_SUCCEEDED, resp.Msg.Status)
+		assert.Equal(t, map[string]string{"status_code": "200"}, resp.Msg.Result.Outputs)
+		assert.Equal(t, `{"ok":true}`, resp.Msg.Result.Data)
+		assert.Equal(t, updated, resp.Msg.UpdatedAt.AsTime())
+	})
+
+	t.Run("Without result", func(t *testing.T) {
+		server, taskRepo, _ := newTestServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(&task.Task{Model: gorm.Model{ID: 7}, Status: int(cloudv1.TaskStatusEnum_RUNNING)}, nil)
+		taskRepo.EXPECT().GetTaskResult(mock.Anything, uint(7)).Return(nil, nil)
+
+		resp, err := server.GetTaskResult(context.Background(), connect.NewRequest(&cloudv1.GetTaskResultRequest{Id: 7}))
+		require.NoError(t, err)
+		assert.Equal(t, cloudv1.TaskStatusEnum_RUNNING, resp.Msg.Status)
+		assert.Nil(t, resp.Msg.Result)
+		assert.Nil(t, resp.Msg.UpdatedAt)
+	})
+
+	t.Run("Unknown task", func(t *testing.T) {
+		server, taskRepo, _ := newTestServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(999)).Return(nil, assert.AnError)
+
+		_, err := server.GetTaskResult(context.Background(), connect.NewRequest(&cloudv1.GetTaskResultRequest{Id: 999}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
@@ -0,0 +1,246 @@
+package route
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+	"task/pkg/secret"
+	"task/pkg/x"
+	"task/server/repository/model/task"
+
+	connect "connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// errSecretsNotConfigured is returned by the secret RPCs while the server has no secret key.
+var errSecretsNotConfigured = connect.NewError(connect.CodeFailedPrecondition, errors.New("the secret store is not configured: set SECRET_KEY on the server"))
+
+// PutSecret encrypts and stores the value of a secret. The value is never logged or returned.
+func (s *TaskServer) PutSecret(ctx context.Context, req *connect.Request[v1.PutSecretRequest]) (*connect.Response[v1.Secret], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("put_secret"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Putting secret: name=%s", req.Msg.Name)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+	if err := secret.ValidateName(req.Msg.Name); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("validation failed: %w", err))
+	}
+	if s.secretCipher == nil {
+		return nil, errSecretsNotConfigured
+	}
+
+	sealed, err := s.secretCipher.Seal(req.Msg.Name, []byte(req.Msg.Value))
+	if err != nil {
+		return nil, s.logError(err, "Failed to encrypt secret: name=%s", req.Msg.Name)
+	}
+	stored, err := s.secretRepo.PutSecret(ctx, task.Secret{Name: req.Msg.Name, Value: sealed})
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("put_secret").Inc()
+		return nil, s.logError(err, "Failed to put secret: name=%s", req.Msg.Name)
+	}
+
+	s.logger.Printf("Secret stored successfully: name=%s", req.Msg.Name)
+	return connect.NewResponse(convertSecretToProto(stored)), nil
+}
+
+// ListSecrets lists the secrets kept by the server, without their values.
+func (s *TaskServer) ListSecrets(ctx context.Context, req *connect.Request[v1.ListSecretsRequest]) (*connect.Response[v1.ListSecretsResponse], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("list_secrets"))
+	defer timer.ObserveDuration()
+
+	secrets, err := s.secretRepo.ListSecrets(ctx)
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("list_secrets").Inc()
+		return nil, s.logError(err, "Failed to list secrets")
+	}
+
+	protoSecrets := make([]*v1.Secret, len(secrets))
+	for i, stored := range secrets {
+		protoSecrets[i] = convertSecretToProto(stored)
+	}
+	return connect.NewResponse(&v1.ListSecretsResponse{Secrets: protoSecrets}), nil
+}
+
+// DeleteSecret deletes a secret. Tasks that still refer to it fail when they are run.
+func (s *TaskServer) DeleteSecret(ctx context.Context, req *connect.Request[v1.DeleteSecretRequest]) (*connect.Response[emptypb.Empty], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("delete_secret"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Deleting secret: name=%s", req.Msg.Name)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+
+	if err := s.secretRepo.DeleteSecret(ctx, req.Msg.Name); err != nil {
+		s.metrics.errorCounter.WithLabelValues("delete_secret").Inc()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("secret not found: %s", req.Msg.Name))
+		}
+		return nil, s.logError(err, "Failed to delete secret: name=%s", req.Msg.Name)
+	}
+
+	s.logger.Printf("Secret deleted successfully: name=%s", req.Msg.Name)
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+// ResolveSecrets returns the values of secrets for the worker running a task. Only the
+// secrets the payload parameters or env of the task refer to are resolved, and only
+// while the task has not finished.
+func (s *TaskServer) ResolveSecrets(ctx context.Context, req *connect.Request[v1.ResolveSecretsRequest]) (*connect.Response[v1.ResolveSecretsResponse], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("resolve_secrets"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Resolving secrets: id=%d, names=%v", req.Msg.Id, req.Msg.Names)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+	if s.secretCipher == nil {
+		return nil, errSecretsNotConfigured
+	}
+
+	taskModel, err := s.taskRepo.GetTaskByID(ctx, uint(req.Msg.Id))
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("resolve_secrets").Inc()
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("task not found: %w", err))
+	}
+	if isFinalStatus(v1.TaskStatusEnum(taskModel.Status)) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("task %d has finished", req.Msg.Id))
+	}
+	refs, err := taskSecretRefs(taskModel)
+	if err != nil {
+		return nil, s.logError(err, "Failed to decode task: id=%d", req.Msg.Id)
+	}
+	for _, name := range req.Msg.Names {
+		if _, ok := refs[name]; !ok {
+			return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("task %d does not refer to secret %s", req.Msg.Id, name))
+		}
+	}
+
+	secrets, err := s.secretRepo.GetSecrets(ctx, req.Msg.Names)
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("resolve_secrets").Inc()
+		return nil, s.logError(err, "Failed to get secrets: id=%d", req.Msg.Id)
+	}
+	values := make(map[string]string, len(secrets))
+	for _, stored := range secrets {
+		value, err := s.secretCipher.Open(stored.Name, stored.Value)
+		if err != nil {
+			return nil, s.logError(err, "Failed to decrypt secret: name=%s", stored.Name)
+		}
+		values[stored.Name] = string(value)
+	}
+	for _, name := range req.Msg.Names {
+		if _, ok := values[name]; !ok {
+			return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("secret not found: %s", name))
+		}
+	}
+
+	s.logger.Printf("Secrets resolved successfully: id=%d, count=%d", req.Msg.Id, len(values))
+	return connect.NewResponse(&v1.ResolveSecretsResponse{Values: values}), nil
+}
+
+// workerProcedures are the procedures only workers may call, as they hand out the values
+// of secrets or URLs that write the output artifacts of tasks.
+var workerProcedures = map[string]bool{
+	cloudv1connect.TaskManagementServiceResolveSecretsProcedure:       true,
+	cloudv1connect.TaskManagementServiceGetArtifactUploadURLProcedure: true,
+}
+
+// NewWorkerAuthInterceptor returns an interceptor that rejects the calls of the worker procedures,
+// ResolveSecrets and GetArtifactUploadURL, unless they carry token as their bearer token. The other
+// procedures are not affected. Every call of a worker procedure is rejected when token is empty.
+func NewWorkerAuthInterceptor(token string) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			procedure := req.Spec().Procedure
+			if !workerProcedures[procedure] {
+				return next(ctx, req)
+			}
+			if token == "" {
+				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("%s requires a worker token, which is not configured", path.Base(procedure)))
+			}
+			bearer, ok := strings.CutPrefix(req.Header().Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("%s requires the worker token", path.Base(procedure)))
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// taskSecretRefs returns the names of the secrets the payload parameters and env of a task refer to.
+func taskSecretRefs(taskModel *task.Task) (map[string]struct{}, error) {
+	var params map[string]string
+	if len(taskModel.Payload) > 0 {
+		var err error
+		if params, err = x.ConvertJsonToMap(string(taskModel.Payload)); err != nil {
+			return nil, err
+		}
+	}
+	spec, err := taskModel.ExecutionSpec()
+	if err != nil {
+		return nil, err
+	}
+	refs := map[string]struct{}{}
+	for _, name := range secret.Refs(params, spec.Env) {
+		refs[name] = struct{}{}
+	}
+	return refs, nil
+}
+
+// validateSecretRefs checks the names of the secrets the payload parameters and env of
+// a new task refer to. The secrets themselves may not exist yet, or be Kubernetes Secrets
+// the server does not know about.
+func validateSecretRefs(req *v1.CreateTaskRequest) error {
+	var violations fieldViolations
+	check := func(prefix string, values map[string]string) {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name, ok := secret.ParseRef(values[key])
+			if !ok {
+				continue
+			}
+			if err := secret.ValidateName(name); err != nil {
+				violations.add(prefix+key, err.Error())
+			}
+		}
+	}
+	check("payload.parameters.", req.Payload.GetParameters())
+	check("env.", req.Env)
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return badRequest("invalid secret references", violations...)
+}
+
+// convertSecretToProto converts a secret model to a protobuf Secret message, without its value.
+func convertSecretToProto(stored task.Secret) *v1.Secret {
+	return &v1.Secret{
+		Name:      stored.Name,
+		CreatedAt: timestamppb.New(stored.CreatedAt),
+		UpdatedAt: timestamppb.New(stored.UpdatedAt),
+	}
+}
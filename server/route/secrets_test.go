@@ -0,0 +1,219 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"gorm.io/gorm"
+
+	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/gen/cloud/v1/cloudv1connect"
+	"task/pkg/secret"
+	"task/pkg/x"
+	repomocks "task/server/repository/mocks"
+	"task/server/repository/model/task"
+)
+
+// newTestSecretServer returns a TaskServer with a secret store.
+func newTestSecretServer(t *testing.T) (*TaskServer, *repomocks.TaskRepo, *repomocks.SecretRepo) {
+	t.Helper()
+	server, taskRepo, _ := newTestServer(t)
+	cipher, err := secret.NewCipher(bytes.Repeat([]byte{7}, secret.KeySize))
+	require.NoError(t, err)
+	secretRepo := repomocks.NewSecretRepo(t)
+	server.secretRepo = secretRepo
+	server.secretCipher = cipher
+	return server, taskRepo, secretRepo
+}
+
+// taskWithSecrets returns a running task whose payload parameters and env refer to secrets.
+func taskWithSecrets(t *testing.T, params, env map[string]string) *task.Task {
+	t.Helper()
+	payload, err := x.ConvertMapToJson(params)
+	require.NoError(t, err)
+	taskModel := task.Task{Payload: task.JSON(payload), Status: int(cloudv1.TaskStatusEnum_RUNNING)}
+	require.NoError(t, taskModel.SetExecutionSpec(task.ExecutionSpec{Env: env}))
+	return &taskModel
+}
+
+// sealSecret returns a secret as stored by the server.
+func sealSecret(t *testing.T, server *TaskServer, name, value string) task.Secret {
+	t.Helper()
+	sealed, err := server.secretCipher.Seal(name, []byte(value))
+	require.NoError(t, err)
+	return task.Secret{Name: name, Value: sealed}
+}
+
+func TestPutSecret(t *testing.T) {
+	server, _, secretRepo := newTestSecretServer(t)
+	now := time.Now()
+	secretRepo.EXPECT().PutSecret(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, stored task.Secret) (task.Secret, error) {
+		assert.Equal(t, "smtp", stored.Name)
+		assert.NotContains(t, string(stored.Value), "hunter2")
+		value, err := server.secretCipher.Open("smtp", stored.Value)
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", string(value))
+		stored.CreatedAt, stored.UpdatedAt = now, now
+		return stored, nil
+	})
+
+	resp, err := server.PutSecret(context.Background(), connect.NewRequest(&cloudv1.PutSecretRequest{Name: "smtp", Value: "hunter2"}))
+	require.NoError(t, err)
+	assert.Equal(t, "smtp", resp.Msg.Name)
+	assert.Equal(t, now.Unix(), resp.Msg.CreatedAt.AsTime().Unix())
+}
+
+func TestPutSecretErrors(t *testing.T) {
+	server, _, _ := newTestSecretServer(t)
+	_, err := server.PutSecret(context.Background(), connect.NewRequest(&cloudv1.PutSecretRequest{Name: "SMTP", Value: "hunter2"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	server.secretCipher = nil
+	_, err = server.PutSecret(context.Background(), connect.NewRequest(&cloudv1.PutSecretRequest{Name: "smtp", Value: "hunter2"}))
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+}
+
+func TestDeleteSecret(t *testing.T) {
+	server, _, secretRepo := newTestSecretServer(t)
+	secretRepo.EXPECT().DeleteSecret(mock.Anything, "smtp").Return(nil)
+	secretRepo.EXPECT().DeleteSecret(mock.Anything, "missing").Return(gorm.ErrRecordNotFound)
+
+	_, err := server.DeleteSecret(context.Background(), connect.NewRequest(&cloudv1.DeleteSecretRequest{Name: "smtp"}))
+	require.NoError(t, err)
+	_, err = server.DeleteSecret(context.Background(), connect.NewRequest(&cloudv1.DeleteSecretRequest{Name: "missing"}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Run("Referenced secrets", func(t *testing.T) {
+		server, taskRepo, secretRepo := newTestSecretServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithSecrets(t,
+			map[string]string{"password": "secret://smtp"},
+			map[string]string{"API_TOKEN": "secret://api"},
+		), nil)
+		secretRepo.EXPECT().GetSecrets(mock.Anything, []string{"api", "smtp"}).Return([]task.Secret{
+			sealSecret(t, server, "api", "token"),
+			sealSecret(t, server, "smtp", "hunter2"),
+		}, nil)
+
+		resp, err := server.ResolveSecrets(context.Background(), connect.NewRequest(&cloudv1.ResolveSecretsRequest{Id: 7, Names: []string{"api", "smtp"}}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"api": "token", "smtp": "hunter2"}, resp.Msg.Values)
+	})
+
+	t.Run("Unreferenced secret", func(t *testing.T) {
+		server, taskRepo, _ := newTestSecretServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithSecrets(t, map[string]string{"password": "secret://smtp"}, nil), nil)
+
+		_, err := server.ResolveSecrets(context.Background(), connect.NewRequest(&cloudv1.ResolveSecretsRequest{Id: 7, Names: []string{"db"}}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	})
+
+	t.Run("Finished task", func(t *testing.T) {
+		server, taskRepo, _ := newTestSecretServer(t)
+		finished := taskWithSecrets(t, map[string]string{"password": "secret://smtp"}, nil)
+		finished.Status = int(cloudv1.TaskStatusEnum_SUCCEEDED)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(finished, nil)
+
+		_, err := server.ResolveSecrets(context.Background(), connect.NewRequest(&cloudv1.ResolveSecretsRequest{Id: 7, Names: []string{"smtp"}}))
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+
+	t.Run("Missing secret", func(t *testing.T) {
+		server, taskRepo, secretRepo := newTestSecretServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithSecrets(t, map[string]string{"password": "secret://smtp"}, nil), nil)
+		secretRepo.EXPECT().GetSecrets(mock.Anything, []string{"smtp"}).Return(nil, nil)
+
+		_, err := server.ResolveSecrets(context.Background(), connect.NewRequest(&cloudv1.ResolveSecretsRequest{Id: 7, Names: []string{"smtp"}}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
+
+func TestWorkerAuthInterceptor(t *testing.T) {
+	// newClient serves the server with the interceptor of the token over HTTP
+	newClient := func(t *testing.T, server *TaskServer, token string) cloudv1connect.TaskManagementServiceClient {
+		mux := http.NewServeMux()
+		mux.Handle(cloudv1connect.NewTaskManagementServiceHandler(server, connect.WithInterceptors(NewWorkerAuthInterceptor(token))))
+		httpServer := httptest.NewServer(mux)
+		t.Cleanup(httpServer.Close)
+		return cloudv1connect.NewTaskManagementServiceClient(httpServer.Client(), httpServer.URL)
+	}
+	resolve := func(client cloudv1connect.TaskManagementServiceClient, bearer string) (*connect.Response[cloudv1.ResolveSecretsResponse], error) {
+		req := connect.NewRequest(&cloudv1.ResolveSecretsRequest{Id: 7, Names: []string{"smtp"}})
+		if bearer != "" {
+			req.Header().Set("Authorization", "Bearer "+bearer)
+		}
+		return client.ResolveSecrets(context.Background(), req)
+	}
+
+	t.Run("Unauthenticated caller", func(t *testing.T) {
+		server, _, _ := newTestSecretServer(t)
+		_, err := resolve(newClient(t, server, "worker-token"), "")
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+
+	t.Run("Wrong token", func(t *testing.T) {
+		server, _, _ := newTestSecretServer(t)
+		_, err := resolve(newClient(t, server, "worker-token"), "guess")
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+
+	t.Run("No worker token configured", func(t *testing.T) {
+		server, _, _ := newTestSecretServer(t)
+		_, err := resolve(newClient(t, server, ""), "")
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+
+	t.Run("Worker", func(t *testing.T) {
+		server, taskRepo, secretRepo := newTestSecretServer(t)
+		taskRepo.EXPECT().GetTaskByID(mock.Anything, uint(7)).Return(taskWithSecrets(t, map[string]string{"password": "secret://smtp"}, nil), nil)
+		secretRepo.EXPECT().GetSecrets(mock.Anything, []string{"smtp"}).Return([]task.Secret{sealSecret(t, server, "smtp", "hunter2")}, nil)
+
+		resp, err := resolve(newClient(t, server, "worker-token"), "worker-token")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"smtp": "hunter2"}, resp.Msg.Values)
+	})
+
+	t.Run("Artifact upload URL", func(t *testing.T) {
+		server, _, _ := newTestSecretServer(t)
+		_, err := newClient(t, server, "worker-token").GetArtifactUploadURL(context.Background(), connect.NewRequest(&cloudv1.GetArtifactUploadURLRequest{Id: 7, Name: "scores.csv"}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+
+	t.Run("Other procedures", func(t *testing.T) {
+		server, _, secretRepo := newTestSecretServer(t)
+		secretRepo.EXPECT().DeleteSecret(mock.Anything, "smtp").Return(nil)
+
+		_, err := newClient(t, server, "worker-token").DeleteSecret(context.Background(), connect.NewRequest(&cloudv1.DeleteSecretRequest{Name: "smtp"}))
+		assert.NoError(t, err)
+	})
+}
+
+func TestValidateSecretRefs(t *testing.T) {
+	err := validateSecretRefs(&cloudv1.CreateTaskRequest{
+		Payload: &cloudv1.Payload{Parameters: map[string]string{"password": "secret://smtp", "token": "secret://Bad_Name"}},
+		Env:     map[string]string{"DB_URL": "secret://", "MODE": "fast"},
+	})
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+	require.Len(t, connectErr.Details(), 1)
+	detail, err := connectErr.Details()[0].Value()
+	require.NoError(t, err)
+	var fields []string
+	for _, v := range detail.(*errdetails.BadRequest).FieldViolations {
+		fields = append(fields, v.Field)
+	}
+	assert.Equal(t, []string{"payload.parameters.token", "env.DB_URL"}, fields)
+
+	assert.NoError(t, validateSecretRefs(&cloudv1.CreateTaskRequest{Env: map[string]string{"DB_URL": "secret://db"}}))
+}
@@ -2,17 +2,28 @@ package route
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"task/pkg/artifact"
 	v1 "task/pkg/gen/cloud/v1"
 	"task/pkg/gen/cloud/v1/cloudv1connect"
+	"task/pkg/plugins"
+	"task/pkg/protorules"
+	"task/pkg/secret"
 	"task/pkg/x"
 	interfaces "task/server/repository/interface"
 	"task/server/repository/model/task"
 	"time"
 
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"sync"
 
@@ -25,9 +36,11 @@ import (
 )
 
 const (
-	logPrefix           = "TaskServer: "
-	defaultTaskPriority = 0
-	defaultTaskRetries  = 0
+	logPrefix              = "TaskServer: "
+	defaultTaskPriority    = 0
+	defaultTaskRetries     = 0
+	defaultHistoryPageSize = 100
+	maxHistoryPageSize     = 1000
 )
 
 // TaskServer represents the server handling task-related requests.
@@ -40,10 +53,23 @@ type TaskServer struct {
 	metrics          *taskMetrics
 	workflowRepo     interfaces.WorkflowRepo
 	executionRepo    interfaces.ExecutionRepo
+	logRepo          interfaces.TaskLogRepo
 	channel          chan task.Task
 	maxWorkers       int
 	clientHeartbeats sync.Map
 	heartbeatTimeout time.Duration
+	logPollInterval  time.Duration
+	logFollowGrace   time.Duration
+	// logCommitWindow is how long a followed log stream rereads appended chunks, which may commit out of ID order.
+	logCommitWindow time.Duration
+	// progressInterval is the shortest time between two recorded progress reports of an attempt.
+	progressInterval time.Duration
+	artifactStore    artifact.Store
+	// artifactURLExpiry is how long the artifact URLs handed out by the server stay valid.
+	artifactURLExpiry time.Duration
+	secretRepo        interfaces.SecretRepo
+	// secretCipher encrypts the secrets kept by the server, and is nil when SECRET_KEY is not set.
+	secretCipher *secret.Cipher
 }
 
 type taskMetrics struct {
@@ -93,7 +119,7 @@ func newTaskMetrics() *taskMetrics {
 // NewTaskServer creates and returns a new instance of TaskServer.
 // It initializes the validator, sets up the logger, and configures metrics.
 // The maxWorkers parameter can be configured to control the number of concurrent workers.
-func NewTaskServer(repo interfaces.TaskManagmentInterface) cloudv1connect.TaskManagementServiceHandler {
+func NewTaskServer(repo interfaces.TaskManagmentInterface, store artifact.Store, artifactURLExpiry time.Duration, secrets *secret.Cipher) cloudv1connect.TaskManagementServiceHandler {
 	// Initialize the validator for request validation
 	validator, err := protovalidate.New()
 	if err != nil {
@@ -101,16 +127,28 @@ func NewTaskServer(repo interfaces.TaskManagmentInterface) cloudv1connect.TaskMa
 	}
 
 	maxWorkers := 500 // Configurable maximum number of concurrent workers
+	if artifactURLExpiry <= 0 {
+		artifactURLExpiry = defaultArtifactURLExpiry
+	}
 	server := &TaskServer{
-		taskRepo:         repo.TaskRepo(),
-		historyRepo:      repo.TaskHistoryRepo(),
-		workflowRepo:     repo.WorkflowRepo(),
-		executionRepo:    repo.ExecutionRepo(),
-		logger:           log.New(os.Stdout, logPrefix, log.LstdFlags|log.Lshortfile),
-		validator:        validator,
-		metrics:          newTaskMetrics(),
-		maxWorkers:       maxWorkers,
-		heartbeatTimeout: 30 * time.Second, // Configurable timeout for heartbeats
+		taskRepo:          repo.TaskRepo(),
+		historyRepo:       repo.TaskHistoryRepo(),
+		workflowRepo:      repo.WorkflowRepo(),
+		executionRepo:     repo.ExecutionRepo(),
+		logRepo:           repo.TaskLogRepo(),
+		logger:            log.New(os.Stdout, logPrefix, log.LstdFlags|log.Lshortfile),
+		validator:         validator,
+		metrics:           newTaskMetrics(),
+		maxWorkers:        maxWorkers,
+		heartbeatTimeout:  30 * time.Second, // Configurable timeout for heartbeats
+		logPollInterval:   defaultLogPollInterval,
+		logFollowGrace:    defaultLogFollowGrace,
+		logCommitWindow:   defaultLogCommitWindow,
+		progressInterval:  defaultProgressInterval,
+		artifactStore:     store,
+		artifactURLExpiry: artifactURLExpiry,
+		secretRepo:        repo.SecretRepo(),
+		secretCipher:      secrets,
 	}
 
 	server.logger.Println("TaskServer initialized successfully")
@@ -131,6 +169,26 @@ func (s *TaskServer) CreateTask(ctx context.Context, req *connect.Request[v1.Cre
 		s.logger.Printf("CreateTask validation failed: %v", err)
 		return nil, err
 	}
+	if err := validateRules(req.Msg); err != nil {
+		s.logger.Printf("CreateTask validation failed: %v", err)
+		return nil, err
+	}
+	if err := validateTaskType(req.Msg.Type); err != nil {
+		s.logger.Printf("CreateTask validation failed: %v", err)
+		return nil, err
+	}
+	if err := validateParameters(req.Msg.Type, req.Msg.Payload.GetParameters()); err != nil {
+		s.logger.Printf("CreateTask validation failed: %v", err)
+		return nil, err
+	}
+	if err := validateSecretRefs(req.Msg); err != nil {
+		s.logger.Printf("CreateTask validation failed: %v", err)
+		return nil, err
+	}
+	if err := s.validateArtifacts(ctx, req.Msg); err != nil {
+		s.logger.Printf("CreateTask validation failed: %v", err)
+		return nil, err
+	}
 
 	newTask := s.prepareNewTask(req.Msg)
 
@@ -182,17 +240,71 @@ func (s *TaskServer) GetTaskHistory(ctx context.Context, req *connect.Request[v1
 		return nil, err
 	}
 
+	filter, err := s.prepareTaskHistoryFilter(req.Msg)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// Fetch one extra entry to find out whether there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+
 	// Fetch the task history from the repository
-	history, err := s.historyRepo.ListTaskHistories(ctx, uint(req.Msg.Id))
+	history, err := s.historyRepo.ListTaskHistories(ctx, filter)
 	if err != nil {
 		s.metrics.errorCounter.WithLabelValues("get_task_history").Inc()
 		return nil, s.logError(err, "Failed to retrieve task history: id=%d", req.Msg.Id)
 	}
 
-	protoHistory := s.convertTaskHistoryToProto(history)
+	response := &v1.GetTaskHistoryResponse{}
+	if len(history) > pageSize {
+		history = history[:pageSize]
+		response.NextPageToken = encodePageToken(history[pageSize-1].ID)
+	}
+	response.History = s.convertTaskHistoryToProto(history)
+
+	s.logger.Printf("Task history retrieved: id=%d, records=%d", req.Msg.Id, len(response.History))
+	return connect.NewResponse(response), nil
+}
+
+// GetTaskResult retrieves the result a task reported when it finished.
+// Tasks that have not reported a result are returned with their status and no result.
+func (s *TaskServer) GetTaskResult(ctx context.Context, req *connect.Request[v1.GetTaskResultRequest]) (*connect.Response[v1.GetTaskResultResponse], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("get_task_result"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Retrieving task result: id=%d", req.Msg.Id)
+
+	// Validate the incoming request
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+
+	taskModel, err := s.taskRepo.GetTaskByID(ctx, uint(req.Msg.Id))
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("get_task_result").Inc()
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("task not found: %w", err))
+	}
+
+	result, err := s.taskRepo.GetTaskResult(ctx, uint(req.Msg.Id))
+	if err != nil {
+		s.metrics.errorCounter.WithLabelValues("get_task_result").Inc()
+		return nil, s.logError(err, "Failed to retrieve task result: id=%d", req.Msg.Id)
+	}
+
+	response := &v1.GetTaskResultResponse{
+		Id:     int32(taskModel.ID),
+		Status: v1.TaskStatusEnum(taskModel.Status),
+	}
+	if result != nil {
+		if response.Result, err = convertTaskResultToProto(result); err != nil {
+			return nil, s.logError(err, "Failed to decode task result: id=%d", req.Msg.Id)
+		}
+		response.UpdatedAt = timestamppb.New(result.UpdatedAt)
+	}
 
-	s.logger.Printf("Task history retrieved: id=%d, records=%d", req.Msg.Id, len(protoHistory))
-	return connect.NewResponse(&v1.GetTaskHistoryResponse{History: protoHistory}), nil
+	s.logger.Printf("Task result retrieved: id=%d, found=%t", req.Msg.Id, result != nil)
+	return connect.NewResponse(response), nil
 }
 
 // UpdateTaskStatus updates the status of a task and logs the operation.
@@ -208,8 +320,23 @@ func (s *TaskServer) UpdateTaskStatus(ctx context.Context, req *connect.Request[
 		return nil, err
 	}
 
-	// Update the task status in the repository
-	if err := s.taskRepo.UpdateTaskStatus(ctx, uint(req.Msg.Id), int(req.Msg.Status)); err != nil {
+	if err := validateTaskResult(req.Msg.Result); err != nil {
+		s.logger.Printf("UpdateTaskStatus validation failed: %v", err)
+		return nil, err
+	}
+
+	// Encode the result the worker reported with the status
+	var result *task.TaskResult
+	if req.Msg.Result != nil {
+		encoded, err := prepareTaskResult(uint(req.Msg.Id), req.Msg.Result)
+		if err != nil {
+			return nil, s.logError(err, "Failed to encode task result: id=%d", req.Msg.Id)
+		}
+		result = &encoded
+	}
+
+	// Update the task status and store its result in the repository
+	if err := s.taskRepo.UpdateTaskStatus(ctx, uint(req.Msg.Id), int(req.Msg.Status), result); err != nil {
 		s.metrics.errorCounter.WithLabelValues("update_task_status").Inc()
 		return nil, s.logError(err, "Failed to update task status: id=%d", req.Msg.Id)
 	}
@@ -235,6 +362,11 @@ func (s *TaskServer) ListTasks(ctx context.Context, req *connect.Request[v1.Task
 	if err := s.validateRequest(req.Msg); err != nil {
 		return nil, err
 	}
+	if req.Msg.Type != nil {
+		if err := validateTaskType(req.Msg.GetType()); err != nil {
+			return nil, err
+		}
+	}
 
 	// Set default limit to 100 if not specified or invalid
 	limit := int(req.Msg.Limit)
@@ -248,8 +380,15 @@ func (s *TaskServer) ListTasks(ctx context.Context, req *connect.Request[v1.Task
 		offset = 0 // Default offset
 	}
 
+	filter, err := s.prepareTaskFilter(req.Msg)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
 	// Fetch the list of tasks from the repository
-	tasks, err := s.taskRepo.ListTasks(ctx, limit, offset, int(req.Msg.GetStatus()), req.Msg.GetType())
+	tasks, err := s.taskRepo.ListTasks(ctx, filter)
 	if err != nil {
 		s.metrics.errorCounter.WithLabelValues("list_tasks").Inc()
 		return nil, s.logError(err, "Failed to retrieve task list")
@@ -277,8 +416,13 @@ func (s *TaskServer) GetStatus(ctx context.Context, req *connect.Request[v1.GetS
 		return nil, err
 	}
 
+	filter, err := prepareStatusCountFilter(req.Msg)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
 	// Fetch the task status counts from the repository
-	statusCounts, err := s.taskRepo.GetTaskStatusCounts(ctx)
+	statusCounts, err := s.taskRepo.GetTaskStatusCounts(ctx, filter)
 	if err != nil {
 		s.metrics.errorCounter.WithLabelValues("get_status").Inc()
 		return nil, s.logError(err, "Failed to retrieve task status counts")
@@ -287,15 +431,56 @@ func (s *TaskServer) GetStatus(ctx context.Context, req *connect.Request[v1.GetS
 	response := &v1.GetStatusResponse{
 		StatusCounts: make(map[int32]int64),
 	}
+	if filter.ByType {
+		response.TypeCounts = make(map[string]*v1.StatusCounts)
+	}
 
-	for status, count := range statusCounts {
-		response.StatusCounts[int32(status)] = count
+	for _, count := range statusCounts {
+		response.StatusCounts[int32(count.Status)] += count.Count
+		if !filter.ByType {
+			continue
+		}
+		typeCounts, ok := response.TypeCounts[count.Type]
+		if !ok {
+			typeCounts = &v1.StatusCounts{StatusCounts: make(map[int32]int64)}
+			response.TypeCounts[count.Type] = typeCounts
+		}
+		typeCounts.StatusCounts[int32(count.Status)] += count.Count
 	}
 
 	s.logger.Printf("Task status counts retrieved successfully")
 	return connect.NewResponse(response), nil
 }
 
+// ListTaskTypes returns the task types registered with the server.
+func (s *TaskServer) ListTaskTypes(ctx context.Context, req *connect.Request[v1.ListTaskTypesRequest]) (*connect.Response[v1.ListTaskTypesResponse], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("list_task_types"))
+	defer timer.ObserveDuration()
+
+	s.logger.Print("Retrieving task types")
+	return connect.NewResponse(&v1.ListTaskTypesResponse{TaskTypes: plugins.Types()}), nil
+}
+
+// DescribeTaskType returns the parameter schema of a task type.
+func (s *TaskServer) DescribeTaskType(ctx context.Context, req *connect.Request[v1.DescribeTaskTypeRequest]) (*connect.Response[v1.DescribeTaskTypeResponse], error) {
+	timer := prometheus.NewTimer(s.metrics.taskDuration.WithLabelValues("describe_task_type"))
+	defer timer.ObserveDuration()
+
+	s.logger.Printf("Describing task type: %s", req.Msg.TaskType)
+	if err := s.validateRequest(req.Msg); err != nil {
+		return nil, err
+	}
+	if err := validateTaskType(req.Msg.TaskType); err != nil {
+		return nil, err
+	}
+
+	schema, err := plugins.Describe(req.Msg.TaskType)
+	if err != nil {
+		return nil, s.logError(err, "Failed to describe task type %s", req.Msg.TaskType)
+	}
+	return connect.NewResponse(convertSchemaToProto(req.Msg.TaskType, schema)), nil
+}
+
 // Heartbeat handles client heartbeats to maintain connection status.
 func (s *TaskServer) Heartbeat(ctx context.Context, req *connect.Request[v1.HeartbeatRequest]) (*connect.Response[v1.HeartbeatResponse], error) {
 	s.clientHeartbeats.Store("clientID", req.Msg.Timestamp)
@@ -338,7 +523,7 @@ func (s *TaskServer) PullEvents(ctx context.Context, req *connect.Request[v1.Pul
 
 // updateTaskStatus updates the task status and creates a history entry.
 func (s *TaskServer) updateTaskStatus(ctx context.Context, taskID uint, status v1.TaskStatusEnum, message string) error {
-	if err := s.taskRepo.UpdateTaskStatus(ctx, taskID, int(status)); err != nil {
+	if err := s.taskRepo.UpdateTaskStatus(ctx, taskID, int(status), nil); err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
 
@@ -351,7 +536,7 @@ func (s *TaskServer) updateTaskStatus(ctx context.Context, taskID uint, status v
 
 // handleUpdateTaskStatus processes task status update requests.
 func (s *TaskServer) handleUpdateTaskStatus(ctx context.Context, update *v1.UpdateTaskStatusRequest) error {
-	if err := s.taskRepo.UpdateTaskStatus(ctx, uint(update.Id), int(update.Status)); err != nil {
+	if err := s.taskRepo.UpdateTaskStatus(ctx, uint(update.Id), int(update.Status), nil); err != nil {
 		s.metrics.errorCounter.WithLabelValues("update_task_status").Inc()
 		return fmt.Errorf("failed to update task status: id=%d, error: %w", update.Id, err)
 	}
@@ -377,6 +562,32 @@ func (s *TaskServer) createTaskStatusHistory(ctx context.Context, taskID uint, s
 	return nil
 }
 
+// prepareTaskResult converts a reported result to the task result model.
+func prepareTaskResult(taskID uint, result *v1.TaskResult) (task.TaskResult, error) {
+	model := task.TaskResult{TaskID: taskID}
+	if err := model.SetOutputs(result.Outputs); err != nil {
+		return task.TaskResult{}, err
+	}
+	if result.Data != "" {
+		data := task.JSON(result.Data)
+		model.Data = &data
+	}
+	return model, nil
+}
+
+// convertTaskResultToProto converts a task result model to a protobuf TaskResult message.
+func convertTaskResultToProto(result *task.TaskResult) (*v1.TaskResult, error) {
+	outputs, err := result.OutputMap()
+	if err != nil {
+		return nil, err
+	}
+	proto := &v1.TaskResult{Outputs: outputs}
+	if result.Data != nil {
+		proto.Data = string(*result.Data)
+	}
+	return proto, nil
+}
+
 // validateRequest validates the request using protovalidate.
 // It returns an error if the message is not a valid protobuf message or fails validation.
 func (s *TaskServer) validateRequest(msg interface{}) error {
@@ -391,6 +602,117 @@ func (s *TaskServer) validateRequest(msg interface{}) error {
 	return nil
 }
 
+// validateRules checks the fields of the request against the rules declared on them in cloud.proto,
+// which protovalidate does not enforce. The admission webhook of the controller checks the same rules.
+func validateRules(req *v1.CreateTaskRequest) error {
+	var violations fieldViolations
+	for _, v := range protorules.Validate(req) {
+		violations.add(v.Field, v.Description)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return badRequest("invalid fields", violations...)
+}
+
+// validateTaskType checks that a plugin is registered for the task type.
+func validateTaskType(taskType string) error {
+	if !plugins.IsRegistered(taskType) {
+		return connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("validation failed: unknown task type %q, must be one of: %s", taskType, strings.Join(plugins.Types(), ", ")))
+	}
+	return nil
+}
+
+// validateParameters checks the payload parameters against the schema of the task type.
+func validateParameters(taskType string, params map[string]string) error {
+	schema, err := plugins.Describe(taskType)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("validation failed: %w", err))
+	}
+	err = schema.Validate(params)
+	if err == nil {
+		return nil
+	}
+
+	var violations fieldViolations
+	var validationErr *plugins.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, field := range validationErr.Fields {
+			violations.add("payload.parameters."+field.Parameter, field.Message)
+		}
+	}
+	return badRequest(err.Error(), violations...)
+}
+
+// validateTaskResult checks a reported result against the limits workers cut results
+// down to with plugins.Result.Limit. The validate.rules annotations of TaskResult only
+// document them, as they are not enforced by protovalidate.
+func validateTaskResult(result *v1.TaskResult) error {
+	if result == nil {
+		return nil
+	}
+	var violations fieldViolations
+
+	if len(result.Outputs) > plugins.MaxOutputs {
+		violations.add("result.outputs", fmt.Sprintf("must have at most %d outputs", plugins.MaxOutputs))
+	}
+	names := make([]string, 0, len(result.Outputs))
+	for name := range result.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch {
+		case name == "" || len(name) > plugins.MaxOutputNameLength:
+			violations.add("result.outputs", fmt.Sprintf("output names must be 1 to %d bytes long", plugins.MaxOutputNameLength))
+		case len(result.Outputs[name]) > plugins.MaxOutputValueLength:
+			violations.add("result.outputs."+name, fmt.Sprintf("must be at most %d bytes long", plugins.MaxOutputValueLength))
+		}
+	}
+
+	if len(result.Data) > plugins.MaxDataBytes {
+		violations.add("result.data", fmt.Sprintf("must be at most %d bytes long", plugins.MaxDataBytes))
+	} else if result.Data != "" && !json.Valid([]byte(result.Data)) {
+		violations.add("result.data", "must be a JSON document")
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return badRequest("invalid task result", violations...)
+}
+
+// parameterTypes maps the plugin parameter types onto the proto parameter types.
+var parameterTypes = map[plugins.ParameterType]v1.ParameterType{
+	plugins.ParameterString:   v1.ParameterType_PARAMETER_TYPE_STRING,
+	plugins.ParameterInt:      v1.ParameterType_PARAMETER_TYPE_INT,
+	plugins.ParameterFloat:    v1.ParameterType_PARAMETER_TYPE_FLOAT,
+	plugins.ParameterBool:     v1.ParameterType_PARAMETER_TYPE_BOOL,
+	plugins.ParameterDuration: v1.ParameterType_PARAMETER_TYPE_DURATION,
+}
+
+// convertSchemaToProto converts the schema of a task type to a DescribeTaskTypeResponse.
+func convertSchemaToProto(taskType string, schema plugins.Schema) *v1.DescribeTaskTypeResponse {
+	resp := &v1.DescribeTaskTypeResponse{
+		TaskType:             taskType,
+		Description:          schema.Description,
+		AdditionalParameters: schema.AdditionalParameters,
+	}
+	for _, p := range schema.Parameters {
+		resp.Parameters = append(resp.Parameters, &v1.ParameterSchema{
+			Name:         p.Name,
+			Type:         parameterTypes[p.Type],
+			Required:     p.Required,
+			DefaultValue: p.Default,
+			Pattern:      p.Pattern,
+			Secret:       p.Secret,
+			Description:  p.Description,
+		})
+	}
+	return resp
+}
+
 // prepareNewTask creates a new task.Task from the CreateTaskRequest.
 // It handles the conversion of the payload to JSON and sets default values.
 func (s *TaskServer) prepareNewTask(req *v1.CreateTaskRequest) task.Task {
@@ -404,15 +726,180 @@ func (s *TaskServer) prepareNewTask(req *v1.CreateTaskRequest) task.Task {
 		Status:      int(v1.TaskStatusEnum_UNKNOWN),
 		Description: req.Description,
 		Type:        req.Type,
-		Payload:     payloadJSON,
+		Payload:     task.JSON(payloadJSON),
 		Retries:     defaultTaskRetries,
 		Priority:    defaultTaskPriority,
 	}
+	if err := newTask.SetExecutionSpec(task.ExecutionSpec{
+		BaseImage:       req.BaseImage,
+		Entrypoint:      req.Entrypoint,
+		Args:            req.Args,
+		Env:             req.Env,
+		InputArtifacts:  convertArtifactInputsFromProto(req.InputArtifacts),
+		OutputArtifacts: req.OutputArtifacts,
+	}); err != nil {
+		s.logger.Printf("WARNING: Failed to convert execution spec to JSON: %v", err)
+	}
 
 	s.logger.Printf("Prepared new task: name=%s, type=%s", newTask.Name, newTask.Type)
 	return newTask
 }
 
+// taskSortFields maps the proto sort fields onto the repository sort fields.
+var taskSortFields = map[v1.TaskSortField]task.SortField{
+	v1.TaskSortField_TASK_SORT_FIELD_UNSPECIFIED: task.SortByCreatedAt,
+	v1.TaskSortField_TASK_SORT_FIELD_CREATED_AT:  task.SortByCreatedAt,
+	v1.TaskSortField_TASK_SORT_FIELD_UPDATED_AT:  task.SortByUpdatedAt,
+	v1.TaskSortField_TASK_SORT_FIELD_PRIORITY:    task.SortByPriority,
+	v1.TaskSortField_TASK_SORT_FIELD_NAME:        task.SortByName,
+	v1.TaskSortField_TASK_SORT_FIELD_ID:          task.SortByID,
+}
+
+// prepareTaskFilter creates a task.TaskFilter from the TaskListRequest.
+// The repeated statuses take precedence over the single status, and ALL disables status filtering.
+func (s *TaskServer) prepareTaskFilter(req *v1.TaskListRequest) (task.TaskFilter, error) {
+	filter := task.TaskFilter{
+		Type:       req.GetType(),
+		NamePrefix: req.NamePrefix,
+		Search:     req.Search,
+	}
+
+	statuses := req.Statuses
+	if len(statuses) == 0 && req.Status != nil {
+		statuses = []v1.TaskStatusEnum{req.GetStatus()}
+	}
+	for _, status := range statuses {
+		if status == v1.TaskStatusEnum_ALL {
+			filter.Statuses = nil
+			break
+		}
+		filter.Statuses = append(filter.Statuses, int(status))
+	}
+
+	if req.MinPriority != nil {
+		minPriority := int(req.GetMinPriority())
+		filter.MinPriority = &minPriority
+	}
+	if req.MaxPriority != nil {
+		maxPriority := int(req.GetMaxPriority())
+		filter.MaxPriority = &maxPriority
+	}
+	if filter.MinPriority != nil && filter.MaxPriority != nil && *filter.MinPriority > *filter.MaxPriority {
+		return task.TaskFilter{}, fmt.Errorf("min_priority %d is greater than max_priority %d", *filter.MinPriority, *filter.MaxPriority)
+	}
+
+	if req.CreatedAfter != nil {
+		filter.CreatedAfter = req.CreatedAfter.AsTime()
+	}
+	if req.CreatedBefore != nil {
+		filter.CreatedBefore = req.CreatedBefore.AsTime()
+	}
+	if req.UpdatedAfter != nil {
+		filter.UpdatedAfter = req.UpdatedAfter.AsTime()
+	}
+	if req.UpdatedBefore != nil {
+		filter.UpdatedBefore = req.UpdatedBefore.AsTime()
+	}
+
+	sortBy, ok := taskSortFields[req.SortBy]
+	if !ok {
+		return task.TaskFilter{}, fmt.Errorf("unsupported sort field: %s", req.SortBy)
+	}
+	filter.SortBy = sortBy
+
+	switch req.SortOrder {
+	case v1.SortOrder_SORT_ORDER_ASC:
+		filter.SortOrder = task.SortAsc
+	case v1.SortOrder_SORT_ORDER_UNSPECIFIED, v1.SortOrder_SORT_ORDER_DESC:
+		filter.SortOrder = task.SortDesc
+	default:
+		return task.TaskFilter{}, fmt.Errorf("unsupported sort order: %s", req.SortOrder)
+	}
+
+	return filter, nil
+}
+
+// prepareTaskHistoryFilter creates a task.TaskHistoryFilter from the GetTaskHistoryRequest.
+func (s *TaskServer) prepareTaskHistoryFilter(req *v1.GetTaskHistoryRequest) (task.TaskHistoryFilter, error) {
+	filter := task.TaskHistoryFilter{
+		TaskID: uint(req.Id),
+		Limit:  int(req.PageSize),
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultHistoryPageSize
+	}
+	if filter.Limit > maxHistoryPageSize {
+		return task.TaskHistoryFilter{}, fmt.Errorf("page_size %d exceeds the maximum of %d", req.PageSize, maxHistoryPageSize)
+	}
+
+	if req.PageToken != "" {
+		cursor, err := decodePageToken(req.PageToken)
+		if err != nil {
+			return task.TaskHistoryFilter{}, err
+		}
+		filter.Cursor = cursor
+	}
+
+	for _, status := range req.Statuses {
+		if status == v1.TaskStatusEnum_ALL {
+			filter.Statuses = nil
+			break
+		}
+		filter.Statuses = append(filter.Statuses, int(status))
+	}
+
+	if req.Since != nil {
+		filter.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		filter.Until = req.Until.AsTime()
+	}
+
+	switch req.SortOrder {
+	case v1.SortOrder_SORT_ORDER_UNSPECIFIED, v1.SortOrder_SORT_ORDER_DESC:
+		filter.SortOrder = task.SortDesc
+	case v1.SortOrder_SORT_ORDER_ASC:
+		filter.SortOrder = task.SortAsc
+	default:
+		return task.TaskHistoryFilter{}, fmt.Errorf("unsupported sort order: %s", req.SortOrder)
+	}
+
+	return filter, nil
+}
+
+// prepareStatusCountFilter creates a task.StatusCountFilter from the GetStatusRequest.
+func prepareStatusCountFilter(req *v1.GetStatusRequest) (task.StatusCountFilter, error) {
+	filter := task.StatusCountFilter{ByType: req.ByType}
+	if req.CreatedAfter != nil {
+		filter.CreatedAfter = req.CreatedAfter.AsTime()
+	}
+	if req.CreatedBefore != nil {
+		filter.CreatedBefore = req.CreatedBefore.AsTime()
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		return task.StatusCountFilter{}, fmt.Errorf("created_after must be before created_before")
+	}
+	return filter, nil
+}
+
+// encodePageToken creates an opaque page token pointing after the history entry with the given ID.
+func encodePageToken(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+// decodePageToken returns the history entry ID encoded in a page token.
+func decodePageToken(token string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid page token")
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid page token")
+	}
+	return uint(id), nil
+}
+
 // logTaskCreationHistory logs the task creation in the history.
 // It creates a new TaskHistory entry with the initial QUEUED status.
 func (s *TaskServer) logTaskCreationHistory(ctx context.Context, taskID uint) error {
@@ -430,20 +917,31 @@ func (s *TaskServer) logTaskCreationHistory(ctx context.Context, taskID uint) er
 
 // convertTaskToProto converts a task model to a protobuf Task message.
 func (s *TaskServer) convertTaskToProto(taskModel *task.Task) *v1.Task {
-	jsonMap, err := x.ConvertJsonToMap(taskModel.Payload)
+	jsonMap, err := x.ConvertJsonToMap(string(taskModel.Payload))
 	if err != nil {
 		s.logger.Printf("WARNING: Failed to convert task payload to map: %v", err)
 	}
+	spec, err := taskModel.ExecutionSpec()
+	if err != nil {
+		s.logger.Printf("WARNING: Failed to convert task spec: %v", err)
+	}
 
 	return &v1.Task{
-		Id:          int32(taskModel.ID),
-		Name:        taskModel.Name,
-		Description: taskModel.Description,
-		Status:      v1.TaskStatusEnum(taskModel.Status),
-		Priority:    int32(taskModel.Priority),
-		Retries:     int32(taskModel.Retries),
-		Payload:     &v1.Payload{Parameters: jsonMap},
-		Type:        taskModel.Type,
+		Id:              int32(taskModel.ID),
+		Name:            taskModel.Name,
+		Description:     taskModel.Description,
+		Status:          v1.TaskStatusEnum(taskModel.Status),
+		Priority:        int32(taskModel.Priority),
+		Retries:         int32(taskModel.Retries),
+		Payload:         &v1.Payload{Parameters: jsonMap},
+		Type:            taskModel.Type,
+		BaseImage:       spec.BaseImage,
+		Entrypoint:      spec.Entrypoint,
+		Args:            spec.Args,
+		Env:             spec.Env,
+		InputArtifacts:  convertArtifactInputsToProto(spec.InputArtifacts),
+		OutputArtifacts: spec.OutputArtifacts,
+		Progress:        convertProgressToProto(taskModel.Progress),
 	}
 }
 
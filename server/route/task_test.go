@@ -5,17 +5,24 @@ import (
 	"errors"
 	"log"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"connectrpc.com/connect"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	cloudv1 "task/pkg/gen/cloud/v1"
+	"task/pkg/plugins"
 	"task/pkg/plugins/email"
+	"task/pkg/plugins/query"
 	"task/server/repository/model/task"
 	"task/server/route/mocks"
 )
@@ -236,6 +243,25 @@ func TestConvertTaskToProto(t *testing.T) {
 		assert.Equal(t, map[string]string{"key": "value"}, protoTask.Payload.Parameters)
 	})
 
+	t.Run("Execution spec", func(t *testing.T) {
+		taskModel := mockServer.prepareNewTask(&cloudv1.CreateTaskRequest{
+			Name:       "process-task",
+			Type:       "process",
+			Payload:    &cloudv1.Payload{},
+			BaseImage:  "python:3.12",
+			Entrypoint: "python",
+			Args:       []string{"-c", "print('hello')"},
+			Env:        map[string]string{"GREETING": "hello"},
+		})
+
+		protoTask := mockServer.convertTaskToProto(&taskModel)
+
+		assert.Equal(t, "python:3.12", protoTask.BaseImage)
+		assert.Equal(t, "python", protoTask.Entrypoint)
+		assert.Equal(t, []string{"-c", "print('hello')"}, protoTask.Args)
+		assert.Equal(t, map[string]string{"GREETING": "hello"}, protoTask.Env)
+	})
+
 	t.Run("Invalid JSON payload", func(t *testing.T) {
 		taskModel := &task.Task{
 
@@ -250,3 +276,241 @@ func TestConvertTaskToProto(t *testing.T) {
 		assert.Nil(t, protoTask.Payload.Parameters)
 	})
 }
+
+func TestPrepareTaskFilter(t *testing.T) {
+	mockServer := &TaskServer{
+		logger: log.New(os.Stdout, "TestPrepareTaskFilter: ", log.LstdFlags),
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		filter, err := mockServer.prepareTaskFilter(&cloudv1.TaskListRequest{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, filter.Statuses)
+		assert.Equal(t, task.SortByCreatedAt, filter.SortBy)
+		assert.Equal(t, task.SortDesc, filter.SortOrder)
+	})
+
+	t.Run("All filters", func(t *testing.T) {
+		taskType := email.PLUGIN_NAME
+		minPriority, maxPriority := int32(1), int32(5)
+		createdAfter := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+		req := &cloudv1.TaskListRequest{
+			Type:         &taskType,
+			Statuses:     []cloudv1.TaskStatusEnum{cloudv1.TaskStatusEnum_QUEUED, cloudv1.TaskStatusEnum_RUNNING},
+			NamePrefix:   "nightly",
+			MinPriority:  &minPriority,
+			MaxPriority:  &maxPriority,
+			CreatedAfter: timestamppb.New(createdAfter),
+			Search:       "report",
+			SortBy:       cloudv1.TaskSortField_TASK_SORT_FIELD_PRIORITY,
+			SortOrder:    cloudv1.SortOrder_SORT_ORDER_ASC,
+		}
+
+		filter, err := mockServer.prepareTaskFilter(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 1}, filter.Statuses)
+		assert.Equal(t, email.PLUGIN_NAME, filter.Type)
+		assert.Equal(t, "nightly", filter.NamePrefix)
+		assert.Equal(t, 1, *filter.MinPriority)
+		assert.Equal(t, 5, *filter.MaxPriority)
+		assert.True(t, filter.CreatedAfter.Equal(createdAfter))
+		assert.True(t, filter.CreatedBefore.IsZero())
+		assert.Equal(t, "report", filter.Search)
+		assert.Equal(t, task.SortByPriority, filter.SortBy)
+		assert.Equal(t, task.SortAsc, filter.SortOrder)
+	})
+
+	t.Run("Single status", func(t *testing.T) {
+		status := cloudv1.TaskStatusEnum_FAILED
+		filter, err := mockServer.prepareTaskFilter(&cloudv1.TaskListRequest{Status: &status})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{int(cloudv1.TaskStatusEnum_FAILED)}, filter.Statuses)
+	})
+
+	t.Run("ALL status disables filtering", func(t *testing.T) {
+		status := cloudv1.TaskStatusEnum_ALL
+		filter, err := mockServer.prepareTaskFilter(&cloudv1.TaskListRequest{Status: &status})
+
+		assert.NoError(t, err)
+		assert.Nil(t, filter.Statuses)
+	})
+
+	t.Run("Inverted priority range", func(t *testing.T) {
+		minPriority, maxPriority := int32(5), int32(1)
+		_, err := mockServer.prepareTaskFilter(&cloudv1.TaskListRequest{MinPriority: &minPriority, MaxPriority: &maxPriority})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestPrepareTaskHistoryFilter(t *testing.T) {
+	mockServer := &TaskServer{
+		logger: log.New(os.Stdout, "TestPrepareTaskHistoryFilter: ", log.LstdFlags),
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		filter, err := mockServer.prepareTaskHistoryFilter(&cloudv1.GetTaskHistoryRequest{Id: 7})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), filter.TaskID)
+		assert.Equal(t, defaultHistoryPageSize, filter.Limit)
+		assert.Zero(t, filter.Cursor)
+		assert.Nil(t, filter.Statuses)
+		assert.Equal(t, task.SortDesc, filter.SortOrder)
+	})
+
+	t.Run("All filters", func(t *testing.T) {
+		since := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+		until := since.Add(time.Hour)
+		req := &cloudv1.GetTaskHistoryRequest{
+			Id:        7,
+			PageSize:  20,
+			PageToken: encodePageToken(42),
+			Statuses:  []cloudv1.TaskStatusEnum{cloudv1.TaskStatusEnum_FAILED, cloudv1.TaskStatusEnum_SUCCEEDED},
+			Since:     timestamppb.New(since),
+			Until:     timestamppb.New(until),
+			SortOrder: cloudv1.SortOrder_SORT_ORDER_ASC,
+		}
+
+		filter, err := mockServer.prepareTaskHistoryFilter(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 20, filter.Limit)
+		assert.Equal(t, uint(42), filter.Cursor)
+		assert.Equal(t, []int{int(cloudv1.TaskStatusEnum_FAILED), int(cloudv1.TaskStatusEnum_SUCCEEDED)}, filter.Statuses)
+		assert.True(t, filter.Since.Equal(since))
+		assert.True(t, filter.Until.Equal(until))
+		assert.Equal(t, task.SortAsc, filter.SortOrder)
+	})
+
+	t.Run("ALL status disables filtering", func(t *testing.T) {
+		filter, err := mockServer.prepareTaskHistoryFilter(&cloudv1.GetTaskHistoryRequest{
+			Id:       7,
+			Statuses: []cloudv1.TaskStatusEnum{cloudv1.TaskStatusEnum_FAILED, cloudv1.TaskStatusEnum_ALL},
+		})
+
+		assert.NoError(t, err)
+		assert.Nil(t, filter.Statuses)
+	})
+
+	t.Run("Page size too large", func(t *testing.T) {
+		_, err := mockServer.prepareTaskHistoryFilter(&cloudv1.GetTaskHistoryRequest{Id: 7, PageSize: maxHistoryPageSize + 1})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid page token", func(t *testing.T) {
+		_, err := mockServer.prepareTaskHistoryFilter(&cloudv1.GetTaskHistoryRequest{Id: 7, PageToken: "not a token"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestPageToken(t *testing.T) {
+	id, err := decodePageToken(encodePageToken(12345))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(12345), id)
+
+	for _, token := range []string{"!!", encodePageToken(0), "YWJj"} {
+		_, err := decodePageToken(token)
+		assert.Error(t, err, token)
+	}
+}
+
+func TestPrepareStatusCountFilter(t *testing.T) {
+	after := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	before := after.Add(24 * time.Hour)
+
+	filter, err := prepareStatusCountFilter(&cloudv1.GetStatusRequest{
+		CreatedAfter:  timestamppb.New(after),
+		CreatedBefore: timestamppb.New(before),
+		ByType:        true,
+	})
+	assert.NoError(t, err)
+	assert.True(t, filter.CreatedAfter.Equal(after))
+	assert.True(t, filter.CreatedBefore.Equal(before))
+	assert.True(t, filter.ByType)
+
+	filter, err = prepareStatusCountFilter(&cloudv1.GetStatusRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, task.StatusCountFilter{}, filter)
+
+	_, err = prepareStatusCountFilter(&cloudv1.GetStatusRequest{
+		CreatedAfter:  timestamppb.New(before),
+		CreatedBefore: timestamppb.New(after),
+	})
+	assert.Error(t, err)
+}
+
+func TestValidateTaskType(t *testing.T) {
+	assert.NoError(t, validateTaskType(email.PLUGIN_NAME))
+
+	err := validateTaskType("unknown_type")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown task type "unknown_type"`)
+	assert.Contains(t, err.Error(), email.PLUGIN_NAME)
+}
+
+func TestValidateParameters(t *testing.T) {
+	assert.NoError(t, validateParameters(email.PLUGIN_NAME, map[string]string{"to": "ada@example.com", "name": "Ada"}))
+
+	err := validateParameters(query.PLUGIN_NAME, map[string]string{"query": "SELECT 1", "format": "xml", "limit": "10"})
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+
+	require.Len(t, connectErr.Details(), 1)
+	detail, err := connectErr.Details()[0].Value()
+	require.NoError(t, err)
+	badRequest, ok := detail.(*errdetails.BadRequest)
+	require.True(t, ok)
+
+	var fields []string
+	for _, v := range badRequest.FieldViolations {
+		fields = append(fields, v.Field+": "+v.Description)
+	}
+	assert.Equal(t, []string{
+		"payload.parameters.data_source: is required",
+		"payload.parameters.format: must match csv|json",
+		"payload.parameters.limit: is not a parameter of this task type",
+	}, fields)
+}
+
+func TestValidateRules(t *testing.T) {
+	assert.NoError(t, validateRules(&cloudv1.CreateTaskRequest{Name: "welcome_email", Type: email.PLUGIN_NAME, Payload: &cloudv1.Payload{}}))
+
+	err := validateRules(&cloudv1.CreateTaskRequest{
+		Name:    "welcome email",
+		Type:    email.PLUGIN_NAME,
+		Payload: &cloudv1.Payload{Parameters: map[string]string{"body": strings.Repeat("a", 1025)}},
+		Env:     map[string]string{"1LANG": "en"},
+	})
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+
+	require.Len(t, connectErr.Details(), 1)
+	detail, err := connectErr.Details()[0].Value()
+	require.NoError(t, err)
+	var fields []string
+	for _, v := range detail.(*errdetails.BadRequest).FieldViolations {
+		fields = append(fields, v.Field)
+	}
+	assert.Equal(t, []string{"name", "payload.parameters.body", "env.1LANG"}, fields)
+}
+
+func TestConvertSchemaToProto(t *testing.T) {
+	schema, err := plugins.Describe(query.PLUGIN_NAME)
+	require.NoError(t, err)
+
+	resp := convertSchemaToProto(query.PLUGIN_NAME, schema)
+	assert.Equal(t, query.PLUGIN_NAME, resp.TaskType)
+	assert.False(t, resp.AdditionalParameters)
+	require.Len(t, resp.Parameters, len(schema.Parameters))
+	assert.Equal(t, "data_source", resp.Parameters[0].Name)
+	assert.True(t, resp.Parameters[0].Required)
+	assert.Equal(t, cloudv1.ParameterType_PARAMETER_TYPE_DURATION, resp.Parameters[4].Type)
+}
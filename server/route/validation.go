@@ -0,0 +1,30 @@
+package route
+
+import (
+	"errors"
+
+	"connectrpc.com/connect"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// fieldViolations collects the invalid fields of a request.
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+// add records that the field of the request is invalid for the reason in description.
+func (v *fieldViolations) add(field, description string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+}
+
+// badRequest returns an InvalidArgument error with the message "validation failed: msg".
+// The error carries a BadRequest detail with the violations, so that clients can tell
+// which fields of the request are invalid.
+func badRequest(msg string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	err := connect.NewError(connect.CodeInvalidArgument, errors.New("validation failed: "+msg))
+	if len(violations) == 0 {
+		return err
+	}
+	if detail, detailErr := connect.NewErrorDetail(&errdetails.BadRequest{FieldViolations: violations}); detailErr == nil {
+		err.AddDetail(detail)
+	}
+	return err
+}